@@ -0,0 +1,124 @@
+// Package commands reúne os comandos de linha de comando do backend
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/services"
+)
+
+// Run executa um comando de linha de comando em vez de subir o servidor:
+//
+//	go run main.go backup -email usuario@exemplo.com -out backup.zip
+//	go run main.go restore -email usuario@exemplo.com -in backup.zip
+func Run(db *database.Database, args []string) error {
+	backupService := services.NewBackupService(db, services.NewSuggestionService(db))
+
+	switch args[0] {
+	case "backup":
+		return runBackupCommand(db, backupService, args[1:])
+	case "restore":
+		return runRestoreCommand(db, backupService, args[1:])
+	}
+	return fmt.Errorf("comando desconhecido: %s (disponíveis: backup, restore)", args[0])
+}
+
+// runBackupCommand grava o backup do usuário em um arquivo (zip quando termina em .zip) ou na saída padrão
+func runBackupCommand(db *database.Database, backupService *services.BackupService, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	userID := flags.String("user", "", "ID do usuário")
+	email := flags.String("email", "", "e-mail do usuário (alternativa a -user)")
+	out := flags.String("out", "", "arquivo de saída (.json ou .zip); vazio grava JSON na saída padrão")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	id, err := commandUserID(db, *userID, *email)
+	if err != nil {
+		return err
+	}
+
+	backup, err := backupService.CreateBackup(id)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return fmt.Errorf("erro ao criar %s: %w", *out, err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := services.WriteBackup(w, backup, strings.HasSuffix(strings.ToLower(*out), ".zip")); err != nil {
+		return fmt.Errorf("erro ao gravar backup: %w", err)
+	}
+
+	log.Printf("Backup gerado: %d categorias, %d contas, %d transações", len(backup.Categories), len(backup.Accounts), len(backup.Transactions))
+	return nil
+}
+
+// runRestoreCommand restaura um backup (JSON ou zip) no usuário informado
+func runRestoreCommand(db *database.Database, backupService *services.BackupService, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	userID := flags.String("user", "", "ID do usuário de destino")
+	email := flags.String("email", "", "e-mail do usuário de destino (alternativa a -user)")
+	in := flags.String("in", "", "arquivo de backup (.json ou .zip)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return fmt.Errorf("-in é obrigatório")
+	}
+
+	id, err := commandUserID(db, *userID, *email)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(*in)
+	if err != nil {
+		return fmt.Errorf("erro ao ler %s: %w", *in, err)
+	}
+	backup, err := services.ReadBackup(content)
+	if err != nil {
+		return err
+	}
+
+	result, err := backupService.RestoreBackup(id, backup)
+	if err != nil {
+		return err
+	}
+
+	log.Println(result.Message)
+	for _, warning := range result.Warnings {
+		log.Printf("Aviso: %s", warning)
+	}
+	return nil
+}
+
+// commandUserID resolve o usuário pelo ID ou pelo e-mail
+func commandUserID(db *database.Database, userID, email string) (string, error) {
+	if userID != "" {
+		return userID, nil
+	}
+	if email == "" {
+		return "", fmt.Errorf("informe -user ou -email")
+	}
+	user, err := db.GetUserByEmail(email)
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil {
+		return "", fmt.Errorf("usuário não encontrado: %s", email)
+	}
+	return user.ID, nil
+}
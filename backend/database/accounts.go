@@ -9,11 +9,15 @@ import (
 
 // CreateAccount insere uma nova conta no banco
 func (d *Database) CreateAccount(account structs.Account) error {
+	return insertAccount(d.db, account)
+}
+
+func insertAccount(e execer, account structs.Account) error {
 	query := `
 	INSERT INTO accounts (id, currency, name, color, type, is_active, created_at, updated_at, deleted_at, user_id)
 	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
-	_, err := d.db.Exec(query,
+	_, err := e.Exec(query,
 		account.ID,
 		account.Currency,
 		account.Name,
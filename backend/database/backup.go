@@ -0,0 +1,56 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// RestoreBackup grava os registros de um backup em uma única transação do banco: ou tudo é
+// criado, ou nada. Os IDs já devem ter sido trocados e as seções precisam estar em ordem de
+// dependência (categorias pai antes das filhas, transações pai antes das parcelas).
+func (d *Database) RestoreBackup(backup structs.Backup) error {
+	dbTx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	for _, category := range backup.Categories {
+		if err := insertCategory(dbTx, category); err != nil {
+			return fmt.Errorf("erro ao criar categoria '%s': %w", category.Name, err)
+		}
+	}
+	for _, account := range backup.Accounts {
+		if err := insertAccount(dbTx, account); err != nil {
+			return fmt.Errorf("erro ao criar conta '%s': %w", account.Name, err)
+		}
+	}
+	for _, payee := range backup.Payees {
+		if err := insertPayee(dbTx, payee); err != nil {
+			return fmt.Errorf("erro ao criar beneficiário '%s': %w", payee.Name, err)
+		}
+	}
+	for _, rule := range backup.PayeeRules {
+		if err := insertPayeeRule(dbTx, rule); err != nil {
+			return fmt.Errorf("erro ao criar regra de beneficiário '%s': %w", rule.Pattern, err)
+		}
+	}
+	for _, rule := range backup.Rules {
+		if err := insertRule(dbTx, rule); err != nil {
+			return fmt.Errorf("erro ao criar regra '%s': %w", rule.Name, err)
+		}
+	}
+	for _, profile := range backup.CSVProfiles {
+		if err := insertCSVProfile(dbTx, profile); err != nil {
+			return fmt.Errorf("erro ao criar perfil de CSV '%s': %w", profile.Name, err)
+		}
+	}
+	for _, tx := range backup.Transactions {
+		if err := insertTransaction(dbTx, tx); err != nil {
+			return fmt.Errorf("erro ao criar transação '%s': %w", tx.Description, err)
+		}
+	}
+
+	return dbTx.Commit()
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// scanCalendarToken lê um token de calendário na ordem user_id, token_hash, created_at, last_accessed_at
+func scanCalendarToken(row rowScanner) (*structs.CalendarToken, error) {
+	var token structs.CalendarToken
+	var lastAccessedAt sql.NullTime
+
+	err := row.Scan(&token.UserID, &token.TokenHash, &token.CreatedAt, &lastAccessedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lastAccessedAt.Valid {
+		token.LastAccessedAt = &lastAccessedAt.Time
+	}
+	return &token, nil
+}
+
+// SaveCalendarToken grava o hash do token do usuário, substituindo o token anterior
+func (d *Database) SaveCalendarToken(userID, tokenHash string) (*structs.CalendarToken, error) {
+	query := `INSERT INTO calendar_tokens (user_id, token_hash, created_at, last_accessed_at)
+              VALUES ($1, $2, NOW(), NULL)
+              ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = NOW(), last_accessed_at = NULL
+              RETURNING user_id, token_hash, created_at, last_accessed_at`
+	token, err := scanCalendarToken(d.db.QueryRow(query, userID, tokenHash))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao salvar token do calendário: %w", err)
+	}
+	return token, nil
+}
+
+// GetCalendarToken busca o token ativo do usuário
+func (d *Database) GetCalendarToken(userID string) (*structs.CalendarToken, error) {
+	query := `SELECT user_id, token_hash, created_at, last_accessed_at FROM calendar_tokens WHERE user_id = $1`
+	token, err := scanCalendarToken(d.db.QueryRow(query, userID))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar token do calendário: %w", err)
+	}
+	return token, nil
+}
+
+// GetCalendarTokenByHash busca o token pelo hash, usado ao servir o feed
+func (d *Database) GetCalendarTokenByHash(tokenHash string) (*structs.CalendarToken, error) {
+	query := `SELECT user_id, token_hash, created_at, last_accessed_at FROM calendar_tokens WHERE token_hash = $1`
+	token, err := scanCalendarToken(d.db.QueryRow(query, tokenHash))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar token do calendário: %w", err)
+	}
+	return token, nil
+}
+
+// TouchCalendarToken registra a leitura do feed
+func (d *Database) TouchCalendarToken(userID string) error {
+	_, err := d.db.Exec(`UPDATE calendar_tokens SET last_accessed_at = NOW() WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar token do calendário: %w", err)
+	}
+	return nil
+}
+
+// DeleteCalendarToken revoga o token do usuário. Retorna false quando não havia token.
+func (d *Database) DeleteCalendarToken(userID string) (bool, error) {
+	result, err := d.db.Exec(`DELETE FROM calendar_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao revogar token do calendário: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,142 @@
+package database
+
+import "github.com/tonnarruda/my-personal-finance/structs"
+
+// AdjustCategoryModel soma (delta positivo) ou subtrai (delta negativo) uma transação
+// das contagens do modelo de sugestão de categorias
+func (d *Database) AdjustCategoryModel(userID string, categoryID string, tokens []string, delta int) error {
+	counts := make(map[string]int, len(tokens))
+	for _, token := range tokens {
+		counts[token]++
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for token, count := range counts {
+		_, err := tx.Exec(`
+			INSERT INTO category_model_tokens (user_id, category_id, token, count)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, category_id, token)
+			DO UPDATE SET count = category_model_tokens.count + EXCLUDED.count`,
+			userID, categoryID, token, count*delta)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM category_model_tokens
+		WHERE user_id = $1 AND category_id = $2 AND count <= 0`,
+		userID, categoryID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO category_model_totals (user_id, category_id, documents, tokens, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (user_id, category_id)
+		DO UPDATE SET documents = category_model_totals.documents + EXCLUDED.documents,
+			tokens = category_model_totals.tokens + EXCLUDED.tokens,
+			updated_at = NOW()`,
+		userID, categoryID, delta, len(tokens)*delta)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM category_model_totals
+		WHERE user_id = $1 AND category_id = $2 AND documents <= 0`,
+		userID, categoryID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetCategoryModelTotals retorna os totais do modelo por categoria
+func (d *Database) GetCategoryModelTotals(userID string) ([]structs.CategoryModelTotal, error) {
+	rows, err := d.db.Query(`
+		SELECT category_id, documents, tokens
+		FROM category_model_totals
+		WHERE user_id = $1 AND documents > 0`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := []structs.CategoryModelTotal{}
+	for rows.Next() {
+		var total structs.CategoryModelTotal
+		if err := rows.Scan(&total.CategoryID, &total.Documents, &total.Tokens); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+// GetCategoryModelTokens retorna todas as contagens de tokens do modelo do usuário
+func (d *Database) GetCategoryModelTokens(userID string) ([]structs.CategoryModelToken, error) {
+	rows, err := d.db.Query(`
+		SELECT category_id, token, count
+		FROM category_model_tokens
+		WHERE user_id = $1 AND count > 0`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []structs.CategoryModelToken{}
+	for rows.Next() {
+		var count structs.CategoryModelToken
+		if err := rows.Scan(&count.CategoryID, &count.Token, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// ReplaceCategoryModel substitui todo o modelo do usuário em uma única transação
+func (d *Database) ReplaceCategoryModel(userID string, totals []structs.CategoryModelTotal, tokens []structs.CategoryModelToken) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM category_model_tokens WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM category_model_totals WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, total := range totals {
+		_, err := tx.Exec(`
+			INSERT INTO category_model_totals (user_id, category_id, documents, tokens, updated_at)
+			VALUES ($1, $2, $3, $4, NOW())`,
+			userID, total.CategoryID, total.Documents, total.Tokens)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, token := range tokens {
+		_, err := tx.Exec(`
+			INSERT INTO category_model_tokens (user_id, category_id, token, count)
+			VALUES ($1, $2, $3, $4)`,
+			userID, token.CategoryID, token.Token, token.Count)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
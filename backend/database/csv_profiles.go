@@ -0,0 +1,168 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+const csvProfileColumns = `id, user_id, name, delimiter, encoding, decimal_separator, date_format, date_column, description_column, memo_column, amount_column, debit_column, credit_column, category_column, external_id_column, invert_amount, has_header, created_at, updated_at, deleted_at`
+
+// scanCSVProfile lê um perfil de CSV na ordem de csvProfileColumns
+func scanCSVProfile(row rowScanner) (*structs.CSVProfile, error) {
+	var profile structs.CSVProfile
+	var delimiter, encoding, decimalSeparator, dateFormat sql.NullString
+	var memoColumn, amountColumn, debitColumn, creditColumn, categoryColumn, externalIDColumn sql.NullString
+	var deletedAt sql.NullTime
+
+	err := row.Scan(
+		&profile.ID,
+		&profile.UserID,
+		&profile.Name,
+		&delimiter,
+		&encoding,
+		&decimalSeparator,
+		&dateFormat,
+		&profile.DateColumn,
+		&profile.DescriptionColumn,
+		&memoColumn,
+		&amountColumn,
+		&debitColumn,
+		&creditColumn,
+		&categoryColumn,
+		&externalIDColumn,
+		&profile.InvertAmount,
+		&profile.HasHeader,
+		&profile.CreatedAt,
+		&profile.UpdatedAt,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Delimiter = delimiter.String
+	profile.Encoding = encoding.String
+	profile.DecimalSeparator = decimalSeparator.String
+	profile.DateFormat = dateFormat.String
+	profile.MemoColumn = memoColumn.String
+	profile.AmountColumn = amountColumn.String
+	profile.DebitColumn = debitColumn.String
+	profile.CreditColumn = creditColumn.String
+	profile.CategoryColumn = categoryColumn.String
+	profile.ExternalIDColumn = externalIDColumn.String
+	if deletedAt.Valid {
+		profile.DeletedAt = &deletedAt.Time
+	}
+
+	return &profile, nil
+}
+
+// CreateCSVProfile cria um novo perfil de CSV
+func (d *Database) CreateCSVProfile(profile structs.CSVProfile) error {
+	return insertCSVProfile(d.db, profile)
+}
+
+func insertCSVProfile(e execer, profile structs.CSVProfile) error {
+	query := `
+	INSERT INTO csv_profiles (` + csvProfileColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, NULL)
+	`
+	_, err := e.Exec(query,
+		profile.ID,
+		profile.UserID,
+		profile.Name,
+		nullableString(profile.Delimiter),
+		nullableString(profile.Encoding),
+		nullableString(profile.DecimalSeparator),
+		nullableString(profile.DateFormat),
+		profile.DateColumn,
+		profile.DescriptionColumn,
+		nullableString(profile.MemoColumn),
+		nullableString(profile.AmountColumn),
+		nullableString(profile.DebitColumn),
+		nullableString(profile.CreditColumn),
+		nullableString(profile.CategoryColumn),
+		nullableString(profile.ExternalIDColumn),
+		profile.InvertAmount,
+		profile.HasHeader,
+		profile.CreatedAt,
+		profile.UpdatedAt,
+	)
+	return err
+}
+
+// GetCSVProfileByID busca um perfil de CSV do usuário
+func (d *Database) GetCSVProfileByID(id string, userID string) (*structs.CSVProfile, error) {
+	query := `SELECT ` + csvProfileColumns + ` FROM csv_profiles WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+
+	profile, err := scanCSVProfile(d.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return profile, nil
+}
+
+// GetCSVProfilesByUser lista os perfis de CSV do usuário
+func (d *Database) GetCSVProfilesByUser(userID string) ([]structs.CSVProfile, error) {
+	query := `SELECT ` + csvProfileColumns + ` FROM csv_profiles WHERE user_id = $1 AND deleted_at IS NULL ORDER BY name`
+
+	rows, err := d.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []structs.CSVProfile{}
+	for rows.Next() {
+		profile, err := scanCSVProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *profile)
+	}
+	return profiles, rows.Err()
+}
+
+// UpdateCSVProfile atualiza um perfil de CSV
+func (d *Database) UpdateCSVProfile(profile structs.CSVProfile) error {
+	query := `
+	UPDATE csv_profiles SET name = $1, delimiter = $2, encoding = $3, decimal_separator = $4, date_format = $5,
+		date_column = $6, description_column = $7, memo_column = $8, amount_column = $9, debit_column = $10,
+		credit_column = $11, category_column = $12, external_id_column = $13, invert_amount = $14, has_header = $15,
+		updated_at = $16
+	WHERE id = $17 AND user_id = $18 AND deleted_at IS NULL
+	`
+	_, err := d.db.Exec(query,
+		profile.Name,
+		nullableString(profile.Delimiter),
+		nullableString(profile.Encoding),
+		nullableString(profile.DecimalSeparator),
+		nullableString(profile.DateFormat),
+		profile.DateColumn,
+		profile.DescriptionColumn,
+		nullableString(profile.MemoColumn),
+		nullableString(profile.AmountColumn),
+		nullableString(profile.DebitColumn),
+		nullableString(profile.CreditColumn),
+		nullableString(profile.CategoryColumn),
+		nullableString(profile.ExternalIDColumn),
+		profile.InvertAmount,
+		profile.HasHeader,
+		time.Now(),
+		profile.ID,
+		profile.UserID,
+	)
+	return err
+}
+
+// DeleteCSVProfile remove um perfil de CSV (soft delete)
+func (d *Database) DeleteCSVProfile(id string, userID string) error {
+	query := `UPDATE csv_profiles SET deleted_at = $1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL`
+	_, err := d.db.Exec(query, time.Now(), id, userID)
+	return err
+}
@@ -14,6 +14,11 @@ type Database struct {
 	db *sql.DB
 }
 
+// execer abstrai *sql.DB e *sql.Tx para reaproveitar os inserts dentro de uma transação do banco
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 // DatabaseConfig configurações do banco de dados
 type DatabaseConfig struct {
 	Host     string
@@ -84,6 +89,11 @@ func NewDatabase(config DatabaseConfig) (*Database, error) {
 	return database, nil
 }
 
+// NewDatabaseWithConnection usa uma conexão já aberta, sem criar o banco nem rodar verificações
+func NewDatabaseWithConnection(db *sql.DB) *Database {
+	return &Database{db: db}
+}
+
 // Close fecha a conexão com o banco de dados
 func (d *Database) Close() error {
 	return d.db.Close()
@@ -91,9 +101,13 @@ func (d *Database) Close() error {
 
 // CreateCategory insere uma nova categoria no banco
 func (d *Database) CreateCategory(category structs.Category) error {
+	return insertCategory(d.db, category)
+}
+
+func insertCategory(e execer, category structs.Category) error {
 	query := `
-	INSERT INTO categories (id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at, user_id)
-	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	INSERT INTO categories (id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at, user_id, tax_section)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
 	var userID interface{}
@@ -103,7 +117,7 @@ func (d *Database) CreateCategory(category structs.Category) error {
 		userID = category.UserID
 	}
 
-	_, err := d.db.Exec(query,
+	_, err := e.Exec(query,
 		category.ID,
 		category.Name,
 		category.Description,
@@ -116,18 +130,20 @@ func (d *Database) CreateCategory(category structs.Category) error {
 		category.CreatedAt,
 		category.UpdatedAt,
 		userID,
+		category.TaxSection,
 	)
 
 	return err
 }
 
-// GetCategoryByID busca uma categoria pelo ID
-func (d *Database) GetCategoryByID(id string) (*structs.Category, error) {
-	query := `SELECT id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at 
-			  FROM categories WHERE id = $1`
+// GetCategoryByID busca uma categoria do usuário pelo ID. Categorias sem dono (de sistema, como a
+// de transferência) também são encontradas, com UserID vazio.
+func (d *Database) GetCategoryByID(id string, userID string) (*structs.Category, error) {
+	query := `SELECT id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at, tax_section, COALESCE(user_id, '') as user_id 
+			  FROM categories WHERE id = $1 AND (user_id = $2 OR user_id IS NULL)`
 
 	var category structs.Category
-	err := d.db.QueryRow(query, id).Scan(
+	err := d.db.QueryRow(query, id, userID).Scan(
 		&category.ID,
 		&category.Name,
 		&category.Description,
@@ -139,6 +155,8 @@ func (d *Database) GetCategoryByID(id string) (*structs.Category, error) {
 		&category.Visible,
 		&category.CreatedAt,
 		&category.UpdatedAt,
+		&category.TaxSection,
+		&category.UserID,
 	)
 
 	if err != nil {
@@ -185,7 +203,7 @@ func (d *Database) GetCategoryByName(name string, categoryType string, userID st
 
 // GetAllCategories busca todas as categorias do usuário
 func (d *Database) GetAllCategories(userID string) ([]structs.Category, error) {
-	query := `SELECT id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at, deleted_at, user_id 
+	query := `SELECT id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at, deleted_at, user_id, tax_section 
 			  FROM categories WHERE deleted_at IS NULL AND user_id = $1 ORDER BY LOWER(name)`
 
 	rows, err := d.db.Query(query, userID)
@@ -214,6 +232,7 @@ func (d *Database) GetAllCategories(userID string) ([]structs.Category, error) {
 			&category.UpdatedAt,
 			&deletedAt,
 			&userID,
+			&category.TaxSection,
 		)
 		if err != nil {
 			return nil, err
@@ -259,7 +278,7 @@ func (d *Database) GetAllCategories(userID string) ([]structs.Category, error) {
 
 // GetCategoriesByType busca categorias por tipo (receita ou despesa) do usuário
 func (d *Database) GetCategoriesByType(userID string, categoryType structs.CategoryType) ([]structs.Category, error) {
-	query := `SELECT id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at, deleted_at, user_id 
+	query := `SELECT id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at, deleted_at, user_id, tax_section 
 			  FROM categories WHERE type = $1 AND deleted_at IS NULL AND user_id = $2 ORDER BY LOWER(name)`
 
 	rows, err := d.db.Query(query, categoryType, userID)
@@ -288,6 +307,7 @@ func (d *Database) GetCategoriesByType(userID string, categoryType structs.Categ
 			&category.UpdatedAt,
 			&deletedAt,
 			&userID,
+			&category.TaxSection,
 		)
 		if err != nil {
 			return nil, err
@@ -333,7 +353,7 @@ func (d *Database) GetCategoriesByType(userID string, categoryType structs.Categ
 
 // GetSubcategories busca as subcategorias de uma categoria pai do usuário
 func (d *Database) GetSubcategories(parentID string, userID string) ([]structs.Category, error) {
-	query := `SELECT id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at, deleted_at, user_id 
+	query := `SELECT id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at, deleted_at, user_id, tax_section 
 			  FROM categories WHERE parent_id = $1 AND deleted_at IS NULL AND user_id = $2 ORDER BY LOWER(name)`
 
 	rows, err := d.db.Query(query, parentID, userID)
@@ -359,6 +379,7 @@ func (d *Database) GetSubcategories(parentID string, userID string) ([]structs.C
 			&category.UpdatedAt,
 			&category.DeletedAt,
 			&category.UserID,
+			&category.TaxSection,
 		)
 		if err != nil {
 			return nil, err
@@ -370,11 +391,11 @@ func (d *Database) GetSubcategories(parentID string, userID string) ([]structs.C
 }
 
 // GetSubcategoriesIncludingDeleted busca as subcategorias de uma categoria pai incluindo as deletadas
-func (d *Database) GetSubcategoriesIncludingDeleted(parentID string) ([]structs.Category, error) {
+func (d *Database) GetSubcategoriesIncludingDeleted(parentID string, userID string) ([]structs.Category, error) {
 	query := `SELECT id, name, description, type, color, icon, parent_id, is_active, visible, created_at, updated_at, deleted_at 
-			  FROM categories WHERE parent_id = $1 ORDER BY LOWER(name)`
+			  FROM categories WHERE parent_id = $1 AND user_id = $2 ORDER BY LOWER(name)`
 
-	rows, err := d.db.Query(query, parentID)
+	rows, err := d.db.Query(query, parentID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -410,8 +431,8 @@ func (d *Database) GetSubcategoriesIncludingDeleted(parentID string) ([]structs.
 func (d *Database) UpdateCategory(id string, req structs.UpdateCategoryRequest) error {
 	query := `
 	UPDATE categories 
-	SET name = $1, description = $2, color = $3, icon = $4, is_active = $5, visible = $6, updated_at = $7
-	WHERE id = $8 AND user_id = $9
+	SET name = $1, description = $2, color = $3, icon = $4, is_active = $5, visible = $6, tax_section = $7, updated_at = $8
+	WHERE id = $9 AND user_id = $10
 	`
 
 	isActive := true
@@ -431,6 +452,7 @@ func (d *Database) UpdateCategory(id string, req structs.UpdateCategoryRequest)
 		req.Icon,
 		isActive,
 		visible,
+		req.TaxSection,
 		time.Now(),
 		id,
 		req.UserID,
@@ -447,9 +469,9 @@ func (d *Database) DeleteCategory(id string, userID string) error {
 }
 
 // HardDeleteCategory remove uma categoria permanentemente
-func (d *Database) HardDeleteCategory(id string) error {
-	query := `DELETE FROM categories WHERE id = $1`
-	_, err := d.db.Exec(query, id)
+func (d *Database) HardDeleteCategory(id string, userID string) error {
+	query := `DELETE FROM categories WHERE id = $1 AND user_id = $2`
+	_, err := d.db.Exec(query, id, userID)
 	return err
 }
 
@@ -547,6 +569,14 @@ func (d *Database) EnsureTransferCategory() (*structs.Category, error) {
 	return category, nil
 }
 
+// nullableString converte string vazia em NULL ao gravar no banco
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 func (d *Database) GetDB() *sql.DB {
 	return d.db
 }
@@ -0,0 +1,87 @@
+// Package databasetest oferece um banco falso para os testes: toda consulta volta sem linhas,
+// como o banco faz quando o filtro por user_id exclui o registro de outro usuário, e os
+// comandos recebidos ficam guardados para conferência.
+package databasetest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+)
+
+// Call é um comando recebido pelo banco falso
+type Call struct {
+	Query string
+	Args  []driver.Value
+	Exec  bool
+}
+
+// Driver guarda os comandos recebidos pelas conexões abertas com Open
+type Driver struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Open abre um *sql.DB ligado a um banco falso novo, fechado ao fim do teste
+func Open(t testing.TB) (*sql.DB, *Driver) {
+	t.Helper()
+	d := &Driver{}
+	db := sql.OpenDB(d)
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func (d *Driver) Connect(context.Context) (driver.Conn, error) { return &conn{d}, nil }
+func (d *Driver) Driver() driver.Driver                        { return d }
+func (d *Driver) Open(string) (driver.Conn, error)             { return &conn{d}, nil }
+
+func (d *Driver) record(call Call) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.calls = append(d.calls, call)
+}
+
+// Reset devolve os comandos recebidos desde a última chamada e limpa a lista
+func (d *Driver) Reset() []Call {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	calls := d.calls
+	d.calls = nil
+	return calls
+}
+
+type conn struct{ d *Driver }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) { return &stmt{c.d, query}, nil }
+func (c *conn) Close() error                              { return nil }
+func (c *conn) Begin() (driver.Tx, error)                 { return tx{}, nil }
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type stmt struct {
+	d     *Driver
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.record(Call{Query: s.query, Args: args, Exec: true})
+	return driver.RowsAffected(0), nil
+}
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.record(Call{Query: s.query, Args: args})
+	return rows{}, nil
+}
+
+type rows struct{}
+
+func (rows) Columns() []string         { return nil }
+func (rows) Close() error              { return nil }
+func (rows) Next([]driver.Value) error { return io.EOF }
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+const exchangeRateColumns = `id, user_id, from_currency, to_currency, rate_date, rate, source, created_at, updated_at`
+
+// scanExchangeRate lê uma taxa de câmbio na ordem de exchangeRateColumns
+func scanExchangeRate(row rowScanner) (*structs.ExchangeRate, error) {
+	var rate structs.ExchangeRate
+	err := row.Scan(
+		&rate.ID,
+		&rate.UserID,
+		&rate.FromCurrency,
+		&rate.ToCurrency,
+		&rate.Date,
+		&rate.Rate,
+		&rate.Source,
+		&rate.CreatedAt,
+		&rate.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// SaveExchangeRate grava a taxa do par na data, substituindo a taxa existente do mesmo dono
+// (usuário ou API) para o mesmo par e data
+func (d *Database) SaveExchangeRate(rate structs.ExchangeRate) error {
+	conflict := `(from_currency, to_currency, rate_date) WHERE user_id IS NULL`
+	if rate.UserID != nil {
+		conflict = `(user_id, from_currency, to_currency, rate_date) WHERE user_id IS NOT NULL`
+	}
+	query := `INSERT INTO exchange_rates (id, user_id, from_currency, to_currency, rate_date, rate, source, created_at, updated_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+              ON CONFLICT ` + conflict + ` DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source, updated_at = EXCLUDED.updated_at`
+	_, err := d.db.Exec(query, rate.ID, rate.UserID, rate.FromCurrency, rate.ToCurrency, rate.Date, rate.Rate, rate.Source, time.Now())
+	if err != nil {
+		return fmt.Errorf("erro ao salvar taxa de câmbio: %w", err)
+	}
+	return nil
+}
+
+// GetExchangeRates lista as taxas visíveis ao usuário (as dele e as da API) entre as duas moedas,
+// nos dois sentidos, até a data informada, da mais antiga para a mais recente. Quando o usuário e
+// a API têm taxa para o mesmo par e data, as duas são devolvidas e a do usuário vem por último.
+func (d *Database) GetExchangeRates(userID, currencyA, currencyB string, until time.Time) ([]structs.ExchangeRate, error) {
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates
+              WHERE (user_id IS NULL OR user_id = $1)
+                AND ((from_currency = $2 AND to_currency = $3) OR (from_currency = $3 AND to_currency = $2))
+                AND rate_date <= $4
+              ORDER BY rate_date ASC, (user_id IS NOT NULL) ASC`
+	rows, err := d.db.Query(query, userID, currencyA, currencyB, until)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar taxas de câmbio: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []structs.ExchangeRate
+	for rows.Next() {
+		rate, err := scanExchangeRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *rate)
+	}
+	return rates, rows.Err()
+}
+
+// DeleteExchangeRate remove uma taxa informada pelo usuário
+func (d *Database) DeleteExchangeRate(id, userID string) (bool, error) {
+	result, err := d.db.Exec(`DELETE FROM exchange_rates WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover taxa de câmbio: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
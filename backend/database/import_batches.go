@@ -0,0 +1,254 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+const importBatchColumns = `id, user_id, account_id, format, file_name, file_hash, date_start, date_end, transactions_total, transactions_imported, transactions_skipped, errors, status, created_at, updated_at, reverted_at, ledger_balance, ledger_balance_date, opening_balance, computed_balance, balance_difference`
+
+// scanImportBatch lê um lote de importação na ordem de importBatchColumns
+func scanImportBatch(row rowScanner) (*structs.ImportBatch, error) {
+	var batch structs.ImportBatch
+	var dateStart, dateEnd, revertedAt, ledgerBalanceDate sql.NullTime
+	var ledgerBalance, openingBalance, computedBalance, balanceDifference sql.NullInt64
+	var errors pq.StringArray
+
+	err := row.Scan(
+		&batch.ID,
+		&batch.UserID,
+		&batch.AccountID,
+		&batch.Format,
+		&batch.FileName,
+		&batch.FileHash,
+		&dateStart,
+		&dateEnd,
+		&batch.TransactionsTotal,
+		&batch.TransactionsImported,
+		&batch.TransactionsSkipped,
+		&errors,
+		&batch.Status,
+		&batch.CreatedAt,
+		&batch.UpdatedAt,
+		&revertedAt,
+		&ledgerBalance,
+		&ledgerBalanceDate,
+		&openingBalance,
+		&computedBalance,
+		&balanceDifference,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if dateStart.Valid {
+		batch.DateStart = &dateStart.Time
+	}
+	if dateEnd.Valid {
+		batch.DateEnd = &dateEnd.Time
+	}
+	if revertedAt.Valid {
+		batch.RevertedAt = &revertedAt.Time
+	}
+	if ledgerBalanceDate.Valid {
+		batch.LedgerBalanceDate = &ledgerBalanceDate.Time
+	}
+	batch.LedgerBalance = nullableInt(ledgerBalance)
+	batch.OpeningBalance = nullableInt(openingBalance)
+	batch.ComputedBalance = nullableInt(computedBalance)
+	batch.BalanceDifference = nullableInt(balanceDifference)
+	batch.Errors = []string(errors)
+	if batch.Errors == nil {
+		batch.Errors = []string{}
+	}
+
+	return &batch, nil
+}
+
+// nullableInt converte um inteiro anulável do banco em ponteiro
+func nullableInt(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	v := int(value.Int64)
+	return &v
+}
+
+// CreateImportBatch insere um novo lote de importação
+func (d *Database) CreateImportBatch(batch structs.ImportBatch) error {
+	query := `
+	INSERT INTO import_batches (` + importBatchColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+	`
+	_, err := d.db.Exec(query,
+		batch.ID,
+		batch.UserID,
+		batch.AccountID,
+		batch.Format,
+		batch.FileName,
+		batch.FileHash,
+		batch.DateStart,
+		batch.DateEnd,
+		batch.TransactionsTotal,
+		batch.TransactionsImported,
+		batch.TransactionsSkipped,
+		pq.StringArray(batch.Errors),
+		batch.Status,
+		batch.CreatedAt,
+		batch.UpdatedAt,
+		batch.RevertedAt,
+		batch.LedgerBalance,
+		batch.LedgerBalanceDate,
+		batch.OpeningBalance,
+		batch.ComputedBalance,
+		batch.BalanceDifference,
+	)
+	return err
+}
+
+// FinishImportBatch grava os totais do processamento e a situação final do lote (concluído ou cancelado)
+func (d *Database) FinishImportBatch(batch structs.ImportBatch) error {
+	query := `
+	UPDATE import_batches
+	SET transactions_total = $1, transactions_imported = $2, transactions_skipped = $3, errors = $4, status = $5, updated_at = $6
+	WHERE id = $7 AND user_id = $8
+	`
+	_, err := d.db.Exec(query,
+		batch.TransactionsTotal,
+		batch.TransactionsImported,
+		batch.TransactionsSkipped,
+		pq.StringArray(batch.Errors),
+		batch.Status,
+		time.Now(),
+		batch.ID,
+		batch.UserID,
+	)
+	return err
+}
+
+// UpdateImportBatchBalance grava o resultado da conferência de saldo do lote
+func (d *Database) UpdateImportBatchBalance(batch structs.ImportBatch) error {
+	query := `
+	UPDATE import_batches
+	SET computed_balance = $1, balance_difference = $2, updated_at = $3
+	WHERE id = $4 AND user_id = $5
+	`
+	_, err := d.db.Exec(query, batch.ComputedBalance, batch.BalanceDifference, time.Now(), batch.ID, batch.UserID)
+	return err
+}
+
+// GetImportBatchByID busca um lote de importação do usuário
+func (d *Database) GetImportBatchByID(id string, userID string) (*structs.ImportBatch, error) {
+	query := `SELECT ` + importBatchColumns + ` FROM import_batches WHERE id = $1 AND user_id = $2`
+	batch, err := scanImportBatch(d.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return batch, nil
+}
+
+// GetActiveImportBatchByHash busca um lote do usuário com o mesmo hash de arquivo que não foi
+// revertido nem cancelado. Reenviar um arquivo cancelado retoma a importação: as linhas já
+// criadas são reconhecidas como duplicatas.
+func (d *Database) GetActiveImportBatchByHash(fileHash string, userID string) (*structs.ImportBatch, error) {
+	query := `SELECT ` + importBatchColumns + ` FROM import_batches WHERE file_hash = $1 AND user_id = $2 AND status NOT IN ($3, $4) ORDER BY created_at DESC LIMIT 1`
+	batch, err := scanImportBatch(d.db.QueryRow(query, fileHash, userID, structs.ImportBatchStatusReverted, structs.ImportBatchStatusCancelled))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return batch, nil
+}
+
+// GetImportBatchesByUser lista os lotes de importação do usuário, opcionalmente filtrando pela conta
+func (d *Database) GetImportBatchesByUser(userID string, accountID string) ([]structs.ImportBatch, error) {
+	query := `SELECT ` + importBatchColumns + ` FROM import_batches WHERE user_id = $1 AND ($2 = '' OR account_id = $2) ORDER BY created_at DESC`
+	rows, err := d.db.Query(query, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := make([]structs.ImportBatch, 0)
+	for rows.Next() {
+		batch, err := scanImportBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, *batch)
+	}
+	return batches, rows.Err()
+}
+
+// GetTransactionsByImportBatch lista as transações ativas criadas por um lote de importação
+func (d *Database) GetTransactionsByImportBatch(batchID string, userID string) ([]structs.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE import_batch_id = $1 AND user_id = $2 AND deleted_at IS NULL ORDER BY due_date ASC, created_at ASC`
+	rows, err := d.db.Query(query, batchID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransactions(rows)
+}
+
+// GetBalanceCheckpoints lista os lotes ativos da conta que informaram o saldo do banco,
+// do saldo mais antigo para o mais recente
+func (d *Database) GetBalanceCheckpoints(accountID string, userID string) ([]structs.ImportBatch, error) {
+	query := `SELECT ` + importBatchColumns + ` FROM import_batches
+			  WHERE account_id = $1 AND user_id = $2 AND status = $3 AND ledger_balance IS NOT NULL AND ledger_balance_date IS NOT NULL
+			  ORDER BY ledger_balance_date ASC, created_at ASC`
+	rows, err := d.db.Query(query, accountID, userID, structs.ImportBatchStatusCompleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := make([]structs.ImportBatch, 0)
+	for rows.Next() {
+		batch, err := scanImportBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, *batch)
+	}
+	return batches, rows.Err()
+}
+
+// RevertImportBatch remove (soft delete) as transações do lote e o marca como revertido,
+// retornando quantas transações foram removidas
+func (d *Database) RevertImportBatch(batchID string, userID string) (int64, error) {
+	dbTx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer dbTx.Rollback()
+
+	now := time.Now()
+	result, err := dbTx.Exec(`UPDATE transactions SET deleted_at = $1, updated_at = $1 WHERE import_batch_id = $2 AND user_id = $3 AND deleted_at IS NULL`,
+		now, batchID, userID)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = dbTx.Exec(`UPDATE import_batches SET status = $1, reverted_at = $2, updated_at = $2 WHERE id = $3 AND user_id = $4`,
+		structs.ImportBatchStatusReverted, now, batchID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
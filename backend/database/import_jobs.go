@@ -0,0 +1,264 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+const importJobColumns = `id, user_id, account_id, kind, format, file_name, status, total_lines, processed_lines, transactions_imported, transactions_skipped, import_batch_id, outcomes, result, error, cancel_requested, payload, created_at, updated_at, started_at, finished_at`
+
+// scanImportJob lê um job de importação na ordem de importJobColumns
+func scanImportJob(row rowScanner) (*structs.ImportJob, error) {
+	var job structs.ImportJob
+	var batchID sql.NullString
+	var outcomes, payload []byte
+	var result []byte
+	var startedAt, finishedAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.AccountID,
+		&job.Kind,
+		&job.Format,
+		&job.FileName,
+		&job.Status,
+		&job.TotalLines,
+		&job.ProcessedLines,
+		&job.TransactionsImported,
+		&job.TransactionsSkipped,
+		&batchID,
+		&outcomes,
+		&result,
+		&job.Error,
+		&job.CancelRequested,
+		&payload,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.ImportBatchID = batchID.String
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	if err := json.Unmarshal(outcomes, &job.Outcomes); err != nil {
+		return nil, err
+	}
+	if job.Outcomes == nil {
+		job.Outcomes = []structs.ImportLineOutcome{}
+	}
+	if len(result) > 0 {
+		if err := json.Unmarshal(result, &job.Result); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(payload, &job.Payload); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// CreateImportJob insere um novo job de importação
+func (d *Database) CreateImportJob(job structs.ImportJob) error {
+	outcomes, err := json.Marshal(job.Outcomes)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(job.Payload)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO import_jobs (id, user_id, account_id, kind, format, file_name, status, total_lines, outcomes, payload, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`
+	_, err = d.db.Exec(query,
+		job.ID,
+		job.UserID,
+		job.AccountID,
+		job.Kind,
+		job.Format,
+		job.FileName,
+		job.Status,
+		job.TotalLines,
+		string(outcomes),
+		string(payload),
+		job.CreatedAt,
+		job.UpdatedAt,
+	)
+	return err
+}
+
+// GetImportJob busca um job de importação do usuário
+func (d *Database) GetImportJob(id string, userID string) (*structs.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE id = $1 AND user_id = $2`
+	job, err := scanImportJob(d.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetImportJobByID busca um job de importação pelo ID, sem filtrar pelo usuário (uso dos workers)
+func (d *Database) GetImportJobByID(id string) (*structs.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE id = $1`
+	job, err := scanImportJob(d.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetImportJobsByUser lista os jobs de importação mais recentes do usuário
+func (d *Database) GetImportJobsByUser(userID string, limit int) ([]structs.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+	rows, err := d.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanImportJobs(rows)
+}
+
+// GetUnfinishedImportJobs lista os jobs que estavam na fila ou em processamento, do mais antigo ao mais novo
+func (d *Database) GetUnfinishedImportJobs() ([]structs.ImportJob, error) {
+	query := `SELECT ` + importJobColumns + ` FROM import_jobs WHERE status IN ($1, $2) ORDER BY created_at ASC`
+	rows, err := d.db.Query(query, structs.ImportJobStatusQueued, structs.ImportJobStatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	return scanImportJobs(rows)
+}
+
+func scanImportJobs(rows *sql.Rows) ([]structs.ImportJob, error) {
+	defer rows.Close()
+
+	jobs := make([]structs.ImportJob, 0)
+	for rows.Next() {
+		job, err := scanImportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// StartImportJob marca o job como em processamento, antes de o lote de importação ser criado.
+// Retorna false quando o job foi cancelado enquanto aguardava na fila.
+func (d *Database) StartImportJob(job structs.ImportJob) (bool, error) {
+	query := `UPDATE import_jobs SET status = $1, import_batch_id = $2, started_at = $3, updated_at = $4 WHERE id = $5 AND status IN ($6, $1)`
+	result, err := d.db.Exec(query, structs.ImportJobStatusRunning, nullableString(job.ImportBatchID), job.StartedAt, time.Now(), job.ID, structs.ImportJobStatusQueued)
+	if err != nil {
+		return false, err
+	}
+	started, err := result.RowsAffected()
+	return started > 0, err
+}
+
+// SetImportJobBatch grava o lote de importação criado para o job
+func (d *Database) SetImportJobBatch(id string, batchID string) error {
+	_, err := d.db.Exec(`UPDATE import_jobs SET import_batch_id = $1, updated_at = $2 WHERE id = $3`, batchID, time.Now(), id)
+	return err
+}
+
+// UpdateImportJobProgress grava o progresso do job e retorna se o usuário pediu o cancelamento
+func (d *Database) UpdateImportJobProgress(job structs.ImportJob) (bool, error) {
+	outcomes, err := json.Marshal(job.Outcomes)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+	UPDATE import_jobs
+	SET processed_lines = $1, transactions_imported = $2, transactions_skipped = $3, outcomes = $4, updated_at = $5
+	WHERE id = $6
+	RETURNING cancel_requested
+	`
+	var cancelRequested bool
+	err = d.db.QueryRow(query,
+		job.ProcessedLines,
+		job.TransactionsImported,
+		job.TransactionsSkipped,
+		string(outcomes),
+		time.Now(),
+		job.ID,
+	).Scan(&cancelRequested)
+	return cancelRequested, err
+}
+
+// FinishImportJob grava o estado final do job
+func (d *Database) FinishImportJob(job structs.ImportJob) error {
+	outcomes, err := json.Marshal(job.Outcomes)
+	if err != nil {
+		return err
+	}
+	var result interface{}
+	if job.Result != nil {
+		encoded, err := json.Marshal(job.Result)
+		if err != nil {
+			return err
+		}
+		result = string(encoded)
+	}
+
+	query := `
+	UPDATE import_jobs
+	SET status = $1, processed_lines = $2, transactions_imported = $3, transactions_skipped = $4, outcomes = $5,
+	    result = $6, error = $7, finished_at = $8, updated_at = $8
+	WHERE id = $9
+	`
+	_, err = d.db.Exec(query,
+		job.Status,
+		job.ProcessedLines,
+		job.TransactionsImported,
+		job.TransactionsSkipped,
+		string(outcomes),
+		result,
+		job.Error,
+		job.FinishedAt,
+		job.ID,
+	)
+	return err
+}
+
+// RequestImportJobCancel pede o cancelamento de um job ainda não finalizado.
+// Jobs na fila são cancelados imediatamente. Retorna false quando o job já terminou.
+func (d *Database) RequestImportJobCancel(id string, userID string) (bool, error) {
+	now := time.Now()
+	result, err := d.db.Exec(`UPDATE import_jobs SET status = $1, cancel_requested = TRUE, finished_at = $2, updated_at = $2
+		WHERE id = $3 AND user_id = $4 AND status = $5`,
+		structs.ImportJobStatusCancelled, now, id, userID, structs.ImportJobStatusQueued)
+	if err != nil {
+		return false, err
+	}
+	if cancelled, err := result.RowsAffected(); err != nil || cancelled > 0 {
+		return cancelled > 0, err
+	}
+
+	result, err = d.db.Exec(`UPDATE import_jobs SET cancel_requested = TRUE, updated_at = $1 WHERE id = $2 AND user_id = $3 AND status = $4`,
+		now, id, userID, structs.ImportJobStatusRunning)
+	if err != nil {
+		return false, err
+	}
+	requested, err := result.RowsAffected()
+	return requested > 0, err
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// CreateImportPreview grava uma pré-visualização de importação
+func (d *Database) CreateImportPreview(preview structs.ImportPreview) error {
+	lines, err := json.Marshal(preview.Lines)
+	if err != nil {
+		return err
+	}
+
+	var ledgerBalance *int
+	var ledgerBalanceDate *time.Time
+	if preview.LedgerBalance != nil {
+		ledgerBalance = &preview.LedgerBalance.Amount
+		ledgerBalanceDate = &preview.LedgerBalance.Date
+	}
+
+	query := `
+	INSERT INTO import_previews (id, user_id, format, file_name, file_hash, lines, created_at, expires_at, ledger_balance, ledger_balance_date)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	_, err = d.db.Exec(query,
+		preview.ID,
+		preview.UserID,
+		preview.Format,
+		preview.FileName,
+		preview.FileHash,
+		string(lines),
+		preview.CreatedAt,
+		preview.ExpiresAt,
+		ledgerBalance,
+		ledgerBalanceDate,
+	)
+	return err
+}
+
+// GetImportPreview busca uma pré-visualização ainda válida do usuário
+func (d *Database) GetImportPreview(id string, userID string) (*structs.ImportPreview, error) {
+	query := `SELECT id, user_id, format, file_name, file_hash, lines, created_at, expires_at, ledger_balance, ledger_balance_date
+			  FROM import_previews WHERE id = $1 AND user_id = $2 AND expires_at > $3`
+
+	var preview structs.ImportPreview
+	var lines []byte
+	var ledgerBalance sql.NullInt64
+	var ledgerBalanceDate sql.NullTime
+	err := d.db.QueryRow(query, id, userID, time.Now()).Scan(
+		&preview.ID,
+		&preview.UserID,
+		&preview.Format,
+		&preview.FileName,
+		&preview.FileHash,
+		&lines,
+		&preview.CreatedAt,
+		&preview.ExpiresAt,
+		&ledgerBalance,
+		&ledgerBalanceDate,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(lines, &preview.Lines); err != nil {
+		return nil, err
+	}
+	if ledgerBalance.Valid && ledgerBalanceDate.Valid {
+		preview.LedgerBalance = &structs.StatementBalance{Amount: int(ledgerBalance.Int64), Date: ledgerBalanceDate.Time}
+	}
+	return &preview, nil
+}
+
+// DeleteImportPreview remove uma pré-visualização já utilizada
+func (d *Database) DeleteImportPreview(id string, userID string) error {
+	_, err := d.db.Exec(`DELETE FROM import_previews WHERE id = $1 AND user_id = $2`, id, userID)
+	return err
+}
+
+// DeleteExpiredImportPreviews remove as pré-visualizações vencidas
+func (d *Database) DeleteExpiredImportPreviews() error {
+	_, err := d.db.Exec(`DELETE FROM import_previews WHERE expires_at <= $1`, time.Now())
+	return err
+}
@@ -0,0 +1,314 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+const payeeColumns = `id, user_id, name, normalized_name, aliases, created_at, updated_at, deleted_at`
+
+// scanPayee lê um beneficiário na ordem de payeeColumns
+func scanPayee(row rowScanner, extra ...interface{}) (*structs.Payee, error) {
+	var payee structs.Payee
+	var aliases pq.StringArray
+	var deletedAt sql.NullTime
+
+	dest := []interface{}{
+		&payee.ID,
+		&payee.UserID,
+		&payee.Name,
+		&payee.NormalizedName,
+		&aliases,
+		&payee.CreatedAt,
+		&payee.UpdatedAt,
+		&deletedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	payee.Aliases = []string(aliases)
+	if payee.Aliases == nil {
+		payee.Aliases = []string{}
+	}
+	if deletedAt.Valid {
+		payee.DeletedAt = &deletedAt.Time
+	}
+	return &payee, nil
+}
+
+// CreatePayee insere um novo beneficiário
+func (d *Database) CreatePayee(payee structs.Payee) error {
+	return insertPayee(d.db, payee)
+}
+
+func insertPayee(e execer, payee structs.Payee) error {
+	query := `
+	INSERT INTO payees (` + payeeColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := e.Exec(query,
+		payee.ID,
+		payee.UserID,
+		payee.Name,
+		payee.NormalizedName,
+		tagsArray(payee.Aliases),
+		payee.CreatedAt,
+		payee.UpdatedAt,
+		payee.DeletedAt,
+	)
+	return err
+}
+
+// GetPayeeByID busca um beneficiário do usuário
+func (d *Database) GetPayeeByID(id string, userID string) (*structs.Payee, error) {
+	query := `SELECT ` + payeeColumns + ` FROM payees WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	payee, err := scanPayee(d.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return payee, nil
+}
+
+// GetPayeesByUser lista os beneficiários do usuário em ordem alfabética, com a quantidade
+// de transações e a data da mais recente
+func (d *Database) GetPayeesByUser(userID string) ([]structs.Payee, error) {
+	query := `
+	SELECT p.id, p.user_id, p.name, p.normalized_name, p.aliases, p.created_at, p.updated_at, p.deleted_at,
+		COUNT(t.id), MAX(t.due_date)
+	FROM payees p
+	LEFT JOIN transactions t ON t.payee_id = p.id AND t.deleted_at IS NULL
+	WHERE p.user_id = $1 AND p.deleted_at IS NULL
+	GROUP BY p.id
+	ORDER BY p.name ASC
+	`
+	rows, err := d.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payees := make([]structs.Payee, 0)
+	for rows.Next() {
+		var lastTransactionAt sql.NullTime
+		var count int
+		payee, err := scanPayee(rows, &count, &lastTransactionAt)
+		if err != nil {
+			return nil, err
+		}
+		payee.TransactionCount = count
+		if lastTransactionAt.Valid {
+			payee.LastTransactionAt = &lastTransactionAt.Time
+		}
+		payees = append(payees, *payee)
+	}
+	return payees, rows.Err()
+}
+
+// UpdatePayee grava o nome e os nomes alternativos de um beneficiário, propagando o
+// nome para as transações dele
+func (d *Database) UpdatePayee(payee structs.Payee) error {
+	dbTx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	now := time.Now()
+	_, err = dbTx.Exec(`UPDATE payees SET name = $1, normalized_name = $2, aliases = $3, updated_at = $4 WHERE id = $5 AND user_id = $6`,
+		payee.Name, payee.NormalizedName, tagsArray(payee.Aliases), now, payee.ID, payee.UserID)
+	if err != nil {
+		return err
+	}
+	_, err = dbTx.Exec(`UPDATE transactions SET payee = $1, updated_at = $2 WHERE payee_id = $3 AND user_id = $4`,
+		payee.Name, now, payee.ID, payee.UserID)
+	if err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// DeletePayee remove um beneficiário (soft delete) com suas regras. As transações
+// mantêm o nome do beneficiário em texto, mas deixam de apontar para ele.
+func (d *Database) DeletePayee(id string, userID string) error {
+	dbTx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	now := time.Now()
+	if _, err := dbTx.Exec(`UPDATE payees SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND user_id = $3`, now, id, userID); err != nil {
+		return err
+	}
+	if _, err := dbTx.Exec(`UPDATE payee_rules SET deleted_at = $1, updated_at = $1 WHERE payee_id = $2 AND user_id = $3 AND deleted_at IS NULL`, now, id, userID); err != nil {
+		return err
+	}
+	if _, err := dbTx.Exec(`UPDATE transactions SET payee_id = NULL, updated_at = $1 WHERE payee_id = $2 AND user_id = $3`, now, id, userID); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// MergePayees move as transações e regras dos beneficiários de origem para o destino,
+// grava os nomes alternativos do destino e remove os de origem
+func (d *Database) MergePayees(target structs.Payee, sourceIDs []string) error {
+	dbTx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	now := time.Now()
+	for _, sourceID := range sourceIDs {
+		if _, err := dbTx.Exec(`UPDATE transactions SET payee_id = $1, payee = $2, updated_at = $3 WHERE payee_id = $4 AND user_id = $5`,
+			target.ID, target.Name, now, sourceID, target.UserID); err != nil {
+			return err
+		}
+		if _, err := dbTx.Exec(`UPDATE payee_rules SET payee_id = $1, updated_at = $2 WHERE payee_id = $3 AND user_id = $4`,
+			target.ID, now, sourceID, target.UserID); err != nil {
+			return err
+		}
+		if _, err := dbTx.Exec(`UPDATE payees SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND user_id = $3`,
+			now, sourceID, target.UserID); err != nil {
+			return err
+		}
+	}
+	if _, err := dbTx.Exec(`UPDATE payees SET aliases = $1, updated_at = $2 WHERE id = $3 AND user_id = $4`,
+		tagsArray(target.Aliases), now, target.ID, target.UserID); err != nil {
+		return err
+	}
+
+	return dbTx.Commit()
+}
+
+// GetTransactionsByPayee lista as transações de um beneficiário, opcionalmente dentro de um período
+func (d *Database) GetTransactionsByPayee(payeeID string, userID string, from *time.Time, to *time.Time) ([]structs.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions
+		WHERE payee_id = $1 AND user_id = $2 AND deleted_at IS NULL
+		AND ($3::timestamp IS NULL OR due_date >= $3) AND ($4::timestamp IS NULL OR due_date < $4)
+		ORDER BY due_date DESC, created_at DESC`
+
+	var end *time.Time
+	if to != nil {
+		next := to.AddDate(0, 0, 1)
+		end = &next
+	}
+	rows, err := d.db.Query(query, payeeID, userID, from, end)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransactions(rows)
+}
+
+// UpdateTransactionPayee grava o beneficiário de uma transação
+func (d *Database) UpdateTransactionPayee(id string, userID string, payeeID string, payeeName string) error {
+	query := `UPDATE transactions SET payee_id = $1, payee = $2, updated_at = $3 WHERE id = $4 AND user_id = $5`
+	_, err := d.db.Exec(query, payeeID, payeeName, time.Now(), id, userID)
+	return err
+}
+
+const payeeRuleColumns = `id, user_id, payee_id, pattern, match_type, priority, created_at, updated_at, deleted_at`
+
+func scanPayeeRule(row rowScanner) (*structs.PayeeRule, error) {
+	var rule structs.PayeeRule
+	var deletedAt sql.NullTime
+
+	err := row.Scan(
+		&rule.ID,
+		&rule.UserID,
+		&rule.PayeeID,
+		&rule.Pattern,
+		&rule.MatchType,
+		&rule.Priority,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if deletedAt.Valid {
+		rule.DeletedAt = &deletedAt.Time
+	}
+	return &rule, nil
+}
+
+// CreatePayeeRule insere uma nova regra de beneficiário
+func (d *Database) CreatePayeeRule(rule structs.PayeeRule) error {
+	return insertPayeeRule(d.db, rule)
+}
+
+func insertPayeeRule(e execer, rule structs.PayeeRule) error {
+	query := `
+	INSERT INTO payee_rules (` + payeeRuleColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := e.Exec(query,
+		rule.ID,
+		rule.UserID,
+		rule.PayeeID,
+		rule.Pattern,
+		rule.MatchType,
+		rule.Priority,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+		rule.DeletedAt,
+	)
+	return err
+}
+
+// GetPayeeRuleByID busca uma regra de beneficiário do usuário
+func (d *Database) GetPayeeRuleByID(id string, userID string) (*structs.PayeeRule, error) {
+	query := `SELECT ` + payeeRuleColumns + ` FROM payee_rules WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	rule, err := scanPayeeRule(d.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetPayeeRulesByUser lista as regras de beneficiário do usuário em ordem de prioridade
+func (d *Database) GetPayeeRulesByUser(userID string) ([]structs.PayeeRule, error) {
+	query := `SELECT ` + payeeRuleColumns + ` FROM payee_rules WHERE user_id = $1 AND deleted_at IS NULL ORDER BY priority ASC, created_at ASC`
+	rows, err := d.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]structs.PayeeRule, 0)
+	for rows.Next() {
+		rule, err := scanPayeeRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+// UpdatePayeeRule atualiza uma regra de beneficiário
+func (d *Database) UpdatePayeeRule(rule structs.PayeeRule) error {
+	query := `UPDATE payee_rules SET payee_id = $1, pattern = $2, match_type = $3, priority = $4, updated_at = $5 WHERE id = $6 AND user_id = $7`
+	_, err := d.db.Exec(query, rule.PayeeID, rule.Pattern, rule.MatchType, rule.Priority, time.Now(), rule.ID, rule.UserID)
+	return err
+}
+
+// DeletePayeeRule remove uma regra de beneficiário (soft delete)
+func (d *Database) DeletePayeeRule(id string, userID string) error {
+	query := `UPDATE payee_rules SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND user_id = $3`
+	_, err := d.db.Exec(query, time.Now(), id, userID)
+	return err
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// pivotDimension é a expressão SQL da chave e do rótulo de uma dimensão da consulta dinâmica.
+// Em key e label, {date} é trocado pela coluna de data da consulta.
+type pivotDimension struct {
+	key   string
+	label string
+}
+
+// pivotDimensions são as únicas expressões que entram no SQL da consulta dinâmica; os valores
+// informados pelo usuário vão sempre como parâmetros
+var pivotDimensions = map[string]pivotDimension{
+	"month":           {"to_char({date}, 'YYYY-MM')", "to_char({date}, 'YYYY-MM')"},
+	"week":            {"to_char(date_trunc('week', {date}), 'YYYY-MM-DD')", "to_char(date_trunc('week', {date}), 'YYYY-MM-DD')"},
+	"year":            {"to_char({date}, 'YYYY')", "to_char({date}, 'YYYY')"},
+	"category":        {"t.category_id", "COALESCE(c.name, 'Sem categoria')"},
+	"parent_category": {"COALESCE(c.parent_id, t.category_id)", "COALESCE(pc.name, c.name, 'Sem categoria')"},
+	"account":         {"t.account_id", "COALESCE(a.name, 'Conta removida')"},
+	"currency":        {"COALESCE(a.currency, '')", "COALESCE(a.currency, '')"},
+	"type":            {"t.type", "CASE t.type WHEN 'income' THEN 'Receita' WHEN 'expense' THEN 'Despesa' ELSE t.type END"},
+	"tag":             {"COALESCE(tg.tag, '')", "COALESCE(tg.tag, 'Sem tag')"},
+	"payee":           {"COALESCE(p.name, NULLIF(t.payee, ''), '')", "COALESCE(p.name, NULLIF(t.payee, ''), 'Sem beneficiário')"},
+}
+
+// GetPivotGroups soma as transações do usuário agrupadas pelas dimensões da consulta. Com a
+// dimensão tag, uma transação com várias tags entra no grupo de cada uma. Retorna até
+// spec.Limit+1 grupos, para quem chama saber que o resultado passou do limite.
+func (d *Database) GetPivotGroups(userID string, spec structs.PivotSpec) ([]structs.PivotGroup, error) {
+	where, args := transactionFilterQuery(userID, spec.Filter)
+	conditions := []string{where}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, strings.ReplaceAll(condition, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+	if spec.Currency != "" {
+		add("a.currency = $?", spec.Currency)
+	}
+	if len(spec.Tags) > 0 {
+		add("t.tags && $?", pq.StringArray(spec.Tags))
+	}
+	if !spec.IncludeTransfers {
+		conditions = append(conditions, "t.transfer_id IS NULL", "(c.type IS NULL OR c.type <> 'transfer')")
+	}
+
+	dateColumn := "t.due_date"
+	if spec.Filter.ByCompetence {
+		dateColumn = "t.competence_date"
+	}
+
+	var keys, labels, groupBy, orderBy []string
+	joinTags := false
+	for i, name := range spec.Dimensions {
+		dimension, ok := pivotDimensions[name]
+		if !ok {
+			return nil, fmt.Errorf("dimensão inválida: %s", name)
+		}
+		joinTags = joinTags || name == "tag"
+		keys = append(keys, strings.ReplaceAll(dimension.key, "{date}", dateColumn))
+		labels = append(labels, strings.ReplaceAll(dimension.label, "{date}", dateColumn))
+		groupBy = append(groupBy, fmt.Sprintf("%d, %d", 2*i+1, 2*i+2))
+		// Em ordem alfabética pelo rótulo; nos períodos, o rótulo já é cronológico
+		orderBy = append(orderBy, fmt.Sprintf("%d, %d", 2*i+2, 2*i+1))
+	}
+
+	var columns []string
+	for i := range keys {
+		columns = append(columns, keys[i], labels[i])
+	}
+	columns = append(columns,
+		"COALESCE(SUM(t.amount), 0)",
+		"COALESCE(SUM(CASE WHEN t.type = 'income' THEN t.amount ELSE -t.amount END), 0)",
+		"COUNT(*)")
+
+	query := `SELECT ` + strings.Join(columns, ", ") + `
+	FROM transactions t
+	LEFT JOIN accounts a ON a.id = t.account_id
+	LEFT JOIN categories c ON c.id = t.category_id
+	LEFT JOIN categories pc ON pc.id = c.parent_id
+	LEFT JOIN payees p ON p.id = t.payee_id`
+	if joinTags {
+		query += `
+	LEFT JOIN LATERAL unnest(t.tags) AS tg(tag) ON TRUE`
+	}
+	query += `
+	WHERE ` + strings.Join(conditions, " AND ")
+	if len(groupBy) > 0 {
+		query += `
+	GROUP BY ` + strings.Join(groupBy, ", ") + `
+	ORDER BY ` + strings.Join(orderBy, ", ")
+	}
+	args = append(args, spec.Limit+1)
+	query += fmt.Sprintf(`
+	LIMIT $%d`, len(args))
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao agrupar transações: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []structs.PivotGroup
+	for rows.Next() {
+		group := structs.PivotGroup{
+			Keys:   make([]string, len(keys)),
+			Labels: make([]string, len(keys)),
+		}
+		var sum, net int64
+		dest := make([]interface{}, 0, 2*len(keys)+3)
+		for i := range keys {
+			dest = append(dest, &group.Keys[i], &group.Labels[i])
+		}
+		dest = append(dest, &sum, &net, &group.Count)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		group.Sum = int(sum)
+		group.Net = int(net)
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
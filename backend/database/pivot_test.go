@@ -0,0 +1,82 @@
+package database
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tonnarruda/my-personal-finance/database/databasetest"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+func TestGetPivotGroupsUsesOnlyParameters(t *testing.T) {
+	sqlDB, fake := databasetest.Open(t)
+	d := &Database{db: sqlDB}
+
+	// Valores com cara de SQL, que só podem chegar ao banco como parâmetros
+	malicious := "x'); DROP TABLE transactions; --"
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	isPaid := true
+	spec := structs.PivotSpec{
+		Dimensions: []string{"month", "tag"},
+		Filter: structs.TransactionFilter{
+			From:       &from,
+			To:         &to,
+			AccountID:  malicious,
+			CategoryID: malicious,
+			Type:       malicious,
+			IsPaid:     &isPaid,
+			PayeeID:    malicious,
+		},
+		Currency: malicious,
+		Tags:     []string{malicious},
+		Limit:    100,
+	}
+	if _, err := d.GetPivotGroups("usuario' OR '1'='1", spec); err != nil {
+		t.Fatalf("GetPivotGroups: %v", err)
+	}
+
+	calls := fake.Reset()
+	if len(calls) != 1 || calls[0].Exec {
+		t.Fatalf("%d comandos no banco, esperado uma consulta", len(calls))
+	}
+	query, args := calls[0].Query, calls[0].Args
+	if strings.Contains(query, "DROP") || strings.Contains(query, "usuario") {
+		t.Fatalf("valor do usuário no SQL:\n%s", query)
+	}
+	if !strings.Contains(query, "unnest(t.tags)") {
+		t.Errorf("dimensão tag sem o join das tags:\n%s", query)
+	}
+
+	// Cada $n aponta para um parâmetro e todo parâmetro é usado
+	used := make(map[int]bool)
+	for _, match := range regexp.MustCompile(`\$(\d+)`).FindAllStringSubmatch(query, -1) {
+		n, _ := strconv.Atoi(match[1])
+		if n < 1 || n > len(args) {
+			t.Errorf("parâmetro $%d sem valor (%d valores)", n, len(args))
+		}
+		used[n] = true
+	}
+	if len(used) != len(args) {
+		t.Errorf("%d parâmetros usados no SQL, %d valores enviados", len(used), len(args))
+	}
+
+	var strs []string
+	for _, arg := range args {
+		if s, ok := arg.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	tags, _ := pq.StringArray{malicious}.Value()
+	want := []string{"usuario' OR '1'='1", malicious, malicious, malicious, malicious, malicious, tags.(string)}
+	if strings.Join(strs, "|") != strings.Join(want, "|") {
+		t.Errorf("parâmetros = %q, esperado %q", strs, want)
+	}
+	if limit := args[len(args)-1]; limit != int64(101) {
+		t.Errorf("limite = %v, esperado 101", limit)
+	}
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// GetMonthlyCategoryTotals soma por mês (de competência) e categoria as transações pagas do tipo
+// informado nas contas da moeda, entre from e to (inclusive), sem as transferências
+func (d *Database) GetMonthlyCategoryTotals(userID, currency, txType string, from, to time.Time, transferCategoryID string) ([]structs.CategoryMonthTotal, error) {
+	query := `
+	SELECT date_trunc('month', t.competence_date) AS month, t.category_id, t.type, SUM(t.amount), COUNT(*)
+	FROM transactions t
+	JOIN accounts a ON a.id = t.account_id AND a.deleted_at IS NULL
+	WHERE t.user_id = $1 AND a.currency = $2 AND t.type = $3
+	  AND t.competence_date >= $4 AND t.competence_date < $5
+	  AND t.is_paid = TRUE AND t.deleted_at IS NULL
+	  AND t.transfer_id IS NULL AND t.category_id <> $6
+	GROUP BY 1, 2, 3
+	ORDER BY 1, 2`
+	rows, err := d.db.Query(query, userID, currency, txType, from, to.AddDate(0, 0, 1), transferCategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao somar transações por mês: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []structs.CategoryMonthTotal
+	for rows.Next() {
+		var total structs.CategoryMonthTotal
+		var amount int64
+		if err := rows.Scan(&total.Month, &total.CategoryID, &total.Type, &amount, &total.Count); err != nil {
+			return nil, err
+		}
+		total.Amount = int(amount)
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+const ruleColumns = `id, user_id, name, priority, is_active, description_contains, description_regex, amount_min, amount_max, account_id, transaction_type, category_id, tags, payee, observation, created_at, updated_at, deleted_at`
+
+// scanRule lê uma regra de categorização na ordem de ruleColumns
+func scanRule(row rowScanner) (*structs.CategorizationRule, error) {
+	var rule structs.CategorizationRule
+	var descriptionContains, descriptionRegex, transactionType, payee, observation sql.NullString
+	var accountID, categoryID sql.NullString
+	var amountMin, amountMax sql.NullInt64
+	var tags pq.StringArray
+	var deletedAt sql.NullTime
+
+	err := row.Scan(
+		&rule.ID,
+		&rule.UserID,
+		&rule.Name,
+		&rule.Priority,
+		&rule.IsActive,
+		&descriptionContains,
+		&descriptionRegex,
+		&amountMin,
+		&amountMax,
+		&accountID,
+		&transactionType,
+		&categoryID,
+		&tags,
+		&payee,
+		&observation,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.DescriptionContains = descriptionContains.String
+	rule.DescriptionRegex = descriptionRegex.String
+	if amountMin.Valid {
+		value := int(amountMin.Int64)
+		rule.AmountMin = &value
+	}
+	if amountMax.Valid {
+		value := int(amountMax.Int64)
+		rule.AmountMax = &value
+	}
+	if accountID.Valid {
+		rule.AccountID = &accountID.String
+	}
+	rule.TransactionType = transactionType.String
+	if categoryID.Valid {
+		rule.CategoryID = &categoryID.String
+	}
+	rule.Tags = []string(tags)
+	if rule.Tags == nil {
+		rule.Tags = []string{}
+	}
+	rule.Payee = payee.String
+	rule.Observation = observation.String
+	if deletedAt.Valid {
+		rule.DeletedAt = &deletedAt.Time
+	}
+
+	return &rule, nil
+}
+
+// CreateRule insere uma nova regra de categorização
+func (d *Database) CreateRule(rule structs.CategorizationRule) error {
+	return insertRule(d.db, rule)
+}
+
+func insertRule(e execer, rule structs.CategorizationRule) error {
+	query := `
+	INSERT INTO categorization_rules (` + ruleColumns + `)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`
+	_, err := e.Exec(query,
+		rule.ID,
+		rule.UserID,
+		rule.Name,
+		rule.Priority,
+		rule.IsActive,
+		nullableString(rule.DescriptionContains),
+		nullableString(rule.DescriptionRegex),
+		rule.AmountMin,
+		rule.AmountMax,
+		rule.AccountID,
+		nullableString(rule.TransactionType),
+		rule.CategoryID,
+		tagsArray(rule.Tags),
+		nullableString(rule.Payee),
+		nullableString(rule.Observation),
+		rule.CreatedAt,
+		rule.UpdatedAt,
+		rule.DeletedAt,
+	)
+	return err
+}
+
+// GetRuleByID busca uma regra do usuário
+func (d *Database) GetRuleByID(id string, userID string) (*structs.CategorizationRule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM categorization_rules WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	rule, err := scanRule(d.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetRulesByUser lista as regras do usuário em ordem de prioridade
+func (d *Database) GetRulesByUser(userID string) ([]structs.CategorizationRule, error) {
+	query := `SELECT ` + ruleColumns + ` FROM categorization_rules WHERE user_id = $1 AND deleted_at IS NULL ORDER BY priority ASC, created_at ASC`
+	rows, err := d.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make([]structs.CategorizationRule, 0)
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+// UpdateRule atualiza uma regra existente
+func (d *Database) UpdateRule(rule structs.CategorizationRule) error {
+	query := `
+	UPDATE categorization_rules
+	SET name = $1, priority = $2, is_active = $3, description_contains = $4, description_regex = $5, amount_min = $6, amount_max = $7,
+		account_id = $8, transaction_type = $9, category_id = $10, tags = $11, payee = $12, observation = $13, updated_at = $14
+	WHERE id = $15 AND user_id = $16
+	`
+	_, err := d.db.Exec(query,
+		rule.Name,
+		rule.Priority,
+		rule.IsActive,
+		nullableString(rule.DescriptionContains),
+		nullableString(rule.DescriptionRegex),
+		rule.AmountMin,
+		rule.AmountMax,
+		rule.AccountID,
+		nullableString(rule.TransactionType),
+		rule.CategoryID,
+		tagsArray(rule.Tags),
+		nullableString(rule.Payee),
+		nullableString(rule.Observation),
+		time.Now(),
+		rule.ID,
+		rule.UserID,
+	)
+	return err
+}
+
+// DeleteRule remove uma regra (soft delete)
+func (d *Database) DeleteRule(id string, userID string) error {
+	query := `UPDATE categorization_rules SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND user_id = $3`
+	_, err := d.db.Exec(query, time.Now(), id, userID)
+	return err
+}
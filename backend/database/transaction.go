@@ -6,18 +6,23 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/tonnarruda/my-personal-finance/structs"
 )
 
 // CreateTransaction insere uma nova transação no banco
 func (d *Database) CreateTransaction(tx structs.Transaction) error {
+	return insertTransaction(d.db, tx)
+}
+
+func insertTransaction(e execer, tx structs.Transaction) error {
 	query := `
 	INSERT INTO transactions (
-		id, user_id, description, amount, type, category_id, account_id, due_date, competence_date, is_paid, observation, is_recurring, recurring_type, installments, current_installment, parent_transaction_id, transfer_id, created_at, updated_at, deleted_at
+		id, user_id, description, amount, type, category_id, account_id, due_date, competence_date, is_paid, observation, is_recurring, recurring_type, installments, current_installment, parent_transaction_id, transfer_id, import_batch_id, external_id, tags, payee, payee_id, original_description, tax_section, payee_tax_id, created_at, updated_at, deleted_at
 	) VALUES (
-		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28
 	)`
-	_, err := d.db.Exec(query,
+	_, err := e.Exec(query,
 		tx.ID,
 		tx.UserID,
 		tx.Description,
@@ -35,6 +40,14 @@ func (d *Database) CreateTransaction(tx structs.Transaction) error {
 		tx.CurrentInstallment,
 		tx.ParentTransactionID,
 		tx.TransferID,
+		tx.ImportBatchID,
+		nullableString(tx.ExternalID),
+		tagsArray(tx.Tags),
+		nullableString(tx.Payee),
+		tx.PayeeID,
+		nullableString(tx.OriginalDescription),
+		tx.TaxSection,
+		nullableString(tx.PayeeTaxID),
 		tx.CreatedAt,
 		tx.UpdatedAt,
 		tx.DeletedAt,
@@ -42,15 +55,23 @@ func (d *Database) CreateTransaction(tx structs.Transaction) error {
 	return err
 }
 
-// GetTransactionByID busca uma transação pelo ID e userID
-func (d *Database) GetTransactionByID(id string, userID string) (*structs.Transaction, error) {
-	query := `SELECT id, user_id, description, amount, type, category_id, account_id, due_date, competence_date, is_paid, observation, is_recurring, recurring_type, installments, current_installment, parent_transaction_id, transfer_id, created_at, updated_at, deleted_at FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+// transactionColumns lista as colunas lidas em todas as consultas de transações
+const transactionColumns = `id, user_id, description, amount, type, category_id, account_id, due_date, competence_date, is_paid, observation, is_recurring, recurring_type, installments, current_installment, parent_transaction_id, transfer_id, import_batch_id, external_id, tags, payee, payee_id, original_description, tax_section, payee_tax_id, created_at, updated_at, deleted_at`
 
+// rowScanner abstrai *sql.Row e *sql.Rows para reaproveitar o scan de transações
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTransaction lê uma transação na ordem de transactionColumns, tratando valores NULL.
+// Colunas adicionais selecionadas depois das da transação são lidas em extra.
+func scanTransaction(row rowScanner, extra ...interface{}) (*structs.Transaction, error) {
 	var tx structs.Transaction
-	var observation, recurringType, parentTransactionID, transferID sql.NullString
+	var observation, recurringType, parentTransactionID, transferID, importBatchID, externalID, payee, payeeID, originalDescription, taxSection, payeeTaxID sql.NullString
+	var tags pq.StringArray
 	var deletedAt sql.NullTime
 
-	err := d.db.QueryRow(query, id, userID).Scan(
+	dest := []interface{}{
 		&tx.ID,
 		&tx.UserID,
 		&tx.Description,
@@ -68,128 +89,186 @@ func (d *Database) GetTransactionByID(id string, userID string) (*structs.Transa
 		&tx.CurrentInstallment,
 		&parentTransactionID,
 		&transferID,
+		&importBatchID,
+		&externalID,
+		&tags,
+		&payee,
+		&payeeID,
+		&originalDescription,
+		&taxSection,
+		&payeeTaxID,
 		&tx.CreatedAt,
 		&tx.UpdatedAt,
 		&deletedAt,
-	)
-	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
-			return nil, nil
-		}
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
 		return nil, err
 	}
 
 	// Atribuir valores NULL corretamente
-	if observation.Valid {
-		tx.Observation = observation.String
-	} else {
-		tx.Observation = ""
-	}
-
+	tx.Observation = observation.String
 	if recurringType.Valid {
 		tx.RecurringType = &recurringType.String
-	} else {
-		tx.RecurringType = nil
 	}
-
 	if parentTransactionID.Valid {
 		tx.ParentTransactionID = &parentTransactionID.String
-	} else {
-		tx.ParentTransactionID = nil
 	}
-
 	if transferID.Valid {
 		tx.TransferID = &transferID.String
-	} else {
-		tx.TransferID = nil
 	}
-
+	if importBatchID.Valid {
+		tx.ImportBatchID = &importBatchID.String
+	}
+	tx.ExternalID = externalID.String
+	tx.Tags = []string(tags)
+	if tx.Tags == nil {
+		tx.Tags = []string{}
+	}
+	tx.Payee = payee.String
+	if payeeID.Valid {
+		tx.PayeeID = &payeeID.String
+	}
+	tx.OriginalDescription = originalDescription.String
+	if taxSection.Valid {
+		tx.TaxSection = &taxSection.String
+	}
+	tx.PayeeTaxID = payeeTaxID.String
 	if deletedAt.Valid {
 		tx.DeletedAt = &deletedAt.Time
-	} else {
-		tx.DeletedAt = nil
 	}
 
 	return &tx, nil
 }
 
-// GetAllTransactionsByUser lista todas as transações de um usuário
-func (d *Database) GetAllTransactionsByUser(userID string) ([]structs.Transaction, error) {
-	query := `SELECT id, user_id, description, amount, type, category_id, account_id, due_date, competence_date, is_paid, observation, is_recurring, recurring_type, installments, current_installment, parent_transaction_id, transfer_id, created_at, updated_at, deleted_at FROM transactions WHERE user_id = $1 AND deleted_at IS NULL ORDER BY due_date ASC, created_at ASC`
-	rows, err := d.db.Query(query, userID)
-	if err != nil {
-		return nil, err
-	}
+// scanTransactions percorre o resultado de uma consulta e retorna as transações encontradas
+func scanTransactions(rows *sql.Rows) ([]structs.Transaction, error) {
 	defer rows.Close()
 
 	// Inicializar com slice vazio para garantir que nunca seja nil
 	txs := make([]structs.Transaction, 0)
 	for rows.Next() {
-		var tx structs.Transaction
-		var observation, recurringType, parentTransactionID, transferID sql.NullString
-		var deletedAt sql.NullTime
-
-		err := rows.Scan(
-			&tx.ID,
-			&tx.UserID,
-			&tx.Description,
-			&tx.Amount,
-			&tx.Type,
-			&tx.CategoryID,
-			&tx.AccountID,
-			&tx.DueDate,
-			&tx.CompetenceDate,
-			&tx.IsPaid,
-			&observation,
-			&tx.IsRecurring,
-			&recurringType,
-			&tx.Installments,
-			&tx.CurrentInstallment,
-			&parentTransactionID,
-			&transferID,
-			&tx.CreatedAt,
-			&tx.UpdatedAt,
-			&deletedAt,
-		)
+		tx, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
+		txs = append(txs, *tx)
+	}
+	return txs, rows.Err()
+}
 
-		// Tratar valores NULL
-		if observation.Valid {
-			tx.Observation = observation.String
-		} else {
-			tx.Observation = ""
-		}
-		if recurringType.Valid {
-			tx.RecurringType = &recurringType.String
-		} else {
-			tx.RecurringType = nil
-		}
-		if parentTransactionID.Valid {
-			tx.ParentTransactionID = &parentTransactionID.String
-		} else {
-			tx.ParentTransactionID = nil
+// GetTransactionByID busca uma transação pelo ID e userID
+func (d *Database) GetTransactionByID(id string, userID string) (*structs.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
+
+	tx, err := scanTransaction(d.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
 		}
-		if transferID.Valid {
-			tx.TransferID = &transferID.String
-		} else {
-			tx.TransferID = nil
+		return nil, err
+	}
+	return tx, nil
+}
+
+// GetAllTransactionsByUser lista todas as transações de um usuário
+func (d *Database) GetAllTransactionsByUser(userID string) ([]structs.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE user_id = $1 AND deleted_at IS NULL ORDER BY due_date ASC, created_at ASC`
+	rows, err := d.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransactions(rows)
+}
+
+// transactionFilterQuery monta a condição de uma consulta de transações do usuário (tabela com alias t)
+func transactionFilterQuery(userID string, filter structs.TransactionFilter) (string, []interface{}) {
+	conditions := []string{"t.user_id = $1", "t.deleted_at IS NULL"}
+	args := []interface{}{userID}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, strings.ReplaceAll(condition, "$?", fmt.Sprintf("$%d", len(args))))
+	}
+
+	dateColumn := "t.due_date"
+	if filter.ByCompetence {
+		dateColumn = "t.competence_date"
+	}
+	if filter.From != nil {
+		add(dateColumn+" >= $?", *filter.From)
+	}
+	if filter.To != nil {
+		add(dateColumn+" < $?", filter.To.AddDate(0, 0, 1))
+	}
+	if filter.AccountID != "" {
+		add("t.account_id = $?", filter.AccountID)
+	}
+	if filter.CategoryID != "" {
+		add("(t.category_id = $? OR t.category_id IN (SELECT id FROM categories WHERE parent_id = $?))", filter.CategoryID)
+	}
+	if filter.Type != "" {
+		add("t.type = $?", filter.Type)
+	}
+	if filter.IsPaid != nil {
+		add("t.is_paid = $?", *filter.IsPaid)
+	}
+	if filter.PayeeID != "" {
+		add("t.payee_id = $?", filter.PayeeID)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// prefixedTransactionColumns são as colunas de transactionColumns com o alias t
+var prefixedTransactionColumns = "t." + strings.ReplaceAll(transactionColumns, ", ", ", t.")
+
+// GetFilteredTransactions lista as transações do usuário que atendem ao filtro
+func (d *Database) GetFilteredTransactions(userID string, filter structs.TransactionFilter) ([]structs.Transaction, error) {
+	where, args := transactionFilterQuery(userID, filter)
+	query := `SELECT ` + prefixedTransactionColumns + ` FROM transactions t WHERE ` + where + ` ORDER BY t.due_date ASC, t.created_at ASC`
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanTransactions(rows)
+}
+
+// StreamTransactions percorre as transações do usuário que atendem ao filtro, uma por vez,
+// sem carregar o resultado inteiro em memória. Para transferências, counterpartAccountID é a
+// conta do outro lado; nas demais transações é vazio.
+func (d *Database) StreamTransactions(userID string, filter structs.TransactionFilter, fn func(tx structs.Transaction, counterpartAccountID string) error) error {
+	where, args := transactionFilterQuery(userID, filter)
+	query := `SELECT ` + prefixedTransactionColumns + `, counterpart.account_id
+	FROM transactions t
+	LEFT JOIN LATERAL (
+		SELECT o.account_id FROM transactions o
+		WHERE t.transfer_id IS NOT NULL AND o.transfer_id = t.transfer_id AND o.id <> t.id AND o.deleted_at IS NULL
+		LIMIT 1
+	) counterpart ON TRUE
+	WHERE ` + where + `
+	ORDER BY t.due_date ASC, t.created_at ASC`
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var counterpartAccountID sql.NullString
+		tx, err := scanTransaction(rows, &counterpartAccountID)
+		if err != nil {
+			return err
 		}
-		if deletedAt.Valid {
-			tx.DeletedAt = &deletedAt.Time
-		} else {
-			tx.DeletedAt = nil
+		if err := fn(*tx, counterpartAccountID.String); err != nil {
+			return err
 		}
-
-		txs = append(txs, tx)
 	}
-	return txs, nil
+	return rows.Err()
 }
 
 // UpdateTransaction atualiza uma transação existente
 func (d *Database) UpdateTransaction(id string, userID string, tx structs.Transaction) error {
-	query := `UPDATE transactions SET description=$1, amount=$2, type=$3, category_id=$4, account_id=$5, due_date=$6, competence_date=$7, is_paid=$8, observation=$9, is_recurring=$10, recurring_type=$11, installments=$12, current_installment=$13, parent_transaction_id=$14, transfer_id=$15, updated_at=$16, deleted_at=$17 WHERE id=$18 AND user_id=$19`
+	query := `UPDATE transactions SET description=$1, amount=$2, type=$3, category_id=$4, account_id=$5, due_date=$6, competence_date=$7, is_paid=$8, observation=$9, is_recurring=$10, recurring_type=$11, installments=$12, current_installment=$13, parent_transaction_id=$14, transfer_id=$15, tags=$16, payee=$17, payee_id=$18, tax_section=$19, payee_tax_id=$20, updated_at=$21, deleted_at=$22 WHERE id=$23 AND user_id=$24`
 	_, err := d.db.Exec(query,
 		tx.Description,
 		tx.Amount,
@@ -206,6 +285,11 @@ func (d *Database) UpdateTransaction(id string, userID string, tx structs.Transa
 		tx.CurrentInstallment,
 		tx.ParentTransactionID,
 		tx.TransferID,
+		tagsArray(tx.Tags),
+		nullableString(tx.Payee),
+		tx.PayeeID,
+		tx.TaxSection,
+		nullableString(tx.PayeeTaxID),
 		tx.UpdatedAt,
 		tx.DeletedAt,
 		id,
@@ -214,25 +298,57 @@ func (d *Database) UpdateTransaction(id string, userID string, tx structs.Transa
 	return err
 }
 
-// UpdateTransactionPartial atualiza apenas campos específicos de uma transação
-func (d *Database) UpdateTransactionPartial(id string, userID string, updates map[string]interface{}) error {
-	if len(updates) == 0 {
-		return fmt.Errorf("no fields to update")
-	}
-
-	// Adicionar updated_at automaticamente
-	updates["updated_at"] = time.Now()
+// transactionEditableColumns são as colunas que UpdateTransactionPartial aceita; os nomes dos campos
+// entram no SQL, então qualquer outro campo da requisição é ignorado
+var transactionEditableColumns = map[string]bool{
+	"description":         true,
+	"amount":              true,
+	"type":                true,
+	"category_id":         true,
+	"account_id":          true,
+	"due_date":            true,
+	"competence_date":     true,
+	"is_paid":             true,
+	"observation":         true,
+	"is_recurring":        true,
+	"recurring_type":      true,
+	"installments":        true,
+	"current_installment": true,
+	"tags":                true,
+	"payee":               true,
+	"payee_id":            true,
+	"tax_section":         true,
+	"payee_tax_id":        true,
+}
 
+// UpdateTransactionPartial atualiza apenas campos específicos de uma transação. Só as colunas de
+// transactionEditableColumns são alteradas; as demais chaves de updates são ignoradas.
+func (d *Database) UpdateTransactionPartial(id string, userID string, updates map[string]interface{}) error {
 	// Construir a query dinamicamente
 	setParts := []string{}
 	args := []interface{}{}
 	argIndex := 1
 
 	for field, value := range updates {
+		if !transactionEditableColumns[field] {
+			continue
+		}
+		// Arrays vindos do JSON precisam ser convertidos para o formato do PostgreSQL
+		if field == "tags" {
+			value = tagsArray(toStringSlice(value))
+		}
 		setParts = append(setParts, fmt.Sprintf("%s=$%d", field, argIndex))
 		args = append(args, value)
 		argIndex++
 	}
+	if len(setParts) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	// Adicionar updated_at automaticamente
+	setParts = append(setParts, fmt.Sprintf("updated_at=$%d", argIndex))
+	args = append(args, time.Now())
+	argIndex++
 
 	query := fmt.Sprintf("UPDATE transactions SET %s WHERE id=$%d AND user_id=$%d",
 		strings.Join(setParts, ", "), argIndex, argIndex+1)
@@ -242,6 +358,47 @@ func (d *Database) UpdateTransactionPartial(id string, userID string, updates ma
 	return err
 }
 
+// UpdateTransactionClassification grava os campos que as regras de categorização podem alterar
+func (d *Database) UpdateTransactionClassification(tx structs.Transaction) error {
+	query := `UPDATE transactions SET category_id = $1, tags = $2, payee = $3, payee_id = $4, observation = $5, updated_at = $6 WHERE id = $7 AND user_id = $8`
+	_, err := d.db.Exec(query,
+		tx.CategoryID,
+		tagsArray(tx.Tags),
+		nullableString(tx.Payee),
+		tx.PayeeID,
+		tx.Observation,
+		time.Now(),
+		tx.ID,
+		tx.UserID,
+	)
+	return err
+}
+
+// tagsArray converte as tags para o formato de array do PostgreSQL (nunca NULL)
+func tagsArray(tags []string) pq.StringArray {
+	if tags == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(tags)
+}
+
+// toStringSlice converte um valor decodificado de JSON em lista de strings
+func toStringSlice(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
 // DeleteTransaction remove uma transação do banco (soft delete)
 func (d *Database) DeleteTransaction(id string, userID string) error {
 	query := `UPDATE transactions SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
@@ -251,37 +408,15 @@ func (d *Database) DeleteTransaction(id string, userID string) error {
 
 // GetInitialTransaction busca a transação inicial de uma conta
 func (d *Database) GetInitialTransaction(accountID string, userID string) (*structs.Transaction, error) {
-	query := `SELECT id, user_id, description, amount, type, category_id, account_id, due_date, competence_date, is_paid, observation, is_recurring, recurring_type, installments, current_installment, parent_transaction_id, transfer_id, created_at, updated_at, deleted_at FROM transactions WHERE account_id = $1 AND user_id = $2 AND description = 'Saldo Inicial' AND deleted_at IS NULL ORDER BY created_at ASC LIMIT 1`
-	var tx structs.Transaction
-	err := d.db.QueryRow(query, accountID, userID).Scan(
-		&tx.ID,
-		&tx.UserID,
-		&tx.Description,
-		&tx.Amount,
-		&tx.Type,
-		&tx.CategoryID,
-		&tx.AccountID,
-		&tx.DueDate,
-		&tx.CompetenceDate,
-		&tx.IsPaid,
-		&tx.Observation,
-		&tx.IsRecurring,
-		&tx.RecurringType,
-		&tx.Installments,
-		&tx.CurrentInstallment,
-		&tx.ParentTransactionID,
-		&tx.TransferID,
-		&tx.CreatedAt,
-		&tx.UpdatedAt,
-		&tx.DeletedAt,
-	)
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE account_id = $1 AND user_id = $2 AND description = 'Saldo Inicial' AND deleted_at IS NULL ORDER BY created_at ASC LIMIT 1`
+	tx, err := scanTransaction(d.db.QueryRow(query, accountID, userID))
 	if err != nil {
-		if err.Error() == "sql: no rows in result set" {
+		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &tx, nil
+	return tx, nil
 }
 
 // HasTransactionsByAccount verifica se há transações associadas a uma conta
@@ -308,43 +443,12 @@ func (d *Database) HasTransactionsByCategory(categoryID string, userID string) (
 
 // GetTransactionsByTransferID busca transações pelo transfer_id
 func (d *Database) GetTransactionsByTransferID(transferID string, userID string) ([]structs.Transaction, error) {
-	query := `SELECT id, user_id, description, amount, type, category_id, account_id, due_date, competence_date, is_paid, observation, is_recurring, recurring_type, installments, current_installment, parent_transaction_id, transfer_id, created_at, updated_at, deleted_at FROM transactions WHERE transfer_id = $1 AND user_id = $2 AND deleted_at IS NULL`
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE transfer_id = $1 AND user_id = $2 AND deleted_at IS NULL`
 	rows, err := d.db.Query(query, transferID, userID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	var txs []structs.Transaction
-	for rows.Next() {
-		var tx structs.Transaction
-		err := rows.Scan(
-			&tx.ID,
-			&tx.UserID,
-			&tx.Description,
-			&tx.Amount,
-			&tx.Type,
-			&tx.CategoryID,
-			&tx.AccountID,
-			&tx.DueDate,
-			&tx.CompetenceDate,
-			&tx.IsPaid,
-			&tx.Observation,
-			&tx.IsRecurring,
-			&tx.RecurringType,
-			&tx.Installments,
-			&tx.CurrentInstallment,
-			&tx.ParentTransactionID,
-			&tx.TransferID,
-			&tx.CreatedAt,
-			&tx.UpdatedAt,
-			&tx.DeletedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		txs = append(txs, tx)
-	}
-	return txs, nil
+	return scanTransactions(rows)
 }
 
 // DeleteTransactionsByTransferID remove todas as transações com o mesmo transfer_id
@@ -353,3 +457,59 @@ func (d *Database) DeleteTransactionsByTransferID(transferID string, userID stri
 	_, err := d.db.Exec(query, transferID, userID)
 	return err
 }
+
+// GetAccountBalanceAsOf calcula o saldo da conta (em centavos) considerando as transações pagas
+// com vencimento até o fim do dia informado
+func (d *Database) GetAccountBalanceAsOf(accountID string, userID string, date time.Time) (int, error) {
+	query := `
+	SELECT COALESCE(SUM(CASE WHEN type = 'income' THEN amount ELSE -amount END), 0)
+	FROM transactions
+	WHERE account_id = $1 AND user_id = $2 AND is_paid = TRUE AND deleted_at IS NULL AND due_date < $3
+	`
+	endOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()).AddDate(0, 0, 1)
+
+	var balance int64
+	if err := d.db.QueryRow(query, accountID, userID, endOfDay).Scan(&balance); err != nil {
+		return 0, err
+	}
+	return int(balance), nil
+}
+
+// GetUnlinkedTransactions lista as transações do usuário que não fazem parte de uma transferência,
+// com vencimento entre as datas informadas (inclusive)
+func (d *Database) GetUnlinkedTransactions(userID string, from time.Time, to time.Time) ([]structs.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions
+			  WHERE user_id = $1 AND transfer_id IS NULL AND deleted_at IS NULL AND due_date >= $2 AND due_date < $3
+			  ORDER BY due_date ASC, created_at ASC`
+	rows, err := d.db.Query(query, userID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	return scanTransactions(rows)
+}
+
+// LinkTransfer vincula duas transações existentes como os lados de uma transferência,
+// gravando o mesmo transfer_id e a categoria de transferência em ambas
+func (d *Database) LinkTransfer(expenseID string, incomeID string, userID string, transferID string, categoryID string) error {
+	dbTx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer dbTx.Rollback()
+
+	result, err := dbTx.Exec(`UPDATE transactions SET transfer_id = $1, category_id = $2, updated_at = $3
+		WHERE id IN ($4, $5) AND user_id = $6 AND transfer_id IS NULL AND deleted_at IS NULL`,
+		transferID, categoryID, time.Now(), expenseID, incomeID, userID)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated != 2 {
+		return fmt.Errorf("as transações não estão mais disponíveis para vinculação")
+	}
+
+	return dbTx.Commit()
+}
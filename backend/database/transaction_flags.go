@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// SaveTransactionFlag grava o alerta, a menos que a transação já tenha um alerta do mesmo tipo
+// (mesmo dispensado). Retorna true quando o alerta foi criado.
+func (d *Database) SaveTransactionFlag(flag structs.TransactionFlag) (bool, error) {
+	query := `INSERT INTO transaction_flags (id, user_id, transaction_id, kind, message, related_transaction_id, expected_amount, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+              ON CONFLICT (transaction_id, kind) DO NOTHING`
+	result, err := d.db.Exec(query, flag.ID, flag.UserID, flag.TransactionID, flag.Kind, flag.Message, flag.RelatedTransactionID, flag.ExpectedAmount, flag.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("erro ao salvar alerta da transação: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// GetTransactionFlags lista os alertas das transações ativas do usuário, do mais recente para o
+// mais antigo, com os dados principais da transação
+func (d *Database) GetTransactionFlags(userID string, includeDismissed bool) ([]structs.TransactionFlag, error) {
+	query := `SELECT f.id, f.user_id, f.transaction_id, f.kind, f.message, f.related_transaction_id, f.expected_amount, f.created_at, f.dismissed_at,
+                     t.description, t.amount, t.due_date, t.account_id, t.category_id, COALESCE(t.payee, '')
+              FROM transaction_flags f
+              JOIN transactions t ON t.id = f.transaction_id AND t.deleted_at IS NULL
+              WHERE f.user_id = $1 AND ($2 OR f.dismissed_at IS NULL)
+              ORDER BY t.due_date DESC, f.created_at DESC`
+	rows, err := d.db.Query(query, userID, includeDismissed)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar alertas: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []structs.TransactionFlag{}
+	for rows.Next() {
+		var flag structs.TransactionFlag
+		var tx structs.FlaggedTransaction
+		var relatedID sql.NullString
+		var expectedAmount sql.NullInt64
+		var dismissedAt sql.NullTime
+		err := rows.Scan(&flag.ID, &flag.UserID, &flag.TransactionID, &flag.Kind, &flag.Message, &relatedID, &expectedAmount, &flag.CreatedAt, &dismissedAt,
+			&tx.Description, &tx.Amount, &tx.DueDate, &tx.AccountID, &tx.CategoryID, &tx.Payee)
+		if err != nil {
+			return nil, err
+		}
+		if relatedID.Valid {
+			flag.RelatedTransactionID = &relatedID.String
+		}
+		if expectedAmount.Valid {
+			amount := int(expectedAmount.Int64)
+			flag.ExpectedAmount = &amount
+		}
+		if dismissedAt.Valid {
+			flag.DismissedAt = &dismissedAt.Time
+		}
+		flag.Transaction = &tx
+		flags = append(flags, flag)
+	}
+	return flags, rows.Err()
+}
+
+// DismissTransactionFlag marca o alerta como dispensado pelo usuário
+func (d *Database) DismissTransactionFlag(id, userID string) (bool, error) {
+	result, err := d.db.Exec(`UPDATE transaction_flags SET dismissed_at = $1 WHERE id = $2 AND user_id = $3 AND dismissed_at IS NULL`, time.Now(), id, userID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao dispensar alerta: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
@@ -22,11 +22,11 @@ func NewAccountHandler(accountService *services.AccountService) *AccountHandler
 
 // CreateAccount cria uma nova conta
 func (h *AccountHandler) CreateAccount(c *gin.Context) {
-	// Obter user_id do query parameter
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
@@ -66,11 +66,11 @@ func (h *AccountHandler) GetAccountByID(c *gin.Context) {
 		return
 	}
 
-	// Obter user_id do corpo da requisição
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
@@ -98,11 +98,11 @@ func (h *AccountHandler) GetAccountByID(c *gin.Context) {
 
 // GetAllAccounts busca todas as contas
 func (h *AccountHandler) GetAllAccounts(c *gin.Context) {
-	// Obter user_id do corpo da requisição
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
@@ -146,6 +146,16 @@ func (h *AccountHandler) UpdateAccount(c *gin.Context) {
 		return
 	}
 
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	req.UserID = userID
 	account, err := h.accountService.UpdateAccount(id, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -170,11 +180,11 @@ func (h *AccountHandler) DeleteAccount(c *gin.Context) {
 		return
 	}
 
-	// Obter user_id do corpo da requisição
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
@@ -210,11 +220,11 @@ func (h *AccountHandler) GetInitialTransaction(c *gin.Context) {
 		return
 	}
 
-	// Obter user_id do query parameter
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
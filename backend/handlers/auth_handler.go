@@ -10,8 +10,6 @@ import (
 	"github.com/tonnarruda/my-personal-finance/services"
 )
 
-const jwtSecret = "supersecretkey123" // Troque por uma chave forte em produção
-
 // Sessão em memória (não é mais necessário, mas mantido para referência)
 // var sessionStore = struct {
 // 	m map[string]SessionData
@@ -55,9 +53,7 @@ func SessionAuthMiddleware() gin.HandlerFunc {
 
 		// Validar e decodificar JWT
 		claims := jwt.MapClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
+		token, err := sessionKeys.Parse(tokenString, claims)
 		if err != nil || !token.Valid {
 			fmt.Printf("[AUTH] ❌ JWT inválido: %v\n", err)
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sessão expirada ou inválida"})
@@ -84,14 +80,13 @@ func SessionAuthMiddleware() gin.HandlerFunc {
 		email, _ := claims["email"].(string)
 		name, _ := claims["name"].(string)
 		// Renovar JWT (rolling session)
-		newToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		newTokenString, _ := sessionKeys.Sign(jwt.MapClaims{
 			"user_id": userID,
 			"email":   email,
 			"name":    name,
 			"iat":     time.Now().Unix(),
 			"exp":     time.Now().Add(sessionDuration).Unix(),
 		})
-		newTokenString, _ := newToken.SignedString([]byte(jwtSecret))
 
 		// Configuração de cookie melhorada para Safari
 		http.SetCookie(c.Writer, &http.Cookie{
@@ -108,6 +103,12 @@ func SessionAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// currentUserID retorna o usuário autenticado por SessionAuthMiddleware. Os handlers usam só este
+// valor: o user_id da URL ou do corpo da requisição é ignorado.
+func currentUserID(c *gin.Context) string {
+	return c.GetString("user_id")
+}
+
 type SignupRequest struct {
 	Nome  string `json:"nome" binding:"required"`
 	Email string `json:"email" binding:"required,email"`
@@ -171,14 +172,13 @@ func (h *AuthHandler) LoginHandler(c *gin.Context) {
 	}
 
 	// Gera JWT de sessão com claims extras
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	tokenString, err := sessionKeys.Sign(jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
 		"name":    user.Nome,
 		"iat":     time.Now().Unix(),
 		"exp":     time.Now().Add(sessionDuration).Unix(),
 	})
-	tokenString, err := token.SignedString([]byte(jwtSecret))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"erro": "Erro ao gerar token de sessão"})
 		return
@@ -207,9 +207,9 @@ func (h *AuthHandler) LoginHandler(c *gin.Context) {
 
 // GetMeHandler retorna os dados do usuário autenticado
 func (h *AuthHandler) GetMeHandler(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id é obrigatório"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
 		return
 	}
 	user, err := h.UserService.GetUserByID(userID)
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+)
+
+// maxBackupSize limita o tamanho do arquivo enviado na restauração (100 MB)
+const maxBackupSize = 100 << 20
+
+type BackupHandler struct {
+	backupService *services.BackupService
+}
+
+// NewBackupHandler cria uma nova instância do handler de backup
+func NewBackupHandler(backupService *services.BackupService) *BackupHandler {
+	return &BackupHandler{
+		backupService: backupService,
+	}
+}
+
+// ExportBackup baixa todos os dados do usuário em JSON (format=json, padrão) ou zip (format=zip)
+func (h *BackupHandler) ExportBackup(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "format deve ser 'json' ou 'zip'",
+		})
+		return
+	}
+
+	backup, err := h.backupService.CreateBackup(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	contentType := "application/json"
+	if format == "zip" {
+		contentType = "application/zip"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="backup-%s.%s"`, time.Now().Format("20060102"), format))
+	c.Status(http.StatusOK)
+
+	if err := services.WriteBackup(c.Writer, backup, format == "zip"); err != nil {
+		log.Printf("Erro ao gravar backup do usuário %s: %v", userID, err)
+	}
+}
+
+// RestoreBackup restaura um backup no usuário. Aceita o arquivo no campo "file" de um
+// formulário multipart ou o próprio JSON/zip no corpo da requisição.
+func (h *BackupHandler) RestoreBackup(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBackupSize)
+
+	var content []byte
+	if file, err := c.FormFile("file"); err == nil {
+		src, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Erro ao abrir arquivo: " + err.Error(),
+			})
+			return
+		}
+		defer src.Close()
+		content, err = io.ReadAll(src)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Erro ao ler arquivo: " + err.Error(),
+			})
+			return
+		}
+	} else if c.ContentType() == "multipart/form-data" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Arquivo de backup é obrigatório (campo file)",
+		})
+		return
+	} else {
+		content, err = io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Erro ao ler backup: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	backup, err := services.ReadBackup(content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.backupService.RestoreBackup(userID, backup)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
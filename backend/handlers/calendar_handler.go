@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+)
+
+type CalendarHandler struct {
+	calendarService *services.CalendarService
+	// publicBaseURL é o endereço público da API usado nas URLs do feed (PUBLIC_BASE_URL)
+	publicBaseURL string
+	// trustedProxies são os proxies reversos cujos cabeçalhos X-Forwarded-* são aceitos
+	trustedProxies []*net.IPNet
+}
+
+// NewCalendarHandler cria uma nova instância do handler do feed de calendário. Sem
+// publicBaseURL, o endereço vem da requisição e só considera os cabeçalhos X-Forwarded-*
+// quando ela chega por um dos trustedProxies.
+func NewCalendarHandler(calendarService *services.CalendarService, publicBaseURL string, trustedProxies []*net.IPNet) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+		publicBaseURL:   strings.TrimRight(publicBaseURL, "/"),
+		trustedProxies:  trustedProxies,
+	}
+}
+
+// ParsePublicBaseURL confere o endereço público da API (ex.: https://api.exemplo.com)
+func ParsePublicBaseURL(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", fmt.Errorf("PUBLIC_BASE_URL inválida: use o formato https://host[:porta][/caminho]")
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("PUBLIC_BASE_URL não pode ter query nem fragmento")
+	}
+	return strings.TrimRight(value, "/"), nil
+}
+
+// ParseTrustedProxies lê a lista de proxies confiáveis, separada por vírgula, com IPs ou
+// faixas CIDR (ex.: 10.0.0.0/8,127.0.0.1)
+func ParseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("proxy confiável inválido: %s", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("proxy confiável inválido: %s", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// GetFeedInfo informa se o usuário tem um feed de calendário ativo
+func (h *CalendarHandler) GetFeedInfo(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	info, err := h.calendarService.GetFeedInfo(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// RegenerateToken gera um novo token para o feed e devolve a URL de assinatura. O token
+// anterior deixa de funcionar.
+func (h *CalendarHandler) RegenerateToken(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	info, err := h.calendarService.RegenerateToken(userID, h.requestBaseURL(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, info)
+}
+
+// RevokeToken desativa o feed de calendário do usuário
+func (h *CalendarHandler) RevokeToken(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	revoked, err := h.calendarService.RevokeToken(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Nenhum feed de calendário ativo",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Feed de calendário revogado com sucesso",
+	})
+}
+
+// GetFeed serve o arquivo .ics do feed. A rota é pública: o token secreto no nome do arquivo
+// identifica o usuário, pois os aplicativos de calendário não enviam a sessão.
+func (h *CalendarHandler) GetFeed(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("file"), ".ics")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Calendário não encontrado",
+		})
+		return
+	}
+
+	userID, err := h.calendarService.UserIDForToken(token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if userID == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Calendário não encontrado",
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.calendarService.WriteFeed(&buf, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Erro ao gerar calendário: " + err.Error(),
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=0")
+	c.Header("Content-Disposition", `inline; filename="contas.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// requestBaseURL devolve o endereço público da API: o configurado em PUBLIC_BASE_URL ou, sem ele,
+// o da requisição. X-Forwarded-Proto e X-Forwarded-Host só valem quando a requisição chega por
+// um proxy confiável; de qualquer outro cliente, poderiam apontar o feed para outro servidor.
+func (h *CalendarHandler) requestBaseURL(c *gin.Context) string {
+	if h.publicBaseURL != "" {
+		return h.publicBaseURL
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	host := c.Request.Host
+	if h.fromTrustedProxy(c) {
+		if proto := strings.TrimSpace(strings.Split(c.GetHeader("X-Forwarded-Proto"), ",")[0]); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwarded := strings.TrimSpace(strings.Split(c.GetHeader("X-Forwarded-Host"), ",")[0]); forwarded != "" {
+			host = forwarded
+		}
+	}
+	return scheme + "://" + host
+}
+
+// fromTrustedProxy informa se a conexão veio de um dos proxies confiáveis
+func (h *CalendarHandler) fromTrustedProxy(c *gin.Context) bool {
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil {
+		return false
+	}
+	for _, network := range h.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCalendarRequestBaseURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		publicURL  string
+		remoteAddr string
+		want       string
+	}{
+		{"URL pública configurada", "https://api.exemplo.com/", "203.0.113.9:4000", "https://api.exemplo.com"},
+		{"cliente qualquer ignora X-Forwarded-*", "", "203.0.113.9:4000", "http://api.interno:8080"},
+		{"proxy confiável", "", "10.1.2.3:4000", "https://financas.exemplo.com"},
+		{"proxy confiável por IP", "", "127.0.0.1:4000", "https://financas.exemplo.com"},
+	}
+	for _, tt := range tests {
+		h := NewCalendarHandler(nil, tt.publicURL, proxies)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "http://api.interno:8080/api/calendar/token", nil)
+		c.Request.RemoteAddr = tt.remoteAddr
+		c.Request.Header.Set("X-Forwarded-Proto", "https")
+		c.Request.Header.Set("X-Forwarded-Host", "financas.exemplo.com")
+
+		if got := h.requestBaseURL(c); got != tt.want {
+			t.Errorf("%s: = %q, esperado %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseConfiguredBaseURLAndProxies(t *testing.T) {
+	for _, value := range []string{"api.exemplo.com", "ftp://api.exemplo.com", "https://api.exemplo.com?x=1"} {
+		if _, err := ParsePublicBaseURL(value); err == nil {
+			t.Errorf("ParsePublicBaseURL(%q) deveria falhar", value)
+		}
+	}
+	for _, value := range []string{"10.0.0.0/33", "proxy.local"} {
+		if _, err := ParseTrustedProxies(value); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) deveria falhar", value)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+type CAMTHandler struct {
+	importService    *services.ImportService
+	importJobService *services.ImportJobService
+}
+
+// NewCAMTHandler cria uma nova instância do handler de importação ISO 20022 (camt.053/camt.052)
+func NewCAMTHandler(importService *services.ImportService, importJobService *services.ImportJobService) *CAMTHandler {
+	return &CAMTHandler{
+		importService:    importService,
+		importJobService: importJobService,
+	}
+}
+
+// PreviewCAMTResponse representa a resposta da pré-visualização de um extrato camt
+type PreviewCAMTResponse struct {
+	Success         bool                     `json:"success"`
+	Message         string                   `json:"message"`
+	MessageType     string                   `json:"message_type"`
+	Statements      []services.CAMTStatement `json:"statements"`
+	Transactions    []OFXTransactionDTO      `json:"transactions"`
+	PreviewToken    string                   `json:"preview_token"`
+	ExpiresAt       time.Time                `json:"expires_at"`
+	DuplicateImport *structs.ImportBatch     `json:"duplicate_import,omitempty"`
+	Errors          []string                 `json:"errors,omitempty"`
+}
+
+// PreviewCAMT faz o parsing do extrato camt e retorna os lançamentos e saldos para revisão
+func (h *CAMTHandler) PreviewCAMT(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("camt_file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao obter arquivo camt", "details": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao abrir arquivo camt", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao ler arquivo camt", "details": err.Error()})
+		return
+	}
+
+	parsed, err := services.ParseCAMT(content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao fazer parse do arquivo camt", "details": err.Error()})
+		return
+	}
+
+	// O saldo final só pode ser conferido quando o arquivo traz o extrato de uma única conta
+	var ledgerBalance *structs.StatementBalance
+	if len(parsed.Statements) == 1 && parsed.Statements[0].ClosingBalance != nil {
+		closing := parsed.Statements[0].ClosingBalance
+		ledgerBalance = &structs.StatementBalance{Amount: int(math.Round(closing.Amount * 100)), Date: closing.Date}
+	}
+
+	preview, err := h.importService.CreatePreview(userID, "camt", fileHeader.Filename, content, parsed.Lines, ledgerBalance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar pré-visualização", "details": err.Error()})
+		return
+	}
+
+	transactionDTOs, err := previewTransactionDTOs(h.importService, userID, c.PostForm("account_id"), parsed.Lines)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao sugerir categorias", "details": err.Error()})
+		return
+	}
+
+	duplicateImport, err := h.importService.FindDuplicateImport(preview.FileHash, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar importações anteriores", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, &PreviewCAMTResponse{
+		Success:         true,
+		Message:         fmt.Sprintf("Extrato %s processado com sucesso. %d transações encontradas.", parsed.MessageType, len(transactionDTOs)),
+		MessageType:     parsed.MessageType,
+		Statements:      parsed.Statements,
+		Transactions:    transactionDTOs,
+		PreviewToken:    preview.ID,
+		ExpiresAt:       preview.ExpiresAt,
+		DuplicateImport: duplicateImport,
+		Errors:          []string{},
+	})
+}
+
+// ImportCAMT importa as linhas revisadas de uma pré-visualização camt
+func (h *CAMTHandler) ImportCAMT(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	importReviewedPreview(c, h.importService, h.importJobService, userID)
+}
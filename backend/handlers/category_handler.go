@@ -22,11 +22,11 @@ func NewCategoryHandler(categoryService *services.CategoryService) *CategoryHand
 
 // CreateCategory cria uma nova categoria
 func (h *CategoryHandler) CreateCategory(c *gin.Context) {
-	// Obter user_id do query parameter
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
@@ -66,6 +66,15 @@ func (h *CategoryHandler) GetCategoryByID(c *gin.Context) {
 		return
 	}
 
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
 	// Validar se o ID é um UUID válido
 	if !utils.IsValidUUID(id) {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -74,7 +83,7 @@ func (h *CategoryHandler) GetCategoryByID(c *gin.Context) {
 		return
 	}
 
-	category, err := h.categoryService.GetCategoryByID(id)
+	category, err := h.categoryService.GetCategoryByID(id, userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": err.Error(),
@@ -89,11 +98,11 @@ func (h *CategoryHandler) GetCategoryByID(c *gin.Context) {
 
 // GetAllCategories busca todas as categorias
 func (h *CategoryHandler) GetAllCategories(c *gin.Context) {
-	// Obter user_id do corpo da requisição
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
@@ -121,11 +130,11 @@ func (h *CategoryHandler) GetCategoriesByType(c *gin.Context) {
 		return
 	}
 
-	// Obter user_id do corpo da requisição
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
@@ -161,11 +170,11 @@ func (h *CategoryHandler) GetCategoriesWithSubcategories(c *gin.Context) {
 		return
 	}
 
-	// Obter user_id do corpo da requisição
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
@@ -201,11 +210,11 @@ func (h *CategoryHandler) GetSubcategories(c *gin.Context) {
 		return
 	}
 
-	// Obter user_id do corpo da requisição
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
@@ -257,6 +266,16 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 		return
 	}
 
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	req.UserID = userID
 	category, err := h.categoryService.UpdateCategory(id, req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -281,11 +300,11 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	// Obter user_id do corpo da requisição
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
@@ -321,6 +340,15 @@ func (h *CategoryHandler) HardDeleteCategory(c *gin.Context) {
 		return
 	}
 
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
 	// Validar se o ID é um UUID válido
 	if !utils.IsValidUUID(id) {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -329,7 +357,7 @@ func (h *CategoryHandler) HardDeleteCategory(c *gin.Context) {
 		return
 	}
 
-	err := h.categoryService.HardDeleteCategory(id)
+	err := h.categoryService.HardDeleteCategory(id, userID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
@@ -352,11 +380,11 @@ func (h *CategoryHandler) UpdateCategoryColor(c *gin.Context) {
 		return
 	}
 
-	// Obter user_id do corpo da requisição
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id é obrigatório",
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
 		})
 		return
 	}
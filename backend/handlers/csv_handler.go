@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+type CSVHandler struct {
+	csvService       *services.CSVService
+	importService    *services.ImportService
+	importJobService *services.ImportJobService
+}
+
+// NewCSVHandler cria uma nova instância do handler de importação de CSV
+func NewCSVHandler(csvService *services.CSVService, importService *services.ImportService, importJobService *services.ImportJobService) *CSVHandler {
+	return &CSVHandler{
+		csvService:       csvService,
+		importService:    importService,
+		importJobService: importJobService,
+	}
+}
+
+// PreviewCSVResponse representa a resposta da pré-visualização de um CSV
+type PreviewCSVResponse struct {
+	Success      bool                `json:"success"`
+	Message      string              `json:"message"`
+	Transactions []OFXTransactionDTO `json:"transactions"`
+	PreviewToken string              `json:"preview_token,omitempty"`
+	ExpiresAt    *time.Time          `json:"expires_at,omitempty"`
+	// MappingRequired indica que o arquivo não foi reconhecido e o usuário precisa mapear as colunas
+	MappingRequired bool                  `json:"mapping_required"`
+	Mapping         *structs.CSVMapping   `json:"mapping,omitempty"`
+	Detection       *structs.CSVDetection `json:"detection"`
+	DuplicateImport *structs.ImportBatch  `json:"duplicate_import,omitempty"`
+	Errors          []string              `json:"errors,omitempty"`
+}
+
+// PreviewCSV lê o CSV com um perfil, preset ou mapeamento e retorna as linhas para revisão
+func (h *CSVHandler) PreviewCSV(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("csv_file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao obter arquivo CSV", "details": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao abrir arquivo CSV", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao ler arquivo CSV", "details": err.Error()})
+		return
+	}
+
+	mapping, detection, err := h.csvService.ResolveMapping(userID, c.PostForm("profile_id"), c.PostForm("preset"), c.PostForm("mapping"), content)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if mapping == nil {
+		c.JSON(http.StatusOK, &PreviewCSVResponse{
+			Success:         true,
+			Message:         "Formato do arquivo não reconhecido. Informe o mapeamento das colunas.",
+			Transactions:    []OFXTransactionDTO{},
+			MappingRequired: true,
+			Detection:       detection,
+			Errors:          []string{},
+		})
+		return
+	}
+
+	lines, detection, err := services.ParseCSV(content, *mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao ler arquivo CSV", "details": err.Error()})
+		return
+	}
+
+	preview, err := h.importService.CreatePreview(userID, "csv", fileHeader.Filename, content, lines, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar pré-visualização", "details": err.Error()})
+		return
+	}
+
+	transactionDTOs, err := previewTransactionDTOs(h.importService, userID, c.PostForm("account_id"), lines)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao sugerir categorias", "details": err.Error()})
+		return
+	}
+
+	duplicateImport, err := h.importService.FindDuplicateImport(preview.FileHash, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar importações anteriores", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, &PreviewCSVResponse{
+		Success:         true,
+		Message:         fmt.Sprintf("Arquivo CSV processado com sucesso. %d transações encontradas.", len(transactionDTOs)),
+		Transactions:    transactionDTOs,
+		PreviewToken:    preview.ID,
+		ExpiresAt:       &preview.ExpiresAt,
+		Mapping:         mapping,
+		Detection:       detection,
+		DuplicateImport: duplicateImport,
+		Errors:          []string{},
+	})
+}
+
+// ImportCSV importa as linhas revisadas de uma pré-visualização de CSV
+func (h *CSVHandler) ImportCSV(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	importReviewedPreview(c, h.importService, h.importJobService, userID)
+}
+
+// GetPresets lista os mapeamentos prontos para CSVs de bancos
+func (h *CSVHandler) GetPresets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"presets": services.GetCSVPresets()})
+}
+
+// CreateProfile salva um mapeamento de colunas como perfil
+func (h *CSVHandler) CreateProfile(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	var req structs.CSVProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	profile, err := h.csvService.CreateProfile(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Perfil de CSV criado com sucesso",
+		"profile": profile,
+	})
+}
+
+// GetProfiles lista os perfis de CSV do usuário
+func (h *CSVHandler) GetProfiles(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	profiles, err := h.csvService.GetProfiles(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles})
+}
+
+// GetProfileByID busca um perfil de CSV
+func (h *CSVHandler) GetProfileByID(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	profile, err := h.csvService.GetProfileByID(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+// UpdateProfile atualiza um perfil de CSV
+func (h *CSVHandler) UpdateProfile(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	var req structs.CSVProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos: " + err.Error()})
+		return
+	}
+	req.UserID = userID
+
+	profile, err := h.csvService.UpdateProfile(c.Param("id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Perfil de CSV atualizado com sucesso",
+		"profile": profile,
+	})
+}
+
+// DeleteProfile remove um perfil de CSV
+func (h *CSVHandler) DeleteProfile(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	if err := h.csvService.DeleteProfile(c.Param("id"), userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Perfil de CSV excluído com sucesso"})
+}
@@ -5,16 +5,19 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/tonnarruda/my-personal-finance/services"
+	"github.com/tonnarruda/my-personal-finance/structs"
 )
 
 type ExchangeHandler struct {
 	exchangeService services.ExchangeServiceInterface
+	exchangeHistory *services.ExchangeHistoryService
 }
 
 // NewExchangeHandler cria uma nova instância do handler de câmbio
-func NewExchangeHandler(exchangeService services.ExchangeServiceInterface) *ExchangeHandler {
+func NewExchangeHandler(exchangeService services.ExchangeServiceInterface, exchangeHistory *services.ExchangeHistoryService) *ExchangeHandler {
 	return &ExchangeHandler{
 		exchangeService: exchangeService,
+		exchangeHistory: exchangeHistory,
 	}
 }
 
@@ -79,6 +82,68 @@ func (h *ExchangeHandler) GetExchangeRateSimple(c *gin.Context) {
 	})
 }
 
+// GetRateHistory lista as taxas históricas conhecidas entre duas moedas (from e to)
+func (h *ExchangeHandler) GetRateHistory(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+	fromCurrency := c.Query("from")
+	toCurrency := c.Query("to")
+	if fromCurrency == "" || toCurrency == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Parâmetros 'from' e 'to' são obrigatórios"})
+		return
+	}
+
+	rates, err := h.exchangeHistory.ListRates(userID, fromCurrency, toCurrency)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// SaveHistoricalRate grava a taxa de câmbio de uma data passada, usada nos relatórios de patrimônio
+func (h *ExchangeHandler) SaveHistoricalRate(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	var req structs.SaveExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	rate, err := h.exchangeHistory.SaveManualRate(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// DeleteHistoricalRate remove uma taxa de câmbio informada pelo usuário
+func (h *ExchangeHandler) DeleteHistoricalRate(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	if err := h.exchangeHistory.DeleteManualRate(c.Param("id"), userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Taxa de câmbio removida com sucesso"})
+}
+
 // isValidCurrency verifica se a moeda é válida
 func isValidCurrency(currency string, supportedCurrencies []string) bool {
 	for _, supported := range supportedCurrencies {
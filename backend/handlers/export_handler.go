@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+)
+
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandler cria uma nova instância do handler de exportação
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportTransactions exporta as transações em CSV ou XLSX, com os mesmos filtros da listagem.
+// Parâmetros: format (csv|xlsx), columns (separadas por vírgula), locale (pt-BR|en-US),
+// decimal_separator, date_format, delimiter e bom (apenas CSV)
+func (h *ExportHandler) ExportTransactions(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	options := services.ExportOptions{
+		Format:           strings.ToLower(c.Query("format")),
+		Locale:           c.Query("locale"),
+		DecimalSeparator: c.Query("decimal_separator"),
+		DateFormat:       c.Query("date_format"),
+		Delimiter:        c.Query("delimiter"),
+	}
+	if value := c.Query("columns"); value != "" {
+		for _, column := range strings.Split(value, ",") {
+			if column = strings.TrimSpace(column); column != "" {
+				options.Columns = append(options.Columns, column)
+			}
+		}
+	}
+	if value := c.Query("bom"); value != "" {
+		bom, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "bom deve ser true ou false",
+			})
+			return
+		}
+		options.BOM = &bom
+	}
+
+	export, err := h.exportService.NewTransactionExport(userID, filter, options)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="`+export.FileName()+`"`)
+	c.Status(http.StatusOK)
+
+	// O arquivo já começou a ser enviado; um erro aqui só pode ser registrado
+	if err := export.Write(c.Writer); err != nil {
+		log.Printf("Erro ao exportar transações do usuário %s: %v", userID, err)
+	}
+}
+
+// ExportOFX gera o extrato OFX 2.x de uma conta (account_id, from e to no formato AAAA-MM-DD)
+func (h *ExportHandler) ExportOFX(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	accountID := c.Query("account_id")
+	if accountID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "account_id é obrigatório",
+		})
+		return
+	}
+
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	export, err := h.exportService.NewOFXStatementExport(userID, accountID, filter.From, filter.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ofx")
+	c.Header("Content-Disposition", `attachment; filename="`+export.FileName()+`"`)
+	c.Status(http.StatusOK)
+
+	if err := export.Write(c.Writer); err != nil {
+		log.Printf("Erro ao exportar extrato OFX do usuário %s: %v", userID, err)
+	}
+}
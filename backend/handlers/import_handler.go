@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+type ImportHandler struct {
+	importService *services.ImportService
+}
+
+// NewImportHandler cria uma nova instância do handler de importações
+func NewImportHandler(importService *services.ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+// GetImportBatches lista o histórico de importações do usuário
+func (h *ImportHandler) GetImportBatches(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	batches, err := h.importService.GetImportBatches(userID, c.Query("account_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imports": batches,
+	})
+}
+
+// GetImportBatch busca uma importação com as transações criadas por ela
+func (h *ImportHandler) GetImportBatch(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	batch, err := h.importService.GetImportBatch(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"import": batch,
+	})
+}
+
+// RevertImportBatch desfaz uma importação inteira
+func (h *ImportHandler) RevertImportBatch(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	batch, removed, err := h.importService.RevertImportBatch(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "Importação desfeita com sucesso",
+		"import":               batch,
+		"transactions_removed": removed,
+	})
+}
+
+// GetBalanceCheck confere o saldo da conta com o saldo do extrato de uma importação
+func (h *ImportHandler) GetBalanceCheck(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	check, err := h.importService.GetImportBalanceCheck(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"balance_check": check,
+	})
+}
+
+// CreateBalanceAdjustment cria a transação de ajuste que iguala o saldo da conta ao do extrato
+func (h *ImportHandler) CreateBalanceAdjustment(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	// O corpo é opcional: sem ele são usados a categoria e a descrição padrão
+	var req structs.BalanceAdjustmentRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+
+	transaction, check, err := h.importService.CreateBalanceAdjustment(c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Ajuste de saldo criado com sucesso",
+		"transaction":   transaction,
+		"balance_check": check,
+	})
+}
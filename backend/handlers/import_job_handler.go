@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+type ImportJobHandler struct {
+	importJobService *services.ImportJobService
+}
+
+// NewImportJobHandler cria uma nova instância do handler de jobs de importação
+func NewImportJobHandler(importJobService *services.ImportJobService) *ImportJobHandler {
+	return &ImportJobHandler{
+		importJobService: importJobService,
+	}
+}
+
+// GetJobs lista os jobs de importação mais recentes do usuário
+func (h *ImportJobHandler) GetJobs(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	jobs, err := h.importJobService.GetJobs(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs": jobs,
+	})
+}
+
+// GetJob retorna o progresso e o resultado de cada linha de um job de importação
+func (h *ImportJobHandler) GetJob(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	job, err := h.importJobService.GetJob(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job": job,
+	})
+}
+
+// StreamJob envia o progresso do job por Server-Sent Events: um evento "progress" por linha
+// processada e um evento "done" com o job completo quando ele termina
+func (h *ImportJobHandler) StreamJob(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	job, err := h.importJobService.GetJob(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// Inscrever antes de reler o job: se ele terminar entre a leitura e a inscrição, o canal
+	// nunca seria fechado. O job é gravado antes de ser publicado, então a releitura já o vê pronto.
+	updates, unsubscribe := h.importJobService.Subscribe(job.ID)
+	defer unsubscribe()
+
+	job, err = h.importJobService.GetJob(job.ID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if job.Finished() {
+		c.SSEvent("done", job)
+		return
+	}
+
+	c.SSEvent("progress", jobProgress(*job))
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				// O job terminou; o estado final é lido do banco para não depender de atualizações descartadas
+				if final, err := h.importJobService.GetJob(job.ID, userID); err == nil {
+					c.SSEvent("done", final)
+				}
+				return false
+			}
+			c.SSEvent("progress", jobProgress(update))
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// jobProgress resume o estado do job com o resultado apenas da última linha processada
+func jobProgress(job structs.ImportJob) gin.H {
+	progress := gin.H{
+		"id":                    job.ID,
+		"status":                job.Status,
+		"total_lines":           job.TotalLines,
+		"processed_lines":       job.ProcessedLines,
+		"transactions_imported": job.TransactionsImported,
+		"transactions_skipped":  job.TransactionsSkipped,
+	}
+	if len(job.Outcomes) > 0 {
+		progress["outcome"] = job.Outcomes[len(job.Outcomes)-1]
+	}
+	return progress
+}
+
+// CancelJob cancela um job de importação na fila ou em processamento
+func (h *ImportJobHandler) CancelJob(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	job, err := h.importJobService.CancelJob(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Cancelamento solicitado",
+		"job":     job,
+	})
+}
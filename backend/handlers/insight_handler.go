@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+)
+
+// insightEvaluateDays é o período avaliado por padrão em /api/insights/evaluate
+const insightEvaluateDays = 90
+
+type InsightHandler struct {
+	insightService *services.InsightService
+}
+
+// NewInsightHandler cria uma nova instância do handler de alertas de gastos incomuns
+func NewInsightHandler(insightService *services.InsightService) *InsightHandler {
+	return &InsightHandler{
+		insightService: insightService,
+	}
+}
+
+// GetInsights lista os alertas de transações e as categorias acima do habitual no mês (month no
+// formato AAAA-MM, padrão: mês atual; currency, padrão BRL; include_dismissed=true inclui os
+// alertas dispensados)
+func (h *InsightHandler) GetInsights(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	month := time.Now()
+	if value := c.Query("month"); value != "" {
+		parsed, err := time.Parse("2006-01", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "month deve estar no formato AAAA-MM",
+			})
+			return
+		}
+		month = parsed
+	}
+
+	insights, err := h.insightService.GetInsights(userID, reportCurrency(c), month, c.Query("include_dismissed") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, insights)
+}
+
+// EvaluateTransactions avalia as despesas com vencimento entre from e to (AAAA-MM-DD, padrão: os
+// últimos 90 dias) e grava os alertas que faltarem
+func (h *InsightHandler) EvaluateTransactions(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "to deve estar no formato AAAA-MM-DD",
+			})
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -insightEvaluateDays)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "from deve estar no formato AAAA-MM-DD",
+			})
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from deve ser anterior a to",
+		})
+		return
+	}
+
+	evaluation, err := h.insightService.EvaluatePeriod(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, evaluation)
+}
+
+// DismissFlag dispensa um alerta de transação, que deixa de aparecer na listagem
+func (h *InsightHandler) DismissFlag(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	if err := h.insightService.DismissFlag(c.Param("id"), userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alerta dispensado com sucesso",
+	})
+}
@@ -1,33 +1,26 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/services"
 	"github.com/tonnarruda/my-personal-finance/structs"
 )
 
 type OFXHandler struct {
-	DB *database.Database
+	DB               *database.Database
+	ImportService    *services.ImportService
+	ImportJobService *services.ImportJobService
 }
 
-func NewOFXHandler(db *database.Database) *OFXHandler {
-	return &OFXHandler{DB: db}
-}
-
-// ImportOFXResponse representa a resposta da importação OFX
-type ImportOFXResponse struct {
-	Success              bool     `json:"success"`
-	Message              string   `json:"message"`
-	TransactionsImported int      `json:"transactions_imported"`
-	TransactionsSkipped  int      `json:"transactions_skipped"`
-	Errors               []string `json:"errors,omitempty"`
+func NewOFXHandler(db *database.Database, importService *services.ImportService, importJobService *services.ImportJobService) *OFXHandler {
+	return &OFXHandler{DB: db, ImportService: importService, ImportJobService: importJobService}
 }
 
 // PreviewOFXResponse representa a resposta da pré-visualização OFX
@@ -35,24 +28,54 @@ type PreviewOFXResponse struct {
 	Success      bool                `json:"success"`
 	Message      string              `json:"message"`
 	Transactions []OFXTransactionDTO `json:"transactions"`
-	Errors       []string            `json:"errors,omitempty"`
+	// PreviewToken permite importar a revisão sem reenviar o arquivo
+	PreviewToken string    `json:"preview_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	// DuplicateImport é preenchido quando um arquivo idêntico já foi importado e não foi desfeito
+	DuplicateImport *structs.ImportBatch `json:"duplicate_import,omitempty"`
+	// LedgerBalance é o saldo informado pelo banco (LEDGERBAL), conferido após a importação
+	LedgerBalance *structs.StatementBalance `json:"ledger_balance,omitempty"`
+	Errors        []string                  `json:"errors,omitempty"`
 }
 
-// OFXTransactionDTO representa uma transação OFX para o frontend
+// OFXTransactionDTO representa uma linha importada (OFX ou CSV) para o frontend
 type OFXTransactionDTO struct {
-	ID          string    `json:"id"`
-	Amount      float64   `json:"amount"`
-	Date        time.Time `json:"date"`
-	Description string    `json:"description"`
-	Memo        string    `json:"memo"`
-	Type        string    `json:"type"` // "income" ou "expense"
+	ID          string     `json:"id"`
+	Amount      float64    `json:"amount"`
+	Date        time.Time  `json:"date"`
+	ValueDate   *time.Time `json:"value_date,omitempty"`
+	Description string     `json:"description"`
+	Memo        string     `json:"memo"`
+	Type        string     `json:"type"` // "income" ou "expense"
+	// Categoria e conta de transferência informadas no próprio arquivo (CSV e QIF)
+	FileCategory    string `json:"file_category,omitempty"`
+	TransferAccount string `json:"transfer_account,omitempty"`
+	// Sugestões das regras de categorização
+	SuggestedCategoryID string   `json:"suggested_category_id,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
+	Payee               string   `json:"payee,omitempty"`
+	PayeeID             string   `json:"payee_id,omitempty"` // Beneficiário cadastrado reconhecido na linha
+	MatchedRuleIDs      []string `json:"matched_rule_ids,omitempty"`
+	// SuggestionSource indica se a categoria sugerida veio de uma regra ("rule") ou do histórico ("history")
+	SuggestionSource    string                       `json:"suggestion_source,omitempty"`
+	CategorySuggestions []structs.CategorySuggestion `json:"category_suggestions,omitempty"`
+	// TransferCandidates são possíveis contrapartidas em outras contas (use link_transaction_id para vincular)
+	TransferCandidates []structs.TransferCandidate `json:"transfer_candidates,omitempty"`
 }
 
-// ImportOFX processa arquivo OFX e importa transações
+// ImportOFX importa transações OFX. Com corpo JSON, importa as linhas revisadas de uma
+// pré-visualização; com formulário multipart, importa o arquivo inteiro (fluxo legado). Nos dois
+// casos a importação roda em segundo plano e a resposta traz o job; com sync=true, roda na própria
+// requisição e a resposta traz o resultado.
 func (h *OFXHandler) ImportOFX(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id é obrigatório"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	if c.ContentType() == "application/json" {
+		importReviewedPreview(c, h.ImportService, h.ImportJobService, userID)
 		return
 	}
 
@@ -105,85 +128,59 @@ func (h *OFXHandler) ImportOFX(c *gin.Context) {
 		return
 	}
 
-	// Processar arquivo OFX
-	response, err := h.processOFXFile(content, accountID, userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao processar arquivo OFX", "details": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, response)
-}
-
-// processOFXFile processa o conteúdo do arquivo OFX
-func (h *OFXHandler) processOFXFile(content []byte, accountID, userID string) (*ImportOFXResponse, error) {
-	response := &ImportOFXResponse{
-		Success: true,
-		Message: "Arquivo OFX processado com sucesso",
-		Errors:  []string{},
+	// Verificar se o mesmo arquivo já foi importado (a menos que o usuário force a reimportação)
+	fileHash := services.HashFileContent(content)
+	forceValues := form.Value["force"]
+	force := len(forceValues) > 0 && forceValues[0] == "true"
+	if !force {
+		previous, err := h.ImportService.FindDuplicateImport(fileHash, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar importações anteriores", "details": err.Error()})
+			return
+		}
+		if previous != nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":        "Este arquivo já foi importado. Desfaça a importação anterior ou envie force=true para importar novamente",
+				"import_batch": previous,
+			})
+			return
+		}
 	}
 
 	// Parse do arquivo OFX usando parsing manual
-	transactions, err := h.parseOFXManually(content)
+	statement, err := services.ParseOFXStatement(content)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao fazer parse do arquivo OFX: %v", err)
-	}
-
-	// Buscar ou criar categoria padrão para importação
-	defaultCategory, err := h.DB.EnsureTransferCategory()
-	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar categoria padrão: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao fazer parse do arquivo OFX", "details": err.Error()})
+		return
 	}
 
-	// Processar cada transação encontrada
-	for _, ofxTx := range transactions {
-		// Converter transação OFX para nossa estrutura
-		tx, err := h.convertOFXTransactionManual(ofxTx, accountID, userID, defaultCategory.ID)
+	if c.Query("sync") == "true" {
+		result, err := h.ImportService.ImportFile(userID, accountID, "ofx", file.Filename, fileHash, statement.Lines, statement.LedgerBalance)
 		if err != nil {
-			response.Errors = append(response.Errors, fmt.Sprintf("Erro ao converter transação: %v", err))
-			response.TransactionsSkipped++
-			continue
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao processar arquivo OFX", "details": err.Error()})
+			return
 		}
-
-		// Verificar se transação já existe (por data, valor e descrição)
-		exists, err := h.transactionExists(tx, userID)
-		if err != nil {
-			response.Errors = append(response.Errors, fmt.Sprintf("Erro ao verificar transação existente: %v", err))
-			response.TransactionsSkipped++
-			continue
-		}
-
-		if exists {
-			response.TransactionsSkipped++
-			continue
-		}
-
-		// Criar transação no banco
-		if err := h.DB.CreateTransaction(*tx); err != nil {
-			response.Errors = append(response.Errors, fmt.Sprintf("Erro ao criar transação: %v", err))
-			response.TransactionsSkipped++
-			continue
-		}
-
-		response.TransactionsImported++
+		c.JSON(http.StatusOK, result)
+		return
 	}
 
-	// Atualizar mensagem de sucesso
-	if response.TransactionsImported > 0 {
-		response.Message = fmt.Sprintf("Importação concluída! %d transações importadas, %d ignoradas.",
-			response.TransactionsImported, response.TransactionsSkipped)
-	} else {
-		response.Message = "Nenhuma transação nova encontrada para importar."
+	job, err := h.ImportJobService.EnqueueFileImport(userID, accountID, "ofx", file.Filename, fileHash, statement.Lines, statement.LedgerBalance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao processar arquivo OFX", "details": err.Error()})
+		return
 	}
 
-	return response, nil
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": fmt.Sprintf("Importação iniciada. %d transações serão processadas em segundo plano.", job.TotalLines),
+		"job":     job,
+	})
 }
 
 // PreviewOFX faz o parsing do arquivo OFX e retorna as transações encontradas para revisão
 func (h *OFXHandler) PreviewOFX(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id é obrigatório"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
 		return
 	}
 
@@ -209,208 +206,123 @@ func (h *OFXHandler) PreviewOFX(c *gin.Context) {
 	}
 
 	// Parse do arquivo OFX
-	transactions, err := h.parseOFXManually(content)
+	statement, err := services.ParseOFXStatement(content)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao fazer parse do arquivo OFX", "details": err.Error()})
 		return
 	}
+	transactions := statement.Lines
 
-	// Converter para DTOs
-	var transactionDTOs []OFXTransactionDTO
-	for _, tx := range transactions {
-		txType := "expense"
-		if tx.Amount > 0 {
-			txType = "income"
-		}
-
-		dto := OFXTransactionDTO{
-			ID:          tx.ID,
-			Amount:      tx.Amount,
-			Date:        tx.Date,
-			Description: tx.Description,
-			Memo:        tx.Memo,
-			Type:        txType,
-		}
-		transactionDTOs = append(transactionDTOs, dto)
-	}
-
-	response := &PreviewOFXResponse{
-		Success:      true,
-		Message:      fmt.Sprintf("Arquivo OFX processado com sucesso. %d transações encontradas.", len(transactionDTOs)),
-		Transactions: transactionDTOs,
-		Errors:       []string{},
-	}
-
-	c.JSON(http.StatusOK, response)
-}
-
-// OFXTransaction representa uma transação extraída do arquivo OFX
-type OFXTransaction struct {
-	Amount      float64
-	Date        time.Time
-	Description string
-	Memo        string
-	ID          string
-}
-
-// parseOFXManually faz o parsing manual do arquivo OFX
-func (h *OFXHandler) parseOFXManually(content []byte) ([]OFXTransaction, error) {
-	var transactions []OFXTransaction
-	contentStr := string(content)
-
-	// Procurar por blocos STMTTRN (transações)
-	lines := strings.Split(contentStr, "\n")
-	var currentTx *OFXTransaction
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		if line == "<STMTTRN>" {
-			currentTx = &OFXTransaction{}
-		} else if line == "</STMTTRN>" && currentTx != nil {
-			// Finalizar transação atual
-			if currentTx.Amount != 0 && !currentTx.Date.IsZero() {
-				transactions = append(transactions, *currentTx)
-			}
-			currentTx = nil
-		} else if currentTx != nil {
-			// Processar campos da transação
-			if strings.HasPrefix(line, "<TRNAMT>") {
-				amountStr := strings.TrimPrefix(line, "<TRNAMT>")
-				if amount, err := parseFloat(amountStr); err == nil {
-					currentTx.Amount = amount
-				}
-			} else if strings.HasPrefix(line, "<DTPOSTED>") {
-				dateStr := strings.TrimPrefix(line, "<DTPOSTED>")
-				if date, err := parseOFXDate(dateStr); err == nil {
-					currentTx.Date = date
-				}
-			} else if strings.HasPrefix(line, "<NAME>") {
-				currentTx.Description = strings.TrimPrefix(line, "<NAME>")
-			} else if strings.HasPrefix(line, "<MEMO>") {
-				currentTx.Memo = strings.TrimPrefix(line, "<MEMO>")
-			} else if strings.HasPrefix(line, "<FITID>") {
-				currentTx.ID = strings.TrimPrefix(line, "<FITID>")
-			}
-		}
-	}
-
-	return transactions, nil
-}
-
-// convertOFXTransactionManual converte uma transação OFX manual para nossa estrutura
-func (h *OFXHandler) convertOFXTransactionManual(ofxTx OFXTransaction, accountID, userID, categoryID string) (*structs.Transaction, error) {
-	// Determinar tipo da transação baseado no valor
-	txType := "expense"
-	if ofxTx.Amount > 0 {
-		txType = "income"
-	}
-
-	// Converter valor para centavos (multiplicar por 100)
-	amount := int(ofxTx.Amount * 100)
-	if amount < 0 {
-		amount = -amount // Garantir que o valor seja positivo
+	// Guardar o arquivo analisado (e o saldo do banco) para que a importação use exatamente estas linhas
+	preview, err := h.ImportService.CreatePreview(userID, "ofx", fileHeader.Filename, content, transactions, statement.LedgerBalance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar pré-visualização", "details": err.Error()})
+		return
 	}
 
-	// Usar data da transação ou data atual se não disponível
-	datePosted := ofxTx.Date
-	if datePosted.IsZero() {
-		datePosted = time.Now()
+	// A conta é opcional na pré-visualização, mas permite avaliar regras restritas a uma conta
+	transactionDTOs, err := previewTransactionDTOs(h.ImportService, userID, c.PostForm("account_id"), transactions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao sugerir categorias", "details": err.Error()})
+		return
 	}
 
-	// Criar descrição limpa
-	description := strings.TrimSpace(ofxTx.Description)
-	if description == "" {
-		description = strings.TrimSpace(ofxTx.Memo)
-	}
-	if description == "" {
-		description = "Transação importada"
+	// Avisar se o mesmo arquivo já foi importado
+	duplicateImport, err := h.ImportService.FindDuplicateImport(preview.FileHash, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar importações anteriores", "details": err.Error()})
+		return
 	}
 
-	// Criar transação
-	tx := &structs.Transaction{
-		ID:             uuid.New().String(),
-		UserID:         userID,
-		Description:    description,
-		Amount:         amount,
-		Type:           txType,
-		CategoryID:     categoryID,
-		AccountID:      accountID,
-		DueDate:        datePosted,
-		CompetenceDate: datePosted,
-		IsPaid:         true, // Transações importadas são consideradas pagas
-		Observation:    fmt.Sprintf("Importado via OFX - %s", ofxTx.ID),
-		IsRecurring:    false,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+	response := &PreviewOFXResponse{
+		Success:         true,
+		Message:         fmt.Sprintf("Arquivo OFX processado com sucesso. %d transações encontradas.", len(transactionDTOs)),
+		Transactions:    transactionDTOs,
+		PreviewToken:    preview.ID,
+		ExpiresAt:       preview.ExpiresAt,
+		DuplicateImport: duplicateImport,
+		LedgerBalance:   statement.LedgerBalance,
+		Errors:          []string{},
 	}
 
-	return tx, nil
+	c.JSON(http.StatusOK, response)
 }
 
-// parseFloat converte string para float64
-func parseFloat(s string) (float64, error) {
-	// Remover caracteres não numéricos exceto ponto e sinal
-	s = strings.TrimSpace(s)
-
-	// Se a string está vazia, retornar 0
-	if s == "" {
-		return 0, nil
+// previewTransactionDTOs converte as linhas lidas de um arquivo em DTOs com as sugestões
+// de categorização (regras, categoria do arquivo e histórico do usuário)
+func previewTransactionDTOs(importService *services.ImportService, userID, accountID string, lines []structs.ImportLine) ([]OFXTransactionDTO, error) {
+	suggestions, err := importService.SuggestLines(userID, accountID, lines)
+	if err != nil {
+		return nil, err
 	}
 
-	// Converter para float64
-	var result float64
-	_, err := fmt.Sscanf(s, "%f", &result)
-	return result, err
-}
+	transactionDTOs := make([]OFXTransactionDTO, 0, len(lines))
+	for i, line := range lines {
+		txType := "expense"
+		if line.Amount > 0 {
+			txType = "income"
+		}
 
-// parseOFXDate converte data OFX para time.Time
-func parseOFXDate(dateStr string) (time.Time, error) {
-	// Formato OFX: YYYYMMDDHHMMSS ou YYYYMMDD
-	dateStr = strings.TrimSpace(dateStr)
-	if len(dateStr) >= 8 {
-		year := dateStr[0:4]
-		month := dateStr[4:6]
-		day := dateStr[6:8]
-
-		dateStr = fmt.Sprintf("%s-%s-%s", year, month, day)
-		return time.Parse("2006-01-02", dateStr)
-	}
-	return time.Time{}, fmt.Errorf("formato de data inválido: %s", dateStr)
+		suggestion := suggestions[i]
+		transactionDTOs = append(transactionDTOs, OFXTransactionDTO{
+			ID:                  line.ID,
+			Amount:              line.Amount,
+			Date:                line.Date,
+			ValueDate:           line.ValueDate,
+			Description:         line.Description,
+			Memo:                line.Memo,
+			Type:                txType,
+			FileCategory:        line.Category,
+			TransferAccount:     line.TransferAccount,
+			SuggestedCategoryID: suggestion.CategoryID,
+			Tags:                suggestion.Tags,
+			Payee:               suggestion.Payee,
+			PayeeID:             suggestion.PayeeID,
+			MatchedRuleIDs:      suggestion.RuleIDs,
+			SuggestionSource:    suggestion.Source,
+			CategorySuggestions: suggestion.CategorySuggestions,
+			TransferCandidates:  suggestion.TransferCandidates,
+		})
+	}
+	return transactionDTOs, nil
 }
 
-// transactionExists verifica se uma transação já existe
-func (h *OFXHandler) transactionExists(tx *structs.Transaction, userID string) (bool, error) {
-	// Buscar transações do usuário na mesma data e com valor similar
-	transactions, err := h.DB.GetAllTransactionsByUser(userID)
-	if err != nil {
-		return false, err
+// importReviewedPreview trata a importação das linhas revisadas de uma pré-visualização.
+// A importação roda em segundo plano e a resposta traz o job; com sync=true, a resposta traz o resultado.
+func importReviewedPreview(c *gin.Context, importService *services.ImportService, importJobService *services.ImportJobService, userID string) {
+	var req structs.ImportPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Dados inválidos", "details": err.Error()})
+		return
 	}
 
-	for _, existingTx := range transactions {
-		// Verificar se é a mesma conta
-		if existingTx.AccountID != tx.AccountID {
-			continue
-		}
-
-		// Verificar se é a mesma data (com tolerância de 1 dia)
-		dateDiff := existingTx.DueDate.Sub(tx.DueDate)
-		if dateDiff < -24*time.Hour || dateDiff > 24*time.Hour {
-			continue
-		}
-
-		// Verificar se é o mesmo valor (com tolerância de 1 centavo)
-		amountDiff := existingTx.Amount - tx.Amount
-		if amountDiff < -1 || amountDiff > 1 {
-			continue
+	if c.Query("sync") == "true" {
+		result, err := importService.ImportPreview(userID, req)
+		if err != nil {
+			var duplicate *services.DuplicateImportError
+			if errors.As(err, &duplicate) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "import_batch": duplicate.Batch})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
 
-		// Verificar se a descrição é similar
-		if strings.Contains(strings.ToLower(existingTx.Description), strings.ToLower(tx.Description)) ||
-			strings.Contains(strings.ToLower(tx.Description), strings.ToLower(existingTx.Description)) {
-			return true, nil
+	job, err := importJobService.EnqueuePreviewImport(userID, req)
+	if err != nil {
+		var duplicate *services.DuplicateImportError
+		if errors.As(err, &duplicate) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "import_batch": duplicate.Batch})
+			return
 		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	return false, nil
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": fmt.Sprintf("Importação iniciada. %d transações serão processadas em segundo plano.", job.TotalLines),
+		"job":     job,
+	})
 }
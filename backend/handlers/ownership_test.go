@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/database/databasetest"
+	"github.com/tonnarruda/my-personal-finance/services"
+)
+
+const (
+	userA = "11111111-1111-4111-8111-111111111111"
+	userB = "22222222-2222-4222-8222-222222222222"
+	// Dados do usuário A
+	categoryOfA    = "aaaaaaaa-0000-4000-8000-000000000001"
+	accountOfA     = "aaaaaaaa-0000-4000-8000-000000000002"
+	transactionOfA = "aaaaaaaa-0000-4000-8000-000000000003"
+	importOfA      = "aaaaaaaa-0000-4000-8000-000000000004"
+	jobOfA         = "aaaaaaaa-0000-4000-8000-000000000005"
+	payeeOfA       = "aaaaaaaa-0000-4000-8000-000000000006"
+	payeeRuleOfA   = "aaaaaaaa-0000-4000-8000-000000000007"
+	ruleOfA        = "aaaaaaaa-0000-4000-8000-000000000008"
+	// Dados do usuário B
+	transactionOfB = "bbbbbbbb-0000-4000-8000-000000000003"
+	payeeOfB       = "bbbbbbbb-0000-4000-8000-000000000006"
+)
+
+// newOwnershipRouter monta as rotas testadas com o usuário B na sessão
+func newOwnershipRouter(t *testing.T) (*gin.Engine, *databasetest.Driver) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	sqlDB, fake := databasetest.Open(t)
+	db := database.NewDatabaseWithConnection(sqlDB)
+
+	exchangeService := services.NewMockExchangeService()
+	suggestionService := services.NewSuggestionService(db)
+	payeeService := services.NewPayeeService(db)
+	ruleService := services.NewRuleService(db, suggestionService, payeeService)
+	transferService := services.NewTransferService(db, exchangeService, suggestionService)
+	importService := services.NewImportService(db, exchangeService, ruleService, suggestionService, transferService, payeeService, services.NewInsightService(db))
+
+	categoryHandler := NewCategoryHandler(services.NewCategoryService(db))
+	accountHandler := NewAccountHandler(services.NewAccountService(db, services.NewDatabaseTransactionCreator(db)))
+	transactionHandler := &TransactionHandler{DB: db}
+	importHandler := NewImportHandler(importService)
+	importJobHandler := NewImportJobHandler(services.NewImportJobService(db, importService))
+	payeeHandler := NewPayeeHandler(payeeService)
+	ruleHandler := NewRuleHandler(ruleService)
+	backupHandler := NewBackupHandler(services.NewBackupService(db, suggestionService))
+
+	router := gin.New()
+	api := router.Group("/api", func(c *gin.Context) {
+		c.Set("user_id", userB)
+		c.Next()
+	})
+	api.GET("/categories/:id", categoryHandler.GetCategoryByID)
+	api.PUT("/categories/:id", categoryHandler.UpdateCategory)
+	api.PATCH("/categories/:id/color", categoryHandler.UpdateCategoryColor)
+	api.DELETE("/categories/:id", categoryHandler.DeleteCategory)
+	api.DELETE("/categories/:id/permanent", categoryHandler.HardDeleteCategory)
+	api.GET("/accounts/:id", accountHandler.GetAccountByID)
+	api.PUT("/accounts/:id", accountHandler.UpdateAccount)
+	api.DELETE("/accounts/:id", accountHandler.DeleteAccount)
+	api.GET("/transactions/:id", transactionHandler.GetTransactionByID)
+	api.PUT("/transactions/:id", transactionHandler.UpdateTransaction)
+	api.DELETE("/transactions/:id", transactionHandler.DeleteTransaction)
+	api.GET("/imports/:id", importHandler.GetImportBatch)
+	api.POST("/imports/:id/revert", importHandler.RevertImportBatch)
+	api.GET("/imports/:id/balance-check", importHandler.GetBalanceCheck)
+	api.POST("/imports/:id/balance-adjustment", importHandler.CreateBalanceAdjustment)
+	api.GET("/import-jobs/:id", importJobHandler.GetJob)
+	api.GET("/import-jobs/:id/events", importJobHandler.StreamJob)
+	api.POST("/import-jobs/:id/cancel", importJobHandler.CancelJob)
+	api.GET("/payees/:id", payeeHandler.GetPayeeByID)
+	api.PUT("/payees/:id", payeeHandler.UpdatePayee)
+	api.DELETE("/payees/:id", payeeHandler.DeletePayee)
+	api.GET("/payees/:id/history", payeeHandler.GetPayeeHistory)
+	api.POST("/payees/:id/merge", payeeHandler.MergePayees)
+	api.POST("/payee-rules", payeeHandler.CreatePayeeRule)
+	api.PUT("/payee-rules/:id", payeeHandler.UpdatePayeeRule)
+	api.DELETE("/payee-rules/:id", payeeHandler.DeletePayeeRule)
+	api.POST("/rules", ruleHandler.CreateRule)
+	api.GET("/rules/:id", ruleHandler.GetRuleByID)
+	api.PUT("/rules/:id", ruleHandler.UpdateRule)
+	api.DELETE("/rules/:id", ruleHandler.DeleteRule)
+	api.GET("/backup", backupHandler.ExportBackup)
+	api.POST("/backup/restore", backupHandler.RestoreBackup)
+	return router, fake
+}
+
+func TestOtherUsersDataIsNotFound(t *testing.T) {
+	router, fake := newOwnershipRouter(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantError  string
+		// lookupID é o registro do A que precisa ser buscado com o usuário da sessão
+		lookupID string
+	}{
+		{"ver categoria", "GET", "/api/categories/" + categoryOfA, "", http.StatusNotFound, "categoria não encontrada", categoryOfA},
+		{"alterar categoria", "PUT", "/api/categories/" + categoryOfA, `{"name": "Alterada pelo B", "user_id": "` + userA + `"}`, http.StatusBadRequest, "categoria não encontrada", categoryOfA},
+		{"mudar cor da categoria", "PATCH", "/api/categories/" + categoryOfA + "/color", `{"color": "#000000"}`, http.StatusBadRequest, "categoria não encontrada", categoryOfA},
+		{"excluir categoria", "DELETE", "/api/categories/" + categoryOfA, "", http.StatusBadRequest, "categoria não encontrada", categoryOfA},
+		{"excluir categoria permanentemente", "DELETE", "/api/categories/" + categoryOfA + "/permanent", "", http.StatusBadRequest, "categoria não encontrada", categoryOfA},
+		{"ver conta", "GET", "/api/accounts/" + accountOfA, "", http.StatusNotFound, "conta não encontrada", accountOfA},
+		{"alterar conta", "PUT", "/api/accounts/" + accountOfA, `{"name": "Alterada pelo B", "type": "income", "user_id": "` + userA + `"}`, http.StatusBadRequest, "conta não encontrada", accountOfA},
+		{"excluir conta", "DELETE", "/api/accounts/" + accountOfA, "", http.StatusBadRequest, "conta não encontrada", accountOfA},
+		{"ver transação", "GET", "/api/transactions/" + transactionOfA, "", http.StatusNotFound, "", transactionOfA},
+		{"alterar transação", "PUT", "/api/transactions/" + transactionOfA, `{"amount": 1, "user_id": "` + userA + `"}`, http.StatusNotFound, "", transactionOfA},
+		{"excluir transação", "DELETE", "/api/transactions/" + transactionOfA, "", http.StatusNotFound, "", transactionOfA},
+		// O B não consegue mover a própria transação para a conta ou a categoria do A
+		{"transação para conta do outro", "PUT", "/api/transactions/" + transactionOfB, `{"account_id": "` + accountOfA + `"}`, http.StatusBadRequest, "conta não encontrada", accountOfA},
+		{"transação para categoria do outro", "PUT", "/api/transactions/" + transactionOfB, `{"category_id": "` + categoryOfA + `"}`, http.StatusBadRequest, "categoria não encontrada", categoryOfA},
+		{"ver importação", "GET", "/api/imports/" + importOfA, "", http.StatusNotFound, "importação não encontrada", importOfA},
+		{"desfazer importação", "POST", "/api/imports/" + importOfA + "/revert", "", http.StatusBadRequest, "importação não encontrada", importOfA},
+		{"conferir saldo da importação", "GET", "/api/imports/" + importOfA + "/balance-check", "", http.StatusBadRequest, "importação não encontrada", importOfA},
+		{"ajustar saldo da importação", "POST", "/api/imports/" + importOfA + "/balance-adjustment", `{"category_id": "` + categoryOfA + `"}`, http.StatusBadRequest, "importação não encontrada", importOfA},
+		{"ver job de importação", "GET", "/api/import-jobs/" + jobOfA, "", http.StatusNotFound, "job de importação não encontrado", jobOfA},
+		{"acompanhar job de importação", "GET", "/api/import-jobs/" + jobOfA + "/events", "", http.StatusNotFound, "job de importação não encontrado", jobOfA},
+		{"cancelar job de importação", "POST", "/api/import-jobs/" + jobOfA + "/cancel", "", http.StatusBadRequest, "job de importação não encontrado", jobOfA},
+		{"ver beneficiário", "GET", "/api/payees/" + payeeOfA, "", http.StatusNotFound, "beneficiário não encontrado", payeeOfA},
+		{"renomear beneficiário", "PUT", "/api/payees/" + payeeOfA, `{"name": "Alterado pelo B"}`, http.StatusBadRequest, "beneficiário não encontrado", payeeOfA},
+		{"excluir beneficiário", "DELETE", "/api/payees/" + payeeOfA, "", http.StatusBadRequest, "beneficiário não encontrado", payeeOfA},
+		{"histórico do beneficiário", "GET", "/api/payees/" + payeeOfA + "/history", "", http.StatusNotFound, "beneficiário não encontrado", payeeOfA},
+		{"unir beneficiários no do outro", "POST", "/api/payees/" + payeeOfA + "/merge", `{"payee_ids": ["` + payeeOfB + `"]}`, http.StatusBadRequest, "beneficiário não encontrado", payeeOfA},
+		{"regra para beneficiário do outro", "POST", "/api/payee-rules", `{"payee_id": "` + payeeOfA + `", "pattern": "padaria"}`, http.StatusBadRequest, "beneficiário não encontrado", payeeOfA},
+		{"alterar regra de beneficiário", "PUT", "/api/payee-rules/" + payeeRuleOfA, `{"payee_id": "` + payeeOfB + `", "pattern": "padaria"}`, http.StatusBadRequest, "regra de beneficiário não encontrada", payeeRuleOfA},
+		{"excluir regra de beneficiário", "DELETE", "/api/payee-rules/" + payeeRuleOfA, "", http.StatusBadRequest, "regra de beneficiário não encontrada", payeeRuleOfA},
+		{"ver regra", "GET", "/api/rules/" + ruleOfA, "", http.StatusNotFound, "regra não encontrada", ruleOfA},
+		{"alterar regra", "PUT", "/api/rules/" + ruleOfA, `{"name": "Alterada pelo B", "user_id": "` + userA + `"}`, http.StatusBadRequest, "regra não encontrada", ruleOfA},
+		{"excluir regra", "DELETE", "/api/rules/" + ruleOfA, "", http.StatusBadRequest, "regra não encontrada", ruleOfA},
+		{"regra com conta do outro", "POST", "/api/rules", `{"name": "Padaria", "description_contains": "padaria", "account_id": "` + accountOfA + `", "tags": ["padaria"]}`, http.StatusBadRequest, "conta não encontrada", accountOfA},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.Reset()
+			// O user_id da URL é ignorado: vale o da sessão
+			req := httptest.NewRequest(tt.method, tt.path+"?user_id="+userA, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, esperado %d (corpo: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantError != "" && !strings.Contains(w.Body.String(), tt.wantError) {
+				t.Errorf("corpo = %s, esperado erro %q", w.Body.String(), tt.wantError)
+			}
+			checkScopedToSessionUser(t, fake.Reset(), tt.lookupID)
+		})
+	}
+}
+
+// checkScopedToSessionUser confere que o registro foi buscado com o usuário da sessão, que o
+// usuário da URL ou do corpo nunca chegou ao banco e que nada foi gravado
+func checkScopedToSessionUser(t *testing.T, calls []databasetest.Call, lookupID string) {
+	t.Helper()
+	looked := false
+	for _, call := range calls {
+		if call.Exec {
+			t.Errorf("comando de escrita executado: %s", call.Query)
+		}
+		hasID, hasSessionUser := false, false
+		for _, arg := range call.Args {
+			switch fmt.Sprint(arg) {
+			case userA:
+				t.Errorf("usuário A usado na consulta: %s", call.Query)
+			case userB:
+				hasSessionUser = true
+			case lookupID:
+				hasID = true
+			}
+		}
+		if hasID {
+			looked = true
+			if !hasSessionUser || !strings.Contains(call.Query, "user_id") {
+				t.Errorf("busca sem filtrar pelo usuário da sessão: %s", call.Query)
+			}
+		}
+	}
+	if !looked {
+		t.Errorf("%s não foi buscado no banco", lookupID)
+	}
+}
+
+func TestBackupUsesSessionUser(t *testing.T) {
+	router, fake := newOwnershipRouter(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"exportar backup", "GET", "/api/backup", "", http.StatusInternalServerError},
+		// O user_id gravado no arquivo também é ignorado
+		{"restaurar backup", "POST", "/api/backup/restore", `{"version": 1, "user_id": "` + userA + `"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.Reset()
+			req := httptest.NewRequest(tt.method, tt.path+"?user_id="+userA, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// O banco falso não tem o usuário B, então o backup para logo após buscá-lo
+			if w.Code != tt.wantStatus || !strings.Contains(w.Body.String(), "usuário não encontrado") {
+				t.Errorf("status = %d, esperado %d (corpo: %s)", w.Code, tt.wantStatus, w.Body.String())
+			}
+			calls := fake.Reset()
+			looked := false
+			for _, call := range calls {
+				if call.Exec {
+					t.Errorf("comando de escrita executado: %s", call.Query)
+				}
+				for _, arg := range call.Args {
+					switch fmt.Sprint(arg) {
+					case userA:
+						t.Errorf("usuário A usado na consulta: %s", call.Query)
+					case userB:
+						looked = true
+					}
+				}
+			}
+			if !looked {
+				t.Errorf("usuário da sessão não foi buscado no banco")
+			}
+		})
+	}
+}
+
+func TestUpdateTransactionRejectsNonStringReferences(t *testing.T) {
+	router, fake := newOwnershipRouter(t)
+
+	for _, body := range []string{
+		`{"account_id": 123}`,
+		`{"account_id": null}`,
+		`{"account_id": ""}`,
+		`{"category_id": ["` + categoryOfA + `"]}`,
+		`{"category_id": {"id": "` + categoryOfA + `"}}`,
+	} {
+		fake.Reset()
+		req := httptest.NewRequest("PUT", "/api/transactions/"+transactionOfB, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, esperado 400", body, w.Code)
+		}
+		// A requisição é recusada antes de qualquer acesso ao banco
+		if calls := fake.Reset(); len(calls) > 0 {
+			t.Errorf("%s: %d comandos no banco, esperado nenhum", body, len(calls))
+		}
+	}
+}
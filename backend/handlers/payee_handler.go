@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+type PayeeHandler struct {
+	payeeService *services.PayeeService
+}
+
+// NewPayeeHandler cria uma nova instância do handler de beneficiários
+func NewPayeeHandler(payeeService *services.PayeeService) *PayeeHandler {
+	return &PayeeHandler{
+		payeeService: payeeService,
+	}
+}
+
+// CreatePayee cria um novo beneficiário
+func (h *PayeeHandler) CreatePayee(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req structs.PayeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Dados inválidos: " + err.Error(),
+		})
+		return
+	}
+
+	payee, err := h.payeeService.CreatePayee(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Beneficiário criado com sucesso",
+		"payee":   payee,
+	})
+}
+
+// GetPayees lista os beneficiários do usuário (q filtra pelo nome)
+func (h *PayeeHandler) GetPayees(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	payees, err := h.payeeService.GetPayees(userID, c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payees": payees,
+	})
+}
+
+// GetPayeeByID busca um beneficiário pelo ID
+func (h *PayeeHandler) GetPayeeByID(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	payee, err := h.payeeService.GetPayeeByID(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"payee": payee,
+	})
+}
+
+// UpdatePayee renomeia um beneficiário
+func (h *PayeeHandler) UpdatePayee(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req structs.PayeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Dados inválidos: " + err.Error(),
+		})
+		return
+	}
+
+	payee, err := h.payeeService.UpdatePayee(c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Beneficiário atualizado com sucesso",
+		"payee":   payee,
+	})
+}
+
+// DeletePayee remove um beneficiário
+func (h *PayeeHandler) DeletePayee(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	if err := h.payeeService.DeletePayee(c.Param("id"), userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Beneficiário excluído com sucesso",
+	})
+}
+
+// MergePayees reúne outros beneficiários no beneficiário informado
+func (h *PayeeHandler) MergePayees(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req structs.PayeeMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Dados inválidos: " + err.Error(),
+		})
+		return
+	}
+
+	payee, err := h.payeeService.MergePayees(c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Beneficiários mesclados com sucesso",
+		"payee":   payee,
+	})
+}
+
+// GetPayeeHistory retorna o histórico de gastos do beneficiário (from e to no formato AAAA-MM-DD)
+func (h *PayeeHandler) GetPayeeHistory(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var from, to *time.Time
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "from deve estar no formato AAAA-MM-DD",
+			})
+			return
+		}
+		from = &parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "to deve estar no formato AAAA-MM-DD",
+			})
+			return
+		}
+		to = &parsed
+	}
+	if from != nil && to != nil && from.After(*to) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from deve ser anterior a to",
+		})
+		return
+	}
+
+	history, err := h.payeeService.GetPayeeHistory(c.Param("id"), userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// ApplyPayees atribui beneficiários às transações existentes (ou simula com dry_run)
+func (h *PayeeHandler) ApplyPayees(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req structs.ApplyPayeesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Dados inválidos: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.payeeService.ApplyPayees(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// NormalizeDescription mostra o beneficiário que seria reconhecido em uma descrição do banco
+func (h *PayeeHandler) NormalizeDescription(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	description := c.Query("description")
+	if description == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "description é obrigatório",
+		})
+		return
+	}
+
+	normalization, err := h.payeeService.NormalizeDescription(userID, description)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, normalization)
+}
+
+// CreatePayeeRule cria uma nova regra de beneficiário
+func (h *PayeeHandler) CreatePayeeRule(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req structs.PayeeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Dados inválidos: " + err.Error(),
+		})
+		return
+	}
+
+	rule, err := h.payeeService.CreatePayeeRule(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Regra de beneficiário criada com sucesso",
+		"rule":    rule,
+	})
+}
+
+// GetPayeeRules lista as regras de beneficiário do usuário em ordem de prioridade
+func (h *PayeeHandler) GetPayeeRules(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	rules, err := h.payeeService.GetPayeeRules(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+	})
+}
+
+// UpdatePayeeRule atualiza uma regra de beneficiário
+func (h *PayeeHandler) UpdatePayeeRule(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req structs.PayeeRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Dados inválidos: " + err.Error(),
+		})
+		return
+	}
+
+	rule, err := h.payeeService.UpdatePayeeRule(c.Param("id"), userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Regra de beneficiário atualizada com sucesso",
+		"rule":    rule,
+	})
+}
+
+// DeletePayeeRule remove uma regra de beneficiário
+func (h *PayeeHandler) DeletePayeeRule(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	if err := h.payeeService.DeletePayeeRule(c.Param("id"), userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Regra de beneficiário excluída com sucesso",
+	})
+}
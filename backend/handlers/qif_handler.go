@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+type QIFHandler struct {
+	importService    *services.ImportService
+	importJobService *services.ImportJobService
+}
+
+// NewQIFHandler cria uma nova instância do handler de importação QIF
+func NewQIFHandler(importService *services.ImportService, importJobService *services.ImportJobService) *QIFHandler {
+	return &QIFHandler{
+		importService:    importService,
+		importJobService: importJobService,
+	}
+}
+
+// PreviewQIFResponse representa a resposta da pré-visualização de um arquivo QIF
+type PreviewQIFResponse struct {
+	Success      bool                `json:"success"`
+	Message      string              `json:"message"`
+	Transactions []OFXTransactionDTO `json:"transactions"`
+	PreviewToken string              `json:"preview_token"`
+	ExpiresAt    time.Time           `json:"expires_at"`
+	// DateOrder é a ordem de data usada ("dmy" ou "mdy"); quando ambígua, reenvie com date_order
+	DateOrder          string               `json:"date_order"`
+	DateOrderAmbiguous bool                 `json:"date_order_ambiguous"`
+	DuplicateImport    *structs.ImportBatch `json:"duplicate_import,omitempty"`
+	Errors             []string             `json:"errors,omitempty"`
+}
+
+// PreviewQIF faz o parsing do arquivo QIF e retorna os lançamentos para revisão
+func (h *QIFHandler) PreviewQIF(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("qif_file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao obter arquivo QIF", "details": err.Error()})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao abrir arquivo QIF", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao ler arquivo QIF", "details": err.Error()})
+		return
+	}
+
+	dateOrder := c.PostForm("date_order")
+	if dateOrder != "" && dateOrder != services.QIFDateOrderDMY && dateOrder != services.QIFDateOrderMDY {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date_order deve ser 'dmy' ou 'mdy'"})
+		return
+	}
+
+	parsed, err := services.ParseQIF(content, dateOrder)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Erro ao fazer parse do arquivo QIF", "details": err.Error()})
+		return
+	}
+
+	preview, err := h.importService.CreatePreview(userID, "qif", fileHeader.Filename, content, parsed.Lines, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao salvar pré-visualização", "details": err.Error()})
+		return
+	}
+
+	transactionDTOs, err := previewTransactionDTOs(h.importService, userID, c.PostForm("account_id"), parsed.Lines)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao sugerir categorias", "details": err.Error()})
+		return
+	}
+
+	duplicateImport, err := h.importService.FindDuplicateImport(preview.FileHash, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao verificar importações anteriores", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, &PreviewQIFResponse{
+		Success:            true,
+		Message:            fmt.Sprintf("Arquivo QIF processado com sucesso. %d transações encontradas.", len(transactionDTOs)),
+		Transactions:       transactionDTOs,
+		PreviewToken:       preview.ID,
+		ExpiresAt:          preview.ExpiresAt,
+		DateOrder:          parsed.DateOrder,
+		DateOrderAmbiguous: parsed.DateOrderAmbiguous,
+		DuplicateImport:    duplicateImport,
+		Errors:             []string{},
+	})
+}
+
+// ImportQIF importa as linhas revisadas de uma pré-visualização QIF
+func (h *QIFHandler) ImportQIF(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+
+	importReviewedPreview(c, h.importService, h.importJobService, userID)
+}
@@ -0,0 +1,459 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+type ReportHandler struct {
+	reportService *services.ReportService
+}
+
+// NewReportHandler cria uma nova instância do handler de relatórios
+func NewReportHandler(reportService *services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// GetMonthlyReportPDF gera o relatório do mês em PDF (month no formato AAAA-MM, padrão: mês atual;
+// currency, padrão BRL)
+func (h *ReportHandler) GetMonthlyReportPDF(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	month := time.Now()
+	if value := c.Query("month"); value != "" {
+		parsed, err := time.Parse("2006-01", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "month deve estar no formato AAAA-MM",
+			})
+			return
+		}
+		month = parsed
+	}
+
+	report, err := h.reportService.GetMonthlyReport(userID, month, reportCurrency(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	sendReportPDF(c, fmt.Sprintf("relatorio-%s.pdf", month.Format("2006-01")), func(buf *bytes.Buffer) error {
+		return services.WritePeriodReportPDF(buf, report)
+	})
+}
+
+// GetYearlyReportPDF gera o relatório do ano em PDF (year, padrão: ano atual; currency, padrão BRL)
+func (h *ReportHandler) GetYearlyReportPDF(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	year := time.Now().Year()
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1900 || parsed > 9999 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "year deve ser um ano válido, como 2024",
+			})
+			return
+		}
+		year = parsed
+	}
+
+	report, err := h.reportService.GetYearlyReport(userID, year, reportCurrency(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	sendReportPDF(c, fmt.Sprintf("relatorio-%d.pdf", year), func(buf *bytes.Buffer) error {
+		return services.WritePeriodReportPDF(buf, report)
+	})
+}
+
+// GetCategoryReport retorna os totais por categoria do período, com as subcategorias somadas às
+// categorias pai (from e to no formato AAAA-MM-DD, padrão: mês atual; currency, padrão BRL;
+// type income ou expense; parent_id para detalhar as subcategorias de uma categoria)
+func (h *ReportHandler) GetCategoryReport(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	from, to, err := parseReportPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	txType := c.Query("type")
+	if txType != "" && txType != "income" && txType != "expense" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "type deve ser 'income' ou 'expense'",
+		})
+		return
+	}
+
+	parentID := c.Query("parent_id")
+	if parentID != "" && !utils.IsValidUUID(parentID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "parent_id deve ser um UUID válido",
+		})
+		return
+	}
+
+	report, err := h.reportService.GetCategoryReport(userID, reportCurrency(c), txType, from, to, parentID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if err.Error() == "categoria não encontrada" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetIncomeStatement retorna a DRE do período pelos regimes de competência e de caixa, lado a lado,
+// com a conciliação entre eles (from e to no formato AAAA-MM-DD, padrão: mês atual; currency, padrão BRL)
+func (h *ReportHandler) GetIncomeStatement(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	from, to, err := parseReportPeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	statement, err := h.reportService.GetIncomeStatement(userID, reportCurrency(c), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+// GetCategoryTrends retorna as séries mensais por categoria com média móvel, comparação com o ano
+// anterior e desvio em relação à média dos 12 meses anteriores (month no formato AAAA-MM, último mês
+// da série, padrão: mês atual; months, padrão 12, até 60; window, padrão 3, até 12; type, padrão
+// expense; currency, padrão BRL)
+func (h *ReportHandler) GetCategoryTrends(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	end := time.Now()
+	if value := c.Query("month"); value != "" {
+		parsed, err := time.Parse("2006-01", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "month deve estar no formato AAAA-MM",
+			})
+			return
+		}
+		end = parsed
+	}
+
+	months, err := strconv.Atoi(c.DefaultQuery("months", "12"))
+	if err != nil || months < 1 || months > 60 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "months deve ser um número entre 1 e 60",
+		})
+		return
+	}
+	window, err := strconv.Atoi(c.DefaultQuery("window", "3"))
+	if err != nil || window < 1 || window > 12 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "window deve ser um número entre 1 e 12",
+		})
+		return
+	}
+
+	txType := c.DefaultQuery("type", "expense")
+	if txType != "income" && txType != "expense" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "type deve ser 'income' ou 'expense'",
+		})
+		return
+	}
+
+	trends, err := h.reportService.GetCategoryTrends(userID, reportCurrency(c), txType, end, months, window)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, trends)
+}
+
+// GetNetWorth retorna a evolução do saldo por conta e do patrimônio total (from e to no formato
+// AAAA-MM-DD, padrão: últimos 12 meses até hoje; interval month, week ou day, padrão month;
+// currency é a moeda base, padrão BRL)
+func (h *ReportHandler) GetNetWorth(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "to deve estar no formato AAAA-MM-DD",
+			})
+			return
+		}
+		to = parsed
+	}
+	from := time.Date(to.Year(), to.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "from deve estar no formato AAAA-MM-DD",
+			})
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from deve ser anterior a to",
+		})
+		return
+	}
+
+	interval := c.DefaultQuery("interval", "month")
+	if interval != "month" && interval != "week" && interval != "day" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "interval deve ser 'month', 'week' ou 'day'",
+		})
+		return
+	}
+
+	report, err := h.reportService.GetNetWorth(userID, reportCurrency(c), from, to, interval)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetIRPFReport agrupa as transações pagas no ano-calendário nas seções da declaração do IRPF
+// (year, padrão: ano anterior, o da declaração entregue neste ano; currency, padrão BRL)
+func (h *ReportHandler) GetIRPFReport(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	year, err := parseIRPFYear(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	report, err := h.reportService.GetIRPFReport(userID, year, reportCurrency(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetIRPFReportCSV exporta o relatório do IRPF em CSV, com os mesmos parâmetros de GetIRPFReport
+func (h *ReportHandler) GetIRPFReportCSV(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	year, err := parseIRPFYear(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	report, err := h.reportService.GetIRPFReport(userID, year, reportCurrency(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := services.WriteIRPFCSV(&buf, report); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Erro ao gerar CSV: " + err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="irpf-%d.csv"`, year))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// RunPivot executa a consulta dinâmica do corpo (dimensões das linhas e colunas, medidas e
+// filtros) e devolve o resultado como tabela dinâmica
+func (h *ReportHandler) RunPivot(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var query structs.PivotQuery
+	if err := c.ShouldBindJSON(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if _, err := services.ValidatePivotQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.reportService.RunPivot(userID, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseIRPFYear lê o ano-calendário (padrão: ano anterior)
+func parseIRPFYear(c *gin.Context) (int, error) {
+	year := time.Now().Year() - 1
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1900 || parsed > 9999 {
+			return 0, fmt.Errorf("year deve ser um ano válido, como 2024")
+		}
+		year = parsed
+	}
+	return year, nil
+}
+
+// parseReportPeriod lê o período do relatório (from e to no formato AAAA-MM-DD). Sem from, o
+// período começa no primeiro dia do mês atual; sem to, termina no último dia do mês de from.
+func parseReportPeriod(c *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return from, from, fmt.Errorf("from deve estar no formato AAAA-MM-DD")
+		}
+		from = parsed
+	}
+
+	to := time.Date(from.Year(), from.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return from, to, fmt.Errorf("to deve estar no formato AAAA-MM-DD")
+		}
+		to = parsed
+	}
+	if from.After(to) {
+		return from, to, fmt.Errorf("from deve ser anterior a to")
+	}
+	return from, to, nil
+}
+
+// reportCurrency lê a moeda do relatório (padrão BRL)
+func reportCurrency(c *gin.Context) string {
+	return strings.ToUpper(c.DefaultQuery("currency", "BRL"))
+}
+
+// sendReportPDF gera o PDF em memória e o envia; um erro na geração ainda pode ser respondido como JSON
+func sendReportPDF(c *gin.Context, fileName string, write func(buf *bytes.Buffer) error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Erro ao gerar PDF: " + err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
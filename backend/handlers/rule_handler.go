@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+type RuleHandler struct {
+	ruleService *services.RuleService
+}
+
+// NewRuleHandler cria uma nova instância do handler de regras de categorização
+func NewRuleHandler(ruleService *services.RuleService) *RuleHandler {
+	return &RuleHandler{
+		ruleService: ruleService,
+	}
+}
+
+// CreateRule cria uma nova regra de categorização
+func (h *RuleHandler) CreateRule(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req structs.CategorizationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Dados inválidos: " + err.Error(),
+		})
+		return
+	}
+	req.UserID = userID
+
+	rule, err := h.ruleService.CreateRule(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Regra criada com sucesso",
+		"rule":    rule,
+	})
+}
+
+// GetRules lista as regras do usuário em ordem de prioridade
+func (h *RuleHandler) GetRules(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	rules, err := h.ruleService.GetRules(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules": rules,
+	})
+}
+
+// GetRuleByID busca uma regra pelo ID
+func (h *RuleHandler) GetRuleByID(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	rule, err := h.ruleService.GetRuleByID(c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rule": rule,
+	})
+}
+
+// UpdateRule atualiza uma regra existente
+func (h *RuleHandler) UpdateRule(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req structs.CategorizationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Dados inválidos: " + err.Error(),
+		})
+		return
+	}
+	req.UserID = userID
+
+	rule, err := h.ruleService.UpdateRule(c.Param("id"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Regra atualizada com sucesso",
+		"rule":    rule,
+	})
+}
+
+// DeleteRule remove uma regra
+func (h *RuleHandler) DeleteRule(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	if err := h.ruleService.DeleteRule(c.Param("id"), userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Regra excluída com sucesso",
+	})
+}
+
+// ApplyRules aplica as regras às transações já existentes (ou simula com dry_run)
+func (h *RuleHandler) ApplyRules(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req structs.ApplyRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Dados inválidos: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.ruleService.ApplyRules(userID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
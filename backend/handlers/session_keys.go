@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// sessionKeyMinLength é o tamanho mínimo de uma chave de assinatura em produção
+const sessionKeyMinLength = 32
+
+// weakSessionKeys são chaves conhecidas (de exemplos e de versões antigas) recusadas em produção
+var weakSessionKeys = []string{
+	"supersecretkey123", "secret", "changeme", "change-me", "jwtsecret", "password",
+	"troque-por-um-segredo-aleatorio-de-pelo-menos-32-caracteres", // Valor de exemplo do env.example
+}
+
+// SessionKeyConfig é a configuração das chaves de assinatura das sessões. Keys e o arquivo
+// KeyFile trazem pares "kid:segredo" (em Keys separados por vírgula; no arquivo, um por linha,
+// com # para comentários). ActiveKID é a chave usada para assinar; as demais só validam sessões
+// já emitidas, o que permite trocar a chave sem derrubar quem está logado.
+type SessionKeyConfig struct {
+	Keys      string
+	KeyFile   string
+	ActiveKID string
+	// Production recusa chaves ausentes, fracas ou curtas em vez de só avisar
+	Production bool
+}
+
+// SessionKeyRing assina as sessões com a chave ativa e valida com qualquer chave configurada,
+// escolhida pelo kid do cabeçalho do token
+type SessionKeyRing struct {
+	activeKID string
+	keys      map[string][]byte
+}
+
+// sessionKeys são as chaves usadas pelo login e por SessionAuthMiddleware
+var sessionKeys *SessionKeyRing
+
+// ConfigureSessionKeys define as chaves de assinatura das sessões; chamada uma vez na inicialização
+func ConfigureSessionKeys(ring *SessionKeyRing) {
+	sessionKeys = ring
+}
+
+// LoadSessionKeys carrega as chaves da configuração. Fora de produção, sem nenhuma chave, gera uma
+// chave temporária: as sessões deixam de valer quando o servidor reinicia.
+func LoadSessionKeys(config SessionKeyConfig) (*SessionKeyRing, error) {
+	ring := &SessionKeyRing{keys: make(map[string][]byte)}
+	var order []string
+	add := func(entry, source string) error {
+		kid, secret, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		kid = strings.TrimSpace(kid)
+		if !ok || kid == "" || secret == "" {
+			return fmt.Errorf("chave de sessão inválida em %s: use o formato kid:segredo", source)
+		}
+		if _, exists := ring.keys[kid]; exists {
+			return fmt.Errorf("chave de sessão '%s' repetida", kid)
+		}
+		ring.keys[kid] = []byte(secret)
+		order = append(order, kid)
+		return nil
+	}
+
+	for _, entry := range strings.Split(config.Keys, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		if err := add(entry, "JWT_KEYS"); err != nil {
+			return nil, err
+		}
+	}
+	if config.KeyFile != "" {
+		file, err := os.Open(config.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao abrir arquivo de chaves de sessão: %w", err)
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if err := add(line, config.KeyFile); err != nil {
+				return nil, err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("erro ao ler arquivo de chaves de sessão: %w", err)
+		}
+	}
+
+	if len(order) == 0 {
+		if config.Production {
+			return nil, fmt.Errorf("nenhuma chave de sessão configurada: defina JWT_KEYS ou JWT_KEY_FILE")
+		}
+		secret := make([]byte, sessionKeyMinLength)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("erro ao gerar chave de sessão temporária: %w", err)
+		}
+		log.Println("⚠️  Nenhuma chave de sessão configurada (JWT_KEYS ou JWT_KEY_FILE); usando uma chave temporária")
+		ring.keys["temporaria"] = []byte(hex.EncodeToString(secret))
+		order = append(order, "temporaria")
+	}
+
+	for _, kid := range order {
+		if problem := weakSessionKey(ring.keys[kid]); problem != "" {
+			if config.Production {
+				return nil, fmt.Errorf("chave de sessão '%s' %s", kid, problem)
+			}
+			log.Printf("⚠️  Chave de sessão '%s' %s; ela seria recusada em produção", kid, problem)
+		}
+	}
+
+	ring.activeKID = config.ActiveKID
+	if ring.activeKID == "" {
+		ring.activeKID = order[0]
+	}
+	if _, ok := ring.keys[ring.activeKID]; !ok {
+		return nil, fmt.Errorf("JWT_ACTIVE_KID '%s' não está entre as chaves de sessão configuradas", ring.activeKID)
+	}
+	return ring, nil
+}
+
+// weakSessionKey descreve o problema de uma chave fraca, ou retorna vazio
+func weakSessionKey(secret []byte) string {
+	for _, weak := range weakSessionKeys {
+		if strings.EqualFold(string(secret), weak) {
+			return "é uma chave conhecida"
+		}
+	}
+	if len(secret) < sessionKeyMinLength {
+		return fmt.Sprintf("tem menos de %d caracteres", sessionKeyMinLength)
+	}
+	return ""
+}
+
+// Sign assina as claims com a chave ativa, informando o kid no cabeçalho do token
+func (r *SessionKeyRing) Sign(claims jwt.MapClaims) (string, error) {
+	if r == nil {
+		return "", fmt.Errorf("chaves de sessão não configuradas")
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = r.activeKID
+	return token.SignedString(r.keys[r.activeKID])
+}
+
+// Parse valida o token com a chave indicada pelo kid. Tokens sem kid ou com uma chave que não
+// está mais configurada são recusados.
+func (r *SessionKeyRing) Parse(tokenString string, claims jwt.MapClaims) (*jwt.Token, error) {
+	if r == nil {
+		return nil, fmt.Errorf("chaves de sessão não configuradas")
+	}
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := r.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("chave de sessão desconhecida: '%s'", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import "testing"
+
+func TestLoadSessionKeysRejectsWeakKeysInProduction(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		wantErr bool
+	}{
+		{"exemplo do env.example", "2024-01:troque-por-um-segredo-aleatorio-de-pelo-menos-32-caracteres", true},
+		{"chave conhecida", "2024-01:supersecretkey123", true},
+		{"chave curta", "2024-01:abc123", true},
+		{"sem chaves", "", true},
+		{"chave forte", "2024-01:9f2c7a1e5b8d4f60a3c9e7b2d1f4a8c6", false},
+	}
+	for _, tt := range tests {
+		_, err := LoadSessionKeys(SessionKeyConfig{Keys: tt.keys, Production: true})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: erro = %v, esperado erro = %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
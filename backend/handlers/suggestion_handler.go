@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+)
+
+type SuggestionHandler struct {
+	suggestionService *services.SuggestionService
+}
+
+// NewSuggestionHandler cria uma nova instância do handler de sugestão de categorias
+func NewSuggestionHandler(suggestionService *services.SuggestionService) *SuggestionHandler {
+	return &SuggestionHandler{
+		suggestionService: suggestionService,
+	}
+}
+
+// SuggestCategories sugere até 3 categorias para uma descrição, valor e conta
+func (h *SuggestionHandler) SuggestCategories(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	// amount é opcional e vem em centavos, como nas transações
+	amount := 0
+	if value := c.Query("amount"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "amount deve ser um número inteiro em centavos",
+			})
+			return
+		}
+		amount = parsed
+	}
+
+	suggestions, err := h.suggestionService.Suggest(userID, c.Query("description"), amount, c.Query("account_id"), c.Query("type"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suggestions": suggestions,
+	})
+}
+
+// RetrainModel reconstrói o modelo de sugestão a partir de todo o histórico do usuário
+func (h *SuggestionHandler) RetrainModel(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	trained, err := h.suggestionService.Retrain(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "Modelo de sugestão retreinado com sucesso",
+		"transactions_trained": trained,
+	})
+}
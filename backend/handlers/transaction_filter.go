@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+// parseTransactionFilter lê os filtros de transações da query string:
+// from e to (AAAA-MM-DD, pelo vencimento), account_id, category_id, type, is_paid e payee_id
+func parseTransactionFilter(c *gin.Context) (structs.TransactionFilter, error) {
+	var filter structs.TransactionFilter
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, fmt.Errorf("from deve estar no formato AAAA-MM-DD")
+		}
+		filter.From = &parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, fmt.Errorf("to deve estar no formato AAAA-MM-DD")
+		}
+		filter.To = &parsed
+	}
+	if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+		return filter, fmt.Errorf("from deve ser anterior a to")
+	}
+
+	for name, target := range map[string]*string{
+		"account_id":  &filter.AccountID,
+		"category_id": &filter.CategoryID,
+		"payee_id":    &filter.PayeeID,
+	} {
+		value := c.Query(name)
+		if value != "" && !utils.IsValidUUID(value) {
+			return filter, fmt.Errorf("%s deve ser um UUID válido", name)
+		}
+		*target = value
+	}
+
+	filter.Type = c.Query("type")
+	if filter.Type != "" && filter.Type != "income" && filter.Type != "expense" {
+		return filter, fmt.Errorf("type deve ser 'income' ou 'expense'")
+	}
+
+	if value := c.Query("is_paid"); value != "" {
+		paid, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("is_paid deve ser true ou false")
+		}
+		filter.IsPaid = &paid
+	}
+
+	return filter, nil
+}
@@ -10,19 +10,24 @@ import (
 	"github.com/tonnarruda/my-personal-finance/database"
 	"github.com/tonnarruda/my-personal-finance/services"
 	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
 )
 
 type TransactionHandler struct {
-	DB              *database.Database
-	ExchangeService services.ExchangeServiceInterface
+	DB                *database.Database
+	ExchangeService   services.ExchangeServiceInterface
+	RuleService       *services.RuleService
+	SuggestionService *services.SuggestionService
+	PayeeService      *services.PayeeService
+	InsightService    *services.InsightService
 }
 
 // CreateTransaction cria uma nova transação
 func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
-	// Obter user_id do query parameter
-	userID := c.Query("user_id")
+	// Usuário autenticado pela sessão
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id é obrigatório"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
 		return
 	}
 
@@ -201,23 +206,62 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 		// Retornar as duas transações criadas
 		c.JSON(http.StatusCreated, response)
 	} else {
+		if err := h.checkTransactionReferences(userID, req.AccountID, req.CategoryID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := normalizeTransactionTaxFields(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Com apply_rules=true, as regras de categorização preenchem os campos vazios
+		if c.Query("apply_rules") == "true" && h.RuleService != nil {
+			ruleSet, err := h.RuleService.LoadRuleSet(userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			ruleSet.ApplyTo(&req)
+		}
+
+		// payee_id é validado e um nome de beneficiário é ligado ao cadastro (criado se não existir)
+		if h.PayeeService != nil {
+			payees, err := h.PayeeService.LoadPayeeSet(userID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if err := payees.AssignTo(&req, ""); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
 		// Lógica normal para transações que não são transferências
 		if err := h.DB.CreateTransaction(req); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.learnCategory(nil, &req)
+		h.evaluateInsights(userID, req)
 		c.JSON(http.StatusCreated, req)
 	}
 }
 
-// GetAllTransactions lista todas as transações do usuário
+// GetAllTransactions lista as transações do usuário, com os filtros opcionais de parseTransactionFilter
 func (h *TransactionHandler) GetAllTransactions(c *gin.Context) {
-	userID := c.Query("user_id")
+	userID := currentUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
 		return
 	}
-	txs, err := h.DB.GetAllTransactionsByUser(userID)
+	filter, err := parseTransactionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	txs, err := h.DB.GetFilteredTransactions(userID, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -240,9 +284,13 @@ func (h *TransactionHandler) GetAllTransactions(c *gin.Context) {
 // GetTransactionByID busca uma transação pelo ID
 func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.Query("user_id")
-	if id == "" || userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "id and user_id are required"})
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
 		return
 	}
 	tx, err := h.DB.GetTransactionByID(id, userID)
@@ -260,9 +308,13 @@ func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
 // UpdateTransaction atualiza uma transação existente
 func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.Query("user_id")
-	if id == "" || userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "id and user_id are required"})
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
 		return
 	}
 
@@ -273,10 +325,45 @@ func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
 		return
 	}
 
-	// Remover campos que não devem ser atualizados diretamente
-	delete(updates, "id")
-	delete(updates, "user_id")
-	delete(updates, "created_at")
+	// Só as colunas editáveis são gravadas (ver UpdateTransactionPartial); id, user_id e a descrição
+	// original do banco nunca mudam. A nova conta e a nova categoria precisam ser do usuário.
+	accountID, err := referenceUpdate(updates, "account_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	categoryID, err := referenceUpdate(updates, "category_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.checkTransactionReferences(userID, accountID, categoryID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := resolveTaxUpdates(updates); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if h.PayeeService != nil {
+		if err := h.PayeeService.ResolvePayeeUpdate(userID, updates); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Guardar a versão anterior para atualizar o modelo de sugestão de categorias
+	previousTx, err := h.DB.GetTransactionByID(id, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if previousTx == nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
 
 	if err := h.DB.UpdateTransactionPartial(id, userID, updates); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -289,6 +376,7 @@ func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated transaction"})
 		return
 	}
+	h.learnCategory(previousTx, updatedTx)
 
 	c.JSON(http.StatusOK, updatedTx)
 }
@@ -296,9 +384,13 @@ func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
 // DeleteTransaction remove uma transação
 func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.Query("user_id")
-	if id == "" || userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "id and user_id are required"})
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Usuário não autenticado"})
+		return
+	}
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
 		return
 	}
 
@@ -325,7 +417,132 @@ func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.learnCategory(tx, nil)
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// checkTransactionReferences confere se a conta e a categoria informadas são do usuário; categorias
+// de sistema (sem dono) são aceitas
+func (h *TransactionHandler) checkTransactionReferences(userID string, accountID string, categoryID string) error {
+	if accountID != "" {
+		account, err := h.DB.GetAccountByID(accountID, userID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar conta: %w", err)
+		}
+		if account == nil {
+			return fmt.Errorf("conta não encontrada")
+		}
+	}
+	if categoryID != "" {
+		category, err := h.DB.GetCategoryByID(categoryID, userID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar categoria: %w", err)
+		}
+		if category == nil {
+			return fmt.Errorf("categoria não encontrada")
+		}
+	}
+	return nil
+}
+
+// referenceUpdate lê o ID de conta ou categoria de uma atualização parcial. Quando o campo vem,
+// precisa ser um ID não vazio: outro tipo deixaria a verificação de dono para trás.
+func referenceUpdate(updates map[string]interface{}, field string) (string, error) {
+	value, present := updates[field]
+	if !present {
+		return "", nil
+	}
+	id, ok := value.(string)
+	if !ok || id == "" {
+		return "", fmt.Errorf("%s deve ser um ID válido", field)
+	}
+	return id, nil
+}
+
+// learnCategory mantém o modelo de sugestão de categorias em dia com a transação criada,
+// alterada ou excluída. Falhas não interrompem a requisição: o modelo pode ser retreinado.
+func (h *TransactionHandler) learnCategory(before *structs.Transaction, after *structs.Transaction) {
+	if h.SuggestionService == nil {
+		return
+	}
+
+	var err error
+	switch {
+	case before != nil && after != nil:
+		err = h.SuggestionService.Relearn(*before, *after)
+	case after != nil:
+		err = h.SuggestionService.Learn(*after)
+	case before != nil:
+		err = h.SuggestionService.Unlearn(*before)
+	}
+	if err != nil {
+		fmt.Printf("Erro ao atualizar modelo de sugestão de categorias: %v\n", err)
+	}
+}
+
+// evaluateInsights gera os alertas de gastos incomuns da transação criada. Falhas não interrompem a
+// requisição: os alertas podem ser gerados de novo em /api/insights/evaluate.
+func (h *TransactionHandler) evaluateInsights(userID string, tx structs.Transaction) {
+	if h.InsightService == nil {
+		return
+	}
+	if _, err := h.InsightService.EvaluateTransactions(userID, []structs.Transaction{tx}); err != nil {
+		fmt.Printf("Erro ao avaliar gastos incomuns: %v\n", err)
+	}
+}
+
+// normalizeTransactionTaxFields valida a seção do IRPF e deixa só os dígitos do CPF/CNPJ do beneficiário
+func normalizeTransactionTaxFields(tx *structs.Transaction) error {
+	if tx.TaxSection != nil && *tx.TaxSection == "" {
+		tx.TaxSection = nil
+	}
+	if tx.TaxSection != nil {
+		if err := structs.ValidateTaxSection(*tx.TaxSection, true); err != nil {
+			return err
+		}
+	}
+	if tx.PayeeTaxID != "" {
+		taxID, err := utils.NormalizeTaxID(tx.PayeeTaxID)
+		if err != nil {
+			return err
+		}
+		tx.PayeeTaxID = taxID
+	}
+	return nil
+}
+
+// resolveTaxUpdates faz o mesmo que normalizeTransactionTaxFields em uma atualização parcial;
+// valores vazios limpam os campos
+func resolveTaxUpdates(updates map[string]interface{}) error {
+	if value, ok := updates["tax_section"]; ok {
+		section, isString := value.(string)
+		switch {
+		case value == nil || (isString && section == ""):
+			updates["tax_section"] = nil
+		case !isString:
+			return fmt.Errorf("tax_section deve ser um texto")
+		default:
+			if err := structs.ValidateTaxSection(section, true); err != nil {
+				return err
+			}
+		}
+	}
+	if value, ok := updates["payee_tax_id"]; ok {
+		taxID, isString := value.(string)
+		switch {
+		case value == nil || (isString && taxID == ""):
+			updates["payee_tax_id"] = nil
+		case !isString:
+			return fmt.Errorf("payee_tax_id deve ser um texto")
+		default:
+			normalized, err := utils.NormalizeTaxID(taxID)
+			if err != nil {
+				return err
+			}
+			updates["payee_tax_id"] = normalized
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tonnarruda/my-personal-finance/services"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// transferScanDefaultDays é o período analisado quando from não é informado
+const transferScanDefaultDays = 90
+
+type TransferHandler struct {
+	transferService *services.TransferService
+}
+
+// NewTransferHandler cria uma nova instância do handler de detecção de transferências
+func NewTransferHandler(transferService *services.TransferService) *TransferHandler {
+	return &TransferHandler{
+		transferService: transferService,
+	}
+}
+
+// GetCandidates procura pares de transações já gravadas que parecem ser a mesma transferência
+func (h *TransferHandler) GetCandidates(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	// Período padrão: últimos 90 dias
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "to deve estar no formato AAAA-MM-DD",
+			})
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -transferScanDefaultDays)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "from deve estar no formato AAAA-MM-DD",
+			})
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from deve ser anterior a to",
+		})
+		return
+	}
+
+	candidates, err := h.transferService.ScanCandidates(userID, c.Query("account_id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"candidates": candidates,
+	})
+}
+
+// LinkTransfers vincula pares de transações como transferências entre contas do usuário
+func (h *TransferHandler) LinkTransfers(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuário não autenticado",
+		})
+		return
+	}
+
+	var req structs.TransferLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	results, err := h.transferService.LinkTransfers(userID, req.Pairs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	linked := 0
+	for _, result := range results {
+		if result.Error == "" {
+			linked++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"linked":  linked,
+		"results": results,
+	})
+}
@@ -4,8 +4,10 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/joho/godotenv"
+	"github.com/tonnarruda/my-personal-finance/commands"
 	"github.com/tonnarruda/my-personal-finance/database"
 	"github.com/tonnarruda/my-personal-finance/handlers"
 	"github.com/tonnarruda/my-personal-finance/routes"
@@ -77,6 +79,28 @@ func main() {
 		log.Fatalf("Erro ao rodar migrations: %v", err)
 	}
 
+	// Comandos de linha de comando (backup e restore) rodam e encerram sem subir o servidor
+	if len(os.Args) > 1 {
+		if err := commands.Run(db, os.Args[1:]); err != nil {
+			db.Close()
+			log.Fatalf("Erro: %v", err)
+		}
+		return
+	}
+
+	// Chaves de assinatura das sessões. Em produção (APP_ENV=production ou GIN_MODE=release), o
+	// servidor não sobe sem uma chave forte.
+	sessionKeys, err := handlers.LoadSessionKeys(handlers.SessionKeyConfig{
+		Keys:       os.Getenv("JWT_KEYS"),
+		KeyFile:    os.Getenv("JWT_KEY_FILE"),
+		ActiveKID:  os.Getenv("JWT_ACTIVE_KID"),
+		Production: getEnv("APP_ENV", "") == "production" || getEnv("GIN_MODE", "") == "release",
+	})
+	if err != nil {
+		log.Fatalf("Erro nas chaves de sessão: %v", err)
+	}
+	handlers.ConfigureSessionKeys(sessionKeys)
+
 	// Inicializar serviços
 	categoryService := services.NewCategoryService(db)
 	transactionCreator := services.NewDatabaseTransactionCreator(db)
@@ -87,17 +111,62 @@ func main() {
 	exchangeService := services.NewMockExchangeService() // Usar mock para desenvolvimento
 	// Para produção, usar: services.NewExchangeService(os.Getenv("EXCHANGE_API_KEY"))
 
+	suggestionService := services.NewSuggestionService(db)
+	payeeService := services.NewPayeeService(db)
+	ruleService := services.NewRuleService(db, suggestionService, payeeService)
+	transferService := services.NewTransferService(db, exchangeService, suggestionService)
+	insightService := services.NewInsightService(db)
+	importService := services.NewImportService(db, exchangeService, ruleService, suggestionService, transferService, payeeService, insightService)
+	csvService := services.NewCSVService(db)
+	exportService := services.NewExportService(db)
+	backupService := services.NewBackupService(db, suggestionService)
+	exchangeHistoryService := services.NewExchangeHistoryService(db, exchangeService)
+	reportService := services.NewReportService(db, exchangeHistoryService)
+	calendarService := services.NewCalendarService(db)
+	importJobService := services.NewImportJobService(db, importService)
+	// Workers que processam as importações em segundo plano (IMPORT_JOB_WORKERS, padrão 2)
+	importJobWorkers, err := strconv.Atoi(getEnv("IMPORT_JOB_WORKERS", "2"))
+	if err != nil || importJobWorkers < 1 {
+		importJobWorkers = 2
+	}
+	importJobService.Start(importJobWorkers)
+
+	// Endereço público da API nas URLs do feed de calendário. Sem ele, o endereço vem da
+	// requisição, e os cabeçalhos X-Forwarded-* só valem vindos de TRUSTED_PROXIES.
+	publicBaseURL, err := handlers.ParsePublicBaseURL(getEnv("PUBLIC_BASE_URL", ""))
+	if err != nil {
+		log.Fatalf("Erro na configuração: %v", err)
+	}
+	trustedProxies, err := handlers.ParseTrustedProxies(getEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Fatalf("Erro na configuração: %v", err)
+	}
+
 	// Inicializar handlers
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
 	accountHandler := handlers.NewAccountHandler(accountService)
 	authHandler := handlers.NewAuthHandler(userService)
-	transactionHandler := &handlers.TransactionHandler{DB: db, ExchangeService: exchangeService}
-	exchangeHandler := handlers.NewExchangeHandler(exchangeService)
-	ofxHandler := handlers.NewOFXHandler(db)
+	transactionHandler := &handlers.TransactionHandler{DB: db, ExchangeService: exchangeService, RuleService: ruleService, SuggestionService: suggestionService, PayeeService: payeeService, InsightService: insightService}
+	exchangeHandler := handlers.NewExchangeHandler(exchangeService, exchangeHistoryService)
+	ofxHandler := handlers.NewOFXHandler(db, importService, importJobService)
+	importHandler := handlers.NewImportHandler(importService)
+	importJobHandler := handlers.NewImportJobHandler(importJobService)
+	csvHandler := handlers.NewCSVHandler(csvService, importService, importJobService)
+	qifHandler := handlers.NewQIFHandler(importService, importJobService)
+	camtHandler := handlers.NewCAMTHandler(importService, importJobService)
+	ruleHandler := handlers.NewRuleHandler(ruleService)
+	suggestionHandler := handlers.NewSuggestionHandler(suggestionService)
+	transferHandler := handlers.NewTransferHandler(transferService)
+	payeeHandler := handlers.NewPayeeHandler(payeeService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	backupHandler := handlers.NewBackupHandler(backupService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	calendarHandler := handlers.NewCalendarHandler(calendarService, publicBaseURL, trustedProxies)
+	insightHandler := handlers.NewInsightHandler(insightService)
 	keepAliveHandler := handlers.NewKeepAliveHandler()
 
 	// Configurar rotas
-	router := routes.SetupRoutes(categoryHandler, accountHandler, authHandler, transactionHandler, exchangeHandler, ofxHandler, keepAliveHandler)
+	router := routes.SetupRoutes(categoryHandler, accountHandler, authHandler, transactionHandler, exchangeHandler, ofxHandler, importHandler, importJobHandler, csvHandler, qifHandler, camtHandler, ruleHandler, suggestionHandler, transferHandler, payeeHandler, exportHandler, backupHandler, reportHandler, calendarHandler, insightHandler, keepAliveHandler)
 
 	// Configurar porta do servidor
 	port := getEnv("PORT", "8080")
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_transactions_external_id;
+DROP INDEX IF EXISTS idx_transactions_import_batch_id;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS fk_transaction_import_batch;
+ALTER TABLE transactions DROP COLUMN IF EXISTS external_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS import_batch_id;
+
+DROP TABLE IF EXISTS import_batches;
@@ -0,0 +1,34 @@
+-- Criação da Tabela de Lotes de Importação
+CREATE TABLE IF NOT EXISTS import_batches (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    account_id VARCHAR(36) NOT NULL,
+    format VARCHAR(10) NOT NULL DEFAULT 'ofx',
+    file_name VARCHAR(255) NOT NULL,
+    file_hash VARCHAR(64) NOT NULL,
+    date_start TIMESTAMP NULL,
+    date_end TIMESTAMP NULL,
+    transactions_total INT NOT NULL DEFAULT 0,
+    transactions_imported INT NOT NULL DEFAULT 0,
+    transactions_skipped INT NOT NULL DEFAULT 0,
+    errors TEXT[] NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'processing' CHECK (status IN ('processing', 'completed', 'reverted')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    reverted_at TIMESTAMP NULL,
+    CONSTRAINT fk_import_batch_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_import_batch_account FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_batches_user_id ON import_batches(user_id);
+CREATE INDEX IF NOT EXISTS idx_import_batches_file_hash ON import_batches(user_id, file_hash);
+
+-- Vincula cada transação importada ao seu lote e guarda o identificador de origem (FITID)
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS import_batch_id VARCHAR(36);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+
+ALTER TABLE transactions ADD CONSTRAINT fk_transaction_import_batch
+    FOREIGN KEY (import_batch_id) REFERENCES import_batches(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_import_batch_id ON transactions(import_batch_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_external_id ON transactions(account_id, external_id);
@@ -0,0 +1,15 @@
+-- Pré-visualizações de importação mantidas no servidor até a confirmação do usuário
+CREATE TABLE IF NOT EXISTS import_previews (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    file_hash VARCHAR(64) NOT NULL,
+    lines JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    CONSTRAINT fk_import_preview_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_previews_user_id ON import_previews(user_id);
+CREATE INDEX IF NOT EXISTS idx_import_previews_expires_at ON import_previews(expires_at);
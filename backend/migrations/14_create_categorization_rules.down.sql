@@ -0,0 +1,4 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS payee;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tags;
+
+DROP TABLE IF EXISTS categorization_rules;
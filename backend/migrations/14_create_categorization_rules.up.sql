@@ -0,0 +1,33 @@
+-- Regras de categorização automática definidas pelo usuário
+CREATE TABLE IF NOT EXISTS categorization_rules (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    priority INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    -- Condições (todas as informadas precisam ser atendidas)
+    description_contains VARCHAR(255),
+    description_regex VARCHAR(500),
+    amount_min INTEGER,
+    amount_max INTEGER,
+    account_id VARCHAR(36),
+    transaction_type VARCHAR(10) CHECK (transaction_type IN ('income', 'expense')),
+    -- Ações
+    category_id VARCHAR(36),
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    payee VARCHAR(255),
+    observation TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP NULL,
+    CONSTRAINT fk_rule_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_rule_account FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE,
+    CONSTRAINT fk_rule_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_categorization_rules_user_id ON categorization_rules(user_id);
+CREATE INDEX IF NOT EXISTS idx_categorization_rules_priority ON categorization_rules(user_id, priority);
+
+-- Campos que as regras podem preencher nas transações
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS payee VARCHAR(255);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_category_model_tokens_token;
+DROP TABLE IF EXISTS category_model_tokens;
+DROP TABLE IF EXISTS category_model_totals;
@@ -0,0 +1,23 @@
+-- Modelo naive Bayes de sugestão de categorias, treinado por usuário com o histórico de transações
+CREATE TABLE IF NOT EXISTS category_model_totals (
+    user_id VARCHAR(36) NOT NULL,
+    category_id VARCHAR(36) NOT NULL,
+    documents INTEGER NOT NULL DEFAULT 0,
+    tokens INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, category_id),
+    CONSTRAINT fk_category_model_totals_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_category_model_totals_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS category_model_tokens (
+    user_id VARCHAR(36) NOT NULL,
+    category_id VARCHAR(36) NOT NULL,
+    token VARCHAR(100) NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, category_id, token),
+    CONSTRAINT fk_category_model_tokens_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_category_model_tokens_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_model_tokens_token ON category_model_tokens(user_id, token);
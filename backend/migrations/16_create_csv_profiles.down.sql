@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_csv_profiles_user_id;
+DROP TABLE IF EXISTS csv_profiles;
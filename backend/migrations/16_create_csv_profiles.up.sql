@@ -0,0 +1,27 @@
+-- Perfis de mapeamento de colunas para importação de CSV
+CREATE TABLE IF NOT EXISTS csv_profiles (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    delimiter VARCHAR(5),
+    encoding VARCHAR(20),
+    decimal_separator VARCHAR(1),
+    date_format VARCHAR(20),
+    -- Colunas são referenciadas pelo nome do cabeçalho ou pela posição (a partir de 1)
+    date_column VARCHAR(100) NOT NULL,
+    description_column VARCHAR(100) NOT NULL,
+    memo_column VARCHAR(100),
+    amount_column VARCHAR(100),
+    debit_column VARCHAR(100),
+    credit_column VARCHAR(100),
+    category_column VARCHAR(100),
+    external_id_column VARCHAR(100),
+    invert_amount BOOLEAN NOT NULL DEFAULT FALSE,
+    has_header BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP NULL,
+    CONSTRAINT fk_csv_profile_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_csv_profiles_user_id ON csv_profiles(user_id);
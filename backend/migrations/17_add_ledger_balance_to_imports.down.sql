@@ -0,0 +1,10 @@
+ALTER TABLE import_previews
+    DROP COLUMN IF EXISTS ledger_balance_date,
+    DROP COLUMN IF EXISTS ledger_balance;
+
+ALTER TABLE import_batches
+    DROP COLUMN IF EXISTS balance_difference,
+    DROP COLUMN IF EXISTS computed_balance,
+    DROP COLUMN IF EXISTS opening_balance,
+    DROP COLUMN IF EXISTS ledger_balance_date,
+    DROP COLUMN IF EXISTS ledger_balance;
@@ -0,0 +1,12 @@
+-- Saldo informado pelo banco no extrato (LEDGERBAL do OFX, saldo final do camt) e resultado da conferência
+ALTER TABLE import_batches
+    ADD COLUMN IF NOT EXISTS ledger_balance INTEGER NULL,
+    ADD COLUMN IF NOT EXISTS ledger_balance_date TIMESTAMP NULL,
+    -- Saldo no início do período do arquivo, deduzido do saldo final menos as linhas do extrato
+    ADD COLUMN IF NOT EXISTS opening_balance INTEGER NULL,
+    ADD COLUMN IF NOT EXISTS computed_balance INTEGER NULL,
+    ADD COLUMN IF NOT EXISTS balance_difference INTEGER NULL;
+
+ALTER TABLE import_previews
+    ADD COLUMN IF NOT EXISTS ledger_balance INTEGER NULL,
+    ADD COLUMN IF NOT EXISTS ledger_balance_date TIMESTAMP NULL;
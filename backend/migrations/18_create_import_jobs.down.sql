@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_import_jobs_status;
+DROP INDEX IF EXISTS idx_import_jobs_user_id;
+
+DROP TABLE IF EXISTS import_jobs;
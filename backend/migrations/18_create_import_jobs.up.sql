@@ -0,0 +1,29 @@
+-- Importações processadas em segundo plano, com progresso persistido para sobreviver a reinícios
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    account_id VARCHAR(36) NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    format VARCHAR(10) NOT NULL,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    total_lines INTEGER NOT NULL DEFAULT 0,
+    processed_lines INTEGER NOT NULL DEFAULT 0,
+    transactions_imported INTEGER NOT NULL DEFAULT 0,
+    transactions_skipped INTEGER NOT NULL DEFAULT 0,
+    import_batch_id VARCHAR(36) NULL,
+    outcomes JSONB NOT NULL DEFAULT '[]',
+    result JSONB NULL,
+    error TEXT NOT NULL DEFAULT '',
+    cancel_requested BOOLEAN NOT NULL DEFAULT FALSE,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+    CONSTRAINT fk_import_job_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_import_job_batch FOREIGN KEY (import_batch_id) REFERENCES import_batches(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_user_id ON import_jobs(user_id);
+CREATE INDEX IF NOT EXISTS idx_import_jobs_status ON import_jobs(status);
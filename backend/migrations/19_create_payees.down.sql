@@ -0,0 +1,7 @@
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS fk_transaction_payee;
+DROP INDEX IF EXISTS idx_transactions_payee_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS original_description;
+ALTER TABLE transactions DROP COLUMN IF EXISTS payee_id;
+
+DROP TABLE IF EXISTS payee_rules;
+DROP TABLE IF EXISTS payees;
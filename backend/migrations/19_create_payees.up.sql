@@ -0,0 +1,46 @@
+-- Beneficiários (estabelecimentos, pessoas) com nome canônico por usuário
+CREATE TABLE IF NOT EXISTS payees (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    -- Nome sem acentos e em minúsculas, usado para reconhecer o beneficiário
+    normalized_name VARCHAR(255) NOT NULL,
+    -- Outros nomes normalizados que levam a este beneficiário (nomes antigos e beneficiários mesclados)
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP NULL,
+    CONSTRAINT fk_payee_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payees_user_id ON payees(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payees_user_normalized_name ON payees(user_id, normalized_name) WHERE deleted_at IS NULL;
+
+-- Regras que levam descrições brutas do banco a um beneficiário
+CREATE TABLE IF NOT EXISTS payee_rules (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    payee_id VARCHAR(36) NOT NULL,
+    pattern VARCHAR(500) NOT NULL,
+    match_type VARCHAR(10) NOT NULL DEFAULT 'contains' CHECK (match_type IN ('exact', 'contains', 'regex')),
+    priority INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP NULL,
+    CONSTRAINT fk_payee_rule_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_payee_rule_payee FOREIGN KEY (payee_id) REFERENCES payees(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payee_rules_user_id ON payee_rules(user_id, priority);
+
+-- Beneficiário da transação e a descrição original vinda do banco, mantida para rastreabilidade
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS payee_id VARCHAR(36);
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_description TEXT;
+
+ALTER TABLE transactions ADD CONSTRAINT fk_transaction_payee
+    FOREIGN KEY (payee_id) REFERENCES payees(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_payee_id ON transactions(payee_id);
+
+-- Transações já importadas guardam a descrição que veio do arquivo
+UPDATE transactions SET original_description = description WHERE import_batch_id IS NOT NULL AND original_description IS NULL;
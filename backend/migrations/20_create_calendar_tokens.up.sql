@@ -0,0 +1,11 @@
+-- Token secreto do feed de calendário (iCal) de cada usuário. Guardamos apenas o hash SHA-256:
+-- o token aparece uma única vez, ao ser gerado, dentro da URL do feed.
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id VARCHAR(36) PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_accessed_at TIMESTAMP NULL,
+    CONSTRAINT fk_calendar_token_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendar_tokens_token_hash ON calendar_tokens(token_hash);
@@ -0,0 +1,17 @@
+-- Histórico de taxas de câmbio, usado para converter saldos na data de cada período.
+-- user_id nulo: taxa obtida da API, compartilhada; preenchido: taxa informada pelo usuário.
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NULL,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    source VARCHAR(10) NOT NULL DEFAULT 'api' CHECK (source IN ('api', 'manual')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_exchange_rate_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_rates_global_pair_date ON exchange_rates(from_currency, to_currency, rate_date) WHERE user_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_rates_user_pair_date ON exchange_rates(user_id, from_currency, to_currency, rate_date) WHERE user_id IS NOT NULL;
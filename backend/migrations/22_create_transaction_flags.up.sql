@@ -0,0 +1,20 @@
+-- Alertas de gasto incomum gerados para as transações (valor fora do habitual, possível cobrança duplicada)
+CREATE TABLE IF NOT EXISTS transaction_flags (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    transaction_id VARCHAR(36) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('amount_outlier', 'possible_duplicate')),
+    message TEXT NOT NULL,
+    -- Transação parecida, no caso de possível duplicata
+    related_transaction_id VARCHAR(36) NULL,
+    -- Valor habitual (mediana do histórico), no caso de valor fora do habitual
+    expected_amount INTEGER NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    dismissed_at TIMESTAMP NULL,
+    CONSTRAINT fk_transaction_flag_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_transaction_flag_transaction FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_transaction_flag_related FOREIGN KEY (related_transaction_id) REFERENCES transactions(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaction_flags_transaction_kind ON transaction_flags(transaction_id, kind);
+CREATE INDEX IF NOT EXISTS idx_transaction_flags_user_id ON transaction_flags(user_id, created_at);
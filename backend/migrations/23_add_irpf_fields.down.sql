@@ -0,0 +1,6 @@
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_tax_section_check;
+ALTER TABLE transactions DROP COLUMN IF EXISTS payee_tax_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tax_section;
+
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_tax_section_check;
+ALTER TABLE categories DROP COLUMN IF EXISTS tax_section;
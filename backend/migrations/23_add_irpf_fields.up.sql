@@ -0,0 +1,13 @@
+-- Seção da declaração do IRPF em que entram as transações da categoria (herdada pelas subcategorias)
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_section VARCHAR(30) NULL;
+ALTER TABLE categories ADD CONSTRAINT categories_tax_section_check CHECK (tax_section IN (
+    'rendimentos_pj', 'rendimentos_pf', 'rendimentos_isentos', 'tributacao_exclusiva',
+    'saude', 'educacao', 'pensao_alimenticia', 'previdencia_privada'));
+
+-- Seção própria da transação, que substitui a da categoria ('none' tira a transação da declaração),
+-- e o CPF/CNPJ do beneficiário (só os dígitos)
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS tax_section VARCHAR(30) NULL;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS payee_tax_id VARCHAR(14) NULL;
+ALTER TABLE transactions ADD CONSTRAINT transactions_tax_section_check CHECK (tax_section IN (
+    'rendimentos_pj', 'rendimentos_pf', 'rendimentos_isentos', 'tributacao_exclusiva',
+    'saude', 'educacao', 'pensao_alimenticia', 'previdencia_privada', 'none'));
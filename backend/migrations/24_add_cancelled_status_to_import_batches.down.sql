@@ -0,0 +1,3 @@
+UPDATE import_batches SET status = 'completed' WHERE status = 'cancelled';
+ALTER TABLE import_batches DROP CONSTRAINT IF EXISTS import_batches_status_check;
+ALTER TABLE import_batches ADD CONSTRAINT import_batches_status_check CHECK (status IN ('processing', 'completed', 'reverted'));
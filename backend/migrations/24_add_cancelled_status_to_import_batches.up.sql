@@ -0,0 +1,3 @@
+-- Importações canceladas no meio guardam as transações já criadas, mas não contam como arquivo importado
+ALTER TABLE import_batches DROP CONSTRAINT IF EXISTS import_batches_status_check;
+ALTER TABLE import_batches ADD CONSTRAINT import_batches_status_check CHECK (status IN ('processing', 'completed', 'cancelled', 'reverted'));
@@ -10,7 +10,7 @@ import (
 )
 
 // SetupRoutes configura todas as rotas da aplicação
-func SetupRoutes(categoryHandler *handlers.CategoryHandler, accountHandler *handlers.AccountHandler, authHandler *handlers.AuthHandler, transactionHandler *handlers.TransactionHandler, exchangeHandler *handlers.ExchangeHandler, ofxHandler *handlers.OFXHandler, keepAliveHandler *handlers.KeepAliveHandler) *gin.Engine {
+func SetupRoutes(categoryHandler *handlers.CategoryHandler, accountHandler *handlers.AccountHandler, authHandler *handlers.AuthHandler, transactionHandler *handlers.TransactionHandler, exchangeHandler *handlers.ExchangeHandler, ofxHandler *handlers.OFXHandler, importHandler *handlers.ImportHandler, importJobHandler *handlers.ImportJobHandler, csvHandler *handlers.CSVHandler, qifHandler *handlers.QIFHandler, camtHandler *handlers.CAMTHandler, ruleHandler *handlers.RuleHandler, suggestionHandler *handlers.SuggestionHandler, transferHandler *handlers.TransferHandler, payeeHandler *handlers.PayeeHandler, exportHandler *handlers.ExportHandler, backupHandler *handlers.BackupHandler, reportHandler *handlers.ReportHandler, calendarHandler *handlers.CalendarHandler, insightHandler *handlers.InsightHandler, keepAliveHandler *handlers.KeepAliveHandler) *gin.Engine {
 	router := gin.Default()
 
 	// Middleware CORS robusto
@@ -41,11 +41,16 @@ func SetupRoutes(categoryHandler *handlers.CategoryHandler, accountHandler *hand
 		categories.OPTIONS("/:id", func(c *gin.Context) {
 			c.Status(204)
 		})
+		categories.OPTIONS("/suggest/retrain", func(c *gin.Context) {
+			c.Status(204)
+		})
 
 		categories.POST("", categoryHandler.CreateCategory)
 		categories.GET("", categoryHandler.GetAllCategories)
 		categories.GET("/by-type", categoryHandler.GetCategoriesByType)
 		categories.GET("/with-subcategories", categoryHandler.GetCategoriesWithSubcategories)
+		categories.GET("/suggest", suggestionHandler.SuggestCategories)
+		categories.POST("/suggest/retrain", suggestionHandler.RetrainModel)
 		categories.GET("/:id", categoryHandler.GetCategoryByID)
 		categories.GET("/:id/subcategories", categoryHandler.GetSubcategories)
 		categories.PUT("/:id", categoryHandler.UpdateCategory)
@@ -77,10 +82,12 @@ func SetupRoutes(categoryHandler *handlers.CategoryHandler, accountHandler *hand
 	transactions := router.Group("/api/transactions", handlers.SessionAuthMiddleware())
 	{
 		transactions.OPTIONS("", func(c *gin.Context) { c.Status(204) })
+		transactions.OPTIONS("export", func(c *gin.Context) { c.Status(204) })
 		transactions.OPTIONS(":id", func(c *gin.Context) { c.Status(204) })
 
 		transactions.POST("", transactionHandler.CreateTransaction)
 		transactions.GET("", transactionHandler.GetAllTransactions)
+		transactions.GET("export", exportHandler.ExportTransactions)
 		transactions.GET(":id", transactionHandler.GetTransactionByID)
 		transactions.PUT(":id", transactionHandler.UpdateTransaction)
 		transactions.DELETE(":id", transactionHandler.DeleteTransaction)
@@ -92,20 +99,212 @@ func SetupRoutes(categoryHandler *handlers.CategoryHandler, accountHandler *hand
 		exchange.OPTIONS("", func(c *gin.Context) { c.Status(204) })
 		exchange.OPTIONS("/rate", func(c *gin.Context) { c.Status(204) })
 		exchange.OPTIONS("/rate/simple", func(c *gin.Context) { c.Status(204) })
+		exchange.OPTIONS("/history", func(c *gin.Context) { c.Status(204) })
+		exchange.OPTIONS("/history/:id", func(c *gin.Context) { c.Status(204) })
 
 		exchange.POST("/rate", exchangeHandler.GetExchangeRate)
 		exchange.GET("/rate/simple", exchangeHandler.GetExchangeRateSimple)
+		exchange.GET("/history", exchangeHandler.GetRateHistory)
+		exchange.POST("/history", exchangeHandler.SaveHistoricalRate)
+		exchange.DELETE("/history/:id", exchangeHandler.DeleteHistoricalRate)
 	}
 
-	// Grupo de rotas para importação OFX
+	// Grupo de rotas para importação e exportação OFX
 	ofx := router.Group("/api/ofx", handlers.SessionAuthMiddleware())
 	{
 		ofx.OPTIONS("", func(c *gin.Context) { c.Status(204) })
 		ofx.OPTIONS("/import", func(c *gin.Context) { c.Status(204) })
 		ofx.OPTIONS("/preview", func(c *gin.Context) { c.Status(204) })
+		ofx.OPTIONS("/export", func(c *gin.Context) { c.Status(204) })
 
 		ofx.POST("/preview", ofxHandler.PreviewOFX)
 		ofx.POST("/import", ofxHandler.ImportOFX)
+		ofx.GET("/export", exportHandler.ExportOFX)
+	}
+
+	// Grupo de rotas para importação CSV
+	csvImport := router.Group("/api/csv", handlers.SessionAuthMiddleware())
+	{
+		csvImport.OPTIONS("/preview", func(c *gin.Context) { c.Status(204) })
+		csvImport.OPTIONS("/import", func(c *gin.Context) { c.Status(204) })
+		csvImport.OPTIONS("/presets", func(c *gin.Context) { c.Status(204) })
+		csvImport.OPTIONS("/profiles", func(c *gin.Context) { c.Status(204) })
+		csvImport.OPTIONS("/profiles/:id", func(c *gin.Context) { c.Status(204) })
+
+		csvImport.POST("/preview", csvHandler.PreviewCSV)
+		csvImport.POST("/import", csvHandler.ImportCSV)
+		csvImport.GET("/presets", csvHandler.GetPresets)
+		csvImport.POST("/profiles", csvHandler.CreateProfile)
+		csvImport.GET("/profiles", csvHandler.GetProfiles)
+		csvImport.GET("/profiles/:id", csvHandler.GetProfileByID)
+		csvImport.PUT("/profiles/:id", csvHandler.UpdateProfile)
+		csvImport.DELETE("/profiles/:id", csvHandler.DeleteProfile)
+	}
+
+	// Grupo de rotas para importação QIF
+	qif := router.Group("/api/qif", handlers.SessionAuthMiddleware())
+	{
+		qif.OPTIONS("/preview", func(c *gin.Context) { c.Status(204) })
+		qif.OPTIONS("/import", func(c *gin.Context) { c.Status(204) })
+
+		qif.POST("/preview", qifHandler.PreviewQIF)
+		qif.POST("/import", qifHandler.ImportQIF)
+	}
+
+	// Grupo de rotas para importação ISO 20022 (camt.053/camt.052)
+	camt := router.Group("/api/camt", handlers.SessionAuthMiddleware())
+	{
+		camt.OPTIONS("/preview", func(c *gin.Context) { c.Status(204) })
+		camt.OPTIONS("/import", func(c *gin.Context) { c.Status(204) })
+
+		camt.POST("/preview", camtHandler.PreviewCAMT)
+		camt.POST("/import", camtHandler.ImportCAMT)
+	}
+
+	// Grupo de rotas para histórico de importações
+	imports := router.Group("/api/imports", handlers.SessionAuthMiddleware())
+	{
+		imports.OPTIONS("", func(c *gin.Context) { c.Status(204) })
+		imports.OPTIONS("/:id", func(c *gin.Context) { c.Status(204) })
+		imports.OPTIONS("/:id/revert", func(c *gin.Context) { c.Status(204) })
+		imports.OPTIONS("/:id/balance-check", func(c *gin.Context) { c.Status(204) })
+		imports.OPTIONS("/:id/balance-adjustment", func(c *gin.Context) { c.Status(204) })
+
+		imports.GET("", importHandler.GetImportBatches)
+		imports.GET("/:id", importHandler.GetImportBatch)
+		imports.POST("/:id/revert", importHandler.RevertImportBatch)
+		imports.GET("/:id/balance-check", importHandler.GetBalanceCheck)
+		imports.POST("/:id/balance-adjustment", importHandler.CreateBalanceAdjustment)
+	}
+
+	// Grupo de rotas para importações processadas em segundo plano
+	importJobs := router.Group("/api/import-jobs", handlers.SessionAuthMiddleware())
+	{
+		importJobs.OPTIONS("", func(c *gin.Context) { c.Status(204) })
+		importJobs.OPTIONS("/:id", func(c *gin.Context) { c.Status(204) })
+		importJobs.OPTIONS("/:id/events", func(c *gin.Context) { c.Status(204) })
+		importJobs.OPTIONS("/:id/cancel", func(c *gin.Context) { c.Status(204) })
+
+		importJobs.GET("", importJobHandler.GetJobs)
+		importJobs.GET("/:id", importJobHandler.GetJob)
+		importJobs.GET("/:id/events", importJobHandler.StreamJob)
+		importJobs.POST("/:id/cancel", importJobHandler.CancelJob)
+	}
+
+	// Grupo de rotas para regras de categorização
+	rules := router.Group("/api/rules", handlers.SessionAuthMiddleware())
+	{
+		rules.OPTIONS("", func(c *gin.Context) { c.Status(204) })
+		rules.OPTIONS("/apply", func(c *gin.Context) { c.Status(204) })
+		rules.OPTIONS("/:id", func(c *gin.Context) { c.Status(204) })
+
+		rules.POST("", ruleHandler.CreateRule)
+		rules.GET("", ruleHandler.GetRules)
+		rules.POST("/apply", ruleHandler.ApplyRules)
+		rules.GET("/:id", ruleHandler.GetRuleByID)
+		rules.PUT("/:id", ruleHandler.UpdateRule)
+		rules.DELETE("/:id", ruleHandler.DeleteRule)
+	}
+
+	// Grupo de rotas para detecção de transferências entre contas do usuário
+	transfers := router.Group("/api/transfers", handlers.SessionAuthMiddleware())
+	{
+		transfers.OPTIONS("/candidates", func(c *gin.Context) { c.Status(204) })
+		transfers.OPTIONS("/link", func(c *gin.Context) { c.Status(204) })
+
+		transfers.GET("/candidates", transferHandler.GetCandidates)
+		transfers.POST("/link", transferHandler.LinkTransfers)
+	}
+
+	// Grupo de rotas para beneficiários
+	payees := router.Group("/api/payees", handlers.SessionAuthMiddleware())
+	{
+		payees.OPTIONS("", func(c *gin.Context) { c.Status(204) })
+		payees.OPTIONS("/apply", func(c *gin.Context) { c.Status(204) })
+		payees.OPTIONS("/normalize", func(c *gin.Context) { c.Status(204) })
+		payees.OPTIONS("/:id", func(c *gin.Context) { c.Status(204) })
+		payees.OPTIONS("/:id/history", func(c *gin.Context) { c.Status(204) })
+		payees.OPTIONS("/:id/merge", func(c *gin.Context) { c.Status(204) })
+
+		payees.POST("", payeeHandler.CreatePayee)
+		payees.GET("", payeeHandler.GetPayees)
+		payees.POST("/apply", payeeHandler.ApplyPayees)
+		payees.GET("/normalize", payeeHandler.NormalizeDescription)
+		payees.GET("/:id", payeeHandler.GetPayeeByID)
+		payees.PUT("/:id", payeeHandler.UpdatePayee)
+		payees.DELETE("/:id", payeeHandler.DeletePayee)
+		payees.GET("/:id/history", payeeHandler.GetPayeeHistory)
+		payees.POST("/:id/merge", payeeHandler.MergePayees)
+	}
+
+	// Grupo de rotas para regras de beneficiário (descrição do banco -> beneficiário)
+	payeeRules := router.Group("/api/payee-rules", handlers.SessionAuthMiddleware())
+	{
+		payeeRules.OPTIONS("", func(c *gin.Context) { c.Status(204) })
+		payeeRules.OPTIONS("/:id", func(c *gin.Context) { c.Status(204) })
+
+		payeeRules.POST("", payeeHandler.CreatePayeeRule)
+		payeeRules.GET("", payeeHandler.GetPayeeRules)
+		payeeRules.PUT("/:id", payeeHandler.UpdatePayeeRule)
+		payeeRules.DELETE("/:id", payeeHandler.DeletePayeeRule)
+	}
+
+	// Grupo de rotas para backup e restauração dos dados do usuário
+	backup := router.Group("/api/backup", handlers.SessionAuthMiddleware())
+	{
+		backup.OPTIONS("", func(c *gin.Context) { c.Status(204) })
+		backup.OPTIONS("/restore", func(c *gin.Context) { c.Status(204) })
+
+		backup.GET("", backupHandler.ExportBackup)
+		backup.POST("/restore", backupHandler.RestoreBackup)
+	}
+
+	// Grupo de rotas para relatórios
+	reports := router.Group("/api/reports", handlers.SessionAuthMiddleware())
+	{
+		reports.OPTIONS("/monthly.pdf", func(c *gin.Context) { c.Status(204) })
+		reports.OPTIONS("/yearly.pdf", func(c *gin.Context) { c.Status(204) })
+		reports.OPTIONS("/categories", func(c *gin.Context) { c.Status(204) })
+		reports.OPTIONS("/income-statement", func(c *gin.Context) { c.Status(204) })
+		reports.OPTIONS("/trends", func(c *gin.Context) { c.Status(204) })
+		reports.OPTIONS("/net-worth", func(c *gin.Context) { c.Status(204) })
+		reports.OPTIONS("/irpf", func(c *gin.Context) { c.Status(204) })
+		reports.OPTIONS("/irpf.csv", func(c *gin.Context) { c.Status(204) })
+		reports.OPTIONS("/pivot", func(c *gin.Context) { c.Status(204) })
+
+		reports.GET("/monthly.pdf", reportHandler.GetMonthlyReportPDF)
+		reports.GET("/yearly.pdf", reportHandler.GetYearlyReportPDF)
+		reports.GET("/categories", reportHandler.GetCategoryReport)
+		reports.GET("/income-statement", reportHandler.GetIncomeStatement)
+		reports.GET("/trends", reportHandler.GetCategoryTrends)
+		reports.GET("/net-worth", reportHandler.GetNetWorth)
+		reports.GET("/irpf", reportHandler.GetIRPFReport)
+		reports.GET("/irpf.csv", reportHandler.GetIRPFReportCSV)
+		reports.POST("/pivot", reportHandler.RunPivot)
+	}
+
+	// Grupo de rotas para gerenciar o feed de calendário (iCal) das contas a pagar e receber
+	calendar := router.Group("/api/calendar/token", handlers.SessionAuthMiddleware())
+	{
+		calendar.OPTIONS("", func(c *gin.Context) { c.Status(204) })
+
+		calendar.GET("", calendarHandler.GetFeedInfo)
+		calendar.POST("", calendarHandler.RegenerateToken)
+		calendar.DELETE("", calendarHandler.RevokeToken)
+	}
+	// Feed público, autenticado pelo token no nome do arquivo (/api/calendar/<token>.ics)
+	router.GET("/api/calendar/:file", calendarHandler.GetFeed)
+
+	// Grupo de rotas para alertas de gastos incomuns
+	insights := router.Group("/api/insights", handlers.SessionAuthMiddleware())
+	{
+		insights.OPTIONS("", func(c *gin.Context) { c.Status(204) })
+		insights.OPTIONS("/evaluate", func(c *gin.Context) { c.Status(204) })
+		insights.OPTIONS("/flags/:id/dismiss", func(c *gin.Context) { c.Status(204) })
+
+		insights.GET("", insightHandler.GetInsights)
+		insights.POST("/evaluate", insightHandler.EvaluateTransactions)
+		insights.POST("/flags/:id/dismiss", insightHandler.DismissFlag)
 	}
 
 	// Rotas de autenticação
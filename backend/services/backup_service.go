@@ -0,0 +1,645 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+// backupFileName é o nome do JSON dentro do arquivo zip do backup
+const backupFileName = "backup.json"
+
+// maxBackupProblems limita quantos problemas de integridade são listados no erro da restauração
+const maxBackupProblems = 20
+
+type BackupService struct {
+	db                *database.Database
+	suggestionService *SuggestionService
+}
+
+// NewBackupService cria uma nova instância do serviço de backup
+func NewBackupService(db *database.Database, suggestionService *SuggestionService) *BackupService {
+	return &BackupService{
+		db:                db,
+		suggestionService: suggestionService,
+	}
+}
+
+// CreateBackup reúne todos os dados ativos do usuário. Contas e categorias já excluídas
+// entram apenas quando alguma transação ou regra ainda aponta para elas.
+func (s *BackupService) CreateBackup(userID string) (*structs.Backup, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("usuário não encontrado")
+	}
+
+	backup := &structs.Backup{
+		Version:    structs.BackupVersion,
+		ExportedAt: time.Now(),
+		UserID:     userID,
+	}
+
+	if backup.Categories, err = s.db.GetAllCategories(userID); err != nil {
+		return nil, fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+	if backup.Accounts, err = s.db.GetAllAccounts(userID); err != nil {
+		return nil, fmt.Errorf("erro ao buscar contas: %w", err)
+	}
+	if backup.Payees, err = s.db.GetPayeesByUser(userID); err != nil {
+		return nil, fmt.Errorf("erro ao buscar beneficiários: %w", err)
+	}
+	if backup.PayeeRules, err = s.db.GetPayeeRulesByUser(userID); err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras de beneficiário: %w", err)
+	}
+	if backup.Rules, err = s.db.GetRulesByUser(userID); err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras: %w", err)
+	}
+	if backup.CSVProfiles, err = s.db.GetCSVProfilesByUser(userID); err != nil {
+		return nil, fmt.Errorf("erro ao buscar perfis de CSV: %w", err)
+	}
+	if backup.Transactions, err = s.db.GetAllTransactionsByUser(userID); err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+
+	transferCategory, err := s.db.EnsureTransferCategory()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categoria de transferência: %w", err)
+	}
+	backup.TransferCategoryID = transferCategory.ID
+
+	// Os totais da listagem de beneficiários não fazem parte do backup
+	for i := range backup.Payees {
+		backup.Payees[i].TransactionCount = 0
+		backup.Payees[i].LastTransactionAt = nil
+	}
+
+	if err := s.includeReferenced(backup); err != nil {
+		return nil, err
+	}
+	normalizeBackupSections(backup)
+	return backup, nil
+}
+
+// includeReferenced acrescenta ao backup as contas e categorias fora da listagem do usuário
+// (excluídas ou sem dono) que ainda são referenciadas, para que o arquivo seja íntegro
+func (s *BackupService) includeReferenced(backup *structs.Backup) error {
+	accounts := make(map[string]bool)
+	for _, account := range backup.Accounts {
+		accounts[account.ID] = true
+	}
+	categories := make(map[string]bool)
+	for _, category := range backup.Categories {
+		categories[category.ID] = true
+	}
+	categories[backup.TransferCategoryID] = true
+
+	addAccount := func(id string) error {
+		if id == "" || accounts[id] {
+			return nil
+		}
+		accounts[id] = true
+		account, err := s.db.GetAccountByID(id, backup.UserID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar conta: %w", err)
+		}
+		if account != nil {
+			backup.Accounts = append(backup.Accounts, *account)
+		}
+		return nil
+	}
+	var addCategory func(id string) error
+	addCategory = func(id string) error {
+		if id == "" || categories[id] {
+			return nil
+		}
+		categories[id] = true
+		category, err := s.db.GetCategoryByID(id, backup.UserID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar categoria: %w", err)
+		}
+		if category == nil {
+			return nil
+		}
+		category.UserID = backup.UserID
+		backup.Categories = append(backup.Categories, *category)
+		if category.ParentID != nil {
+			return addCategory(*category.ParentID)
+		}
+		return nil
+	}
+
+	for _, tx := range backup.Transactions {
+		if err := addAccount(tx.AccountID); err != nil {
+			return err
+		}
+		if err := addCategory(tx.CategoryID); err != nil {
+			return err
+		}
+	}
+	for _, rule := range backup.Rules {
+		if rule.AccountID != nil {
+			if err := addAccount(*rule.AccountID); err != nil {
+				return err
+			}
+		}
+		if rule.CategoryID != nil {
+			if err := addCategory(*rule.CategoryID); err != nil {
+				return err
+			}
+		}
+	}
+	for i := 0; i < len(backup.Categories); i++ {
+		if parentID := backup.Categories[i].ParentID; parentID != nil {
+			if err := addCategory(*parentID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeBackupSections troca seções nulas por listas vazias, para que o JSON traga sempre
+// todas as seções
+func normalizeBackupSections(backup *structs.Backup) {
+	if backup.Categories == nil {
+		backup.Categories = []structs.Category{}
+	}
+	if backup.Accounts == nil {
+		backup.Accounts = []structs.Account{}
+	}
+	if backup.Payees == nil {
+		backup.Payees = []structs.Payee{}
+	}
+	if backup.PayeeRules == nil {
+		backup.PayeeRules = []structs.PayeeRule{}
+	}
+	if backup.Rules == nil {
+		backup.Rules = []structs.CategorizationRule{}
+	}
+	if backup.CSVProfiles == nil {
+		backup.CSVProfiles = []structs.CSVProfile{}
+	}
+	if backup.Transactions == nil {
+		backup.Transactions = []structs.Transaction{}
+	}
+}
+
+// WriteBackup grava o backup em JSON ou, com zipped, em um zip contendo backup.json
+func WriteBackup(w io.Writer, backup *structs.Backup, zipped bool) error {
+	if !zipped {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(backup)
+	}
+
+	archive := zip.NewWriter(w)
+	file, err := archive.Create(backupFileName)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(backup); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// ReadBackup lê um backup em JSON ou zipado, conferindo a versão do formato
+func ReadBackup(content []byte) (*structs.Backup, error) {
+	if bytes.HasPrefix(content, []byte("PK\x03\x04")) {
+		archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return nil, fmt.Errorf("arquivo zip inválido: %w", err)
+		}
+		var jsonFile *zip.File
+		for _, file := range archive.File {
+			if file.Name == backupFileName {
+				jsonFile = file
+				break
+			}
+			if jsonFile == nil && strings.EqualFold(path.Ext(file.Name), ".json") {
+				jsonFile = file
+			}
+		}
+		if jsonFile == nil {
+			return nil, fmt.Errorf("o arquivo zip não contém %s", backupFileName)
+		}
+		reader, err := jsonFile.Open()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao abrir %s: %w", jsonFile.Name, err)
+		}
+		defer reader.Close()
+		if content, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("erro ao ler %s: %w", jsonFile.Name, err)
+		}
+	}
+
+	var backup structs.Backup
+	if err := json.Unmarshal(content, &backup); err != nil {
+		return nil, fmt.Errorf("JSON de backup inválido: %w", err)
+	}
+	if backup.Version == 0 {
+		return nil, fmt.Errorf("arquivo sem versão: não parece ser um backup")
+	}
+	if backup.Version > structs.BackupVersion {
+		return nil, fmt.Errorf("backup na versão %d não é suportado (versão máxima: %d)", backup.Version, structs.BackupVersion)
+	}
+	normalizeBackupSections(&backup)
+	return &backup, nil
+}
+
+// RestoreBackup importa o backup no usuário informado, vazio ou não. Todos os registros
+// recebem novos IDs; categorias e beneficiários que já existem no usuário são aproveitados.
+// Nada é gravado se o backup tiver referências quebradas.
+func (s *BackupService) RestoreBackup(userID string, backup *structs.Backup) (*structs.RestoreResult, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("usuário não encontrado")
+	}
+
+	if problems := validateBackup(backup); len(problems) > 0 {
+		if len(problems) > maxBackupProblems {
+			problems = append(problems[:maxBackupProblems], fmt.Sprintf("e mais %d problemas", len(problems)-maxBackupProblems))
+		}
+		return nil, fmt.Errorf("backup inválido: %s", strings.Join(problems, "; "))
+	}
+
+	restore := &backupRestore{
+		s:      s,
+		userID: userID,
+		source: backup,
+		ids:    make(map[string]string),
+		result: &structs.RestoreResult{Created: make(map[string]int), Reused: make(map[string]int)},
+	}
+	if err := restore.remap(); err != nil {
+		return nil, err
+	}
+	if err := s.db.RestoreBackup(restore.target); err != nil {
+		return nil, fmt.Errorf("erro ao restaurar backup: %w", err)
+	}
+
+	if _, err := s.suggestionService.Retrain(userID); err != nil {
+		restore.result.Warnings = append(restore.result.Warnings, "não foi possível atualizar o modelo de sugestão de categorias: "+err.Error())
+	}
+
+	restore.result.Message = fmt.Sprintf("Backup restaurado com sucesso! %d transações, %d contas e %d categorias criadas.",
+		restore.result.Created["transactions"], restore.result.Created["accounts"], restore.result.Created["categories"])
+	return restore.result, nil
+}
+
+// validateBackup confere a integridade referencial do backup e retorna os problemas encontrados
+func validateBackup(backup *structs.Backup) []string {
+	var problems []string
+	seen := make(map[string]string)
+	unique := func(section, id string) {
+		if id == "" {
+			problems = append(problems, fmt.Sprintf("%s sem id", section))
+			return
+		}
+		if other, ok := seen[id]; ok {
+			problems = append(problems, fmt.Sprintf("id %s repetido (%s e %s)", id, other, section))
+			return
+		}
+		seen[id] = section
+	}
+
+	categories := make(map[string]structs.Category)
+	for _, category := range backup.Categories {
+		unique("categoria", category.ID)
+		categories[category.ID] = category
+		if category.Type != structs.CategoryTypeIncome && category.Type != structs.CategoryTypeExpense && category.Type != structs.CategoryTypeTransfer {
+			problems = append(problems, fmt.Sprintf("categoria '%s' com tipo inválido: %s", category.Name, category.Type))
+		}
+		if category.TaxSection != nil && structs.ValidateTaxSection(*category.TaxSection, false) != nil {
+			problems = append(problems, fmt.Sprintf("categoria '%s' com seção do IRPF inválida: %s", category.Name, *category.TaxSection))
+		}
+	}
+	for _, category := range backup.Categories {
+		if category.ParentID == nil {
+			continue
+		}
+		if _, ok := categories[*category.ParentID]; !ok {
+			problems = append(problems, fmt.Sprintf("categoria '%s' aponta para a categoria pai inexistente %s", category.Name, *category.ParentID))
+		} else if backupCategoryDepth(categories, category.ID) < 0 {
+			problems = append(problems, fmt.Sprintf("categoria '%s' faz parte de um ciclo de categorias pai", category.Name))
+		}
+	}
+
+	accounts := make(map[string]bool)
+	for _, account := range backup.Accounts {
+		unique("conta", account.ID)
+		accounts[account.ID] = true
+	}
+	payees := make(map[string]bool)
+	for _, payee := range backup.Payees {
+		unique("beneficiário", payee.ID)
+		payees[payee.ID] = true
+	}
+	for _, rule := range backup.PayeeRules {
+		unique("regra de beneficiário", rule.ID)
+		if !payees[rule.PayeeID] {
+			problems = append(problems, fmt.Sprintf("regra de beneficiário '%s' aponta para o beneficiário inexistente %s", rule.Pattern, rule.PayeeID))
+		}
+	}
+	for _, rule := range backup.Rules {
+		unique("regra", rule.ID)
+		if rule.AccountID != nil && !accounts[*rule.AccountID] {
+			problems = append(problems, fmt.Sprintf("regra '%s' aponta para a conta inexistente %s", rule.Name, *rule.AccountID))
+		}
+		if rule.CategoryID != nil {
+			if _, ok := categories[*rule.CategoryID]; !ok {
+				problems = append(problems, fmt.Sprintf("regra '%s' aponta para a categoria inexistente %s", rule.Name, *rule.CategoryID))
+			}
+		}
+	}
+	for _, profile := range backup.CSVProfiles {
+		unique("perfil de CSV", profile.ID)
+	}
+
+	transfers := make(map[string]int)
+	for _, tx := range backup.Transactions {
+		unique("transação", tx.ID)
+		if tx.Type != "income" && tx.Type != "expense" {
+			problems = append(problems, fmt.Sprintf("transação '%s' com tipo inválido: %s", tx.Description, tx.Type))
+		}
+		if !accounts[tx.AccountID] {
+			problems = append(problems, fmt.Sprintf("transação '%s' aponta para a conta inexistente %s", tx.Description, tx.AccountID))
+		}
+		if _, ok := categories[tx.CategoryID]; !ok && (tx.CategoryID != backup.TransferCategoryID || tx.CategoryID == "") {
+			problems = append(problems, fmt.Sprintf("transação '%s' aponta para a categoria inexistente %s", tx.Description, tx.CategoryID))
+		}
+		if tx.PayeeID != nil && !payees[*tx.PayeeID] {
+			problems = append(problems, fmt.Sprintf("transação '%s' aponta para o beneficiário inexistente %s", tx.Description, *tx.PayeeID))
+		}
+		if tx.TaxSection != nil && structs.ValidateTaxSection(*tx.TaxSection, true) != nil {
+			problems = append(problems, fmt.Sprintf("transação '%s' com seção do IRPF inválida: %s", tx.Description, *tx.TaxSection))
+		}
+		if tx.PayeeTaxID != "" {
+			// O backup guarda só os dígitos, como no banco
+			if taxID, err := utils.NormalizeTaxID(tx.PayeeTaxID); err != nil || taxID != tx.PayeeTaxID {
+				problems = append(problems, fmt.Sprintf("transação '%s' com CPF/CNPJ inválido: %s", tx.Description, tx.PayeeTaxID))
+			}
+		}
+		if tx.TransferID != nil {
+			transfers[*tx.TransferID]++
+		}
+	}
+	for transferID, count := range transfers {
+		if count > 2 {
+			problems = append(problems, fmt.Sprintf("transferência %s com %d lados (máximo 2)", transferID, count))
+		}
+	}
+	return problems
+}
+
+// backupCategoryDepth retorna a profundidade da categoria na hierarquia, ou -1 em caso de ciclo
+func backupCategoryDepth(categories map[string]structs.Category, id string) int {
+	visited := make(map[string]bool)
+	depth := 0
+	for {
+		if visited[id] {
+			return -1
+		}
+		visited[id] = true
+		category, ok := categories[id]
+		if !ok || category.ParentID == nil {
+			return depth
+		}
+		id = *category.ParentID
+		depth++
+	}
+}
+
+// backupRestore guarda o estado de uma restauração: o backup de origem, os registros com os
+// novos IDs e a correspondência entre IDs antigos e novos
+type backupRestore struct {
+	s      *BackupService
+	userID string
+	source *structs.Backup
+	target structs.Backup
+	ids    map[string]string
+	result *structs.RestoreResult
+}
+
+func (r *backupRestore) warn(format string, args ...interface{}) {
+	r.result.Warnings = append(r.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+// mapped retorna o novo ID de uma referência opcional
+func (r *backupRestore) mapped(id *string) *string {
+	if id == nil {
+		return nil
+	}
+	newID := r.ids[*id]
+	return &newID
+}
+
+func (r *backupRestore) remap() error {
+	if err := r.remapCategories(); err != nil {
+		return err
+	}
+	r.remapAccounts()
+	if err := r.remapPayees(); err != nil {
+		return err
+	}
+	r.remapRules()
+	return r.remapTransactions()
+}
+
+// remapCategories cria as categorias das mais altas para as mais profundas na hierarquia,
+// reaproveitando as que já existem no usuário com mesmo nome, tipo e categoria pai
+func (r *backupRestore) remapCategories() error {
+	existing, err := r.s.db.GetAllCategories(r.userID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+	categoryKey := func(category structs.Category, parentID *string) string {
+		parent := ""
+		if parentID != nil {
+			parent = *parentID
+		}
+		return string(category.Type) + "|" + parent + "|" + utils.NormalizeText(category.Name)
+	}
+	byKey := make(map[string]string)
+	for _, category := range existing {
+		byKey[categoryKey(category, category.ParentID)] = category.ID
+	}
+
+	sourceByID := make(map[string]structs.Category)
+	for _, category := range r.source.Categories {
+		sourceByID[category.ID] = category
+	}
+	ordered := append([]structs.Category{}, r.source.Categories...)
+	depths := make(map[string]int)
+	for _, category := range ordered {
+		depths[category.ID] = backupCategoryDepth(sourceByID, category.ID)
+	}
+	for depth := 0; len(ordered) > 0; depth++ {
+		var deeper []structs.Category
+		for _, category := range ordered {
+			if depths[category.ID] != depth {
+				deeper = append(deeper, category)
+				continue
+			}
+			parentID := r.mapped(category.ParentID)
+			key := categoryKey(category, parentID)
+			if id, ok := byKey[key]; ok {
+				r.ids[category.ID] = id
+				r.result.Reused["categories"]++
+				continue
+			}
+
+			r.ids[category.ID] = uuid.New().String()
+			category.ID = r.ids[category.ID]
+			category.ParentID = parentID
+			category.UserID = r.userID
+			category.DeletedAt = nil
+			byKey[key] = category.ID
+			r.target.Categories = append(r.target.Categories, category)
+			r.result.Created["categories"]++
+		}
+		ordered = deeper
+	}
+
+	if r.source.TransferCategoryID != "" {
+		transferCategory, err := r.s.db.EnsureTransferCategory()
+		if err != nil {
+			return fmt.Errorf("erro ao buscar categoria de transferência: %w", err)
+		}
+		r.ids[r.source.TransferCategoryID] = transferCategory.ID
+	}
+	return nil
+}
+
+func (r *backupRestore) remapAccounts() {
+	for _, account := range r.source.Accounts {
+		r.ids[account.ID] = uuid.New().String()
+		account.ID = r.ids[account.ID]
+		account.UserID = r.userID
+		r.target.Accounts = append(r.target.Accounts, account)
+		r.result.Created["accounts"]++
+	}
+}
+
+// remapPayees reaproveita os beneficiários do usuário com o mesmo nome normalizado
+func (r *backupRestore) remapPayees() error {
+	existing, err := r.s.db.GetPayeesByUser(r.userID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar beneficiários: %w", err)
+	}
+	byKey := make(map[string]string)
+	for _, payee := range existing {
+		byKey[payee.NormalizedName] = payee.ID
+	}
+
+	for _, payee := range r.source.Payees {
+		if payee.NormalizedName == "" {
+			payee.NormalizedName = PayeeKey(payee.Name)
+		}
+		if id, ok := byKey[payee.NormalizedName]; ok {
+			r.ids[payee.ID] = id
+			r.result.Reused["payees"]++
+			continue
+		}
+
+		r.ids[payee.ID] = uuid.New().String()
+		payee.ID = r.ids[payee.ID]
+		payee.UserID = r.userID
+		payee.DeletedAt = nil
+		byKey[payee.NormalizedName] = payee.ID
+		r.target.Payees = append(r.target.Payees, payee)
+		r.result.Created["payees"]++
+	}
+
+	for _, rule := range r.source.PayeeRules {
+		rule.ID = uuid.New().String()
+		rule.UserID = r.userID
+		rule.PayeeID = r.ids[rule.PayeeID]
+		rule.DeletedAt = nil
+		r.target.PayeeRules = append(r.target.PayeeRules, rule)
+		r.result.Created["payee_rules"]++
+	}
+	return nil
+}
+
+func (r *backupRestore) remapRules() {
+	for _, rule := range r.source.Rules {
+		rule.ID = uuid.New().String()
+		rule.UserID = r.userID
+		rule.AccountID = r.mapped(rule.AccountID)
+		rule.CategoryID = r.mapped(rule.CategoryID)
+		rule.DeletedAt = nil
+		r.target.Rules = append(r.target.Rules, rule)
+		r.result.Created["rules"]++
+	}
+
+	for _, profile := range r.source.CSVProfiles {
+		profile.ID = uuid.New().String()
+		profile.UserID = r.userID
+		profile.DeletedAt = nil
+		r.target.CSVProfiles = append(r.target.CSVProfiles, profile)
+		r.result.Created["csv_profiles"]++
+	}
+}
+
+// remapTransactions cria as transações pai antes das parcelas e dá um novo transfer_id
+// a cada par de transferência. O vínculo com lotes de importação não é restaurado.
+func (r *backupRestore) remapTransactions() error {
+	inBackup := make(map[string]bool)
+	for _, tx := range r.source.Transactions {
+		r.ids[tx.ID] = uuid.New().String()
+		inBackup[tx.ID] = true
+	}
+
+	var parents, children []structs.Transaction
+	for _, tx := range r.source.Transactions {
+		if tx.ParentTransactionID != nil && !inBackup[*tx.ParentTransactionID] {
+			r.warn("transação '%s' perdeu o vínculo com a transação pai, que não está no backup", tx.Description)
+			tx.ParentTransactionID = nil
+		}
+		if tx.ParentTransactionID != nil {
+			children = append(children, tx)
+		} else {
+			parents = append(parents, tx)
+		}
+	}
+
+	transferIDs := make(map[string]string)
+	for _, tx := range append(parents, children...) {
+		tx.ID = r.ids[tx.ID]
+		tx.UserID = r.userID
+		tx.AccountID = r.ids[tx.AccountID]
+		tx.CategoryID = r.ids[tx.CategoryID]
+		tx.PayeeID = r.mapped(tx.PayeeID)
+		tx.ParentTransactionID = r.mapped(tx.ParentTransactionID)
+		tx.ImportBatchID = nil
+		tx.DeletedAt = nil
+		if tx.TransferID != nil {
+			if _, ok := transferIDs[*tx.TransferID]; !ok {
+				transferIDs[*tx.TransferID] = uuid.New().String()
+			}
+			transferID := transferIDs[*tx.TransferID]
+			tx.TransferID = &transferID
+		}
+		r.target.Transactions = append(r.target.Transactions, tx)
+		r.result.Created["transactions"]++
+	}
+	return nil
+}
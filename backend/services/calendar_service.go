@@ -0,0 +1,301 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+// calendarOverdueDays é quantos dias para trás o feed mostra as contas vencidas e ainda não pagas
+const calendarOverdueDays = 90
+
+// calendarRecurrences traduz o recurring_type das transações para a regra de repetição do
+// iCalendar. O campo é livre, então aceitamos os nomes em inglês e em português.
+var calendarRecurrences = map[string]string{
+	"daily":      "FREQ=DAILY",
+	"diario":     "FREQ=DAILY",
+	"diaria":     "FREQ=DAILY",
+	"weekly":     "FREQ=WEEKLY",
+	"semanal":    "FREQ=WEEKLY",
+	"biweekly":   "FREQ=WEEKLY;INTERVAL=2",
+	"quinzenal":  "FREQ=WEEKLY;INTERVAL=2",
+	"monthly":    "FREQ=MONTHLY",
+	"mensal":     "FREQ=MONTHLY",
+	"bimonthly":  "FREQ=MONTHLY;INTERVAL=2",
+	"bimestral":  "FREQ=MONTHLY;INTERVAL=2",
+	"quarterly":  "FREQ=MONTHLY;INTERVAL=3",
+	"trimestral": "FREQ=MONTHLY;INTERVAL=3",
+	"semiannual": "FREQ=MONTHLY;INTERVAL=6",
+	"semestral":  "FREQ=MONTHLY;INTERVAL=6",
+	"yearly":     "FREQ=YEARLY",
+	"annual":     "FREQ=YEARLY",
+	"anual":      "FREQ=YEARLY",
+}
+
+type CalendarService struct {
+	db *database.Database
+}
+
+// NewCalendarService cria uma nova instância do serviço do feed de calendário
+func NewCalendarService(db *database.Database) *CalendarService {
+	return &CalendarService{db: db}
+}
+
+// GetFeedInfo informa se o usuário tem um feed ativo. A URL não é devolvida, pois o token
+// não fica guardado.
+func (s *CalendarService) GetFeedInfo(userID string) (*structs.CalendarFeedInfo, error) {
+	token, err := s.db.GetCalendarToken(userID)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return &structs.CalendarFeedInfo{Active: false}, nil
+	}
+	return &structs.CalendarFeedInfo{
+		Active:         true,
+		CreatedAt:      &token.CreatedAt,
+		LastAccessedAt: token.LastAccessedAt,
+	}, nil
+}
+
+// RegenerateToken gera um novo token para o usuário, invalidando o anterior, e devolve as
+// URLs do feed montadas a partir de baseURL (ex.: "https://exemplo.com")
+func (s *CalendarService) RegenerateToken(userID, baseURL string) (*structs.CalendarFeedInfo, error) {
+	user, err := s.db.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil {
+		return nil, fmt.Errorf("usuário não encontrado")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("erro ao gerar token do calendário: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	saved, err := s.db.SaveCalendarToken(userID, hashCalendarToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimSuffix(baseURL, "/") + "/api/calendar/" + token + ".ics"
+	webcal := url
+	if index := strings.Index(url, "://"); index >= 0 {
+		webcal = "webcal" + url[index:]
+	}
+	return &structs.CalendarFeedInfo{
+		Active:    true,
+		CreatedAt: &saved.CreatedAt,
+		URL:       url,
+		WebcalURL: webcal,
+	}, nil
+}
+
+// RevokeToken desativa o feed do usuário. Retorna false quando não havia feed ativo.
+func (s *CalendarService) RevokeToken(userID string) (bool, error) {
+	return s.db.DeleteCalendarToken(userID)
+}
+
+// UserIDForToken devolve o usuário dono do token, ou "" quando o token não existe ou foi revogado
+func (s *CalendarService) UserIDForToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	saved, err := s.db.GetCalendarTokenByHash(hashCalendarToken(token))
+	if err != nil || saved == nil {
+		return "", err
+	}
+	if err := s.db.TouchCalendarToken(saved.UserID); err != nil {
+		return "", err
+	}
+	return saved.UserID, nil
+}
+
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// WriteFeed grava o calendário (RFC 5545) com as despesas e receitas não pagas do usuário
+// como eventos de dia inteiro no vencimento. As contas vencidas aparecem por até
+// calendarOverdueDays dias. Nas séries recorrentes e parceladas, a última ocorrência em
+// aberto leva a regra de repetição, para que as próximas apareçam mesmo antes de serem lançadas.
+func (s *CalendarService) WriteFeed(w io.Writer, userID string) error {
+	data := reportData{
+		categories: make(map[string]*structs.Category),
+		accounts:   make(map[string]*structs.Account),
+	}
+	categories, err := s.db.GetAllCategories(userID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+	for i := range categories {
+		data.categories[categories[i].ID] = &categories[i]
+	}
+	accounts, err := s.db.GetAllAccounts(userID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar contas: %w", err)
+	}
+	for i := range accounts {
+		data.accounts[accounts[i].ID] = &accounts[i]
+	}
+	transferCategory, err := s.db.EnsureTransferCategory()
+	if err != nil {
+		return fmt.Errorf("erro ao buscar categoria de transferência: %w", err)
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -calendarOverdueDays)
+	unpaid := false
+	transactions, err := s.db.GetFilteredTransactions(userID, structs.TransactionFilter{
+		From:   &from,
+		IsPaid: &unpaid,
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+
+	var bills []structs.Transaction
+	for _, tx := range transactions {
+		if tx.TransferID != nil || tx.CategoryID == transferCategory.ID {
+			continue
+		}
+		bills = append(bills, tx)
+	}
+
+	// A regra de repetição fica só na última ocorrência em aberto de cada série
+	lastOfSeries := make(map[string]structs.Transaction)
+	for _, tx := range bills {
+		key := calendarSeriesKey(tx)
+		if last, ok := lastOfSeries[key]; !ok || tx.DueDate.After(last.DueDate) {
+			lastOfSeries[key] = tx
+		}
+	}
+	sort.SliceStable(bills, func(i, j int) bool { return bills[i].DueDate.Before(bills[j].DueDate) })
+
+	iw := newICalWriter(w)
+	iw.Line("BEGIN:VCALENDAR")
+	iw.Line("VERSION:2.0")
+	iw.Line("PRODID:-//my-personal-finance//Contas a pagar e receber//PT")
+	iw.Line("CALSCALE:GREGORIAN")
+	iw.Line("METHOD:PUBLISH")
+	iw.Text("X-WR-CALNAME", "Contas a pagar e receber")
+	iw.Text("X-WR-CALDESC", "Despesas e receitas ainda não pagas, pelo vencimento")
+	// Pede aos aplicativos que atualizem o feed a cada hora
+	iw.Line("REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	iw.Line("X-PUBLISHED-TTL:PT1H")
+
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, tx := range bills {
+		rule := ""
+		if lastOfSeries[calendarSeriesKey(tx)].ID == tx.ID {
+			rule = calendarRecurrenceRule(tx)
+		}
+		writeCalendarEvent(iw, data, tx, rule, stamp)
+	}
+
+	iw.Line("END:VCALENDAR")
+	return iw.Flush()
+}
+
+// calendarSeriesKey identifica a série da transação: as parcelas e repetições apontam para a
+// transação original em parent_transaction_id
+func calendarSeriesKey(tx structs.Transaction) string {
+	if tx.ParentTransactionID != nil && *tx.ParentTransactionID != "" {
+		return *tx.ParentTransactionID
+	}
+	return tx.ID
+}
+
+// calendarRecurrenceRule monta o RRULE da transação, ou "" quando ela não se repete. Parcelas
+// sem tipo de recorrência são consideradas mensais; a contagem cobre só as parcelas restantes.
+func calendarRecurrenceRule(tx structs.Transaction) string {
+	frequency := ""
+	if tx.RecurringType != nil {
+		frequency = calendarRecurrences[utils.NormalizeText(*tx.RecurringType)]
+	}
+	installments := tx.Installments > 1
+	if frequency == "" {
+		if !installments {
+			return ""
+		}
+		frequency = "FREQ=MONTHLY"
+	}
+	if !installments && !tx.IsRecurring {
+		return ""
+	}
+
+	// Meses sem o dia 31 seriam pulados; nesse caso a repetição cai no último dia do mês
+	if strings.HasPrefix(frequency, "FREQ=MONTHLY") && tx.DueDate.Day() == 31 {
+		frequency += ";BYMONTHDAY=-1"
+	}
+	if installments {
+		remaining := tx.Installments - tx.CurrentInstallment + 1
+		if remaining <= 1 {
+			return ""
+		}
+		frequency += fmt.Sprintf(";COUNT=%d", remaining)
+	}
+	return frequency
+}
+
+// writeCalendarEvent grava a transação como um evento de dia inteiro no vencimento
+func writeCalendarEvent(iw *icalWriter, data reportData, tx structs.Transaction, rule, stamp string) {
+	currency := "BRL"
+	accountName := "Conta removida"
+	if account, ok := data.accounts[tx.AccountID]; ok {
+		currency = account.Currency
+		accountName = account.Name
+	}
+	amount := formatMoney(tx.Amount, currency)
+	category := reportCategoryPath(data, tx.CategoryID)
+
+	action := "A pagar"
+	if tx.Type == "income" {
+		action = "A receber"
+	}
+
+	description := []string{
+		"Valor: " + amount,
+		"Conta: " + accountName,
+		"Categoria: " + category,
+	}
+	if tx.Installments > 1 {
+		if rule != "" {
+			description = append(description, fmt.Sprintf("Parcelas %d a %d de %d", tx.CurrentInstallment, tx.Installments, tx.Installments))
+		} else {
+			description = append(description, fmt.Sprintf("Parcela %d de %d", tx.CurrentInstallment, tx.Installments))
+		}
+	}
+	if tx.Observation != "" {
+		description = append(description, "Observação: "+tx.Observation)
+	}
+
+	iw.Line("BEGIN:VEVENT")
+	iw.Text("UID", tx.ID+"@my-personal-finance")
+	iw.Line("DTSTAMP:" + stamp)
+	iw.Line("LAST-MODIFIED:" + tx.UpdatedAt.UTC().Format("20060102T150405Z"))
+	iw.Line("DTSTART;VALUE=DATE:" + tx.DueDate.Format("20060102"))
+	iw.Line("DTEND;VALUE=DATE:" + tx.DueDate.AddDate(0, 0, 1).Format("20060102"))
+	if rule != "" {
+		iw.Line("RRULE:" + rule)
+	}
+	iw.Text("SUMMARY", fmt.Sprintf("%s: %s (%s)", action, tx.Description, amount))
+	iw.Text("DESCRIPTION", strings.Join(description, "\n"))
+	iw.Text("CATEGORIES", category)
+	// Eventos de dia inteiro não ocupam a agenda
+	iw.Line("TRANSP:TRANSPARENT")
+	iw.Line("END:VEVENT")
+}
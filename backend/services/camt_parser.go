@@ -0,0 +1,344 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// CAMTStatement é um extrato (camt.053) ou relatório intradiário (camt.052) já convertido
+type CAMTStatement struct {
+	ID             string       `json:"id"`
+	Account        string       `json:"account"` // IBAN ou outro identificador da conta
+	Currency       string       `json:"currency"`
+	OpeningBalance *CAMTBalance `json:"opening_balance,omitempty"`
+	ClosingBalance *CAMTBalance `json:"closing_balance,omitempty"`
+	Entries        int          `json:"entries"`
+	SkippedEntries int          `json:"skipped_entries"` // Lançamentos pendentes ou informativos
+}
+
+// CAMTBalance é um saldo informado pelo banco no extrato
+type CAMTBalance struct {
+	Type     string    `json:"type"` // OPBD, PRCD, CLBD, ITBD, CLAV...
+	Amount   float64   `json:"amount"`
+	Currency string    `json:"currency"`
+	Date     time.Time `json:"date"`
+}
+
+// CAMTParseResult reúne as linhas lidas e os extratos de um arquivo camt
+type CAMTParseResult struct {
+	MessageType string               `json:"message_type"` // camt.053 ou camt.052
+	Statements  []CAMTStatement      `json:"statements"`
+	Lines       []structs.ImportLine `json:"-"`
+}
+
+// Estruturas XML do ISO 20022. As tags não têm namespace para aceitar qualquer versão (camt.053.001.02, .08...).
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+	Reports    []camtStatement `xml:"BkToCstmrAcctRpt>Rpt"`
+}
+
+type camtStatement struct {
+	ID       string        `xml:"Id"`
+	IBAN     string        `xml:"Acct>Id>IBAN"`
+	OtherID  string        `xml:"Acct>Id>Othr>Id"`
+	Currency string        `xml:"Acct>Ccy"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      camtDate   `xml:"Dt"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtEntry struct {
+	Reference      string       `xml:"NtryRef"`
+	Amount         camtAmount   `xml:"Amt"`
+	Indicator      string       `xml:"CdtDbtInd"`
+	Status         camtStatus   `xml:"Sts"`
+	BookingDate    camtDate     `xml:"BookgDt"`
+	ValueDate      camtDate     `xml:"ValDt"`
+	ServicerRef    string       `xml:"AcctSvcrRef"`
+	AdditionalInfo string       `xml:"AddtlNtryInf"`
+	Details        []camtTxInfo `xml:"NtryDtls>TxDtls"`
+}
+
+// camtStatus aceita <Sts>BOOK</Sts> (versões antigas) e <Sts><Cd>BOOK</Cd></Sts>
+type camtStatus struct {
+	Value string `xml:",chardata"`
+	Code  string `xml:"Cd"`
+}
+
+func (s camtStatus) code() string {
+	if s.Code != "" {
+		return strings.TrimSpace(s.Code)
+	}
+	return strings.TrimSpace(s.Value)
+}
+
+type camtTxInfo struct {
+	EndToEndID     string       `xml:"Refs>EndToEndId"`
+	ServicerRef    string       `xml:"Refs>AcctSvcrRef"`
+	TxID           string       `xml:"Refs>TxId"`
+	Amount         camtAmount   `xml:"Amt"`
+	Indicator      string       `xml:"CdtDbtInd"`
+	Debtor         camtParty    `xml:"RltdPties>Dbtr"`
+	Creditor       camtParty    `xml:"RltdPties>Cdtr"`
+	Unstructured   []string     `xml:"RmtInf>Ustrd"`
+	Structured     []camtStrdRf `xml:"RmtInf>Strd"`
+	AdditionalInfo string       `xml:"AddtlTxInf"`
+}
+
+// camtParty aceita o nome direto (até a versão .07) ou dentro de <Pty> (a partir da .08)
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+func (p camtParty) name() string {
+	if p.PartyName != "" {
+		return strings.TrimSpace(p.PartyName)
+	}
+	return strings.TrimSpace(p.Name)
+}
+
+type camtStrdRf struct {
+	Reference string `xml:"CdtrRefInf>Ref"`
+}
+
+// ParseCAMT lê os lançamentos de um extrato ISO 20022 camt.053 ou camt.052. Lançamentos com
+// vários TxDtls (lotes) geram uma linha por transação; lançamentos pendentes são ignorados.
+func ParseCAMT(content []byte) (*CAMTParseResult, error) {
+	var document camtDocument
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.CharsetReader = decodeXMLCharset
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("erro ao ler XML camt: %w", err)
+	}
+
+	result := &CAMTParseResult{MessageType: "camt.053", Statements: []CAMTStatement{}}
+	statements := document.Statements
+	if len(statements) == 0 {
+		statements = document.Reports
+		result.MessageType = "camt.052"
+	}
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("nenhum extrato camt.053 ou camt.052 encontrado no arquivo")
+	}
+
+	for _, stmt := range statements {
+		statement := CAMTStatement{
+			ID:       stmt.ID,
+			Account:  firstNonEmpty(stmt.IBAN, stmt.OtherID),
+			Currency: stmt.Currency,
+		}
+
+		for _, balance := range stmt.Balances {
+			parsed, err := balance.parse()
+			if err != nil {
+				return nil, fmt.Errorf("extrato %s: %w", stmt.ID, err)
+			}
+			switch parsed.Type {
+			case "OPBD", "PRCD":
+				if statement.OpeningBalance == nil || parsed.Type == "OPBD" {
+					statement.OpeningBalance = parsed
+				}
+			case "CLBD", "ITBD":
+				if statement.ClosingBalance == nil || parsed.Type == "CLBD" {
+					statement.ClosingBalance = parsed
+				}
+			}
+		}
+
+		for i, entry := range stmt.Entries {
+			status := entry.Status.code()
+			if status != "" && status != "BOOK" {
+				statement.SkippedEntries++
+				continue
+			}
+
+			lines, err := entry.lines()
+			if err != nil {
+				return nil, fmt.Errorf("extrato %s, lançamento %d: %w", stmt.ID, i+1, err)
+			}
+			result.Lines = append(result.Lines, lines...)
+			statement.Entries++
+		}
+		result.Statements = append(result.Statements, statement)
+	}
+
+	assignImportLineIDs(result.Lines)
+	return result, nil
+}
+
+// lines converte um lançamento em uma linha por TxDtls (ou uma única linha sem detalhes)
+func (e camtEntry) lines() ([]structs.ImportLine, error) {
+	bookingDate, err := e.BookingDate.parse()
+	if err != nil {
+		return nil, fmt.Errorf("data de lançamento inválida: %w", err)
+	}
+	valueDate, err := e.ValueDate.parse()
+	if err != nil {
+		return nil, fmt.Errorf("data valor inválida: %w", err)
+	}
+	if bookingDate.IsZero() {
+		bookingDate = valueDate
+	}
+	if bookingDate.IsZero() {
+		return nil, fmt.Errorf("lançamento sem data")
+	}
+
+	details := e.Details
+	if len(details) == 0 {
+		details = []camtTxInfo{{}}
+	}
+
+	lines := make([]structs.ImportLine, 0, len(details))
+	for i, detail := range details {
+		// Em lotes, cada TxDtls tem seu próprio valor; sem ele, vale o valor do lançamento
+		amountValue, indicator := detail.Amount.Value, detail.Indicator
+		if amountValue == "" || len(details) == 1 {
+			amountValue = e.Amount.Value
+		}
+		if indicator == "" {
+			indicator = e.Indicator
+		}
+		amount, err := signedCAMTAmount(amountValue, indicator)
+		if err != nil {
+			return nil, err
+		}
+
+		// A contraparte é quem recebe nas saídas e quem paga nas entradas
+		counterparty := detail.Debtor.name()
+		if amount < 0 {
+			counterparty = detail.Creditor.name()
+		}
+
+		remittance := strings.TrimSpace(strings.Join(detail.Unstructured, " "))
+		if remittance == "" {
+			for _, structured := range detail.Structured {
+				remittance = strings.TrimSpace(remittance + " " + structured.Reference)
+			}
+		}
+
+		line := structs.ImportLine{
+			Amount:      amount,
+			Date:        bookingDate,
+			Description: firstNonEmpty(counterparty, strings.TrimSpace(e.AdditionalInfo), remittance),
+			Memo:        firstNonEmpty(remittance, strings.TrimSpace(detail.AdditionalInfo), strings.TrimSpace(e.AdditionalInfo)),
+			ExternalID:  camtExternalID(e, detail, i, len(details)),
+		}
+		if !valueDate.IsZero() {
+			line.ValueDate = &valueDate
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// camtExternalID escolhe a referência mais estável do banco para deduplicar reimportações
+func camtExternalID(entry camtEntry, detail camtTxInfo, index, total int) string {
+	if detail.ServicerRef != "" {
+		return detail.ServicerRef
+	}
+	if detail.TxID != "" {
+		return detail.TxID
+	}
+
+	if reference := firstNonEmpty(entry.ServicerRef, entry.Reference); reference != "" {
+		if total > 1 {
+			return fmt.Sprintf("%s-%d", reference, index+1)
+		}
+		return reference
+	}
+
+	// O EndToEndId é definido pelo pagador e pode se repetir entre meses, por isso fica por último
+	if detail.EndToEndID != "" && detail.EndToEndID != "NOTPROVIDED" {
+		return detail.EndToEndID
+	}
+	return ""
+}
+
+func (b camtBalance) parse() (*CAMTBalance, error) {
+	amount, err := signedCAMTAmount(b.Amount.Value, b.Indicator)
+	if err != nil {
+		return nil, err
+	}
+	date, err := b.Date.parse()
+	if err != nil {
+		return nil, fmt.Errorf("data de saldo inválida: %w", err)
+	}
+	return &CAMTBalance{
+		Type:     strings.TrimSpace(b.Code),
+		Amount:   amount,
+		Currency: b.Amount.Currency,
+		Date:     date,
+	}, nil
+}
+
+func (d camtDate) parse() (time.Time, error) {
+	if value := strings.TrimSpace(d.Date); value != "" {
+		return time.Parse("2006-01-02", value)
+	}
+	if value := strings.TrimSpace(d.DateTime); value != "" {
+		for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04:05.999999999"} {
+			if date, err := time.Parse(layout, value); err == nil {
+				return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("data inválida '%s'", value)
+	}
+	return time.Time{}, nil
+}
+
+// signedCAMTAmount aplica o sinal do indicador CRDT (entrada) ou DBIT (saída)
+func signedCAMTAmount(value string, indicator string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("valor inválido '%s'", value)
+	}
+	if strings.TrimSpace(indicator) == "DBIT" {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// decodeXMLCharset permite ler extratos declarados em ISO-8859-1 ou Windows-1252
+func decodeXMLCharset(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1":
+		return charmap.ISO8859_1.NewDecoder().Reader(input), nil
+	case "windows-1252", "cp1252":
+		return charmap.Windows1252.NewDecoder().Reader(input), nil
+	}
+	return nil, fmt.Errorf("codificação não suportada: %s", charset)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+type camtWantLine struct {
+	amount      float64
+	date        string
+	valueDate   string // Vazio quando o lançamento não informa data valor
+	description string
+	externalID  string
+}
+
+func checkCAMTLines(t *testing.T, result *CAMTParseResult, want []camtWantLine) {
+	t.Helper()
+	if len(result.Lines) != len(want) {
+		t.Fatalf("%d linhas lidas, esperado %d", len(result.Lines), len(want))
+	}
+	for i, w := range want {
+		line := result.Lines[i]
+		if math.Abs(line.Amount-w.amount) > 0.001 {
+			t.Errorf("linha %d: valor = %v, esperado %v", i+1, line.Amount, w.amount)
+		}
+		if got := line.Date.Format("2006-01-02"); got != w.date {
+			t.Errorf("linha %d: data = %s, esperado %s", i+1, got, w.date)
+		}
+		valueDate := ""
+		if line.ValueDate != nil {
+			valueDate = line.ValueDate.Format("2006-01-02")
+		}
+		if valueDate != w.valueDate {
+			t.Errorf("linha %d: data valor = %q, esperado %q", i+1, valueDate, w.valueDate)
+		}
+		if line.Description != w.description {
+			t.Errorf("linha %d: descrição = %q, esperado %q", i+1, line.Description, w.description)
+		}
+		if line.ExternalID != w.externalID {
+			t.Errorf("linha %d: identificador = %q, esperado %q", i+1, line.ExternalID, w.externalID)
+		}
+		if line.ID == "" {
+			t.Errorf("linha %d: sem ID", i+1)
+		}
+	}
+}
+
+func TestParseCAMT053Fixture(t *testing.T) {
+	content, err := os.ReadFile("../examples/fixtures/camt053-exemplo.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ParseCAMT(content)
+	if err != nil {
+		t.Fatalf("ParseCAMT: %v", err)
+	}
+
+	if result.MessageType != "camt.053" || len(result.Statements) != 1 {
+		t.Fatalf("tipo = %s com %d extratos", result.MessageType, len(result.Statements))
+	}
+	statement := result.Statements[0]
+	if statement.Account != "DE89370400440532013000" || statement.Currency != "EUR" {
+		t.Errorf("conta = %s %s", statement.Account, statement.Currency)
+	}
+	if statement.Entries != 3 || statement.SkippedEntries != 1 {
+		t.Errorf("lançamentos = %d, ignorados = %d; esperado 3 e 1 (pendente)", statement.Entries, statement.SkippedEntries)
+	}
+	if statement.OpeningBalance == nil || statement.OpeningBalance.Amount != 1000 {
+		t.Errorf("saldo inicial = %+v", statement.OpeningBalance)
+	}
+	if statement.ClosingBalance == nil || statement.ClosingBalance.Amount != 2345.50 {
+		t.Errorf("saldo final = %+v", statement.ClosingBalance)
+	}
+
+	// O lote BANK-REF-0003 vira uma linha por TxDtls, com sufixo na referência
+	checkCAMTLines(t, result, []camtWantLine{
+		{2500.00, "2024-03-05", "2024-03-04", "ACME GmbH", "BANK-REF-0001"},
+		{-950.00, "2024-03-10", "2024-03-10", "Hausverwaltung Schmidt", "BANK-REF-0002"},
+		{-154.50, "2024-03-15", "", "Stadtwerke", "BANK-REF-0003-1"},
+		{-50.00, "2024-03-15", "", "Telekom", "BANK-REF-0003-2"},
+	})
+	if result.Lines[0].Memo != "Gehalt Maerz 2024" {
+		t.Errorf("memo = %q", result.Lines[0].Memo)
+	}
+}
+
+func TestParseCAMT052Fixture(t *testing.T) {
+	content, err := os.ReadFile("../examples/fixtures/camt052-exemplo.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := ParseCAMT(content)
+	if err != nil {
+		t.Fatalf("ParseCAMT: %v", err)
+	}
+
+	if result.MessageType != "camt.052" || len(result.Statements) != 1 {
+		t.Fatalf("tipo = %s com %d extratos", result.MessageType, len(result.Statements))
+	}
+	statement := result.Statements[0]
+	if statement.Account != "0532013000" {
+		t.Errorf("conta = %s", statement.Account)
+	}
+	if statement.OpeningBalance == nil || statement.OpeningBalance.Type != "PRCD" {
+		t.Errorf("saldo inicial = %+v", statement.OpeningBalance)
+	}
+	if statement.ClosingBalance == nil || statement.ClosingBalance.Type != "ITBD" || statement.ClosingBalance.Amount != 2300 {
+		t.Errorf("saldo final = %+v", statement.ClosingBalance)
+	}
+
+	checkCAMTLines(t, result, []camtWantLine{
+		{-45.50, "2024-04-02", "2024-04-02", "REWE Markt", "KARTE-778899"},
+	})
+	if result.Lines[0].Memo != "Kartenzahlung" {
+		t.Errorf("memo = %q", result.Lines[0].Memo)
+	}
+}
+
+func TestParseCAMTReversal(t *testing.T) {
+	// No estorno (RvslInd), o CdtDbtInd já é o sentido do próprio lançamento de estorno
+	tests := []struct {
+		name      string
+		indicator string
+		want      float64
+	}{
+		{"débito de estorno sai da conta", "DBIT", -19.90},
+		{"crédito de estorno volta para a conta", "CRDT", 19.90},
+	}
+	for _, tt := range tests {
+		content := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Id>STMT-1</Id>
+      <Ntry>
+        <NtryRef>0001</NtryRef>
+        <Amt Ccy="EUR">19.90</Amt>
+        <CdtDbtInd>` + tt.indicator + `</CdtDbtInd>
+        <RvslInd>true</RvslInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2024-03-20</Dt></BookgDt>
+        <AddtlNtryInf>Rücklastschrift</AddtlNtryInf>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`)
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseCAMT(content)
+			if err != nil {
+				t.Fatalf("ParseCAMT: %v", err)
+			}
+			checkCAMTLines(t, result, []camtWantLine{
+				{tt.want, "2024-03-20", "", "Rücklastschrift", "0001"},
+			})
+		})
+	}
+}
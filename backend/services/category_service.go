@@ -23,14 +23,19 @@ func (s *CategoryService) CreateCategory(req structs.CreateCategoryRequest) (*st
 	if err := req.ValidateParentID(); err != nil {
 		return nil, err
 	}
+	taxSection, err := categoryTaxSection(req.TaxSection)
+	if err != nil {
+		return nil, err
+	}
+	req.TaxSection = taxSection
 
 	// Validação: se é uma subcategoria, verificar se a categoria pai existe
 	if req.ParentID != nil {
-		parentCategory, err := s.db.GetCategoryByID(*req.ParentID)
+		parentCategory, err := s.db.GetCategoryByID(*req.ParentID, req.UserID)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao buscar categoria pai: %w", err)
 		}
-		if parentCategory == nil {
+		if parentCategory == nil || parentCategory.UserID != req.UserID {
 			return nil, fmt.Errorf("categoria pai não encontrada")
 		}
 		if !parentCategory.IsActive {
@@ -51,14 +56,14 @@ func (s *CategoryService) CreateCategory(req structs.CreateCategoryRequest) (*st
 	return &category, nil
 }
 
-// GetCategoryByID busca uma categoria pelo ID
-func (s *CategoryService) GetCategoryByID(id string) (*structs.Category, error) {
+// GetCategoryByID busca uma categoria do usuário pelo ID
+func (s *CategoryService) GetCategoryByID(id string, userID string) (*structs.Category, error) {
 	// Validar se o ID é um UUID válido
 	if !utils.IsValidUUID(id) {
 		return nil, fmt.Errorf("ID deve ser um UUID válido")
 	}
 
-	category, err := s.db.GetCategoryByID(id)
+	category, err := s.db.GetCategoryByID(id, userID)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar categoria: %w", err)
 	}
@@ -121,7 +126,7 @@ func (s *CategoryService) GetSubcategories(parentID string, userID string) ([]st
 	}
 
 	// Verificar se a categoria pai existe
-	parentCategory, err := s.db.GetCategoryByID(parentID)
+	parentCategory, err := s.db.GetCategoryByID(parentID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar categoria pai: %w", err)
 	}
@@ -144,13 +149,14 @@ func (s *CategoryService) UpdateCategory(id string, req structs.UpdateCategoryRe
 		return nil, fmt.Errorf("ID deve ser um UUID válido")
 	}
 
-	// Verificar se a categoria existe
-	existingCategory, err := s.db.GetCategoryByID(id)
+	// Verificar se a categoria existe e é do usuário
+	existingCategory, err := s.getOwnedCategory(id, req.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar categoria: %w", err)
+		return nil, err
 	}
-	if existingCategory == nil {
-		return nil, fmt.Errorf("categoria não encontrada")
+
+	if req.TaxSection, err = categoryTaxSection(req.TaxSection); err != nil {
+		return nil, err
 	}
 
 	// Atualizar a categoria
@@ -160,12 +166,7 @@ func (s *CategoryService) UpdateCategory(id string, req structs.UpdateCategoryRe
 
 	// Se a cor foi alterada, atualizar a cor de todas as subcategorias (parent_id = id)
 	if req.Color != "" && req.Color != existingCategory.Color {
-		// Buscar userID da categoria se não vier no request
-		userID := req.UserID
-		if userID == "" {
-			userID = existingCategory.UserID
-		}
-		subcategories, err := s.db.GetSubcategories(id, userID)
+		subcategories, err := s.db.GetSubcategories(id, req.UserID)
 		if err != nil {
 			return nil, fmt.Errorf("erro ao buscar subcategorias: %w", err)
 		}
@@ -179,7 +180,8 @@ func (s *CategoryService) UpdateCategory(id string, req structs.UpdateCategoryRe
 				Icon:        subcategory.Icon,
 				IsActive:    &subcategory.IsActive,
 				Visible:     &subcategory.Visible,
-				UserID:      subcategory.UserID,
+				TaxSection:  subcategory.TaxSection,
+				UserID:      req.UserID,
 			}
 
 			if err := s.db.UpdateCategory(subcategory.ID, updateReq); err != nil {
@@ -189,7 +191,7 @@ func (s *CategoryService) UpdateCategory(id string, req structs.UpdateCategoryRe
 	}
 
 	// Buscar a categoria atualizada
-	updatedCategory, err := s.db.GetCategoryByID(id)
+	updatedCategory, err := s.db.GetCategoryByID(id, req.UserID)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar categoria atualizada: %w", err)
 	}
@@ -204,13 +206,10 @@ func (s *CategoryService) DeleteCategory(id string, userID string) error {
 		return fmt.Errorf("ID deve ser um UUID válido")
 	}
 
-	// Verificar se a categoria existe
-	existingCategory, err := s.db.GetCategoryByID(id)
+	// Verificar se a categoria existe e é do usuário
+	existingCategory, err := s.getOwnedCategory(id, userID)
 	if err != nil {
-		return fmt.Errorf("erro ao buscar categoria: %w", err)
-	}
-	if existingCategory == nil {
-		return fmt.Errorf("categoria não encontrada")
+		return err
 	}
 
 	// Verificar se há transações associadas à categoria pai
@@ -254,37 +253,33 @@ func (s *CategoryService) DeleteCategory(id string, userID string) error {
 	return nil
 }
 
-// HardDeleteCategory remove uma categoria permanentemente
-func (s *CategoryService) HardDeleteCategory(id string) error {
+// HardDeleteCategory remove uma categoria do usuário permanentemente
+func (s *CategoryService) HardDeleteCategory(id string, userID string) error {
 	// Validar se o ID é um UUID válido
 	if !utils.IsValidUUID(id) {
 		return fmt.Errorf("ID deve ser um UUID válido")
 	}
 
-	// Verificar se a categoria existe
-	existingCategory, err := s.db.GetCategoryByID(id)
-	if err != nil {
-		return fmt.Errorf("erro ao buscar categoria: %w", err)
-	}
-	if existingCategory == nil {
-		return fmt.Errorf("categoria não encontrada")
+	// Verificar se a categoria existe e é do usuário
+	if _, err := s.getOwnedCategory(id, userID); err != nil {
+		return err
 	}
 
 	// Buscar subcategorias (incluindo as deletadas para hard delete)
-	subcategories, err := s.db.GetSubcategoriesIncludingDeleted(id)
+	subcategories, err := s.db.GetSubcategoriesIncludingDeleted(id, userID)
 	if err != nil {
 		return fmt.Errorf("erro ao verificar subcategorias: %w", err)
 	}
 
 	// Excluir permanentemente todas as subcategorias primeiro
 	for _, subcategory := range subcategories {
-		if err := s.db.HardDeleteCategory(subcategory.ID); err != nil {
+		if err := s.db.HardDeleteCategory(subcategory.ID, userID); err != nil {
 			return fmt.Errorf("erro ao excluir permanentemente subcategoria %s: %w", subcategory.Name, err)
 		}
 	}
 
 	// Excluir permanentemente a categoria pai
-	if err := s.db.HardDeleteCategory(id); err != nil {
+	if err := s.db.HardDeleteCategory(id, userID); err != nil {
 		return fmt.Errorf("erro ao excluir categoria permanentemente: %w", err)
 	}
 
@@ -298,13 +293,10 @@ func (s *CategoryService) UpdateCategoryColor(id string, color string, userID st
 		return nil, fmt.Errorf("ID deve ser um UUID válido")
 	}
 
-	// Verificar se a categoria existe
-	existingCategory, err := s.db.GetCategoryByID(id)
+	// Verificar se a categoria existe e é do usuário
+	existingCategory, err := s.getOwnedCategory(id, userID)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao buscar categoria: %w", err)
-	}
-	if existingCategory == nil {
-		return nil, fmt.Errorf("categoria não encontrada")
+		return nil, err
 	}
 
 	// Criar request apenas com a cor atualizada
@@ -315,6 +307,8 @@ func (s *CategoryService) UpdateCategoryColor(id string, color string, userID st
 		Icon:        existingCategory.Icon,
 		IsActive:    &existingCategory.IsActive,
 		Visible:     &existingCategory.Visible,
+		TaxSection:  existingCategory.TaxSection,
+		UserID:      userID,
 	}
 
 	// Atualizar a categoria
@@ -338,6 +332,8 @@ func (s *CategoryService) UpdateCategoryColor(id string, color string, userID st
 				Icon:        subcategory.Icon,
 				IsActive:    &subcategory.IsActive,
 				Visible:     &subcategory.Visible,
+				TaxSection:  subcategory.TaxSection,
+				UserID:      userID,
 			}
 
 			if err := s.db.UpdateCategory(subcategory.ID, subUpdateReq); err != nil {
@@ -347,10 +343,34 @@ func (s *CategoryService) UpdateCategoryColor(id string, color string, userID st
 	}
 
 	// Buscar a categoria atualizada
-	updatedCategory, err := s.db.GetCategoryByID(id)
+	updatedCategory, err := s.db.GetCategoryByID(id, userID)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar categoria atualizada: %w", err)
 	}
 
 	return updatedCategory, nil
 }
+
+// getOwnedCategory busca uma categoria que o usuário pode alterar; categorias de sistema (sem dono)
+// e de outros usuários são tratadas como não encontradas
+func (s *CategoryService) getOwnedCategory(id string, userID string) (*structs.Category, error) {
+	category, err := s.db.GetCategoryByID(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categoria: %w", err)
+	}
+	if category == nil || category.UserID != userID {
+		return nil, fmt.Errorf("categoria não encontrada")
+	}
+	return category, nil
+}
+
+// categoryTaxSection valida a seção do IRPF da categoria; vazia vira nil (categoria não marcada)
+func categoryTaxSection(section *string) (*string, error) {
+	if section == nil || *section == "" {
+		return nil, nil
+	}
+	if err := structs.ValidateTaxSection(*section, false); err != nil {
+		return nil, err
+	}
+	return section, nil
+}
@@ -0,0 +1,611 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// csvDelimiters são os separadores aceitos, em ordem de preferência
+var csvDelimiters = []string{";", ",", "\t", "|"}
+
+// csvDateFormats são os formatos de data tentados na detecção. O formato brasileiro
+// (dia/mês) vem antes do americano para resolver datas ambíguas.
+var csvDateFormats = []string{
+	"02/01/2006",
+	"2006-01-02",
+	"02-01-2006",
+	"02.01.2006",
+	"02/01/06",
+	"2006/01/02",
+	"01/02/2006",
+	"2006-01-02T15:04:05",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+}
+
+// commaDecimalPattern reconhece valores como 1.234,56 ou -12,3
+var commaDecimalPattern = regexp.MustCompile(`^-?[\d.]*\d,\d{1,2}$`)
+
+// csvPresets são os mapeamentos prontos para os CSVs de bancos comuns
+var csvPresets = []structs.CSVPreset{
+	{
+		ID:   "nubank-cartao",
+		Name: "Nubank - fatura do cartão",
+		CSVMapping: structs.CSVMapping{
+			Delimiter:         ",",
+			DecimalSeparator:  ".",
+			DateFormat:        "2006-01-02",
+			DateColumn:        "date",
+			DescriptionColumn: "title",
+			AmountColumn:      "amount",
+			CategoryColumn:    "category",
+			InvertAmount:      true,
+			HasHeader:         true,
+		},
+	},
+	{
+		ID:   "nubank-conta",
+		Name: "Nubank - extrato da conta",
+		CSVMapping: structs.CSVMapping{
+			Delimiter:         ",",
+			DecimalSeparator:  ".",
+			DateFormat:        "02/01/2006",
+			DateColumn:        "Data",
+			DescriptionColumn: "Descrição",
+			AmountColumn:      "Valor",
+			ExternalIDColumn:  "Identificador",
+			HasHeader:         true,
+		},
+	},
+	{
+		ID:   "inter-conta",
+		Name: "Banco Inter - extrato da conta",
+		CSVMapping: structs.CSVMapping{
+			Delimiter:         ";",
+			DecimalSeparator:  ",",
+			DateFormat:        "02/01/2006",
+			DateColumn:        "Data Lançamento",
+			DescriptionColumn: "Descrição",
+			MemoColumn:        "Histórico",
+			AmountColumn:      "Valor",
+			HasHeader:         true,
+		},
+	},
+	{
+		ID:   "c6-cartao",
+		Name: "C6 Bank - fatura do cartão",
+		CSVMapping: structs.CSVMapping{
+			Delimiter:         ";",
+			DateFormat:        "02/01/2006",
+			DateColumn:        "Data de Compra",
+			DescriptionColumn: "Descrição",
+			MemoColumn:        "Parcela",
+			AmountColumn:      "Valor (em R$)",
+			CategoryColumn:    "Categoria",
+			InvertAmount:      true,
+			HasHeader:         true,
+		},
+	},
+}
+
+// GetCSVPresets retorna os mapeamentos prontos disponíveis
+func GetCSVPresets() []structs.CSVPreset {
+	return csvPresets
+}
+
+// GetCSVPreset busca um mapeamento pronto pelo ID
+func GetCSVPreset(id string) *structs.CSVPreset {
+	for i := range csvPresets {
+		if csvPresets[i].ID == id {
+			return &csvPresets[i]
+		}
+	}
+	return nil
+}
+
+// csvTable é o conteúdo de um CSV já decodificado e separado em células
+type csvTable struct {
+	detection structs.CSVDetection
+	rows      [][]string
+}
+
+// readCSVTable decodifica o arquivo e separa as células, detectando codificação e
+// delimitador quando o mapeamento não os define
+func readCSVTable(content []byte, mapping structs.CSVMapping) (*csvTable, error) {
+	text, encoding, err := decodeCSVContent(content, mapping.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	delimiter := mapping.Delimiter
+	if delimiter == "" {
+		delimiter = detectCSVDelimiter(text)
+	}
+
+	rows, err := splitCSV(text, delimiter)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("arquivo CSV vazio")
+	}
+
+	return &csvTable{
+		detection: structs.CSVDetection{
+			Delimiter: delimiter,
+			Encoding:  encoding,
+		},
+		rows: rows,
+	}, nil
+}
+
+// decodeCSVContent converte o arquivo para UTF-8. Sem codificação informada, arquivos que
+// não são UTF-8 válido são tratados como Windows-1252 (comum em exportações de bancos)
+func decodeCSVContent(content []byte, encoding string) (string, string, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+	if encoding == "" {
+		if utf8.Valid(content) {
+			encoding = "utf-8"
+		} else {
+			encoding = "windows-1252"
+		}
+	}
+
+	switch encoding {
+	case "utf-8", "utf8":
+		return string(content), "utf-8", nil
+	case "windows-1252", "cp1252":
+		decoded, err := charmap.Windows1252.NewDecoder().Bytes(content)
+		if err != nil {
+			return "", "", fmt.Errorf("erro ao decodificar arquivo: %w", err)
+		}
+		return string(decoded), "windows-1252", nil
+	case "iso-8859-1", "latin1", "latin-1":
+		decoded, err := charmap.ISO8859_1.NewDecoder().Bytes(content)
+		if err != nil {
+			return "", "", fmt.Errorf("erro ao decodificar arquivo: %w", err)
+		}
+		return string(decoded), "iso-8859-1", nil
+	default:
+		return "", "", fmt.Errorf("codificação não suportada: %s", encoding)
+	}
+}
+
+// detectCSVDelimiter escolhe o separador que divide as primeiras linhas no mesmo número de colunas
+func detectCSVDelimiter(text string) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+		if len(lines) == 20 {
+			break
+		}
+	}
+	sample := strings.Join(lines, "\n")
+
+	best, bestScore := ",", 0
+	for _, delimiter := range csvDelimiters {
+		rows, err := splitCSV(sample, delimiter)
+		if err != nil {
+			continue
+		}
+
+		frequency := make(map[int]int)
+		for _, row := range rows {
+			if len(row) > 1 {
+				frequency[len(row)]++
+			}
+		}
+
+		// Pontuação: linhas com a contagem de colunas mais comum, ponderada pelo número de colunas
+		for fields, count := range frequency {
+			if score := count * fields; score > bestScore {
+				best, bestScore = delimiter, score
+			}
+		}
+	}
+	return best
+}
+
+// splitCSV separa as células respeitando aspas; linhas podem ter números diferentes de colunas
+func splitCSV(text string, delimiter string) ([][]string, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	rows := [][]string{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler CSV: %w", err)
+		}
+		if isBlankRow(record) {
+			continue
+		}
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+// DetectCSV analisa o arquivo sem mapeamento de colunas: retorna delimitador, codificação,
+// cabeçalho provável, algumas linhas de exemplo e o preset que reconhece o arquivo, se houver
+func DetectCSV(content []byte) (*structs.CSVDetection, error) {
+	table, err := readCSVTable(content, structs.CSVMapping{})
+	if err != nil {
+		return nil, err
+	}
+
+	detection := table.detection
+	for _, preset := range csvPresets {
+		if preset.Delimiter != "" && preset.Delimiter != detection.Delimiter {
+			continue
+		}
+		if headerRow := findHeaderRow(table.rows, preset.CSVMapping); headerRow >= 0 {
+			detection.PresetID = preset.ID
+			detection.HeaderRow = headerRow + 1
+			detection.Columns = table.rows[headerRow]
+			detection.SampleRows = sampleRows(table.rows[headerRow+1:])
+			return &detection, nil
+		}
+	}
+
+	// Sem preset: a primeira linha com o número de colunas mais comum é o cabeçalho provável
+	headerRow := widestRow(table.rows)
+	detection.HeaderRow = headerRow + 1
+	detection.Columns = table.rows[headerRow]
+	detection.SampleRows = sampleRows(table.rows[headerRow+1:])
+	return &detection, nil
+}
+
+// ParseCSV lê as linhas de extrato de um CSV usando o mapeamento informado
+func ParseCSV(content []byte, mapping structs.CSVMapping) ([]structs.ImportLine, *structs.CSVDetection, error) {
+	if mapping.DateColumn == "" || mapping.DescriptionColumn == "" {
+		return nil, nil, fmt.Errorf("as colunas de data e descrição são obrigatórias")
+	}
+	if mapping.AmountColumn == "" && mapping.DebitColumn == "" && mapping.CreditColumn == "" {
+		return nil, nil, fmt.Errorf("informe a coluna de valor ou as colunas de débito e crédito")
+	}
+
+	table, err := readCSVTable(content, mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+	detection := table.detection
+
+	// Localizar o cabeçalho (pode haver linhas de título antes dele, como no extrato do Inter)
+	var header []string
+	dataRows := table.rows
+	if mapping.HasHeader {
+		headerRow := findHeaderRow(table.rows, mapping)
+		if headerRow < 0 {
+			return nil, nil, fmt.Errorf("cabeçalho com as colunas do mapeamento não encontrado no arquivo")
+		}
+		header = table.rows[headerRow]
+		dataRows = table.rows[headerRow+1:]
+		detection.HeaderRow = headerRow + 1
+	}
+	detection.Columns = header
+
+	columns := map[string]int{}
+	for name, reference := range map[string]string{
+		"date":        mapping.DateColumn,
+		"description": mapping.DescriptionColumn,
+		"memo":        mapping.MemoColumn,
+		"amount":      mapping.AmountColumn,
+		"debit":       mapping.DebitColumn,
+		"credit":      mapping.CreditColumn,
+		"category":    mapping.CategoryColumn,
+		"external_id": mapping.ExternalIDColumn,
+	} {
+		if reference == "" {
+			continue
+		}
+		index, err := resolveCSVColumn(header, reference)
+		if err != nil {
+			return nil, nil, err
+		}
+		columns[name] = index
+	}
+
+	detection.DateFormat = mapping.DateFormat
+	if detection.DateFormat == "" {
+		detection.DateFormat = detectDateFormat(columnValues(dataRows, columns["date"]))
+		if detection.DateFormat == "" {
+			return nil, nil, fmt.Errorf("não foi possível detectar o formato das datas. Informe date_format")
+		}
+	}
+
+	detection.DecimalSeparator = mapping.DecimalSeparator
+	if detection.DecimalSeparator == "" {
+		values := []string{}
+		for _, name := range []string{"amount", "debit", "credit"} {
+			if index, ok := columns[name]; ok {
+				values = append(values, columnValues(dataRows, index)...)
+			}
+		}
+		detection.DecimalSeparator = detectDecimalSeparator(values)
+	}
+	detection.SampleRows = sampleRows(dataRows)
+
+	lines := []structs.ImportLine{}
+	for i, row := range dataRows {
+		rowNumber := detection.HeaderRow + i + 1
+
+		dateValue := cell(row, columns["date"])
+		if dateValue == "" {
+			// Linhas de total ou rodapé não têm data
+			continue
+		}
+		date, err := time.Parse(detection.DateFormat, dateValue)
+		if err != nil {
+			return nil, nil, fmt.Errorf("linha %d: data inválida '%s'", rowNumber, dateValue)
+		}
+
+		amount, err := csvRowAmount(row, columns, detection.DecimalSeparator)
+		if err != nil {
+			return nil, nil, fmt.Errorf("linha %d: %w", rowNumber, err)
+		}
+		if mapping.InvertAmount {
+			amount = -amount
+		}
+		if amount == 0 {
+			continue
+		}
+
+		line := structs.ImportLine{
+			Amount:      amount,
+			Date:        date,
+			Description: cell(row, columns["description"]),
+		}
+		if index, ok := columns["memo"]; ok {
+			line.Memo = cell(row, index)
+		}
+		if index, ok := columns["category"]; ok {
+			line.Category = cell(row, index)
+		}
+		if index, ok := columns["external_id"]; ok {
+			line.ExternalID = cell(row, index)
+		}
+		lines = append(lines, line)
+	}
+
+	assignImportLineIDs(lines)
+	return lines, &detection, nil
+}
+
+// csvRowAmount lê o valor com sinal de uma linha a partir da coluna de valor ou de débito/crédito
+func csvRowAmount(row []string, columns map[string]int, decimalSeparator string) (float64, error) {
+	if index, ok := columns["amount"]; ok {
+		return parseCSVAmount(cell(row, index), decimalSeparator)
+	}
+
+	amount := 0.0
+	if index, ok := columns["credit"]; ok {
+		credit, err := parseCSVAmount(cell(row, index), decimalSeparator)
+		if err != nil {
+			return 0, err
+		}
+		amount += absFloat(credit)
+	}
+	if index, ok := columns["debit"]; ok {
+		debit, err := parseCSVAmount(cell(row, index), decimalSeparator)
+		if err != nil {
+			return 0, err
+		}
+		amount -= absFloat(debit)
+	}
+	return amount, nil
+}
+
+// parseCSVAmount converte valores como "R$ -1.234,56", "(12,30)" ou "1234.56"
+func parseCSVAmount(value string, decimalSeparator string) (float64, error) {
+	original := value
+	value = strings.TrimSpace(strings.ReplaceAll(value, "R$", ""))
+	value = strings.ReplaceAll(value, " ", "")
+	if value == "" {
+		return 0, nil
+	}
+
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = strings.Trim(value, "()")
+	}
+	if strings.HasSuffix(value, "-") {
+		negative = true
+		value = strings.TrimSuffix(value, "-")
+	}
+	if strings.HasPrefix(value, "-") {
+		negative = !negative
+		value = strings.TrimPrefix(value, "-")
+	}
+	value = strings.TrimPrefix(value, "+")
+
+	if decimalSeparator == "," {
+		value = strings.ReplaceAll(value, ".", "")
+		value = strings.ReplaceAll(value, ",", ".")
+	} else {
+		value = strings.ReplaceAll(value, ",", "")
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("valor inválido '%s'", original)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// detectDateFormat retorna o primeiro formato que interpreta todas as datas informadas
+func detectDateFormat(values []string) string {
+	for _, format := range csvDateFormats {
+		matched := 0
+		for _, value := range values {
+			if _, err := time.Parse(format, value); err != nil {
+				matched = -1
+				break
+			}
+			matched++
+		}
+		if matched > 0 {
+			return format
+		}
+	}
+	return ""
+}
+
+// detectDecimalSeparator identifica vírgula decimal (padrão brasileiro) ou ponto
+func detectDecimalSeparator(values []string) string {
+	comma, dot := 0, 0
+	for _, value := range values {
+		value = strings.ReplaceAll(strings.ReplaceAll(value, "R$", ""), " ", "")
+		value = strings.Trim(value, "()+")
+		if value == "" {
+			continue
+		}
+		if commaDecimalPattern.MatchString(value) {
+			comma++
+		} else if strings.Contains(value, ".") {
+			dot++
+		}
+	}
+	if comma > dot {
+		return ","
+	}
+	return "."
+}
+
+// findHeaderRow retorna a primeira linha que contém todas as colunas nomeadas do mapeamento
+func findHeaderRow(rows [][]string, mapping structs.CSVMapping) int {
+	references := []string{}
+	for _, reference := range []string{mapping.DateColumn, mapping.DescriptionColumn, mapping.AmountColumn, mapping.DebitColumn, mapping.CreditColumn} {
+		if reference != "" && !isColumnPosition(reference) {
+			references = append(references, reference)
+		}
+	}
+	if len(references) == 0 {
+		return 0
+	}
+
+	for i, row := range rows {
+		found := true
+		for _, reference := range references {
+			if _, err := resolveCSVColumn(row, reference); err != nil {
+				found = false
+				break
+			}
+		}
+		if found {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveCSVColumn encontra a coluna pelo nome do cabeçalho (sem diferenciar acentos) ou pela posição
+func resolveCSVColumn(header []string, reference string) (int, error) {
+	if isColumnPosition(reference) {
+		position, _ := strconv.Atoi(reference)
+		if position < 1 {
+			return 0, fmt.Errorf("posição de coluna inválida: %s", reference)
+		}
+		return position - 1, nil
+	}
+
+	normalized := utils.NormalizeText(reference)
+	for i, name := range header {
+		if utils.NormalizeText(name) == normalized {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("coluna '%s' não encontrada no arquivo", reference)
+}
+
+func isColumnPosition(reference string) bool {
+	_, err := strconv.Atoi(reference)
+	return err == nil
+}
+
+// widestRow retorna a primeira linha com o número de colunas mais frequente
+func widestRow(rows [][]string) int {
+	frequency := make(map[int]int)
+	for _, row := range rows {
+		frequency[len(row)]++
+	}
+	mostCommon := 0
+	for fields, count := range frequency {
+		if count > frequency[mostCommon] || (count == frequency[mostCommon] && fields > mostCommon) {
+			mostCommon = fields
+		}
+	}
+	for i, row := range rows {
+		if len(row) == mostCommon {
+			return i
+		}
+	}
+	return 0
+}
+
+func columnValues(rows [][]string, index int) []string {
+	values := []string{}
+	for _, row := range rows {
+		if value := cell(row, index); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func sampleRows(rows [][]string) [][]string {
+	if len(rows) > 5 {
+		rows = rows[:5]
+	}
+	return rows
+}
+
+func cell(row []string, index int) string {
+	if index < 0 || index >= len(row) {
+		return ""
+	}
+	return row[index]
+}
+
+func isBlankRow(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func absFloat(value float64) float64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
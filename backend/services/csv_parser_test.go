@@ -0,0 +1,292 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestCSVPresets(t *testing.T) {
+	tests := []struct {
+		preset  string
+		content string
+		// want são os valores, datas e descrições esperados, na ordem do arquivo
+		want []struct {
+			amount      float64
+			date        string
+			description string
+		}
+		category   string // Categoria da primeira linha, quando o preset a lê
+		externalID string // Identificador da primeira linha, quando o preset o lê
+		memo       string // Memo da primeira linha, quando o preset o lê
+	}{
+		{
+			preset: "nubank-cartao",
+			content: "date,category,title,amount\n" +
+				"2024-03-05,restaurante,Padaria Central,25.90\n" +
+				"2024-03-06,transporte,Estorno Uber,-10.00\n",
+			want: []struct {
+				amount      float64
+				date        string
+				description string
+			}{
+				{-25.90, "2024-03-05", "Padaria Central"},
+				{10.00, "2024-03-06", "Estorno Uber"},
+			},
+			category: "restaurante",
+		},
+		{
+			preset: "nubank-conta",
+			content: "Data,Valor,Identificador,Descrição\n" +
+				"05/03/2024,-50.00,id-1,Transferência enviada pelo Pix\n" +
+				"06/03/2024,1500.00,id-2,Salário\n",
+			want: []struct {
+				amount      float64
+				date        string
+				description string
+			}{
+				{-50.00, "2024-03-05", "Transferência enviada pelo Pix"},
+				{1500.00, "2024-03-06", "Salário"},
+			},
+			externalID: "id-1",
+		},
+		{
+			preset: "inter-conta",
+			content: "Extrato Conta Corrente\n" +
+				"Conta ;12345678\n" +
+				"Período ;01/03/2024 a 31/03/2024\n" +
+				"\n" +
+				"Data Lançamento;Histórico;Descrição;Valor;Saldo\n" +
+				"05/03/2024;Pix enviado;Maria Silva;-1.234,56;8.765,44\n" +
+				"07/03/2024;Pix recebido;João Souza;300,00;9.065,44\n",
+			want: []struct {
+				amount      float64
+				date        string
+				description string
+			}{
+				{-1234.56, "2024-03-05", "Maria Silva"},
+				{300.00, "2024-03-07", "João Souza"},
+			},
+			memo: "Pix enviado",
+		},
+		{
+			preset: "c6-cartao",
+			content: "Data de Compra;Nome no Cartão;Final do Cartão;Categoria;Descrição;Parcela;Valor (em US$);Cotação (em R$);Valor (em R$)\n" +
+				"05/03/2024;FULANO DE TAL;1234;Restaurante;PADARIA CENTRAL;Única;0;0;1.045,90\n" +
+				"08/03/2024;FULANO DE TAL;1234;Eletrônicos;LOJA;2/10;0;0;199,99\n",
+			want: []struct {
+				amount      float64
+				date        string
+				description string
+			}{
+				{-1045.90, "2024-03-05", "PADARIA CENTRAL"},
+				{-199.99, "2024-03-08", "LOJA"},
+			},
+			category: "Restaurante",
+			memo:     "Única",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			preset := GetCSVPreset(tt.preset)
+			if preset == nil {
+				t.Fatalf("preset %s não encontrado", tt.preset)
+			}
+
+			detection, err := DetectCSV([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("DetectCSV: %v", err)
+			}
+			if detection.PresetID != tt.preset {
+				t.Errorf("preset detectado = %q, esperado %q", detection.PresetID, tt.preset)
+			}
+
+			lines, _, err := ParseCSV([]byte(tt.content), preset.CSVMapping)
+			if err != nil {
+				t.Fatalf("ParseCSV: %v", err)
+			}
+			if len(lines) != len(tt.want) {
+				t.Fatalf("%d linhas lidas, esperado %d", len(lines), len(tt.want))
+			}
+			for i, want := range tt.want {
+				line := lines[i]
+				if math.Abs(line.Amount-want.amount) > 0.001 {
+					t.Errorf("linha %d: valor = %v, esperado %v", i+1, line.Amount, want.amount)
+				}
+				if got := line.Date.Format("2006-01-02"); got != want.date {
+					t.Errorf("linha %d: data = %s, esperado %s", i+1, got, want.date)
+				}
+				if line.Description != want.description {
+					t.Errorf("linha %d: descrição = %q, esperado %q", i+1, line.Description, want.description)
+				}
+				if line.ID == "" {
+					t.Errorf("linha %d: sem ID", i+1)
+				}
+			}
+			if lines[0].Category != tt.category {
+				t.Errorf("categoria = %q, esperado %q", lines[0].Category, tt.category)
+			}
+			if lines[0].ExternalID != tt.externalID {
+				t.Errorf("identificador = %q, esperado %q", lines[0].ExternalID, tt.externalID)
+			}
+			if lines[0].Memo != tt.memo {
+				t.Errorf("memo = %q, esperado %q", lines[0].Memo, tt.memo)
+			}
+		})
+	}
+}
+
+func TestParseCSVAmount(t *testing.T) {
+	tests := []struct {
+		value     string
+		separator string
+		want      float64
+	}{
+		{"1.234,56", ",", 1234.56},
+		{"1,234.56", ".", 1234.56},
+		{"-1.234,56", ",", -1234.56},
+		{"-1,234.56", ".", -1234.56},
+		{"R$ 1.234,56", ",", 1234.56},
+		{"(12,30)", ",", -12.30},
+		{"12,30-", ",", -12.30},
+		{"+99.90", ".", 99.90},
+		{"1234.56", ".", 1234.56},
+		{"", ",", 0},
+	}
+	for _, tt := range tests {
+		got, err := parseCSVAmount(tt.value, tt.separator)
+		if err != nil {
+			t.Errorf("parseCSVAmount(%q, %q): %v", tt.value, tt.separator, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("parseCSVAmount(%q, %q) = %v, esperado %v", tt.value, tt.separator, got, tt.want)
+		}
+	}
+
+	if _, err := parseCSVAmount("abc", "."); err == nil {
+		t.Error("parseCSVAmount(\"abc\") deveria falhar")
+	}
+}
+
+func TestDetectDecimalSeparator(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{"vírgula com milhar", []string{"1.234,56", "-12,30", "R$ 5,00"}, ","},
+		{"ponto com milhar", []string{"1,234.56", "-12.30", "5.00"}, "."},
+		{"vírgula sem milhar", []string{"10,5", "(3,20)"}, ","},
+		{"inteiros", []string{"10", "20"}, "."},
+	}
+	for _, tt := range tests {
+		if got := detectDecimalSeparator(tt.values); got != tt.want {
+			t.Errorf("%s: separador = %q, esperado %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectCSVDelimiter(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"ponto e vírgula com vírgula decimal", "Data;Descrição;Valor\n01/03/2024;Mercado;-10,50\n02/03/2024;Padaria;-3,20\n", ";"},
+		{"vírgula", "Data,Descrição,Valor\n01/03/2024,Mercado,-10.50\n02/03/2024,Padaria,-3.20\n", ","},
+		{"tabulação", "Data\tDescrição\tValor\n01/03/2024\tMercado\t-10,50\n", "\t"},
+		{"barra vertical", "Data|Descrição|Valor\n01/03/2024|Mercado|-10,50\n", "|"},
+	}
+	for _, tt := range tests {
+		if got := detectCSVDelimiter(tt.text); got != tt.want {
+			t.Errorf("%s: delimitador = %q, esperado %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetectDateFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		// Datas ambíguas ficam no formato brasileiro
+		{"dia/mês ambíguo", []string{"05/03/2024", "06/03/2024"}, "02/01/2006"},
+		{"dia/mês com dia acima de 12", []string{"25/03/2024"}, "02/01/2006"},
+		{"mês/dia americano", []string{"03/25/2024"}, "01/02/2006"},
+		{"ISO", []string{"2024-03-25"}, "2006-01-02"},
+		{"inválida", []string{"ontem"}, ""},
+	}
+	for _, tt := range tests {
+		if got := detectDateFormat(tt.values); got != tt.want {
+			t.Errorf("%s: formato = %q, esperado %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeCSVContent(t *testing.T) {
+	latin, err := charmap.Windows1252.NewEncoder().String("Descrição;Valor\nPão de queijo;-5,00\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		content      []byte
+		encoding     string
+		want         string
+		wantEncoding string
+	}{
+		{"UTF-8 com BOM", []byte("\xef\xbb\xbfDescrição"), "", "Descrição", "utf-8"},
+		{"Windows-1252 detectado", []byte(latin), "", "Descrição;Valor\nPão de queijo;-5,00\n", "windows-1252"},
+		{"ISO-8859-1 informado", []byte(latin), "latin1", "Descrição;Valor\nPão de queijo;-5,00\n", "iso-8859-1"},
+	}
+	for _, tt := range tests {
+		got, encoding, err := decodeCSVContent(tt.content, tt.encoding)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want || encoding != tt.wantEncoding {
+			t.Errorf("%s: = (%q, %q), esperado (%q, %q)", tt.name, got, encoding, tt.want, tt.wantEncoding)
+		}
+	}
+
+	if _, _, err := decodeCSVContent([]byte("a"), "utf-16"); err == nil {
+		t.Error("codificação não suportada deveria falhar")
+	}
+}
+
+func TestParseCSVDebitCreditColumns(t *testing.T) {
+	content := "Data;Histórico;Débito;Crédito\n" +
+		"05/03/2024;Tarifa;12,50;\n" +
+		"06/03/2024;Depósito;;1.000,00\n" +
+		";Total;12,50;1.000,00\n"
+	lines, detection, err := ParseCSV([]byte(content), structs.CSVMapping{
+		DateColumn:        "Data",
+		DescriptionColumn: "Histórico",
+		DebitColumn:       "Débito",
+		CreditColumn:      "Crédito",
+		HasHeader:         true,
+	})
+	if err != nil {
+		t.Fatalf("ParseCSV: %v", err)
+	}
+	if detection.Delimiter != ";" || detection.DecimalSeparator != "," || detection.DateFormat != "02/01/2006" {
+		t.Errorf("detecção = %+v", detection)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("%d linhas lidas, esperado 2 (a linha de total não tem data)", len(lines))
+	}
+	if lines[0].Amount != -12.50 || lines[1].Amount != 1000 {
+		t.Errorf("valores = %v e %v, esperado -12.5 e 1000", lines[0].Amount, lines[1].Amount)
+	}
+	if !lines[1].Date.Equal(time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("data = %v", lines[1].Date)
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+type CSVService struct {
+	db *database.Database
+}
+
+// NewCSVService cria uma nova instância do serviço de importação de CSV
+func NewCSVService(db *database.Database) *CSVService {
+	return &CSVService{db: db}
+}
+
+// ResolveMapping escolhe o mapeamento de colunas da importação: um perfil salvo, um preset,
+// um mapeamento enviado como JSON ou, na falta deles, o preset que reconhece o arquivo.
+// Retorna nil quando nenhum mapeamento se aplica e o usuário precisa mapear as colunas.
+func (s *CSVService) ResolveMapping(userID, profileID, presetID, mappingJSON string, content []byte) (*structs.CSVMapping, *structs.CSVDetection, error) {
+	switch {
+	case profileID != "":
+		profile, err := s.GetProfileByID(profileID, userID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &profile.CSVMapping, nil, nil
+	case presetID != "":
+		preset := GetCSVPreset(presetID)
+		if preset == nil {
+			return nil, nil, fmt.Errorf("preset '%s' não encontrado", presetID)
+		}
+		return &preset.CSVMapping, nil, nil
+	case mappingJSON != "":
+		var mapping structs.CSVMapping
+		if err := json.Unmarshal([]byte(mappingJSON), &mapping); err != nil {
+			return nil, nil, fmt.Errorf("mapeamento inválido: %w", err)
+		}
+		if err := validateCSVMapping(mapping); err != nil {
+			return nil, nil, err
+		}
+		return &mapping, nil, nil
+	}
+
+	detection, err := DetectCSV(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	if preset := GetCSVPreset(detection.PresetID); preset != nil {
+		return &preset.CSVMapping, detection, nil
+	}
+	return nil, detection, nil
+}
+
+// CreateProfile salva um mapeamento de colunas com um nome
+func (s *CSVService) CreateProfile(req structs.CSVProfileRequest) (*structs.CSVProfile, error) {
+	if err := validateCSVMapping(req.CSVMapping); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	profile := structs.CSVProfile{
+		ID:         utils.GenerateUUID(),
+		UserID:     req.UserID,
+		Name:       strings.TrimSpace(req.Name),
+		CSVMapping: req.CSVMapping,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := s.db.CreateCSVProfile(profile); err != nil {
+		return nil, fmt.Errorf("erro ao criar perfil de CSV: %w", err)
+	}
+	return &profile, nil
+}
+
+// GetProfiles lista os perfis de CSV do usuário
+func (s *CSVService) GetProfiles(userID string) ([]structs.CSVProfile, error) {
+	profiles, err := s.db.GetCSVProfilesByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar perfis de CSV: %w", err)
+	}
+	return profiles, nil
+}
+
+// GetProfileByID busca um perfil de CSV do usuário
+func (s *CSVService) GetProfileByID(id string, userID string) (*structs.CSVProfile, error) {
+	if !utils.IsValidUUID(id) {
+		return nil, fmt.Errorf("ID deve ser um UUID válido")
+	}
+
+	profile, err := s.db.GetCSVProfileByID(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar perfil de CSV: %w", err)
+	}
+	if profile == nil {
+		return nil, fmt.Errorf("perfil de CSV não encontrado")
+	}
+	return profile, nil
+}
+
+// UpdateProfile atualiza um perfil de CSV
+func (s *CSVService) UpdateProfile(id string, req structs.CSVProfileRequest) (*structs.CSVProfile, error) {
+	profile, err := s.GetProfileByID(id, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCSVMapping(req.CSVMapping); err != nil {
+		return nil, err
+	}
+
+	profile.Name = strings.TrimSpace(req.Name)
+	profile.CSVMapping = req.CSVMapping
+	if err := s.db.UpdateCSVProfile(*profile); err != nil {
+		return nil, fmt.Errorf("erro ao atualizar perfil de CSV: %w", err)
+	}
+	return s.GetProfileByID(id, req.UserID)
+}
+
+// DeleteProfile remove um perfil de CSV
+func (s *CSVService) DeleteProfile(id string, userID string) error {
+	if _, err := s.GetProfileByID(id, userID); err != nil {
+		return err
+	}
+	if err := s.db.DeleteCSVProfile(id, userID); err != nil {
+		return fmt.Errorf("erro ao excluir perfil de CSV: %w", err)
+	}
+	return nil
+}
+
+// validateCSVMapping verifica se o mapeamento tem as colunas obrigatórias e opções conhecidas
+func validateCSVMapping(mapping structs.CSVMapping) error {
+	if strings.TrimSpace(mapping.DateColumn) == "" || strings.TrimSpace(mapping.DescriptionColumn) == "" {
+		return fmt.Errorf("as colunas de data e descrição são obrigatórias")
+	}
+	if mapping.AmountColumn == "" && mapping.DebitColumn == "" && mapping.CreditColumn == "" {
+		return fmt.Errorf("informe a coluna de valor ou as colunas de débito e crédito")
+	}
+	if mapping.AmountColumn != "" && (mapping.DebitColumn != "" || mapping.CreditColumn != "") {
+		return fmt.Errorf("use a coluna de valor ou as colunas de débito e crédito, não ambas")
+	}
+	if mapping.Delimiter != "" && !containsString(csvDelimiters, mapping.Delimiter) {
+		return fmt.Errorf("delimitador não suportado: %q", mapping.Delimiter)
+	}
+	if mapping.DecimalSeparator != "" && mapping.DecimalSeparator != "," && mapping.DecimalSeparator != "." {
+		return fmt.Errorf("decimal_separator deve ser ',' ou '.'")
+	}
+	if mapping.Encoding != "" {
+		if _, _, err := decodeCSVContent(nil, mapping.Encoding); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
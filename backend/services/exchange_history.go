@@ -0,0 +1,169 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// ExchangeHistoryService guarda o histórico de taxas de câmbio: as taxas obtidas da API ficam
+// registradas no dia da consulta e o usuário pode informar as taxas de datas passadas
+type ExchangeHistoryService struct {
+	db              *database.Database
+	exchangeService ExchangeServiceInterface
+}
+
+// NewExchangeHistoryService cria uma nova instância do serviço de histórico de câmbio
+func NewExchangeHistoryService(db *database.Database, exchangeService ExchangeServiceInterface) *ExchangeHistoryService {
+	return &ExchangeHistoryService{db: db, exchangeService: exchangeService}
+}
+
+// SaveManualRate grava a taxa informada pelo usuário para o par e a data
+func (s *ExchangeHistoryService) SaveManualRate(userID string, req structs.SaveExchangeRateRequest) (*structs.ExchangeRate, error) {
+	from := strings.ToUpper(strings.TrimSpace(req.FromCurrency))
+	to := strings.ToUpper(strings.TrimSpace(req.ToCurrency))
+	if len(from) != 3 || len(to) != 3 || from == to {
+		return nil, fmt.Errorf("from_currency e to_currency devem ser códigos de moeda diferentes, como USD e BRL")
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("date deve estar no formato AAAA-MM-DD")
+	}
+	if date.After(time.Now()) {
+		return nil, fmt.Errorf("date não pode estar no futuro")
+	}
+	if req.Rate <= 0 {
+		return nil, fmt.Errorf("rate deve ser maior que zero")
+	}
+
+	rate := structs.ExchangeRate{
+		ID:           uuid.New().String(),
+		UserID:       &userID,
+		FromCurrency: from,
+		ToCurrency:   to,
+		Date:         date,
+		Rate:         req.Rate,
+		Source:       "manual",
+	}
+	if err := s.db.SaveExchangeRate(rate); err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// ListRates lista as taxas conhecidas entre as duas moedas, nos dois sentidos
+func (s *ExchangeHistoryService) ListRates(userID, currencyA, currencyB string) ([]structs.ExchangeRate, error) {
+	rates, err := s.db.GetExchangeRates(userID, strings.ToUpper(currencyA), strings.ToUpper(currencyB), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if rates == nil {
+		rates = []structs.ExchangeRate{}
+	}
+	return rates, nil
+}
+
+// DeleteManualRate remove uma taxa informada pelo usuário
+func (s *ExchangeHistoryService) DeleteManualRate(id, userID string) error {
+	deleted, err := s.db.DeleteExchangeRate(id, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("taxa de câmbio não encontrada")
+	}
+	return nil
+}
+
+// exchangeRatePoint é a taxa de uma moeda para a moeda base em uma data
+type exchangeRatePoint struct {
+	date time.Time
+	rate float64
+}
+
+// exchangeRateTable converte valores de várias moedas para a moeda base pela taxa histórica
+type exchangeRateTable struct {
+	base  string
+	rates map[string][]exchangeRatePoint // Por moeda, da data mais antiga para a mais recente
+}
+
+// loadRateTable carrega todas as taxas conhecidas de cada moeda para base, completando com a
+// cotação atual da API. Moedas sem histórico usam a cotação atual em todo o período; esses casos e
+// as moedas que não puderam ser convertidas são devolvidos em warnings.
+func (s *ExchangeHistoryService) loadRateTable(userID, base string, currencies []string) (*exchangeRateTable, []string, error) {
+	table := &exchangeRateTable{base: base, rates: make(map[string][]exchangeRatePoint)}
+	var warnings []string
+
+	for _, currency := range currencies {
+		if currency == base {
+			continue
+		}
+		history, err := s.db.GetExchangeRates(userID, currency, base, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var points []exchangeRatePoint
+		for _, rate := range history {
+			value := rate.Rate
+			if rate.FromCurrency == base {
+				value = 1 / rate.Rate
+			}
+			// Na mesma data, a taxa do usuário (que vem por último) substitui a da API
+			if len(points) > 0 && points[len(points)-1].date.Equal(rate.Date) {
+				points[len(points)-1].rate = value
+				continue
+			}
+			points = append(points, exchangeRatePoint{date: rate.Date, rate: value})
+		}
+
+		// A cotação do dia vem da API e fica registrada, uma vez por dia
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		if len(points) == 0 || points[len(points)-1].date.Before(today) {
+			current, err := s.exchangeService.GetExchangeRateSimple(currency, base)
+			if err == nil && current > 0 {
+				if err := s.db.SaveExchangeRate(structs.ExchangeRate{
+					ID:           uuid.New().String(),
+					FromCurrency: currency,
+					ToCurrency:   base,
+					Date:         today,
+					Rate:         current,
+					Source:       "api",
+				}); err != nil {
+					return nil, nil, err
+				}
+				if len(points) == 0 {
+					warnings = append(warnings, fmt.Sprintf("Sem histórico de câmbio de %s para %s; usada a cotação atual (%.4f) em todo o período", currency, base, current))
+				}
+				points = append(points, exchangeRatePoint{date: today, rate: current})
+			} else if len(points) == 0 {
+				warnings = append(warnings, fmt.Sprintf("Sem taxa de câmbio de %s para %s; os saldos nessa moeda ficaram fora do total", currency, base))
+				continue
+			}
+		}
+		table.rates[currency] = points
+	}
+	return table, warnings, nil
+}
+
+// Rate devolve a taxa da moeda para a base na data: a mais recente até a data ou, se não houver,
+// a mais antiga conhecida. Retorna false quando a moeda não tem nenhuma taxa.
+func (t *exchangeRateTable) Rate(currency string, date time.Time) (float64, bool) {
+	if currency == t.base {
+		return 1, true
+	}
+	points := t.rates[currency]
+	if len(points) == 0 {
+		return 0, false
+	}
+	index := sort.Search(len(points), func(i int) bool { return points[i].date.After(date) })
+	if index == 0 {
+		return points[0].rate, true
+	}
+	return points[index-1].rate, true
+}
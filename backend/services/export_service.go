@@ -0,0 +1,436 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// Formatos de exportação de transações
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+type ExportService struct {
+	db *database.Database
+}
+
+// NewExportService cria uma nova instância do serviço de exportação
+func NewExportService(db *database.Database) *ExportService {
+	return &ExportService{db: db}
+}
+
+// ExportOptions define o formato do arquivo exportado
+type ExportOptions struct {
+	Format  string   // csv ou xlsx
+	Columns []string // Colunas na ordem desejada; vazio usa defaultExportColumns
+	// Locale preenche separador decimal, formato de data e delimitador: "pt-BR" (padrão) ou "en-US"
+	Locale           string
+	DecimalSeparator string // "," ou "."
+	DateFormat       string // "dd/mm/yyyy", "yyyy-mm-dd" ou "mm/dd/yyyy"
+	Delimiter        string // Separador de campos do CSV: ";", "," ou "tab"
+	BOM              *bool  // Marca UTF-8 no início do CSV, para o Excel reconhecer acentos (padrão: true)
+}
+
+// exportDateFormats associa os formatos de data aceitos ao layout do Go e ao formato do Excel
+var exportDateFormats = map[string]struct{ layout, excel string }{
+	"dd/mm/yyyy": {"02/01/2006", "dd/mm/yyyy"},
+	"yyyy-mm-dd": {"2006-01-02", "yyyy-mm-dd"},
+	"mm/dd/yyyy": {"01/02/2006", "mm/dd/yyyy"},
+}
+
+// exportLocales são os padrões de cada locale; opções informadas explicitamente têm precedência
+var exportLocales = map[string]ExportOptions{
+	"pt-BR": {DecimalSeparator: ",", DateFormat: "dd/mm/yyyy", Delimiter: ";"},
+	"en-US": {DecimalSeparator: ".", DateFormat: "mm/dd/yyyy", Delimiter: ","},
+}
+
+// exportRow reúne o que as colunas precisam para formatar uma transação
+type exportRow struct {
+	tx          structs.Transaction
+	account     *structs.Account
+	counterpart *structs.Account
+}
+
+// exportColumn descreve uma coluna exportável
+type exportColumn struct {
+	header string
+	value  func(e *TransactionExport, row exportRow) exportValue
+}
+
+// exportValue é o valor tipado de uma célula, formatado conforme o destino (CSV ou XLSX)
+type exportValue struct {
+	text   string
+	amount *int       // Em centavos, com sinal
+	date   *time.Time // Data sem hora
+	flag   *bool
+}
+
+func exportText(text string) exportValue { return exportValue{text: text} }
+
+func exportDate(date time.Time) exportValue {
+	if date.IsZero() {
+		return exportValue{}
+	}
+	return exportValue{date: &date}
+}
+
+// exportColumns são as colunas disponíveis, pela chave usada no parâmetro columns
+var exportColumns = map[string]exportColumn{
+	"id": {"ID", func(e *TransactionExport, row exportRow) exportValue { return exportText(row.tx.ID) }},
+	"date": {"Data", func(e *TransactionExport, row exportRow) exportValue {
+		return exportDate(row.tx.DueDate)
+	}},
+	"competence_date": {"Competência", func(e *TransactionExport, row exportRow) exportValue {
+		return exportDate(row.tx.CompetenceDate)
+	}},
+	"description": {"Descrição", func(e *TransactionExport, row exportRow) exportValue { return exportText(row.tx.Description) }},
+	"original_description": {"Descrição original", func(e *TransactionExport, row exportRow) exportValue {
+		return exportText(row.tx.OriginalDescription)
+	}},
+	"amount": {"Valor", func(e *TransactionExport, row exportRow) exportValue {
+		// Despesas saem com valor negativo, para que a soma da coluna seja o saldo
+		amount := row.tx.Amount
+		if row.tx.Type == "expense" {
+			amount = -amount
+		}
+		return exportValue{amount: &amount}
+	}},
+	"type": {"Tipo", func(e *TransactionExport, row exportRow) exportValue {
+		switch {
+		case row.tx.TransferID != nil && row.tx.Type == "expense":
+			return exportText("Transferência (saída)")
+		case row.tx.TransferID != nil:
+			return exportText("Transferência (entrada)")
+		case row.tx.Type == "income":
+			return exportText("Receita")
+		}
+		return exportText("Despesa")
+	}},
+	"category": {"Categoria", func(e *TransactionExport, row exportRow) exportValue {
+		if category, ok := e.categories[row.tx.CategoryID]; ok {
+			return exportText(category.Name)
+		}
+		return exportText("")
+	}},
+	"category_path": {"Categoria completa", func(e *TransactionExport, row exportRow) exportValue {
+		return exportText(e.categoryPath(row.tx.CategoryID))
+	}},
+	"account": {"Conta", func(e *TransactionExport, row exportRow) exportValue {
+		if row.account != nil {
+			return exportText(row.account.Name)
+		}
+		return exportText("")
+	}},
+	"currency": {"Moeda", func(e *TransactionExport, row exportRow) exportValue {
+		if row.account != nil {
+			return exportText(row.account.Currency)
+		}
+		return exportText("")
+	}},
+	"transfer_account": {"Conta da transferência", func(e *TransactionExport, row exportRow) exportValue {
+		if row.counterpart != nil {
+			return exportText(row.counterpart.Name)
+		}
+		return exportText("")
+	}},
+	"is_paid": {"Pago", func(e *TransactionExport, row exportRow) exportValue {
+		paid := row.tx.IsPaid
+		return exportValue{flag: &paid}
+	}},
+	"payee": {"Beneficiário", func(e *TransactionExport, row exportRow) exportValue { return exportText(row.tx.Payee) }},
+	"tags": {"Tags", func(e *TransactionExport, row exportRow) exportValue {
+		return exportText(strings.Join(row.tx.Tags, ", "))
+	}},
+	"observation": {"Observação", func(e *TransactionExport, row exportRow) exportValue { return exportText(row.tx.Observation) }},
+	"installment": {"Parcela", func(e *TransactionExport, row exportRow) exportValue {
+		if row.tx.Installments <= 1 {
+			return exportText("")
+		}
+		return exportText(fmt.Sprintf("%d/%d", row.tx.CurrentInstallment, row.tx.Installments))
+	}},
+}
+
+// defaultExportColumns são as colunas exportadas quando columns não é informado
+var defaultExportColumns = []string{"date", "description", "amount", "type", "category_path", "account", "currency", "transfer_account", "is_paid", "payee", "tags", "observation"}
+
+// TransactionExport é uma exportação já validada, pronta para ser gravada na resposta.
+// As transações são lidas do banco uma a uma enquanto o arquivo é gerado.
+type TransactionExport struct {
+	s        *ExportService
+	userID   string
+	filter   structs.TransactionFilter
+	options  ExportOptions
+	columns  []string
+	layout   string // Layout do Go para as datas do CSV
+	excelFmt string // Formato das datas no XLSX
+
+	accounts   map[string]*structs.Account
+	categories map[string]*structs.Category
+}
+
+// NewTransactionExport valida as opções e carrega contas e categorias usadas nas colunas.
+// Erros aqui ainda podem ser respondidos como JSON, antes de o arquivo começar a ser enviado.
+func (s *ExportService) NewTransactionExport(userID string, filter structs.TransactionFilter, options ExportOptions) (*TransactionExport, error) {
+	if options.Format == "" {
+		options.Format = ExportFormatCSV
+	}
+	if options.Format != ExportFormatCSV && options.Format != ExportFormatXLSX {
+		return nil, fmt.Errorf("format deve ser 'csv' ou 'xlsx'")
+	}
+
+	if options.Locale == "" {
+		options.Locale = "pt-BR"
+	}
+	defaults, ok := exportLocales[options.Locale]
+	if !ok {
+		return nil, fmt.Errorf("locale deve ser 'pt-BR' ou 'en-US'")
+	}
+	if options.DecimalSeparator == "" {
+		options.DecimalSeparator = defaults.DecimalSeparator
+	}
+	if options.DateFormat == "" {
+		options.DateFormat = defaults.DateFormat
+	}
+	if options.Delimiter == "" {
+		options.Delimiter = defaults.Delimiter
+	}
+	if options.DecimalSeparator != "," && options.DecimalSeparator != "." {
+		return nil, fmt.Errorf("decimal_separator deve ser ',' ou '.'")
+	}
+	dateFormat, ok := exportDateFormats[strings.ToLower(options.DateFormat)]
+	if !ok {
+		return nil, fmt.Errorf("date_format deve ser 'dd/mm/yyyy', 'yyyy-mm-dd' ou 'mm/dd/yyyy'")
+	}
+	if options.Delimiter == "tab" {
+		options.Delimiter = "\t"
+	}
+	if options.Delimiter != ";" && options.Delimiter != "," && options.Delimiter != "\t" {
+		return nil, fmt.Errorf("delimiter deve ser ';', ',' ou 'tab'")
+	}
+	if options.Delimiter == options.DecimalSeparator {
+		return nil, fmt.Errorf("delimiter não pode ser igual ao decimal_separator")
+	}
+
+	columns := options.Columns
+	if len(columns) == 0 {
+		columns = defaultExportColumns
+	}
+	for _, column := range columns {
+		if _, ok := exportColumns[column]; !ok {
+			return nil, fmt.Errorf("coluna desconhecida: %s (disponíveis: %s)", column, strings.Join(ExportColumnNames(), ", "))
+		}
+	}
+
+	export := &TransactionExport{
+		s:          s,
+		userID:     userID,
+		filter:     filter,
+		options:    options,
+		columns:    columns,
+		layout:     dateFormat.layout,
+		excelFmt:   dateFormat.excel,
+		accounts:   make(map[string]*structs.Account),
+		categories: make(map[string]*structs.Category),
+	}
+
+	accounts, err := s.db.GetAllAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar contas: %w", err)
+	}
+	for i := range accounts {
+		export.accounts[accounts[i].ID] = &accounts[i]
+	}
+
+	categories, err := s.db.GetAllCategories(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+	for i := range categories {
+		export.categories[categories[i].ID] = &categories[i]
+	}
+	transferCategory, err := s.db.EnsureTransferCategory()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categoria de transferência: %w", err)
+	}
+	export.categories[transferCategory.ID] = transferCategory
+
+	return export, nil
+}
+
+// ExportColumnNames lista as chaves das colunas disponíveis, na ordem padrão seguida das demais
+func ExportColumnNames() []string {
+	names := append([]string{}, defaultExportColumns...)
+	for _, name := range []string{"id", "competence_date", "original_description", "category", "installment"} {
+		names = append(names, name)
+	}
+	return names
+}
+
+// ContentType retorna o tipo do arquivo gerado
+func (e *TransactionExport) ContentType() string {
+	if e.options.Format == ExportFormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FileName retorna o nome sugerido para o arquivo
+func (e *TransactionExport) FileName() string {
+	return fmt.Sprintf("transacoes-%s.%s", time.Now().Format("20060102"), e.options.Format)
+}
+
+// Write grava o arquivo, lendo as transações do banco uma a uma
+func (e *TransactionExport) Write(w io.Writer) error {
+	if e.options.Format == ExportFormatXLSX {
+		return e.writeXLSX(w)
+	}
+	return e.writeCSV(w)
+}
+
+func (e *TransactionExport) headers() []string {
+	headers := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		headers[i] = exportColumns[column].header
+	}
+	return headers
+}
+
+func (e *TransactionExport) stream(fn func(values []exportValue) error) error {
+	return e.s.db.StreamTransactions(e.userID, e.filter, func(tx structs.Transaction, counterpartAccountID string) error {
+		row := exportRow{tx: tx, account: e.accounts[tx.AccountID]}
+		if counterpartAccountID != "" {
+			row.counterpart = e.accounts[counterpartAccountID]
+		}
+		values := make([]exportValue, len(e.columns))
+		for i, column := range e.columns {
+			values[i] = exportColumns[column].value(e, row)
+		}
+		return fn(values)
+	})
+}
+
+func (e *TransactionExport) writeCSV(w io.Writer) error {
+	buffered := bufio.NewWriter(w)
+	if e.options.BOM == nil || *e.options.BOM {
+		buffered.WriteString("\ufeff")
+	}
+
+	writer := csv.NewWriter(buffered)
+	writer.Comma, _ = utf8.DecodeRuneInString(e.options.Delimiter)
+	if err := writer.Write(e.headers()); err != nil {
+		return err
+	}
+
+	record := make([]string, len(e.columns))
+	err := e.stream(func(values []exportValue) error {
+		for i, value := range values {
+			record[i] = e.formatCSV(value)
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
+
+// formatCSV formata o valor conforme o locale escolhido
+func (e *TransactionExport) formatCSV(value exportValue) string {
+	switch {
+	case value.amount != nil:
+		return formatCents(*value.amount, e.options.DecimalSeparator)
+	case value.date != nil:
+		return value.date.Format(e.layout)
+	case value.flag != nil:
+		if *value.flag {
+			return "Sim"
+		}
+		return "Não"
+	}
+	return sanitizeSpreadsheetText(value.text)
+}
+
+func (e *TransactionExport) writeXLSX(w io.Writer) error {
+	writer, err := newXLSXWriter(w, "Transações", e.excelFmt)
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteHeader(e.headers()); err != nil {
+		return err
+	}
+
+	cells := make([]xlsxCell, len(e.columns))
+	err = e.stream(func(values []exportValue) error {
+		for i, value := range values {
+			switch {
+			case value.amount != nil:
+				cells[i] = xlsxNumberCell(float64(*value.amount) / 100)
+			case value.date != nil:
+				cells[i] = xlsxDateCell(*value.date)
+			case value.flag != nil:
+				cells[i] = xlsxBoolCell(*value.flag)
+			case value.text == "":
+				cells[i] = xlsxEmptyCell()
+			default:
+				cells[i] = xlsxTextCell(value.text)
+			}
+		}
+		return writer.WriteRow(cells)
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// categoryPath monta o caminho "Pai > Filha" da categoria
+func (e *TransactionExport) categoryPath(categoryID string) string {
+	category, ok := e.categories[categoryID]
+	if !ok {
+		return ""
+	}
+	path := category.Name
+	// O limite evita laços caso alguma categoria aponte para uma descendente
+	for depth := 0; category.ParentID != nil && depth < 10; depth++ {
+		parent, ok := e.categories[*category.ParentID]
+		if !ok {
+			break
+		}
+		path = parent.Name + " > " + path
+		category = parent
+	}
+	return path
+}
+
+// formatCents formata um valor em centavos com duas casas e o separador decimal informado, sem milhares
+func formatCents(cents int, decimalSeparator string) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d%s%02d", sign, cents/100, decimalSeparator, cents%100)
+}
+
+// sanitizeSpreadsheetText evita que textos começando com =, +, - ou @ sejam interpretados
+// como fórmulas ao abrir o CSV em uma planilha
+func sanitizeSpreadsheetText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// icalMaxLineOctets é o tamanho máximo de uma linha do iCalendar, sem o CRLF (RFC 5545, 3.1)
+const icalMaxLineOctets = 75
+
+// icalWriter grava as linhas de um arquivo iCalendar, dobrando as linhas longas e terminando
+// cada uma com CRLF. O primeiro erro de escrita é guardado e devolvido por Flush.
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func newICalWriter(w io.Writer) *icalWriter {
+	return &icalWriter{w: bufio.NewWriter(w)}
+}
+
+// Line grava uma propriedade já formatada ("NOME;PARAM=X:valor")
+func (iw *icalWriter) Line(line string) {
+	if iw.err != nil {
+		return
+	}
+	for len(line) > 0 {
+		limit := icalMaxLineOctets
+		if limit > len(line) {
+			limit = len(line)
+		}
+		// Não corta um caractere UTF-8 ao meio
+		for limit < len(line) && !utf8.RuneStart(line[limit]) {
+			limit--
+		}
+		if _, iw.err = iw.w.WriteString(line[:limit] + "\r\n"); iw.err != nil {
+			return
+		}
+		line = line[limit:]
+		if len(line) > 0 {
+			// A continuação começa com um espaço, que conta no limite da linha
+			line = " " + line
+		}
+	}
+}
+
+// Text grava uma propriedade de texto, escapando o valor
+func (iw *icalWriter) Text(name, value string) {
+	iw.Line(name + ":" + icalEscape(value))
+}
+
+// Flush descarrega o buffer e devolve o primeiro erro de escrita
+func (iw *icalWriter) Flush() error {
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
+
+// icalEscape escapa barras, vírgulas, ponto e vírgula e quebras de linha de um valor TEXT
+func icalEscape(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+const balanceDateLayout = "02/01/2006"
+
+// SetStatementBalance registra no lote o saldo informado pelo banco e deduz o saldo no início
+// do período do lote, subtraindo do saldo final as linhas do arquivo até a data do saldo
+func SetStatementBalance(batch *structs.ImportBatch, balance *structs.StatementBalance, lines []structs.ImportLine) {
+	if balance == nil {
+		return
+	}
+
+	ledger := balance.Amount
+	ledgerDate := balance.Date
+	batch.LedgerBalance = &ledger
+	batch.LedgerBalanceDate = &ledgerDate
+
+	if batch.DateStart == nil {
+		return
+	}
+
+	opening := balance.Amount
+	lastDay := startOfDay(ledgerDate)
+	for _, line := range lines {
+		date := startOfDay(line.Date)
+		if date.Before(startOfDay(*batch.DateStart)) || date.After(lastDay) {
+			continue
+		}
+		cents := amountToCents(line.Amount)
+		if line.Amount < 0 {
+			cents = -cents
+		}
+		opening -= cents
+	}
+	batch.OpeningBalance = &opening
+}
+
+// CheckImportBalance compara o saldo do extrato com o saldo calculado da conta e grava o resultado no lote.
+// Retorna nil quando o arquivo não informou saldo.
+func (s *ImportService) CheckImportBalance(batch *structs.ImportBatch) (*structs.BalanceCheck, error) {
+	if batch.LedgerBalance == nil || batch.LedgerBalanceDate == nil {
+		return nil, nil
+	}
+
+	computed, err := s.db.GetAccountBalanceAsOf(batch.AccountID, batch.UserID, *batch.LedgerBalanceDate)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular saldo da conta: %w", err)
+	}
+
+	difference := *batch.LedgerBalance - computed
+	check := &structs.BalanceCheck{
+		ImportBatchID:     batch.ID,
+		AccountID:         batch.AccountID,
+		LedgerBalance:     *batch.LedgerBalance,
+		LedgerBalanceDate: *batch.LedgerBalanceDate,
+		ComputedBalance:   computed,
+		Difference:        difference,
+		Matches:           difference == 0,
+	}
+
+	batch.ComputedBalance = &computed
+	batch.BalanceDifference = &difference
+	if err := s.db.UpdateImportBatchBalance(*batch); err != nil {
+		return nil, fmt.Errorf("erro ao gravar conferência de saldo: %w", err)
+	}
+
+	if check.Matches {
+		check.Hint = "O saldo da conta confere com o saldo do extrato."
+		return check, nil
+	}
+
+	if difference > 0 {
+		check.AdjustmentType = "income"
+		check.AdjustmentAmount = difference
+	} else {
+		check.AdjustmentType = "expense"
+		check.AdjustmentAmount = -difference
+	}
+
+	if err := s.locateDivergence(batch, check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// locateDivergence procura o intervalo em que o saldo da conta deixou de conferir com o do banco,
+// usando o saldo de abertura do próprio arquivo e os saldos informados por importações anteriores
+func (s *ImportService) locateDivergence(batch *structs.ImportBatch, check *structs.BalanceCheck) error {
+	upper := startOfDay(*batch.LedgerBalanceDate)
+
+	if batch.DateStart != nil && batch.OpeningBalance != nil {
+		periodStart := startOfDay(*batch.DateStart)
+		computedOpening, err := s.db.GetAccountBalanceAsOf(batch.AccountID, batch.UserID, periodStart.AddDate(0, 0, -1))
+		if err != nil {
+			return fmt.Errorf("erro ao calcular saldo da conta: %w", err)
+		}
+
+		if computedOpening == *batch.OpeningBalance {
+			check.DivergenceFrom = &periodStart
+			check.DivergenceTo = &upper
+			check.Hint = fmt.Sprintf("O saldo conferia em %s; a diferença surgiu no período do extrato, até %s. Verifique transações ignoradas como duplicadas, lançamentos repetidos ou não marcados como pagos.",
+				periodStart.AddDate(0, 0, -1).Format(balanceDateLayout), upper.Format(balanceDateLayout))
+			return nil
+		}
+		upper = periodStart.AddDate(0, 0, -1)
+	}
+
+	checkpoints, err := s.db.GetBalanceCheckpoints(batch.AccountID, batch.UserID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar saldos de importações anteriores: %w", err)
+	}
+
+	// Último saldo anterior que confere e primeiro saldo depois dele que diverge
+	var lastMatch, firstMismatch *time.Time
+	for _, checkpoint := range checkpoints {
+		if checkpoint.ID == batch.ID {
+			continue
+		}
+		date := startOfDay(*checkpoint.LedgerBalanceDate)
+		if date.After(upper) {
+			break
+		}
+
+		computed, err := s.db.GetAccountBalanceAsOf(batch.AccountID, batch.UserID, date)
+		if err != nil {
+			return fmt.Errorf("erro ao calcular saldo da conta: %w", err)
+		}
+		if computed == *checkpoint.LedgerBalance {
+			lastMatch = &date
+			firstMismatch = nil
+		} else if firstMismatch == nil {
+			firstMismatch = &date
+		}
+	}
+
+	to := upper
+	if firstMismatch != nil {
+		to = *firstMismatch
+	}
+	check.DivergenceTo = &to
+
+	if lastMatch == nil {
+		check.Hint = fmt.Sprintf("A diferença é anterior a %s e nenhum saldo importado antes disso confere. Revise o saldo inicial da conta e as transações até essa data.",
+			to.AddDate(0, 0, 1).Format(balanceDateLayout))
+		return nil
+	}
+
+	from := lastMatch.AddDate(0, 0, 1)
+	check.DivergenceFrom = &from
+	check.Hint = fmt.Sprintf("O saldo conferia em %s; a diferença surgiu entre %s e %s.",
+		lastMatch.Format(balanceDateLayout), from.Format(balanceDateLayout), to.Format(balanceDateLayout))
+	return nil
+}
+
+// GetImportBalanceCheck refaz a conferência de saldo de uma importação com as transações atuais da conta
+func (s *ImportService) GetImportBalanceCheck(id string, userID string) (*structs.BalanceCheck, error) {
+	batch, err := s.balanceCheckedBatch(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.CheckImportBalance(batch)
+}
+
+// CreateBalanceAdjustment cria a transação que iguala o saldo da conta ao saldo do extrato
+// e retorna a transação criada com a nova conferência
+func (s *ImportService) CreateBalanceAdjustment(id string, userID string, req structs.BalanceAdjustmentRequest) (*structs.Transaction, *structs.BalanceCheck, error) {
+	batch, err := s.balanceCheckedBatch(id, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	check, err := s.CheckImportBalance(batch)
+	if err != nil {
+		return nil, nil, err
+	}
+	if check.Matches {
+		return nil, nil, fmt.Errorf("o saldo da conta já confere com o saldo do extrato")
+	}
+
+	var category *structs.Category
+	if req.CategoryID != "" {
+		categories, err := s.db.GetAllCategories(userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("erro ao buscar categorias: %w", err)
+		}
+		found, ok := newImportCategories(categories).byID[req.CategoryID]
+		if !ok {
+			return nil, nil, fmt.Errorf("categoria não encontrada")
+		}
+		category = &found
+		if string(category.Type) != check.AdjustmentType {
+			return nil, nil, fmt.Errorf("a categoria do ajuste deve ser do tipo %s", check.AdjustmentType)
+		}
+	} else {
+		categoryName := "Outros"
+		if check.AdjustmentType == "income" {
+			categoryName = "Outras Receitas"
+		}
+		category, err = s.db.GetCategoryByName(categoryName, check.AdjustmentType, userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("erro ao buscar categoria %s: %w", categoryName, err)
+		}
+		if category == nil {
+			return nil, nil, fmt.Errorf("categoria %s não encontrada. Informe category_id", categoryName)
+		}
+	}
+
+	description := req.Description
+	if description == "" {
+		description = "Ajuste de saldo"
+	}
+
+	now := time.Now()
+	tx := structs.Transaction{
+		ID:                 utils.GenerateUUID(),
+		UserID:             userID,
+		Description:        description,
+		Amount:             check.AdjustmentAmount,
+		Type:               check.AdjustmentType,
+		CategoryID:         category.ID,
+		AccountID:          batch.AccountID,
+		DueDate:            check.LedgerBalanceDate,
+		CompetenceDate:     check.LedgerBalanceDate,
+		IsPaid:             true,
+		Observation:        fmt.Sprintf("Ajuste para conferir com o saldo do extrato %s", batch.FileName),
+		Installments:       1,
+		CurrentInstallment: 1,
+		ImportBatchID:      &batch.ID,
+		Tags:               []string{},
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if err := s.db.CreateTransaction(tx); err != nil {
+		return nil, nil, fmt.Errorf("erro ao criar transação de ajuste: %w", err)
+	}
+
+	check, err = s.CheckImportBalance(batch)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &tx, check, nil
+}
+
+// balanceCheckedBatch busca um lote ativo que informou o saldo do banco
+func (s *ImportService) balanceCheckedBatch(id string, userID string) (*structs.ImportBatch, error) {
+	if !utils.IsValidUUID(id) {
+		return nil, fmt.Errorf("ID deve ser um UUID válido")
+	}
+
+	batch, err := s.db.GetImportBatchByID(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar importação: %w", err)
+	}
+	if batch == nil {
+		return nil, fmt.Errorf("importação não encontrada")
+	}
+	if batch.Status == structs.ImportBatchStatusReverted {
+		return nil, fmt.Errorf("esta importação foi desfeita")
+	}
+	if batch.LedgerBalance == nil || batch.LedgerBalanceDate == nil {
+		return nil, fmt.Errorf("o arquivo desta importação não informou o saldo do banco")
+	}
+	return batch, nil
+}
+
+// startOfDay descarta o horário de uma data
+func startOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+// importCategories mantém as categorias do usuário durante uma importação, permitindo
+// resolver caminhos "Categoria:Subcategoria" e criar as que faltarem
+type importCategories struct {
+	list []structs.Category
+	byID map[string]structs.Category
+}
+
+func newImportCategories(categories []structs.Category) *importCategories {
+	c := &importCategories{byID: make(map[string]structs.Category, len(categories))}
+	for _, category := range categories {
+		c.add(category)
+	}
+	return c
+}
+
+func (c *importCategories) add(category structs.Category) {
+	c.list = append(c.list, category)
+	c.byID[category.ID] = category
+}
+
+// find resolve um caminho de categoria do arquivo para uma categoria do usuário
+func (c *importCategories) find(path string, txType string) *structs.Category {
+	return findCategoryByPath(c.list, path, txType)
+}
+
+// ensure resolve o caminho e cria as categorias que ainda não existem
+func (c *importCategories) ensure(db *database.Database, userID string, path string, txType string) (*structs.Category, error) {
+	if category := c.find(path, txType); category != nil {
+		return category, nil
+	}
+
+	names := []string{}
+	for _, name := range strings.Split(path, ":") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	names = flattenCategoryNames(names)
+
+	var parent *structs.Category
+	for depth, name := range names {
+		existing := findCategoryByPath(c.list, strings.Join(names[:depth+1], ":"), txType)
+		if existing != nil {
+			parent = existing
+			continue
+		}
+
+		req := structs.CreateCategoryRequest{
+			Name:        name,
+			Description: "Criada na importação",
+			Type:        structs.CategoryType(txType),
+			Color:       "#6B7280",
+			UserID:      userID,
+		}
+		if parent != nil {
+			req.ParentID = &parent.ID
+		}
+		category := structs.NewCategory(req)
+		if err := db.CreateCategory(category); err != nil {
+			return nil, fmt.Errorf("erro ao criar categoria '%s': %w", name, err)
+		}
+		c.add(category)
+		parent = &category
+	}
+	return parent, nil
+}
+
+// findCategoryByPath busca uma categoria ativa do tipo informado pelo caminho "Pai:Filha",
+// sem diferenciar acentos. Um nome sozinho prefere categorias de primeiro nível.
+func findCategoryByPath(categories []structs.Category, path string, txType string) *structs.Category {
+	names := splitCategoryPath(path)
+	if len(names) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*structs.Category, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+	}
+
+	var fallback *structs.Category
+	for i := range categories {
+		category := &categories[i]
+		if !category.IsActive || string(category.Type) != txType || utils.NormalizeText(category.Name) != names[len(names)-1] {
+			continue
+		}
+
+		// Conferir os ancestrais a partir do fim do caminho
+		matches := true
+		current := category
+		for j := len(names) - 2; j >= 0; j-- {
+			if current.ParentID == nil {
+				matches = false
+				break
+			}
+			parent := byID[*current.ParentID]
+			if parent == nil || utils.NormalizeText(parent.Name) != names[j] {
+				matches = false
+				break
+			}
+			current = parent
+		}
+		if !matches {
+			continue
+		}
+
+		if len(names) > 1 || category.ParentID == nil {
+			return category
+		}
+		if fallback == nil {
+			fallback = category
+		}
+	}
+	return fallback
+}
+
+// splitCategoryPath normaliza os nomes de um caminho "Categoria:Subcategoria"
+func splitCategoryPath(path string) []string {
+	names := []string{}
+	for _, name := range strings.Split(path, ":") {
+		if name = utils.NormalizeText(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return flattenCategoryNames(names)
+}
+
+// flattenCategoryNames limita o caminho aos dois níveis do app (categoria e subcategoria):
+// "Casa:Contas:Luz" vira a subcategoria "Contas / Luz" de "Casa"
+func flattenCategoryNames(names []string) []string {
+	if len(names) <= 2 {
+		return names
+	}
+	return []string{names[0], strings.Join(names[1:], " / ")}
+}
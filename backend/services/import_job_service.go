@@ -0,0 +1,361 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+const (
+	// importJobQueueSize é a capacidade da fila de jobs aguardando um worker
+	importJobQueueSize = 100
+	// importJobProgressEvery define a cada quantas linhas o progresso é gravado no banco
+	importJobProgressEvery = 10
+	// importJobListLimit limita o histórico de jobs retornado ao usuário
+	importJobListLimit = 50
+)
+
+// ImportJobService processa importações em segundo plano. O estado dos jobs fica no banco,
+// então jobs na fila ou interrompidos por um reinício do servidor são retomados em Start.
+type ImportJobService struct {
+	db            *database.Database
+	importService *ImportService
+	queue         chan string
+
+	mu          sync.Mutex
+	cancels     map[string]bool
+	subscribers map[string][]chan structs.ImportJob
+}
+
+// NewImportJobService cria uma nova instância do serviço de jobs de importação
+func NewImportJobService(db *database.Database, importService *ImportService) *ImportJobService {
+	return &ImportJobService{
+		db:            db,
+		importService: importService,
+		queue:         make(chan string, importJobQueueSize),
+		cancels:       make(map[string]bool),
+		subscribers:   make(map[string][]chan structs.ImportJob),
+	}
+}
+
+// Start inicia os workers e coloca de volta na fila os jobs que não terminaram
+func (s *ImportJobService) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		go s.worker()
+	}
+
+	jobs, err := s.db.GetUnfinishedImportJobs()
+	if err != nil {
+		fmt.Printf("Erro ao buscar jobs de importação pendentes: %v\n", err)
+		return
+	}
+	for _, job := range jobs {
+		s.enqueue(job.ID)
+	}
+	if len(jobs) > 0 {
+		fmt.Printf("%d jobs de importação retomados\n", len(jobs))
+	}
+}
+
+func (s *ImportJobService) worker() {
+	for jobID := range s.queue {
+		s.run(jobID)
+	}
+}
+
+// enqueue coloca o job na fila sem bloquear a requisição quando a fila está cheia
+func (s *ImportJobService) enqueue(jobID string) {
+	select {
+	case s.queue <- jobID:
+	default:
+		go func() { s.queue <- jobID }()
+	}
+}
+
+// EnqueueFileImport cria um job que importa todas as linhas de um arquivo na conta informada
+func (s *ImportJobService) EnqueueFileImport(userID, accountID, format, fileName, fileHash string, lines []structs.ImportLine, ledgerBalance *structs.StatementBalance) (*structs.ImportJob, error) {
+	if lines == nil {
+		lines = []structs.ImportLine{}
+	}
+	job := newImportJob(userID, accountID, structs.ImportJobKindFile, format, fileName, len(lines))
+	job.Payload = structs.ImportJobPayload{FileHash: fileHash, Lines: lines, LedgerBalance: ledgerBalance}
+	return s.create(job)
+}
+
+// EnqueuePreviewImport valida a pré-visualização e cria um job que importa as linhas revisadas
+func (s *ImportJobService) EnqueuePreviewImport(userID string, req structs.ImportPreviewRequest) (*structs.ImportJob, error) {
+	preview, err := s.importService.ValidatePreviewImport(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	job := newImportJob(userID, req.AccountID, structs.ImportJobKindPreview, preview.Format, preview.FileName, len(req.Lines))
+	job.Payload = structs.ImportJobPayload{FileHash: preview.FileHash, Request: &req}
+	return s.create(job)
+}
+
+func newImportJob(userID, accountID, kind, format, fileName string, totalLines int) structs.ImportJob {
+	now := time.Now()
+	return structs.ImportJob{
+		ID:         utils.GenerateUUID(),
+		UserID:     userID,
+		AccountID:  accountID,
+		Kind:       kind,
+		Format:     format,
+		FileName:   fileName,
+		Status:     structs.ImportJobStatusQueued,
+		TotalLines: totalLines,
+		Outcomes:   []structs.ImportLineOutcome{},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func (s *ImportJobService) create(job structs.ImportJob) (*structs.ImportJob, error) {
+	if err := s.db.CreateImportJob(job); err != nil {
+		return nil, fmt.Errorf("erro ao registrar job de importação: %w", err)
+	}
+	s.enqueue(job.ID)
+	return &job, nil
+}
+
+// GetJob busca um job de importação do usuário
+func (s *ImportJobService) GetJob(id string, userID string) (*structs.ImportJob, error) {
+	if !utils.IsValidUUID(id) {
+		return nil, fmt.Errorf("ID deve ser um UUID válido")
+	}
+
+	job, err := s.db.GetImportJob(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar job de importação: %w", err)
+	}
+	if job == nil {
+		return nil, fmt.Errorf("job de importação não encontrado")
+	}
+	return job, nil
+}
+
+// GetJobs lista os jobs de importação mais recentes do usuário
+func (s *ImportJobService) GetJobs(userID string) ([]structs.ImportJob, error) {
+	jobs, err := s.db.GetImportJobsByUser(userID, importJobListLimit)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar jobs de importação: %w", err)
+	}
+	return jobs, nil
+}
+
+// CancelJob pede o cancelamento de um job. Jobs na fila são cancelados na hora; jobs em
+// processamento param após a linha atual, mantendo as transações já importadas no lote.
+func (s *ImportJobService) CancelJob(id string, userID string) (*structs.ImportJob, error) {
+	job, err := s.GetJob(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Finished() {
+		return nil, fmt.Errorf("este job de importação já terminou")
+	}
+
+	requested, err := s.db.RequestImportJobCancel(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao cancelar job de importação: %w", err)
+	}
+	if !requested {
+		return nil, fmt.Errorf("este job de importação já terminou")
+	}
+
+	s.mu.Lock()
+	s.cancels[id] = true
+	s.mu.Unlock()
+
+	job, err = s.GetJob(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Finished() {
+		s.publish(*job)
+	}
+	return job, nil
+}
+
+// Subscribe retorna um canal que recebe o estado do job a cada linha processada.
+// O canal é fechado quando o job termina; a função retornada encerra a inscrição antes disso.
+func (s *ImportJobService) Subscribe(id string) (<-chan structs.ImportJob, func()) {
+	ch := make(chan structs.ImportJob, 16)
+
+	s.mu.Lock()
+	s.subscribers[id] = append(s.subscribers[id], ch)
+	s.mu.Unlock()
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		subscribers := s.subscribers[id]
+		for i, subscriber := range subscribers {
+			if subscriber == ch {
+				s.subscribers[id] = append(subscribers[:i], subscribers[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+		if len(s.subscribers[id]) == 0 {
+			delete(s.subscribers, id)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// publish envia o estado do job aos inscritos, descartando atualizações para quem estiver lento.
+// Quando o job termina, os canais são fechados.
+func (s *ImportJobService) publish(job structs.ImportJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ch := range s.subscribers[job.ID] {
+		select {
+		case ch <- job:
+		default:
+		}
+	}
+	if job.Finished() {
+		for _, ch := range s.subscribers[job.ID] {
+			close(ch)
+		}
+		delete(s.subscribers, job.ID)
+		delete(s.cancels, job.ID)
+	}
+}
+
+func (s *ImportJobService) cancelRequested(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cancels[id]
+}
+
+// run processa um job, retomando a partir da última linha gravada quando ele já tinha começado
+func (s *ImportJobService) run(jobID string) {
+	job, err := s.db.GetImportJobByID(jobID)
+	if err != nil {
+		fmt.Printf("Erro ao buscar job de importação %s: %v\n", jobID, err)
+		return
+	}
+	if job == nil || job.Finished() {
+		return
+	}
+
+	// O job passa a processar antes de o lote existir: cancelado na fila, ele termina sem deixar
+	// um lote vazio que seria confundido com uma importação anterior do arquivo
+	if job.StartedAt == nil {
+		now := time.Now()
+		job.StartedAt = &now
+	}
+	job.Status = structs.ImportJobStatusRunning
+	started, err := s.db.StartImportJob(*job)
+	if err != nil {
+		s.fail(job, fmt.Errorf("erro ao iniciar job de importação: %w", err))
+		return
+	}
+	if !started {
+		return
+	}
+
+	importRun, err := s.startRun(job)
+	if err != nil {
+		s.fail(job, err)
+		return
+	}
+	if job.ImportBatchID != importRun.Batch.ID {
+		job.ImportBatchID = importRun.Batch.ID
+		if err := s.db.SetImportJobBatch(job.ID, job.ImportBatchID); err != nil {
+			s.fail(job, fmt.Errorf("erro ao iniciar job de importação: %w", err))
+			return
+		}
+	}
+	s.publish(*job)
+
+	// Ao retomar, as linhas já processadas não são repetidas. Linhas criadas depois do último
+	// progresso gravado são reconhecidas como duplicatas e ignoradas.
+	errors := make([]string, 0)
+	var reviewLineIDs []string
+	for _, outcome := range job.Outcomes {
+		switch outcome.Status {
+		case structs.ImportLineError:
+			errors = append(errors, fmt.Sprintf("Linha %s (%s): %s", outcome.LineID, outcome.Description, outcome.Message))
+		case structs.ImportLineReview:
+			reviewLineIDs = append(reviewLineIDs, outcome.LineID)
+		}
+	}
+	importRun.Restore(job.TransactionsImported, job.TransactionsSkipped, errors, reviewLineIDs)
+
+	cancelled := job.CancelRequested
+	for i := job.ProcessedLines; i < importRun.Len() && !cancelled; i++ {
+		outcome := importRun.ImportLine(i)
+		job.Outcomes = append(job.Outcomes, outcome)
+		job.ProcessedLines = i + 1
+		job.TransactionsImported = importRun.Result.TransactionsImported
+		job.TransactionsSkipped = importRun.Result.TransactionsSkipped
+		job.UpdatedAt = time.Now()
+
+		cancelled = s.cancelRequested(job.ID)
+		if job.ProcessedLines%importJobProgressEvery == 0 {
+			requested, err := s.db.UpdateImportJobProgress(*job)
+			if err != nil {
+				fmt.Printf("Erro ao gravar progresso do job %s: %v\n", job.ID, err)
+			}
+			cancelled = cancelled || requested
+		}
+		s.publish(*job)
+	}
+
+	result, err := importRun.Finish(cancelled)
+	if err != nil {
+		s.fail(job, err)
+		return
+	}
+
+	job.Result = result
+	job.Status = structs.ImportJobStatusCompleted
+	if cancelled {
+		job.Status = structs.ImportJobStatusCancelled
+	}
+	s.finish(job)
+}
+
+// startRun prepara a importação do job, criando o lote na primeira execução ou retomando-o
+func (s *ImportJobService) startRun(job *structs.ImportJob) (*ImportRun, error) {
+	switch job.Kind {
+	case structs.ImportJobKindFile:
+		return s.importService.StartFileImport(job.UserID, job.AccountID, job.Format, job.FileName, job.Payload.FileHash,
+			job.Payload.Lines, job.Payload.LedgerBalance, job.ImportBatchID)
+	case structs.ImportJobKindPreview:
+		if job.Payload.Request == nil {
+			return nil, fmt.Errorf("job de importação sem linhas revisadas")
+		}
+		request := *job.Payload.Request
+		if job.ImportBatchID == "" {
+			// A duplicidade do arquivo já foi verificada ao criar o job
+			request.Force = true
+		}
+		return s.importService.StartPreviewImport(job.UserID, request, job.ImportBatchID)
+	}
+	return nil, fmt.Errorf("tipo de job de importação desconhecido: %s", job.Kind)
+}
+
+func (s *ImportJobService) fail(job *structs.ImportJob, err error) {
+	job.Status = structs.ImportJobStatusFailed
+	job.Error = err.Error()
+	s.finish(job)
+}
+
+func (s *ImportJobService) finish(job *structs.ImportJob) {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.UpdatedAt = now
+	if err := s.db.FinishImportJob(*job); err != nil {
+		fmt.Printf("Erro ao finalizar job de importação %s: %v\n", job.ID, err)
+	}
+	s.publish(*job)
+}
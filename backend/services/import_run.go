@@ -0,0 +1,628 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+// ImportRun processa uma importação linha a linha. Permite acompanhar o progresso, interromper
+// o processamento e retomá-lo a partir de um lote já criado (jobs de importação em segundo plano).
+type ImportRun struct {
+	s      *ImportService
+	userID string
+	format string
+
+	Batch  structs.ImportBatch
+	Result *structs.ImportResult
+
+	lines []structs.ImportLine
+	// reviewed são as linhas revisadas pelo usuário; nil quando o arquivo inteiro é importado
+	reviewed  []structs.ImportPreviewLineRequest
+	linesByID map[string]structs.ImportLine
+	previewID string
+
+	defaultAccount   *structs.Account
+	accounts         map[string]*structs.Account
+	categories       *importCategories
+	createCategories bool
+	ruleSet          *RuleSet
+	payees           *PayeeSet
+	duplicates       *duplicateIndex
+	// pendingReview são as linhas do modo arquivo que nenhuma regra categorizou
+	pendingReview []structs.ImportLine
+}
+
+// errLineNeedsReview indica que a linha do arquivo ficou sem categoria e vai para revisão
+var errLineNeedsReview = errors.New("transação sem categoria; classifique-a na revisão")
+
+// ImportPreview cria exatamente as transações revisadas pelo usuário a partir de uma pré-visualização
+func (s *ImportService) ImportPreview(userID string, req structs.ImportPreviewRequest) (*structs.ImportResult, error) {
+	run, err := s.StartPreviewImport(userID, req, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < run.Len(); i++ {
+		run.ImportLine(i)
+	}
+	return run.Finish(false)
+}
+
+// ImportFile importa todas as linhas de um arquivo, sem revisão, e retorna o resultado
+func (s *ImportService) ImportFile(userID, accountID, format, fileName, fileHash string, lines []structs.ImportLine, ledgerBalance *structs.StatementBalance) (*structs.ImportResult, error) {
+	run, err := s.StartFileImport(userID, accountID, format, fileName, fileHash, lines, ledgerBalance, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < run.Len(); i++ {
+		run.ImportLine(i)
+	}
+	return run.Finish(false)
+}
+
+// ValidatePreviewImport verifica se a pré-visualização pode ser importada na conta informada,
+// retornando DuplicateImportError quando o arquivo já foi importado e force não foi informado
+func (s *ImportService) ValidatePreviewImport(userID string, req structs.ImportPreviewRequest) (*structs.ImportPreview, error) {
+	preview, err := s.db.GetImportPreview(req.PreviewToken, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar pré-visualização: %w", err)
+	}
+	if preview == nil {
+		return nil, fmt.Errorf("pré-visualização não encontrada ou expirada. Envie o arquivo novamente")
+	}
+
+	if _, err := s.getAccount(make(map[string]*structs.Account), req.AccountID, userID); err != nil {
+		return nil, err
+	}
+
+	if !req.Force && preview.FileHash != "" {
+		previous, err := s.FindDuplicateImport(preview.FileHash, userID)
+		if err != nil {
+			return nil, err
+		}
+		if previous != nil {
+			return nil, &DuplicateImportError{Batch: previous}
+		}
+	}
+	return preview, nil
+}
+
+// StartPreviewImport prepara a importação das linhas revisadas de uma pré-visualização.
+// Com batchID, retoma a importação no lote já existente em vez de criar um novo.
+func (s *ImportService) StartPreviewImport(userID string, req structs.ImportPreviewRequest, batchID string) (*ImportRun, error) {
+	var preview *structs.ImportPreview
+	var err error
+	if batchID == "" {
+		preview, err = s.ValidatePreviewImport(userID, req)
+	} else {
+		preview, err = s.db.GetImportPreview(req.PreviewToken, userID)
+		if err == nil && preview == nil {
+			err = fmt.Errorf("pré-visualização não encontrada ou expirada")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := s.newImportRun(userID, preview.Format, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	run.lines = preview.Lines
+	run.reviewed = req.Lines
+	run.previewID = preview.ID
+	run.createCategories = req.CreateCategories
+	run.linesByID = make(map[string]structs.ImportLine, len(preview.Lines))
+	for _, line := range preview.Lines {
+		run.linesByID[line.ID] = line
+	}
+
+	if batchID != "" {
+		return run, run.resumeBatch(batchID)
+	}
+
+	// O lote cobre apenas as linhas escolhidas pelo usuário
+	selected := make([]structs.ImportLine, 0, len(req.Lines))
+	for _, reviewed := range req.Lines {
+		if line, ok := run.linesByID[reviewed.ID]; ok {
+			selected = append(selected, line)
+		}
+	}
+
+	run.Batch = NewImportBatch(preview.Format, selected, preview.FileName, preview.FileHash, run.defaultAccount.ID, userID)
+	run.Batch.TransactionsTotal = len(req.Lines)
+	SetStatementBalance(&run.Batch, preview.LedgerBalance, preview.Lines)
+	if preview.FileHash == "" {
+		// Na revisão as linhas são só parte do extrato: o saldo de abertura não sai delas
+		run.Batch.OpeningBalance = nil
+	}
+	if err := s.db.CreateImportBatch(run.Batch); err != nil {
+		return nil, fmt.Errorf("erro ao registrar importação: %w", err)
+	}
+	run.Result.ImportBatchID = run.Batch.ID
+	return run, nil
+}
+
+// StartFileImport prepara a importação de todas as linhas de um arquivo, sem revisão.
+// Com batchID, retoma a importação no lote já existente em vez de criar um novo.
+func (s *ImportService) StartFileImport(userID, accountID, format, fileName, fileHash string, lines []structs.ImportLine, ledgerBalance *structs.StatementBalance, batchID string) (*ImportRun, error) {
+	run, err := s.newImportRun(userID, format, accountID)
+	if err != nil {
+		return nil, err
+	}
+	run.lines = lines
+
+	if batchID != "" {
+		return run, run.resumeBatch(batchID)
+	}
+
+	// Registrar o lote antes de criar as transações para que elas possam referenciá-lo
+	run.Batch = NewImportBatch(format, lines, fileName, fileHash, run.defaultAccount.ID, userID)
+	SetStatementBalance(&run.Batch, ledgerBalance, lines)
+	if err := s.db.CreateImportBatch(run.Batch); err != nil {
+		return nil, fmt.Errorf("erro ao registrar importação: %w", err)
+	}
+	run.Result.ImportBatchID = run.Batch.ID
+	return run, nil
+}
+
+// newImportRun carrega o que é compartilhado por todas as linhas de uma importação
+func (s *ImportService) newImportRun(userID, format, accountID string) (*ImportRun, error) {
+	run := &ImportRun{
+		s:        s,
+		userID:   userID,
+		format:   format,
+		accounts: make(map[string]*structs.Account),
+		Result: &structs.ImportResult{
+			Success: true,
+			Errors:  []string{},
+		},
+	}
+
+	var err error
+	run.defaultAccount, err = s.getAccount(run.accounts, accountID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.db.GetAllCategories(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+	run.categories = newImportCategories(categories)
+
+	run.ruleSet, err = s.LoadRuleSet(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	run.payees, err = s.payeeService.LoadPayeeSet(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// As transações existentes são carregadas uma única vez para a verificação de duplicatas
+	run.duplicates, err = s.loadDuplicateIndex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// resumeBatch retoma o lote de uma importação interrompida
+func (r *ImportRun) resumeBatch(batchID string) error {
+	batch, err := r.s.db.GetImportBatchByID(batchID, r.userID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar importação: %w", err)
+	}
+	if batch == nil {
+		return fmt.Errorf("importação não encontrada")
+	}
+	r.Batch = *batch
+	r.Result.ImportBatchID = batch.ID
+	return nil
+}
+
+// Restore recupera os totais já processados antes de retomar uma importação, incluindo as
+// linhas do arquivo que já tinham ido para revisão
+func (r *ImportRun) Restore(imported, skipped int, errors []string, reviewLineIDs []string) {
+	r.Result.TransactionsImported = imported
+	r.Result.TransactionsSkipped = skipped
+	r.Result.Errors = append(r.Result.Errors, errors...)
+	for _, id := range reviewLineIDs {
+		for _, line := range r.lines {
+			if line.ID == id {
+				r.pendingReview = append(r.pendingReview, line)
+				break
+			}
+		}
+	}
+	r.Result.PendingReview = len(r.pendingReview)
+}
+
+// Len retorna quantas linhas a importação processa
+func (r *ImportRun) Len() int {
+	if r.reviewed != nil {
+		return len(r.reviewed)
+	}
+	return len(r.lines)
+}
+
+// ImportLine processa a linha de índice i e atualiza os totais do resultado
+func (r *ImportRun) ImportLine(i int) structs.ImportLineOutcome {
+	var line structs.ImportLine
+	var created bool
+	var err error
+
+	if r.reviewed != nil {
+		reviewed := r.reviewed[i]
+		var ok bool
+		line, ok = r.linesByID[reviewed.ID]
+		if !ok {
+			message := fmt.Sprintf("Linha %s não existe na pré-visualização", reviewed.ID)
+			r.Result.Errors = append(r.Result.Errors, message)
+			r.Result.TransactionsSkipped++
+			return structs.ImportLineOutcome{LineID: reviewed.ID, Status: structs.ImportLineError, Message: message}
+		}
+		created, err = r.importReviewedLine(line, reviewed)
+	} else {
+		line = r.lines[i]
+		created, err = r.importFileLine(line)
+	}
+
+	outcome := structs.ImportLineOutcome{LineID: line.ID, Description: ImportLineDescription(line)}
+	switch {
+	case errors.Is(err, errLineNeedsReview):
+		outcome.Status = structs.ImportLineReview
+		outcome.Message = err.Error()
+		r.pendingReview = append(r.pendingReview, line)
+		r.Result.PendingReview++
+	case err != nil:
+		outcome.Status = structs.ImportLineError
+		outcome.Message = err.Error()
+		r.Result.Errors = append(r.Result.Errors, fmt.Sprintf("Linha %s (%s): %v", line.ID, line.Description, err))
+		r.Result.TransactionsSkipped++
+	case !created:
+		outcome.Status = structs.ImportLineSkipped
+		outcome.Message = "Transação já existe na conta"
+		r.Result.TransactionsSkipped++
+	default:
+		outcome.Status = structs.ImportLineImported
+		r.Result.TransactionsImported++
+	}
+	return outcome
+}
+
+// Finish grava o resultado no lote, confere o saldo do extrato e monta a mensagem final.
+// cancelled indica que o processamento foi interrompido pelo usuário antes da última linha.
+func (r *ImportRun) Finish(cancelled bool) (*structs.ImportResult, error) {
+	result := r.Result
+
+	r.Batch.TransactionsImported = result.TransactionsImported
+	r.Batch.TransactionsSkipped = result.TransactionsSkipped
+	r.Batch.Errors = result.Errors
+	r.Batch.Status = structs.ImportBatchStatusCompleted
+	if cancelled {
+		r.Batch.Status = structs.ImportBatchStatusCancelled
+	}
+	if err := r.s.db.FinishImportBatch(r.Batch); err != nil {
+		return nil, fmt.Errorf("erro ao finalizar importação: %w", err)
+	}
+
+	// Conferir o saldo da conta com o saldo informado no extrato. Com linhas em revisão, a conta
+	// ainda não tem todo o extrato: a conferência fica para a importação da revisão.
+	pendingReview := !cancelled && len(r.pendingReview) > 0
+	if pendingReview {
+		result.BalanceCheckPending = r.Batch.LedgerBalance != nil
+	} else if !cancelled {
+		balanceCheck, err := r.s.CheckImportBalance(&r.Batch)
+		if err != nil {
+			fmt.Printf("Erro ao conferir saldo da importação %s: %v\n", r.Batch.ID, err)
+		}
+		result.BalanceCheck = balanceCheck
+	}
+
+	// Avaliar as despesas importadas contra o histórico, gerando os alertas de gastos incomuns
+	if r.s.insightService != nil && result.TransactionsImported > 0 {
+		if err := r.evaluateInsights(result); err != nil {
+			fmt.Printf("Erro ao avaliar gastos incomuns da importação %s: %v\n", r.Batch.ID, err)
+		}
+	}
+
+	// As linhas sem categoria ficam em uma nova pré-visualização, para o usuário revisar
+	if pendingReview {
+		var ledgerBalance *structs.StatementBalance
+		if r.Batch.LedgerBalance != nil && r.Batch.LedgerBalanceDate != nil {
+			ledgerBalance = &structs.StatementBalance{Amount: *r.Batch.LedgerBalance, Date: *r.Batch.LedgerBalanceDate}
+		}
+		preview, err := r.s.createReviewPreview(r.userID, r.format, r.Batch.FileName, r.pendingReview, ledgerBalance)
+		if err != nil {
+			return nil, err
+		}
+		result.ReviewToken = preview.ID
+	}
+
+	// A pré-visualização só pode ser importada uma vez
+	if r.previewID != "" {
+		if err := r.s.db.DeleteImportPreview(r.previewID, r.userID); err != nil {
+			fmt.Printf("Erro ao remover pré-visualização %s: %v\n", r.previewID, err)
+		}
+	}
+
+	switch {
+	case cancelled:
+		result.Message = fmt.Sprintf("Importação cancelada. %d transações importadas antes do cancelamento; desfaça a importação para removê-las.",
+			result.TransactionsImported)
+	case result.TransactionsImported > 0:
+		result.Message = fmt.Sprintf("Importação concluída! %d transações importadas, %d ignoradas.",
+			result.TransactionsImported, result.TransactionsSkipped)
+		if result.Flags > 0 {
+			result.Message += fmt.Sprintf(" %d alertas de gastos incomuns.", result.Flags)
+		}
+	case result.PendingReview == 0:
+		result.Message = "Nenhuma transação nova encontrada para importar."
+	default:
+		result.Message = "Nenhuma transação importada."
+	}
+	if !cancelled && result.PendingReview > 0 {
+		result.Message += fmt.Sprintf(" %d transações sem categoria aguardam revisão.", result.PendingReview)
+	}
+
+	return result, nil
+}
+
+// evaluateInsights gera os alertas de gastos incomuns das transações criadas pelo lote
+func (r *ImportRun) evaluateInsights(result *structs.ImportResult) error {
+	transactions, err := r.s.db.GetTransactionsByImportBatch(r.Batch.ID, r.userID)
+	if err != nil {
+		return err
+	}
+	evaluation, err := r.s.insightService.EvaluateTransactions(r.userID, transactions)
+	if err != nil {
+		return err
+	}
+	result.Flags = evaluation.FlagsCreated
+	return nil
+}
+
+// importFileLine cria a transação de uma linha do arquivo sem revisão do usuário.
+// As regras de categorização definem categoria, tags, beneficiário e observação; sem categoria,
+// a linha não é importada e retorna errLineNeedsReview.
+// Retorna false quando a linha já existia e foi ignorada.
+func (r *ImportRun) importFileLine(line structs.ImportLine) (bool, error) {
+	txType := "expense"
+	if line.Amount > 0 {
+		txType = "income"
+	}
+
+	// Usar data da transação ou data atual se não disponível
+	date := line.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	tx := structs.Transaction{
+		ID:                  utils.GenerateUUID(),
+		UserID:              r.userID,
+		Description:         ImportLineDescription(line),
+		OriginalDescription: OriginalLineDescription(line),
+		Amount:              amountToCents(line.Amount),
+		Type:                txType,
+		AccountID:           r.defaultAccount.ID,
+		DueDate:             date,
+		CompetenceDate:      date,
+		IsPaid:              true, // Transações importadas são consideradas pagas
+		Installments:        1,
+		CurrentInstallment:  1,
+		ImportBatchID:       &r.Batch.ID,
+		ExternalID:          line.ExternalID,
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
+	}
+
+	r.ruleSet.ApplyTo(&tx)
+	if tx.Observation == "" {
+		tx.Observation = fmt.Sprintf("Importado via %s - %s", strings.ToUpper(r.format), line.ExternalID)
+	}
+
+	if r.duplicates.exists(&tx) {
+		return false, nil
+	}
+
+	// Categoria informada no próprio arquivo, se já existir
+	if tx.CategoryID == "" && line.Category != "" {
+		if category := r.categories.find(line.Category, txType); category != nil {
+			tx.CategoryID = category.ID
+		}
+	}
+	if tx.CategoryID == "" {
+		return false, errLineNeedsReview
+	}
+
+	if err := r.payees.AssignTo(&tx, tx.OriginalDescription); err != nil {
+		return false, err
+	}
+	if err := r.s.db.CreateTransaction(tx); err != nil {
+		return false, fmt.Errorf("erro ao criar transação: %w", err)
+	}
+	r.duplicates.add(tx)
+	r.s.LearnCategory(tx)
+	return true, nil
+}
+
+// importReviewedLine cria a transação (ou o par de transferência) de uma linha revisada.
+// Retorna false quando a linha já existia e foi ignorada.
+func (r *ImportRun) importReviewedLine(line structs.ImportLine, reviewed structs.ImportPreviewLineRequest) (bool, error) {
+	s := r.s
+	account := r.defaultAccount
+	if reviewed.AccountID != "" {
+		var err error
+		account, err = s.getAccount(r.accounts, reviewed.AccountID, r.userID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	description := strings.TrimSpace(reviewed.Description)
+	if description == "" {
+		description = ImportLineDescription(line)
+	}
+
+	observation := strings.TrimSpace(reviewed.Observation)
+
+	txType := "expense"
+	if line.Amount > 0 {
+		txType = "income"
+	}
+	isTransfer := reviewed.TransferAccountID != "" || reviewed.LinkTransactionID != ""
+	if reviewed.Type != "" && !isTransfer {
+		if reviewed.Type != "income" && reviewed.Type != "expense" {
+			return false, fmt.Errorf("tipo deve ser 'income' ou 'expense'")
+		}
+		txType = reviewed.Type
+	}
+
+	tx := structs.Transaction{
+		ID:                  utils.GenerateUUID(),
+		UserID:              r.userID,
+		Description:         description,
+		OriginalDescription: OriginalLineDescription(line),
+		Amount:              amountToCents(line.Amount),
+		Type:                txType,
+		AccountID:           account.ID,
+		DueDate:             line.Date,
+		CompetenceDate:      line.Date,
+		IsPaid:              true, // Transações importadas são consideradas pagas
+		Observation:         observation,
+		Installments:        1,
+		CurrentInstallment:  1,
+		ImportBatchID:       &r.Batch.ID,
+		ExternalID:          line.ExternalID,
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
+	}
+
+	// Regras de categorização completam o que o usuário não definiu na revisão
+	if !isTransfer {
+		tx.CategoryID = reviewed.CategoryID
+		tx.PayeeID = emptyToNil(&reviewed.PayeeID)
+		tx.Payee = strings.TrimSpace(reviewed.Payee)
+	}
+	r.ruleSet.ApplyTo(&tx)
+	if tx.Observation == "" {
+		tx.Observation = fmt.Sprintf("Importado via %s - %s", strings.ToUpper(r.format), line.ExternalID)
+	}
+
+	if r.duplicates.exists(&tx) {
+		return false, nil
+	}
+
+	if reviewed.LinkTransactionID != "" {
+		if err := s.transferService.LinkImportedTransaction(tx, reviewed.LinkTransactionID); err != nil {
+			return false, err
+		}
+		r.duplicates.add(tx)
+		return true, nil
+	}
+	if reviewed.TransferAccountID != "" {
+		if err := s.createImportedTransfer(tx, account, reviewed.TransferAccountID, r.accounts, r.userID); err != nil {
+			return false, err
+		}
+		r.duplicates.add(tx)
+		return true, nil
+	}
+
+	if tx.CategoryID == "" && line.Category != "" {
+		// Categoria informada no próprio arquivo (coluna do CSV ou caminho "Categoria:Subcategoria" do QIF)
+		if r.createCategories {
+			category, err := r.categories.ensure(s.db, r.userID, line.Category, txType)
+			if err != nil {
+				return false, err
+			}
+			tx.CategoryID = category.ID
+		} else if category := r.categories.find(line.Category, txType); category != nil {
+			tx.CategoryID = category.ID
+		}
+	}
+
+	category, ok := r.categories.byID[tx.CategoryID]
+	if !ok {
+		return false, fmt.Errorf("categoria não encontrada")
+	}
+	if string(category.Type) != txType {
+		return false, fmt.Errorf("a categoria '%s' não é do tipo %s", category.Name, txType)
+	}
+	tx.CategoryID = category.ID
+
+	if err := r.payees.AssignTo(&tx, tx.OriginalDescription); err != nil {
+		return false, err
+	}
+	if err := s.db.CreateTransaction(tx); err != nil {
+		return false, fmt.Errorf("erro ao criar transação: %w", err)
+	}
+	r.duplicates.add(tx)
+	s.LearnCategory(tx)
+	return true, nil
+}
+
+// duplicateIndex guarda as transações do usuário por conta, para verificar duplicatas
+// sem consultar o banco a cada linha importada
+type duplicateIndex struct {
+	byAccount map[string][]structs.Transaction
+}
+
+func (s *ImportService) loadDuplicateIndex(userID string) (*duplicateIndex, error) {
+	transactions, err := s.db.GetAllTransactionsByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações existentes: %w", err)
+	}
+
+	index := &duplicateIndex{byAccount: make(map[string][]structs.Transaction)}
+	for _, tx := range transactions {
+		index.add(tx)
+	}
+	return index, nil
+}
+
+func (d *duplicateIndex) add(tx structs.Transaction) {
+	d.byAccount[tx.AccountID] = append(d.byAccount[tx.AccountID], tx)
+}
+
+// exists verifica se uma transação importada já existe na conta: mesmo identificador de origem,
+// ou, quando um dos lados não tem identificador, mesma data (±1 dia), mesmo valor (±1 centavo)
+// e descrição semelhante
+func (d *duplicateIndex) exists(tx *structs.Transaction) bool {
+	description := strings.ToLower(tx.Description)
+	for _, existingTx := range d.byAccount[tx.AccountID] {
+		// Mesmo identificador de origem (FITID) na mesma conta é sempre duplicata. Os extratos
+		// OFX exportados pelo sistema usam o ID da própria transação como FITID.
+		if tx.ExternalID != "" && (existingTx.ExternalID == tx.ExternalID || existingTx.ID == tx.ExternalID) {
+			return true
+		}
+		// Identificadores diferentes são lançamentos diferentes, mesmo com data, valor e
+		// descrição iguais (duas compras iguais no mesmo dia)
+		if tx.ExternalID != "" && existingTx.ExternalID != "" {
+			continue
+		}
+
+		dateDiff := existingTx.DueDate.Sub(tx.DueDate)
+		if dateDiff < -24*time.Hour || dateDiff > 24*time.Hour {
+			continue
+		}
+
+		amountDiff := existingTx.Amount - tx.Amount
+		if amountDiff < -1 || amountDiff > 1 {
+			continue
+		}
+
+		existingDescription := strings.ToLower(existingTx.Description)
+		if strings.Contains(existingDescription, description) || strings.Contains(description, existingDescription) {
+			return true
+		}
+	}
+	return false
+}
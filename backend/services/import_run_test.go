@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+func TestDuplicateIndexExists(t *testing.T) {
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	existing := structs.Transaction{
+		ID:          "tx-1",
+		AccountID:   "conta",
+		Description: "PADARIA CENTRAL",
+		Amount:      -1250,
+		DueDate:     day,
+		ExternalID:  "FITID001",
+	}
+	manual := structs.Transaction{
+		ID:          "tx-2",
+		AccountID:   "conta",
+		Description: "Mercado",
+		Amount:      -8990,
+		DueDate:     day,
+	}
+	index := &duplicateIndex{byAccount: make(map[string][]structs.Transaction)}
+	index.add(existing)
+	index.add(manual)
+
+	tests := []struct {
+		name string
+		tx   structs.Transaction
+		want bool
+	}{
+		{"mesmo identificador", structs.Transaction{AccountID: "conta", Description: "Outra", Amount: -1, DueDate: day.AddDate(0, 1, 0), ExternalID: "FITID001"}, true},
+		{"identificador igual ao ID exportado", structs.Transaction{AccountID: "conta", Description: "Mercado", Amount: -8990, DueDate: day, ExternalID: "tx-2"}, true},
+		{"compra igual com outro identificador", structs.Transaction{AccountID: "conta", Description: "PADARIA CENTRAL", Amount: -1250, DueDate: day, ExternalID: "FITID002"}, false},
+		{"linha sem identificador", structs.Transaction{AccountID: "conta", Description: "padaria central", Amount: -1250, DueDate: day.AddDate(0, 0, 1)}, true},
+		{"transação existente sem identificador", structs.Transaction{AccountID: "conta", Description: "MERCADO", Amount: -8991, DueDate: day, ExternalID: "FITID003"}, true},
+		{"outra conta", structs.Transaction{AccountID: "outra", Description: "PADARIA CENTRAL", Amount: -1250, DueDate: day}, false},
+		{"valor diferente", structs.Transaction{AccountID: "conta", Description: "PADARIA CENTRAL", Amount: -1300, DueDate: day}, false},
+	}
+	for _, tt := range tests {
+		if got := index.exists(&tt.tx); got != tt.want {
+			t.Errorf("%s: duplicata = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
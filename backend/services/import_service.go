@@ -0,0 +1,410 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+// importPreviewTTL define por quanto tempo uma pré-visualização pode ser importada
+const importPreviewTTL = 24 * time.Hour
+
+type ImportService struct {
+	db                *database.Database
+	exchangeService   ExchangeServiceInterface
+	ruleService       *RuleService
+	suggestionService *SuggestionService
+	transferService   *TransferService
+	payeeService      *PayeeService
+	insightService    *InsightService
+}
+
+// NewImportService cria uma nova instância do serviço de importações
+func NewImportService(db *database.Database, exchangeService ExchangeServiceInterface, ruleService *RuleService, suggestionService *SuggestionService, transferService *TransferService, payeeService *PayeeService, insightService *InsightService) *ImportService {
+	return &ImportService{db: db, exchangeService: exchangeService, ruleService: ruleService, suggestionService: suggestionService, transferService: transferService, payeeService: payeeService, insightService: insightService}
+}
+
+// LoadCategoryModel carrega o modelo de sugestão de categorias usado na pré-visualização
+func (s *ImportService) LoadCategoryModel(userID string) (*CategoryModel, error) {
+	return s.suggestionService.LoadModel(userID)
+}
+
+// LineSuggestion reúne as sugestões de categorização de uma linha da pré-visualização
+type LineSuggestion struct {
+	RuleOutcome
+	// Source indica a origem da categoria sugerida: "rule", "file" ou "history"
+	Source              string
+	CategorySuggestions []structs.CategorySuggestion
+	// TransferCandidates são transações de outras contas que podem ser a contrapartida da linha
+	TransferCandidates []structs.TransferCandidate
+	// PayeeID é o beneficiário já cadastrado reconhecido na linha; vazio quando Payee seria criado
+	PayeeID string
+}
+
+// SuggestLines sugere categoria, tags e beneficiário para cada linha de um arquivo.
+// Regras têm precedência sobre a categoria informada no arquivo, que tem precedência sobre o histórico.
+// Quando a conta é informada, também sugere contrapartidas de transferência em outras contas.
+func (s *ImportService) SuggestLines(userID, accountID string, lines []structs.ImportLine) ([]LineSuggestion, error) {
+	ruleSet, err := s.LoadRuleSet(userID)
+	if err != nil {
+		return nil, err
+	}
+	model, err := s.LoadCategoryModel(userID)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.db.GetAllCategories(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+	transferCandidates, err := s.transferService.FindLineCandidates(userID, accountID, lines)
+	if err != nil {
+		return nil, err
+	}
+	payees, err := s.payeeService.LoadPayeeSet(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]LineSuggestion, len(lines))
+	for i, line := range lines {
+		txType := "expense"
+		if line.Amount > 0 {
+			txType = "income"
+		}
+		description := ImportLineDescription(line)
+		amount := amountToCents(line.Amount)
+
+		suggestion := LineSuggestion{RuleOutcome: ruleSet.Evaluate(description, amount, accountID, txType)}
+		suggestion.CategorySuggestions = model.Suggest(description, amount, accountID, txType, maxSuggestions)
+		suggestion.TransferCandidates = transferCandidates[i]
+
+		// Beneficiário: o definido por uma regra de categorização ou o reconhecido na descrição do banco
+		if suggestion.Payee != "" {
+			if payee := payees.FindByName(suggestion.Payee); payee != nil {
+				suggestion.PayeeID = payee.ID
+				suggestion.Payee = payee.Name
+			}
+		} else if original := OriginalLineDescription(line); original != "" {
+			payee, name := payees.Match(original)
+			suggestion.Payee = name
+			if payee != nil {
+				suggestion.PayeeID = payee.ID
+			}
+		}
+
+		switch {
+		case suggestion.CategoryID != "":
+			suggestion.Source = "rule"
+		case findCategoryByPath(categories, line.Category, txType) != nil:
+			suggestion.CategoryID = findCategoryByPath(categories, line.Category, txType).ID
+			suggestion.Source = "file"
+		case len(suggestion.CategorySuggestions) > 0:
+			suggestion.CategoryID = suggestion.CategorySuggestions[0].CategoryID
+			suggestion.Source = "history"
+		}
+		suggestions[i] = suggestion
+	}
+	return suggestions, nil
+}
+
+// LearnCategory inclui uma transação importada no modelo de sugestão de categorias
+func (s *ImportService) LearnCategory(tx structs.Transaction) {
+	if err := s.suggestionService.Learn(tx); err != nil {
+		fmt.Printf("Erro ao atualizar modelo de sugestão de categorias: %v\n", err)
+	}
+}
+
+// LoadRuleSet carrega as regras de categorização aplicadas às linhas importadas
+func (s *ImportService) LoadRuleSet(userID string) (*RuleSet, error) {
+	return s.ruleService.LoadRuleSet(userID)
+}
+
+// DuplicateImportError indica que o mesmo arquivo já foi importado e não foi desfeito
+type DuplicateImportError struct {
+	Batch *structs.ImportBatch
+}
+
+func (e *DuplicateImportError) Error() string {
+	return "este arquivo já foi importado. Desfaça a importação anterior ou use force para importar novamente"
+}
+
+// ImportBatchDetails representa um lote de importação com as transações que ele criou
+type ImportBatchDetails struct {
+	structs.ImportBatch
+	Transactions []structs.Transaction `json:"transactions"`
+}
+
+// GetImportBatches lista o histórico de importações do usuário
+func (s *ImportService) GetImportBatches(userID string, accountID string) ([]structs.ImportBatch, error) {
+	batches, err := s.db.GetImportBatchesByUser(userID, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar importações: %w", err)
+	}
+	return batches, nil
+}
+
+// GetImportBatch busca um lote de importação com suas transações
+func (s *ImportService) GetImportBatch(id string, userID string) (*ImportBatchDetails, error) {
+	if !utils.IsValidUUID(id) {
+		return nil, fmt.Errorf("ID deve ser um UUID válido")
+	}
+
+	batch, err := s.db.GetImportBatchByID(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar importação: %w", err)
+	}
+	if batch == nil {
+		return nil, fmt.Errorf("importação não encontrada")
+	}
+
+	transactions, err := s.db.GetTransactionsByImportBatch(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações da importação: %w", err)
+	}
+
+	return &ImportBatchDetails{ImportBatch: *batch, Transactions: transactions}, nil
+}
+
+// RevertImportBatch desfaz uma importação removendo todas as transações criadas por ela
+func (s *ImportService) RevertImportBatch(id string, userID string) (*structs.ImportBatch, int64, error) {
+	if !utils.IsValidUUID(id) {
+		return nil, 0, fmt.Errorf("ID deve ser um UUID válido")
+	}
+
+	batch, err := s.db.GetImportBatchByID(id, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao buscar importação: %w", err)
+	}
+	if batch == nil {
+		return nil, 0, fmt.Errorf("importação não encontrada")
+	}
+	if batch.Status == structs.ImportBatchStatusReverted {
+		return nil, 0, fmt.Errorf("esta importação já foi desfeita")
+	}
+
+	transactions, err := s.db.GetTransactionsByImportBatch(id, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao buscar transações da importação: %w", err)
+	}
+
+	removed, err := s.db.RevertImportBatch(id, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao desfazer importação: %w", err)
+	}
+
+	// As transações removidas deixam de ensinar o modelo de sugestão de categorias
+	for _, tx := range transactions {
+		if err := s.suggestionService.Unlearn(tx); err != nil {
+			fmt.Printf("Erro ao atualizar modelo de sugestão de categorias: %v\n", err)
+		}
+	}
+
+	reverted, err := s.db.GetImportBatchByID(id, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao buscar importação desfeita: %w", err)
+	}
+
+	return reverted, removed, nil
+}
+
+// HashFileContent calcula o SHA-256 do arquivo para detectar reimportações
+func HashFileContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// NewImportBatch monta o lote de importação a partir das linhas lidas do arquivo
+func NewImportBatch(format string, lines []structs.ImportLine, fileName, fileHash, accountID, userID string) structs.ImportBatch {
+	batch := structs.ImportBatch{
+		ID:                utils.GenerateUUID(),
+		UserID:            userID,
+		AccountID:         accountID,
+		Format:            format,
+		FileName:          fileName,
+		FileHash:          fileHash,
+		TransactionsTotal: len(lines),
+		Errors:            []string{},
+		Status:            structs.ImportBatchStatusProcessing,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+
+	// Período coberto pelo arquivo
+	for i := range lines {
+		date := lines[i].Date
+		if batch.DateStart == nil || date.Before(*batch.DateStart) {
+			batch.DateStart = &lines[i].Date
+		}
+		if batch.DateEnd == nil || date.After(*batch.DateEnd) {
+			batch.DateEnd = &lines[i].Date
+		}
+	}
+
+	return batch
+}
+
+// FindDuplicateImport busca uma importação ativa do mesmo arquivo
+func (s *ImportService) FindDuplicateImport(fileHash string, userID string) (*structs.ImportBatch, error) {
+	batch, err := s.db.GetActiveImportBatchByHash(fileHash, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar importações anteriores: %w", err)
+	}
+	return batch, nil
+}
+
+// CreatePreview guarda as linhas analisadas de um arquivo e retorna o token para importá-las.
+// ledgerBalance é o saldo informado pelo banco no arquivo, quando existe.
+func (s *ImportService) CreatePreview(userID, format, fileName string, content []byte, lines []structs.ImportLine, ledgerBalance *structs.StatementBalance) (*structs.ImportPreview, error) {
+	// Aproveitar para descartar pré-visualizações vencidas
+	if err := s.db.DeleteExpiredImportPreviews(); err != nil {
+		fmt.Printf("Erro ao remover pré-visualizações expiradas: %v\n", err)
+	}
+
+	if lines == nil {
+		lines = []structs.ImportLine{}
+	}
+
+	now := time.Now()
+	preview := structs.ImportPreview{
+		ID:            utils.GenerateUUID(),
+		UserID:        userID,
+		Format:        format,
+		FileName:      fileName,
+		FileHash:      HashFileContent(content),
+		Lines:         lines,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(importPreviewTTL),
+		LedgerBalance: ledgerBalance,
+	}
+
+	if err := s.db.CreateImportPreview(preview); err != nil {
+		return nil, fmt.Errorf("erro ao salvar pré-visualização: %w", err)
+	}
+	return &preview, nil
+}
+
+// createReviewPreview guarda as linhas de uma importação de arquivo que ficaram sem categoria.
+// A pré-visualização não tem o hash do arquivo, já importado em parte: a importação das linhas
+// revisadas não é recusada como arquivo repetido, e as duplicatas são conferidas linha a linha.
+// O saldo do extrato vai junto, para a conferência ser feita depois da revisão.
+func (s *ImportService) createReviewPreview(userID, format, fileName string, lines []structs.ImportLine, ledgerBalance *structs.StatementBalance) (*structs.ImportPreview, error) {
+	now := time.Now()
+	preview := structs.ImportPreview{
+		ID:            utils.GenerateUUID(),
+		UserID:        userID,
+		Format:        format,
+		FileName:      fileName,
+		Lines:         lines,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(importPreviewTTL),
+		LedgerBalance: ledgerBalance,
+	}
+	if err := s.db.CreateImportPreview(preview); err != nil {
+		return nil, fmt.Errorf("erro ao salvar linhas para revisão: %w", err)
+	}
+	return &preview, nil
+}
+
+// createImportedTransfer cria o par de transações de uma linha marcada como transferência.
+// A transação da conta importada mantém o sentido do extrato; a contrapartida fica na outra conta.
+func (s *ImportService) createImportedTransfer(tx structs.Transaction, account *structs.Account, otherAccountID string, accounts map[string]*structs.Account, userID string) error {
+	otherAccount, err := s.getAccount(accounts, otherAccountID, userID)
+	if err != nil {
+		return err
+	}
+	if otherAccount.ID == account.ID {
+		return fmt.Errorf("a conta de transferência deve ser diferente da conta importada")
+	}
+
+	transferCategory, err := s.db.EnsureTransferCategory()
+	if err != nil {
+		return fmt.Errorf("erro ao buscar categoria de transferência: %w", err)
+	}
+
+	otherAmount := tx.Amount
+	if account.Currency != otherAccount.Currency {
+		exchangeInfo, err := s.exchangeService.GetExchangeRate(account.Currency, otherAccount.Currency, float64(tx.Amount)/100.0)
+		if err != nil {
+			return fmt.Errorf("erro ao obter taxa de câmbio: %w", err)
+		}
+		otherAmount = amountToCents(exchangeInfo.ConversionResult)
+		tx.Observation = fmt.Sprintf("%s | Câmbio: %.4f %s/%s", tx.Observation, exchangeInfo.ConversionRate, account.Currency, otherAccount.Currency)
+	}
+
+	transferID := utils.GenerateUUID()
+	tx.CategoryID = transferCategory.ID
+	tx.TransferID = &transferID
+
+	counterpart := tx
+	counterpart.ID = utils.GenerateUUID()
+	counterpart.AccountID = otherAccount.ID
+	counterpart.Amount = otherAmount
+	counterpart.ExternalID = ""
+	if tx.Type == "expense" {
+		counterpart.Type = "income"
+	} else {
+		counterpart.Type = "expense"
+	}
+
+	if err := s.db.CreateTransaction(tx); err != nil {
+		return fmt.Errorf("erro ao criar transação: %w", err)
+	}
+	if err := s.db.CreateTransaction(counterpart); err != nil {
+		return fmt.Errorf("erro ao criar contrapartida da transferência: %w", err)
+	}
+	return nil
+}
+
+// getAccount busca (com cache) uma conta ativa do usuário
+func (s *ImportService) getAccount(cache map[string]*structs.Account, accountID, userID string) (*structs.Account, error) {
+	if account, ok := cache[accountID]; ok {
+		return account, nil
+	}
+	if !utils.IsValidUUID(accountID) {
+		return nil, fmt.Errorf("ID da conta deve ser um UUID válido")
+	}
+
+	account, err := s.db.GetAccountByID(accountID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar conta: %w", err)
+	}
+	if account == nil || account.DeletedAt != nil {
+		return nil, fmt.Errorf("conta não encontrada")
+	}
+
+	cache[accountID] = account
+	return account, nil
+}
+
+// ImportLineDescription monta a descrição padrão de uma linha importada
+func ImportLineDescription(line structs.ImportLine) string {
+	description := OriginalLineDescription(line)
+	if description == "" {
+		description = "Transação importada"
+	}
+	return description
+}
+
+// OriginalLineDescription retorna o texto da linha como veio do banco (descrição ou, na falta dela, o memo).
+// Quando a descrição é o início do memo (NAME truncado no OFX), usa o memo completo.
+func OriginalLineDescription(line structs.ImportLine) string {
+	description := strings.TrimSpace(line.Description)
+	memo := strings.TrimSpace(line.Memo)
+	if description == "" || (len(memo) > len(description) && strings.HasPrefix(memo, description)) {
+		description = memo
+	}
+	return description
+}
+
+// amountToCents converte um valor em reais (com sinal) para centavos positivos
+func amountToCents(amount float64) int {
+	return int(math.Round(math.Abs(amount) * 100))
+}
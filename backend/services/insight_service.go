@@ -0,0 +1,379 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+// Parâmetros da detecção de gastos incomuns
+const (
+	// insightHistoryDays é a janela do histórico usado para o valor habitual
+	insightHistoryDays = 365
+	// insightMinSamples é o mínimo de transações no histórico para comparar o valor
+	insightMinSamples = 5
+	// insightOutlierFactor: o valor precisa ser pelo menos esse múltiplo da mediana
+	insightOutlierFactor = 2.5
+	// insightOutlierScore é o desvio mínimo em relação à mediana, em desvios absolutos medianos
+	insightOutlierScore = 3.5
+	// insightMinDifference é a diferença mínima para a mediana (R$ 50,00), para não alertar centavos
+	insightMinDifference = 5000
+	// insightDuplicateDays é a distância máxima entre as datas de duas cobranças iguais
+	insightDuplicateDays = 3
+	// insightUsualMonths é quantos meses anteriores formam o gasto habitual da categoria
+	insightUsualMonths = 6
+	// insightCategoryThreshold: a projeção do mês precisa passar o habitual em pelo menos 20%
+	insightCategoryThreshold = 1.2
+)
+
+type InsightService struct {
+	db *database.Database
+}
+
+// NewInsightService cria uma nova instância do serviço de alertas de gastos incomuns
+func NewInsightService(db *database.Database) *InsightService {
+	return &InsightService{db: db}
+}
+
+// GetInsights lista os alertas das transações e as categorias com gasto acima do habitual no mês
+func (s *InsightService) GetInsights(userID, currency string, month time.Time, includeDismissed bool) (*structs.Insights, error) {
+	flags, err := s.db.GetTransactionFlags(userID, includeDismissed)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categoryAlerts(userID, currency, month)
+	if err != nil {
+		return nil, err
+	}
+	return &structs.Insights{
+		Month:      month.Format("2006-01"),
+		Currency:   currency,
+		Flags:      flags,
+		Categories: categories,
+	}, nil
+}
+
+// DismissFlag dispensa um alerta de transação
+func (s *InsightService) DismissFlag(id, userID string) error {
+	dismissed, err := s.db.DismissTransactionFlag(id, userID)
+	if err != nil {
+		return err
+	}
+	if !dismissed {
+		return fmt.Errorf("alerta não encontrado")
+	}
+	return nil
+}
+
+// EvaluateTransactions compara as despesas com o histórico do usuário e grava os alertas. É
+// chamada ao criar uma transação e ao fim de uma importação. Uma transação nunca recebe de novo
+// um alerta do mesmo tipo, mesmo que o anterior tenha sido dispensado.
+func (s *InsightService) EvaluateTransactions(userID string, transactions []structs.Transaction) (*structs.InsightEvaluation, error) {
+	result := &structs.InsightEvaluation{}
+
+	var targets []structs.Transaction
+	earliest := time.Time{}
+	for _, tx := range transactions {
+		if tx.Type != "expense" || tx.TransferID != nil || tx.Amount <= 0 {
+			continue
+		}
+		targets = append(targets, tx)
+		if earliest.IsZero() || tx.DueDate.Before(earliest) {
+			earliest = tx.DueDate
+		}
+	}
+	if len(targets) == 0 {
+		return result, nil
+	}
+
+	data, err := s.loadInsightData(userID, earliest.AddDate(0, 0, -insightHistoryDays))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tx := range targets {
+		if tx.CategoryID == data.transferCategory {
+			continue
+		}
+		result.Evaluated++
+		for _, flag := range data.evaluate(tx) {
+			created, err := s.db.SaveTransactionFlag(flag)
+			if err != nil {
+				return nil, err
+			}
+			if created {
+				result.FlagsCreated++
+			}
+		}
+	}
+	return result, nil
+}
+
+// EvaluatePeriod avalia de novo as despesas com vencimento no período, para gerar os alertas das
+// transações criadas antes da detecção existir
+func (s *InsightService) EvaluatePeriod(userID string, from, to time.Time) (*structs.InsightEvaluation, error) {
+	transactions, err := s.db.GetFilteredTransactions(userID, structs.TransactionFilter{From: &from, To: &to, Type: "expense"})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+	return s.EvaluateTransactions(userID, transactions)
+}
+
+// insightData é o histórico de despesas do usuário, indexado para as comparações
+type insightData struct {
+	userID           string
+	transferCategory string
+	accounts         map[string]*structs.Account
+	categories       map[string]*structs.Category
+	byPayee          map[string][]structs.Transaction
+	byCategory       map[string][]structs.Transaction
+	byAccountAmount  map[string][]structs.Transaction
+}
+
+func (s *InsightService) loadInsightData(userID string, from time.Time) (*insightData, error) {
+	history, err := s.db.GetFilteredTransactions(userID, structs.TransactionFilter{From: &from, Type: "expense"})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar histórico de transações: %w", err)
+	}
+	transferCategory, err := s.db.EnsureTransferCategory()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categoria de transferência: %w", err)
+	}
+	accounts, err := s.db.GetAllAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar contas: %w", err)
+	}
+	categories, err := s.db.GetAllCategories(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+
+	data := &insightData{
+		userID:           userID,
+		transferCategory: transferCategory.ID,
+		accounts:         make(map[string]*structs.Account),
+		categories:       make(map[string]*structs.Category),
+		byPayee:          make(map[string][]structs.Transaction),
+		byCategory:       make(map[string][]structs.Transaction),
+		byAccountAmount:  make(map[string][]structs.Transaction),
+	}
+	for i := range accounts {
+		data.accounts[accounts[i].ID] = &accounts[i]
+	}
+	for i := range categories {
+		data.categories[categories[i].ID] = &categories[i]
+	}
+	for _, tx := range history {
+		if tx.TransferID != nil || tx.CategoryID == data.transferCategory {
+			continue
+		}
+		if tx.PayeeID != nil {
+			data.byPayee[*tx.PayeeID] = append(data.byPayee[*tx.PayeeID], tx)
+		}
+		data.byCategory[tx.CategoryID] = append(data.byCategory[tx.CategoryID], tx)
+		key := fmt.Sprintf("%s|%d", tx.AccountID, tx.Amount)
+		data.byAccountAmount[key] = append(data.byAccountAmount[key], tx)
+	}
+	return data, nil
+}
+
+// evaluate gera os alertas da despesa
+func (d *insightData) evaluate(tx structs.Transaction) []structs.TransactionFlag {
+	var flags []structs.TransactionFlag
+	if flag := d.duplicateFlag(tx); flag != nil {
+		flags = append(flags, *flag)
+	}
+	if flag := d.outlierFlag(tx); flag != nil {
+		flags = append(flags, *flag)
+	}
+	return flags
+}
+
+// duplicateFlag procura outra despesa com o mesmo valor, na mesma conta e do mesmo beneficiário
+// (ou com a mesma descrição) a poucos dias de distância. Só a cobrança criada por último é marcada.
+func (d *insightData) duplicateFlag(tx structs.Transaction) *structs.TransactionFlag {
+	for _, other := range d.byAccountAmount[fmt.Sprintf("%s|%d", tx.AccountID, tx.Amount)] {
+		if other.ID == tx.ID {
+			continue
+		}
+		if math.Abs(other.DueDate.Sub(tx.DueDate).Hours()) > insightDuplicateDays*24 {
+			continue
+		}
+		samePayee := tx.PayeeID != nil && other.PayeeID != nil && *tx.PayeeID == *other.PayeeID
+		if !samePayee && utils.NormalizeText(tx.Description) != utils.NormalizeText(other.Description) {
+			continue
+		}
+		// Parcelas da mesma compra não são duplicatas
+		if tx.ParentTransactionID != nil && other.ParentTransactionID != nil && *tx.ParentTransactionID == *other.ParentTransactionID {
+			continue
+		}
+		if other.CreatedAt.After(tx.CreatedAt) || (other.CreatedAt.Equal(tx.CreatedAt) && other.ID > tx.ID) {
+			continue
+		}
+
+		related := other.ID
+		return &structs.TransactionFlag{
+			ID:                   uuid.New().String(),
+			UserID:               d.userID,
+			TransactionID:        tx.ID,
+			Kind:                 structs.FlagPossibleDuplicate,
+			Message:              fmt.Sprintf("Possível cobrança duplicada: '%s' de %s também lançada em %s", other.Description, d.money(tx), other.DueDate.Format("02/01/2006")),
+			RelatedTransactionID: &related,
+			CreatedAt:            time.Now(),
+		}
+	}
+	return nil
+}
+
+// outlierFlag compara o valor com a mediana do beneficiário ou, com pouco histórico, da categoria
+func (d *insightData) outlierFlag(tx structs.Transaction) *structs.TransactionFlag {
+	name := ""
+	var amounts []int
+	if tx.PayeeID != nil {
+		amounts = d.historyAmounts(d.byPayee[*tx.PayeeID], tx)
+		name = tx.Payee
+	}
+	if len(amounts) < insightMinSamples {
+		amounts = d.historyAmounts(d.byCategory[tx.CategoryID], tx)
+		name = "a categoria " + reportCategoryName(reportData{categories: d.categories}, tx.CategoryID)
+	}
+	if len(amounts) < insightMinSamples {
+		return nil
+	}
+	if name == "" {
+		name = tx.Description
+	}
+
+	median := medianInt(amounts)
+	if median <= 0 || float64(tx.Amount) < insightOutlierFactor*float64(median) || tx.Amount-median < insightMinDifference {
+		return nil
+	}
+	deviations := make([]int, len(amounts))
+	for i, amount := range amounts {
+		deviations[i] = int(math.Abs(float64(amount - median)))
+	}
+	// Com MAD zero (valores sempre iguais), o fator sobre a mediana já basta
+	if mad := medianInt(deviations); mad > 0 && float64(tx.Amount-median)/(1.4826*float64(mad)) < insightOutlierScore {
+		return nil
+	}
+
+	expected := median
+	return &structs.TransactionFlag{
+		ID:             uuid.New().String(),
+		UserID:         d.userID,
+		TransactionID:  tx.ID,
+		Kind:           structs.FlagAmountOutlier,
+		Message:        fmt.Sprintf("Valor %s acima do habitual para %s (mediana de %s em %d transações)", formatRatio(float64(tx.Amount)/float64(median)), name, d.moneyOf(tx, median), len(amounts)),
+		ExpectedAmount: &expected,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// historyAmounts devolve os valores do histórico anteriores à transação, na janela do histórico
+func (d *insightData) historyAmounts(history []structs.Transaction, tx structs.Transaction) []int {
+	from := tx.DueDate.AddDate(0, 0, -insightHistoryDays)
+	var amounts []int
+	for _, other := range history {
+		if other.ID == tx.ID || other.DueDate.Before(from) || other.DueDate.After(tx.DueDate) {
+			continue
+		}
+		amounts = append(amounts, other.Amount)
+	}
+	return amounts
+}
+
+func (d *insightData) money(tx structs.Transaction) string {
+	return d.moneyOf(tx, tx.Amount)
+}
+
+// moneyOf formata o valor na moeda da conta da transação
+func (d *insightData) moneyOf(tx structs.Transaction, cents int) string {
+	currency := "BRL"
+	if account, ok := d.accounts[tx.AccountID]; ok {
+		currency = account.Currency
+	}
+	return formatMoney(cents, currency)
+}
+
+// categoryAlerts compara o gasto pago de cada categoria principal no mês com a média mensal dos
+// insightUsualMonths meses anteriores. No mês corrente, o gasto é projetado para o mês inteiro
+// no ritmo atual.
+func (s *InsightService) categoryAlerts(userID, currency string, month time.Time) ([]structs.CategoryAlert, error) {
+	data, err := loadReportData(s.db, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	monthStart := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	rows, err := s.db.GetMonthlyCategoryTotals(userID, currency, "expense", monthStart.AddDate(0, -insightUsualMonths, 0), monthEnd, data.transferCategory)
+	if err != nil {
+		return nil, err
+	}
+
+	spent := make(map[string]int)
+	previous := make(map[string]int)
+	for _, row := range rows {
+		categoryID, _ := categoryReportSlot(data, row.CategoryID, "")
+		if row.Month.UTC().Equal(monthStart) {
+			spent[categoryID] += row.Amount
+		} else {
+			previous[categoryID] += row.Amount
+		}
+	}
+
+	// Fração do mês já decorrida: no mês corrente, os dias até hoje; nos meses passados, o mês todo
+	elapsed := 1.0
+	now := time.Now().UTC()
+	if now.Year() == monthStart.Year() && now.Month() == monthStart.Month() {
+		elapsed = float64(now.Day()) / float64(monthEnd.Day())
+	}
+
+	alerts := []structs.CategoryAlert{}
+	for categoryID, amount := range spent {
+		usual := int(math.Round(float64(previous[categoryID]) / insightUsualMonths))
+		if usual <= 0 {
+			continue
+		}
+		projected := int(math.Round(float64(amount) / elapsed))
+		if float64(projected) < insightCategoryThreshold*float64(usual) || projected-usual < insightMinDifference {
+			continue
+		}
+		alerts = append(alerts, structs.CategoryAlert{
+			CategoryID: categoryID,
+			Name:       reportCategoryName(data, categoryID),
+			Spent:      amount,
+			Projected:  projected,
+			Usual:      usual,
+			Exceeded:   amount > usual,
+			Percent:    float64(projected) * 100 / float64(usual),
+		})
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Projected-alerts[i].Usual > alerts[j].Projected-alerts[j].Usual
+	})
+	return alerts, nil
+}
+
+// medianInt calcula a mediana dos valores (a média dos dois do meio, com quantidade par)
+func medianInt(values []int) int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// formatRatio formata uma razão com uma casa decimal e vírgula: "3,2x"
+func formatRatio(value float64) string {
+	return strings.Replace(fmt.Sprintf("%.1fx", value), ".", ",", 1)
+}
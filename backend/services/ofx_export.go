@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// ofxNameMaxLength é o tamanho máximo do elemento NAME na especificação OFX
+const ofxNameMaxLength = 32
+
+// OFXStatementExport é um extrato OFX já validado, pronto para ser gravado na resposta
+type OFXStatementExport struct {
+	account      *structs.Account
+	from         time.Time
+	to           time.Time
+	transactions []structs.Transaction
+	balance      int // Saldo calculado da conta no fim de to, em centavos
+}
+
+// NewOFXStatementExport monta o extrato OFX da conta no período informado. Entram apenas as
+// transações pagas, as mesmas consideradas no saldo (LEDGERBAL). Sem from, o extrato começa
+// na primeira transação; sem to, termina hoje.
+func (s *ExportService) NewOFXStatementExport(userID, accountID string, from, to *time.Time) (*OFXStatementExport, error) {
+	account, err := s.db.GetAccountByID(accountID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar conta: %w", err)
+	}
+	if account == nil || account.DeletedAt != nil {
+		return nil, fmt.Errorf("conta não encontrada")
+	}
+
+	end := startOfDay(time.Now())
+	if to != nil {
+		end = startOfDay(*to)
+	}
+
+	paid := true
+	transactions, err := s.db.GetFilteredTransactions(userID, structs.TransactionFilter{
+		From:      from,
+		To:        &end,
+		AccountID: accountID,
+		IsPaid:    &paid,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+
+	start := end
+	if from != nil {
+		start = startOfDay(*from)
+	} else if len(transactions) > 0 {
+		start = startOfDay(transactions[0].DueDate) // Ordenadas pelo vencimento
+	}
+
+	balance, err := s.db.GetAccountBalanceAsOf(accountID, userID, end)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao calcular saldo da conta: %w", err)
+	}
+
+	return &OFXStatementExport{
+		account:      account,
+		from:         start,
+		to:           end,
+		transactions: transactions,
+		balance:      balance,
+	}, nil
+}
+
+// FileName retorna o nome sugerido para o arquivo
+func (e *OFXStatementExport) FileName() string {
+	return fmt.Sprintf("extrato-%s-%s.ofx", e.from.Format("20060102"), e.to.Format("20060102"))
+}
+
+// Write grava o extrato no formato OFX 2.x (XML). O FITID de cada lançamento é o ID da
+// transação, para que a reimportação do arquivo na mesma conta reconheça as duplicatas.
+func (e *OFXStatementExport) Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	now := time.Now()
+
+	out.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	out.WriteString(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+	out.WriteString("<OFX>\n")
+	out.WriteString("<SIGNONMSGSRSV1>\n<SONRS>\n")
+	out.WriteString("<STATUS>\n<CODE>0</CODE>\n<SEVERITY>INFO</SEVERITY>\n</STATUS>\n")
+	fmt.Fprintf(out, "<DTSERVER>%s</DTSERVER>\n", ofxDateTime(now))
+	out.WriteString("<LANGUAGE>POR</LANGUAGE>\n")
+	out.WriteString("</SONRS>\n</SIGNONMSGSRSV1>\n")
+
+	out.WriteString("<BANKMSGSRSV1>\n<STMTTRNRS>\n")
+	out.WriteString("<TRNUID>0</TRNUID>\n")
+	out.WriteString("<STATUS>\n<CODE>0</CODE>\n<SEVERITY>INFO</SEVERITY>\n</STATUS>\n")
+	out.WriteString("<STMTRS>\n")
+	fmt.Fprintf(out, "<CURDEF>%s</CURDEF>\n", xmlEscape(e.account.Currency))
+	out.WriteString("<BANKACCTFROM>\n")
+	out.WriteString("<BANKID>0000</BANKID>\n")
+	fmt.Fprintf(out, "<ACCTID>%s</ACCTID>\n", xmlEscape(e.account.ID))
+	out.WriteString("<ACCTTYPE>CHECKING</ACCTTYPE>\n")
+	out.WriteString("</BANKACCTFROM>\n")
+
+	out.WriteString("<BANKTRANLIST>\n")
+	fmt.Fprintf(out, "<DTSTART>%s</DTSTART>\n", ofxDate(e.from))
+	fmt.Fprintf(out, "<DTEND>%s</DTEND>\n", ofxDate(e.to))
+	for _, tx := range e.transactions {
+		e.writeTransaction(out, tx)
+	}
+	out.WriteString("</BANKTRANLIST>\n")
+
+	out.WriteString("<LEDGERBAL>\n")
+	fmt.Fprintf(out, "<BALAMT>%s</BALAMT>\n", formatCents(e.balance, "."))
+	fmt.Fprintf(out, "<DTASOF>%s</DTASOF>\n", ofxDate(e.to))
+	out.WriteString("</LEDGERBAL>\n")
+
+	out.WriteString("</STMTRS>\n</STMTTRNRS>\n</BANKMSGSRSV1>\n</OFX>\n")
+	return out.Flush()
+}
+
+func (e *OFXStatementExport) writeTransaction(out *bufio.Writer, tx structs.Transaction) {
+	amount := tx.Amount
+	trnType := "CREDIT"
+	if tx.Type == "expense" {
+		amount = -amount
+		trnType = "DEBIT"
+	}
+	if tx.TransferID != nil {
+		trnType = "XFER"
+	}
+
+	out.WriteString("<STMTTRN>\n")
+	fmt.Fprintf(out, "<TRNTYPE>%s</TRNTYPE>\n", trnType)
+	fmt.Fprintf(out, "<DTPOSTED>%s</DTPOSTED>\n", ofxDate(tx.DueDate))
+	fmt.Fprintf(out, "<TRNAMT>%s</TRNAMT>\n", formatCents(amount, "."))
+	fmt.Fprintf(out, "<FITID>%s</FITID>\n", xmlEscape(tx.ID))
+
+	// NAME tem no máximo 32 caracteres; a descrição completa vai no MEMO quando não cabe
+	name := tx.Description
+	if utf8.RuneCountInString(name) > ofxNameMaxLength {
+		name = string([]rune(name)[:ofxNameMaxLength])
+		fmt.Fprintf(out, "<NAME>%s</NAME>\n", xmlEscape(name))
+		fmt.Fprintf(out, "<MEMO>%s</MEMO>\n", xmlEscape(tx.Description))
+	} else {
+		fmt.Fprintf(out, "<NAME>%s</NAME>\n", xmlEscape(name))
+		if tx.Observation != "" {
+			fmt.Fprintf(out, "<MEMO>%s</MEMO>\n", xmlEscape(tx.Observation))
+		}
+	}
+	out.WriteString("</STMTTRN>\n")
+}
+
+// ofxDate formata uma data no padrão OFX (AAAAMMDD)
+func ofxDate(date time.Time) string {
+	return date.Format("20060102")
+}
+
+// ofxDateTime formata data e hora no padrão OFX, com o fuso (AAAAMMDDHHMMSS[-3:BRT])
+func ofxDateTime(date time.Time) string {
+	name, offset := date.Zone()
+	return fmt.Sprintf("%s[%d:%s]", date.Format("20060102150405"), offset/3600, name)
+}
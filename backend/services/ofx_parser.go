@@ -0,0 +1,164 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// OFXStatement reúne as linhas do extrato e o saldo informado pelo banco
+type OFXStatement struct {
+	Lines []structs.ImportLine
+	// LedgerBalance é o saldo contábil do bloco LEDGERBAL (BALAMT em DTASOF), quando existe
+	LedgerBalance *structs.StatementBalance
+}
+
+// ParseOFX faz o parsing manual do arquivo OFX e retorna as linhas do extrato
+func ParseOFX(content []byte) ([]structs.ImportLine, error) {
+	statement, err := ParseOFXStatement(content)
+	if err != nil {
+		return nil, err
+	}
+	return statement.Lines, nil
+}
+
+// ParseOFXStatement faz o parsing manual do arquivo OFX e retorna as linhas e o saldo do extrato
+func ParseOFXStatement(content []byte) (*OFXStatement, error) {
+	var transactions []structs.ImportLine
+	// Cada elemento vai para uma linha própria: o OFX 2.x (XML) fecha os elementos na mesma
+	// linha (<NAME>Loja</NAME>) e alguns bancos gravam o arquivo inteiro em uma linha só
+	contentStr := strings.ReplaceAll(string(content), "<", "\n<")
+
+	// Procurar por blocos STMTTRN (transações) e LEDGERBAL (saldo)
+	lines := strings.Split(contentStr, "\n")
+	var currentTx *structs.ImportLine
+	var ledgerBalance *structs.StatementBalance
+	inLedgerBal := false
+	var balanceAmount, balanceDate string
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if line == "<LEDGERBAL>" {
+			inLedgerBal = true
+			balanceAmount, balanceDate = "", ""
+		} else if line == "</LEDGERBAL>" && inLedgerBal {
+			inLedgerBal = false
+			if balance, err := parseOFXBalance(balanceAmount, balanceDate); err == nil {
+				ledgerBalance = balance
+			}
+		} else if inLedgerBal {
+			if strings.HasPrefix(line, "<BALAMT>") {
+				balanceAmount = ofxTagValue(line, "BALAMT")
+			} else if strings.HasPrefix(line, "<DTASOF>") {
+				balanceDate = ofxTagValue(line, "DTASOF")
+			}
+		} else if line == "<STMTTRN>" {
+			currentTx = &structs.ImportLine{}
+		} else if line == "</STMTTRN>" && currentTx != nil {
+			// Finalizar transação atual
+			if currentTx.Amount != 0 && !currentTx.Date.IsZero() {
+				transactions = append(transactions, *currentTx)
+			}
+			currentTx = nil
+		} else if currentTx != nil {
+			// Processar campos da transação
+			if strings.HasPrefix(line, "<TRNAMT>") {
+				if amount, err := parseFloat(ofxTagValue(line, "TRNAMT")); err == nil {
+					currentTx.Amount = amount
+				}
+			} else if strings.HasPrefix(line, "<DTPOSTED>") {
+				if date, err := parseOFXDate(ofxTagValue(line, "DTPOSTED")); err == nil {
+					currentTx.Date = date
+				}
+			} else if strings.HasPrefix(line, "<NAME>") {
+				currentTx.Description = ofxTagValue(line, "NAME")
+			} else if strings.HasPrefix(line, "<MEMO>") {
+				currentTx.Memo = ofxTagValue(line, "MEMO")
+			} else if strings.HasPrefix(line, "<FITID>") {
+				currentTx.ExternalID = ofxTagValue(line, "FITID")
+			}
+		}
+	}
+
+	assignImportLineIDs(transactions)
+	return &OFXStatement{Lines: transactions, LedgerBalance: ledgerBalance}, nil
+}
+
+// ofxTagValue retorna o valor de um elemento OFX, aceitando a tag de fechamento do OFX 2.x
+// e decodificando as entidades (&amp;, &lt;...)
+func ofxTagValue(line, tag string) string {
+	value := strings.TrimPrefix(line, "<"+tag+">")
+	value = strings.TrimSuffix(value, "</"+tag+">")
+	return html.UnescapeString(strings.TrimSpace(value))
+}
+
+// parseOFXBalance converte o valor e a data de um bloco de saldo OFX
+func parseOFXBalance(amountStr, dateStr string) (*structs.StatementBalance, error) {
+	if amountStr == "" || dateStr == "" {
+		return nil, fmt.Errorf("saldo incompleto no arquivo OFX")
+	}
+	amount, err := parseFloat(amountStr)
+	if err != nil {
+		return nil, fmt.Errorf("valor de saldo inválido: %s", amountStr)
+	}
+	date, err := parseOFXDate(dateStr)
+	if err != nil {
+		return nil, err
+	}
+	return &structs.StatementBalance{Amount: int(math.Round(amount * 100)), Date: date}, nil
+}
+
+// assignImportLineIDs define o identificador de cada linha da pré-visualização,
+// usando o identificador de origem quando ele existe e é único no arquivo
+func assignImportLineIDs(lines []structs.ImportLine) {
+	seen := make(map[string]int)
+	for _, line := range lines {
+		if line.ExternalID != "" {
+			seen[line.ExternalID]++
+		}
+	}
+
+	for i := range lines {
+		if lines[i].ExternalID != "" && seen[lines[i].ExternalID] == 1 {
+			lines[i].ID = lines[i].ExternalID
+		} else {
+			lines[i].ID = fmt.Sprintf("linha-%d", i+1)
+		}
+	}
+}
+
+// parseFloat converte string para float64
+func parseFloat(s string) (float64, error) {
+	// Remover caracteres não numéricos exceto ponto e sinal
+	s = strings.TrimSpace(s)
+
+	// Se a string está vazia, retornar 0
+	if s == "" {
+		return 0, nil
+	}
+
+	// Converter para float64
+	var result float64
+	_, err := fmt.Sscanf(s, "%f", &result)
+	return result, err
+}
+
+// parseOFXDate converte data OFX para time.Time
+func parseOFXDate(dateStr string) (time.Time, error) {
+	// Formato OFX: YYYYMMDDHHMMSS ou YYYYMMDD
+	dateStr = strings.TrimSpace(dateStr)
+	if len(dateStr) >= 8 {
+		year := dateStr[0:4]
+		month := dateStr[4:6]
+		day := dateStr[6:8]
+
+		dateStr = fmt.Sprintf("%s-%s-%s", year, month, day)
+		return time.Parse("2006-01-02", dateStr)
+	}
+	return time.Time{}, fmt.Errorf("formato de data inválido: %s", dateStr)
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+// payeeProcessorPrefixes são prefixos de subadquirentes e carteiras que aparecem antes do "*"
+// na descrição do cartão, como "PAG*JoseDaSilva" ou "IFD*IFOOD". Nesses casos o estabelecimento
+// vem depois do "*"; nos demais ("UBER *TRIP") o estabelecimento é o que vem antes.
+var payeeProcessorPrefixes = map[string]bool{
+	"pag": true, "pags": true, "pagseguro": true, "pagbank": true, "ps": true,
+	"mp": true, "mercadopago": true, "merpago": true,
+	"ifd": true, "pg": true, "pp": true, "paypal": true, "picpay": true,
+	"sumup": true, "stone": true, "ton": true, "cielo": true, "getnet": true,
+	"ebanx": true, "ebn": true, "ec": true, "dl": true, "sq": true,
+}
+
+// payeeDescriptionPrefixes são textos que os bancos colocam antes do nome do favorecido.
+// Ficam em ordem decrescente de tamanho para que o prefixo mais específico seja removido.
+var payeeDescriptionPrefixes = sortedByLength([]string{
+	"compra cartao deb", "compra cartao credito", "compra cartao", "compra no debito", "compra no credito",
+	"compra debito", "compra credito", "compra com cartao", "compra",
+	"pagamento de boleto", "pagamento boleto", "pagto boleto", "pag boleto", "pagamento", "pagto",
+	"pix enviado", "pix recebido", "pix transf", "pix qrs", "pix",
+	"ted enviada", "ted recebida", "ted", "doc enviado", "doc recebido", "doc",
+	"transferencia enviada", "transferencia recebida", "transf enviada", "transf recebida",
+	"debito automatico", "deb autom", "deb aut", "debito",
+})
+
+// payeeSiteSuffixes são os sufixos que identificam um endereço de site na descrição
+var payeeSiteSuffixes = map[string]bool{"com": true, "br": true, "net": true, "org": true, "io": true, "app": true}
+
+// payeeConnectors ficam em minúsculas no nome normalizado ("Jose da Silva")
+var payeeConnectors = map[string]bool{"da": true, "de": true, "do": true, "das": true, "dos": true, "e": true}
+
+func sortedByLength(values []string) []string {
+	sort.SliceStable(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	return values
+}
+
+// NormalizePayeeName extrai um nome de beneficiário legível da descrição bruta do banco.
+// Exemplos: "PAG*JoseDaSilva 1234" -> "Jose da Silva", "UBER *TRIP HELP.UBER.COM" -> "Uber",
+// "IFD*IFOOD" -> "Ifood". Quando nada sobra da limpeza, retorna a descrição sem espaços extras.
+func NormalizePayeeName(description string) string {
+	original := strings.Join(strings.Fields(description), " ")
+	name := stripPayeeDescriptionPrefix(original)
+
+	if before, after, found := strings.Cut(name, "*"); found {
+		processor := strings.ReplaceAll(utils.NormalizeText(before), " ", "")
+		if payeeProcessorPrefixes[processor] && strings.TrimSpace(after) != "" {
+			name = after
+		} else if strings.TrimSpace(before) != "" {
+			name = before
+		} else {
+			name = after
+		}
+	}
+
+	words := make([]string, 0)
+	for _, token := range strings.Fields(strings.ReplaceAll(name, "*", " ")) {
+		if site := payeeSiteName(token); site != "" {
+			// Um site no início já identifica o estabelecimento ("NETFLIX.COM SAO PAULO")
+			if len(words) == 0 {
+				words = append(words, site)
+				break
+			}
+			continue
+		}
+		if !hasLetter(token) {
+			continue
+		}
+		words = append(words, splitCamelCase(token)...)
+	}
+	// Siglas de país no fim da descrição do cartão ("NETFLIX.COM SAO PAULO BR")
+	if len(words) > 1 {
+		last := strings.ToUpper(words[len(words)-1])
+		if last == "BR" || last == "BRA" {
+			words = words[:len(words)-1]
+		}
+	}
+	if len(words) == 0 {
+		return original
+	}
+
+	for i, word := range words {
+		words[i] = titleWord(word, i == 0)
+	}
+	return strings.Join(words, " ")
+}
+
+// stripPayeeDescriptionPrefix remove o tipo de operação que o banco coloca antes do nome,
+// desde que sobre algum texto depois dele
+func stripPayeeDescriptionPrefix(description string) string {
+	normalized := utils.NormalizeText(description)
+	for _, prefix := range payeeDescriptionPrefixes {
+		if !strings.HasPrefix(normalized, prefix+" ") {
+			continue
+		}
+		// A normalização não altera a quantidade de palavras, então o prefixo é removido por palavras
+		words := strings.Fields(description)
+		rest := strings.TrimLeft(strings.Join(words[len(strings.Fields(prefix)):], " "), "-: ")
+		if rest != "" {
+			return rest
+		}
+	}
+	return description
+}
+
+// hasLetter descarta tokens sem letras, como parcelas, códigos e datas
+func hasLetter(token string) bool {
+	for _, r := range token {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// payeeSiteName retorna o nome principal de um endereço de site ("HELP.UBER.COM" -> "UBER"),
+// ou vazio quando o token não é um site
+func payeeSiteName(token string) string {
+	lower := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(token), "https://"), "http://")
+	labels := strings.Split(strings.Trim(lower, "./"), ".")
+	if len(labels) < 2 {
+		return ""
+	}
+	if !payeeSiteSuffixes[labels[len(labels)-1]] {
+		return ""
+	}
+	for i := len(labels) - 2; i >= 0; i-- {
+		if !payeeSiteSuffixes[labels[i]] && labels[i] != "www" && hasLetter(labels[i]) {
+			return labels[i]
+		}
+	}
+	return ""
+}
+
+// splitCamelCase separa nomes colados como "JoseDaSilva" em palavras
+func splitCamelCase(token string) []string {
+	runes := []rune(token)
+	words := make([]string, 0, 1)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1]) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+// titleWord deixa a palavra com inicial maiúscula, mantendo conectores em minúsculas
+func titleWord(word string, first bool) string {
+	lower := strings.ToLower(word)
+	if !first && payeeConnectors[lower] {
+		return lower
+	}
+	runes := []rune(lower)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// PayeeKey é a chave usada para reconhecer beneficiários com grafias diferentes do mesmo nome
+func PayeeKey(name string) string {
+	return utils.NormalizeText(name)
+}
@@ -0,0 +1,714 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+type PayeeService struct {
+	db *database.Database
+}
+
+// NewPayeeService cria uma nova instância do serviço de beneficiários
+func NewPayeeService(db *database.Database) *PayeeService {
+	return &PayeeService{db: db}
+}
+
+// PayeeSet reúne os beneficiários e as regras de beneficiário de um usuário, prontos para
+// reconhecer descrições brutas do banco. Beneficiários criados pelo conjunto passam a ser
+// reconhecidos nas linhas seguintes da mesma importação.
+type PayeeSet struct {
+	s      *PayeeService
+	userID string
+	byID   map[string]*structs.Payee
+	byKey  map[string]*structs.Payee
+	rules  []compiledPayeeRule
+}
+
+type compiledPayeeRule struct {
+	rule    structs.PayeeRule
+	pattern string
+	regex   *regexp.Regexp
+}
+
+// LoadPayeeSet carrega os beneficiários e compila as regras de beneficiário do usuário
+func (s *PayeeService) LoadPayeeSet(userID string) (*PayeeSet, error) {
+	payees, err := s.db.GetPayeesByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar beneficiários: %w", err)
+	}
+	rules, err := s.db.GetPayeeRulesByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras de beneficiário: %w", err)
+	}
+
+	set := &PayeeSet{
+		s:      s,
+		userID: userID,
+		byID:   make(map[string]*structs.Payee, len(payees)),
+		byKey:  make(map[string]*structs.Payee, len(payees)),
+	}
+	for i := range payees {
+		set.add(&payees[i])
+	}
+
+	for _, rule := range rules {
+		compiled := compiledPayeeRule{rule: rule, pattern: utils.NormalizeText(rule.Pattern)}
+		if rule.MatchType == structs.PayeeMatchRegex {
+			regex, err := regexp.Compile("(?i)" + rule.Pattern)
+			if err != nil {
+				// Regras inválidas são ignoradas em vez de interromper a importação
+				fmt.Printf("Regra de beneficiário %s com expressão regular inválida: %v\n", rule.ID, err)
+				continue
+			}
+			compiled.regex = regex
+		}
+		set.rules = append(set.rules, compiled)
+	}
+	return set, nil
+}
+
+func (ps *PayeeSet) add(payee *structs.Payee) {
+	ps.byID[payee.ID] = payee
+	ps.byKey[payee.NormalizedName] = payee
+	for _, alias := range payee.Aliases {
+		if _, exists := ps.byKey[alias]; !exists {
+			ps.byKey[alias] = payee
+		}
+	}
+}
+
+// Match procura o beneficiário de uma descrição bruta: primeiro pelas regras, em ordem de
+// prioridade, depois pelo nome normalizado. Retorna também o nome normalizado, usado para
+// criar o beneficiário quando nenhum existente é encontrado.
+func (ps *PayeeSet) Match(description string) (*structs.Payee, string) {
+	name := NormalizePayeeName(description)
+	if ps == nil {
+		return nil, name
+	}
+
+	normalized := utils.NormalizeText(description)
+	for _, compiled := range ps.rules {
+		matched := false
+		switch compiled.rule.MatchType {
+		case structs.PayeeMatchExact:
+			matched = normalized == compiled.pattern
+		case structs.PayeeMatchRegex:
+			matched = compiled.regex.MatchString(description)
+		default:
+			matched = compiled.pattern != "" && strings.Contains(normalized, compiled.pattern)
+		}
+		if payee, ok := ps.byID[compiled.rule.PayeeID]; matched && ok {
+			return payee, payee.Name
+		}
+	}
+
+	return ps.byKey[PayeeKey(name)], name
+}
+
+// FindByName busca o beneficiário pelo nome ou por um dos nomes alternativos
+func (ps *PayeeSet) FindByName(name string) *structs.Payee {
+	if ps == nil {
+		return nil
+	}
+	return ps.byKey[PayeeKey(name)]
+}
+
+// Ensure busca o beneficiário com o nome informado, criando-o quando não existe
+func (ps *PayeeSet) Ensure(name string) (*structs.Payee, bool, error) {
+	name = strings.TrimSpace(name)
+	if payee := ps.FindByName(name); payee != nil {
+		return payee, false, nil
+	}
+
+	now := time.Now()
+	payee := &structs.Payee{
+		ID:             utils.GenerateUUID(),
+		UserID:         ps.userID,
+		Name:           name,
+		NormalizedName: PayeeKey(name),
+		Aliases:        []string{},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := ps.s.db.CreatePayee(*payee); err != nil {
+		return nil, false, fmt.Errorf("erro ao criar beneficiário: %w", err)
+	}
+	ps.add(payee)
+	return payee, true, nil
+}
+
+// AssignTo define o beneficiário da transação. Um payee_id informado é validado; um nome de
+// beneficiário já preenchido (pelo usuário ou por uma regra de categorização) é usado como
+// está; nos demais casos o beneficiário é reconhecido a partir da descrição original do banco.
+// Transferências entre contas não recebem beneficiário.
+func (ps *PayeeSet) AssignTo(tx *structs.Transaction, description string) error {
+	if ps == nil || tx.TransferID != nil {
+		return nil
+	}
+
+	if tx.PayeeID != nil && *tx.PayeeID != "" {
+		payee, ok := ps.byID[*tx.PayeeID]
+		if !ok {
+			return fmt.Errorf("beneficiário não encontrado")
+		}
+		tx.Payee = payee.Name
+		return nil
+	}
+	tx.PayeeID = nil
+
+	var payee *structs.Payee
+	if strings.TrimSpace(tx.Payee) != "" {
+		var err error
+		if payee, _, err = ps.Ensure(tx.Payee); err != nil {
+			return err
+		}
+	} else {
+		if strings.TrimSpace(description) == "" {
+			return nil
+		}
+		var name string
+		payee, name = ps.Match(description)
+		if payee == nil {
+			var err error
+			if payee, _, err = ps.Ensure(name); err != nil {
+				return err
+			}
+		}
+	}
+
+	tx.PayeeID = &payee.ID
+	tx.Payee = payee.Name
+	return nil
+}
+
+// CreatePayee cria um novo beneficiário
+func (s *PayeeService) CreatePayee(userID string, req structs.PayeeRequest) (*structs.Payee, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("nome do beneficiário é obrigatório")
+	}
+	if err := s.checkNameAvailable(userID, "", PayeeKey(name)); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	payee := structs.Payee{
+		ID:             utils.GenerateUUID(),
+		UserID:         userID,
+		Name:           name,
+		NormalizedName: PayeeKey(name),
+		Aliases:        []string{},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.db.CreatePayee(payee); err != nil {
+		return nil, fmt.Errorf("erro ao criar beneficiário: %w", err)
+	}
+	return &payee, nil
+}
+
+// GetPayees lista os beneficiários do usuário. Com query, filtra pelo nome ou pelos nomes alternativos.
+func (s *PayeeService) GetPayees(userID string, query string) ([]structs.Payee, error) {
+	payees, err := s.db.GetPayeesByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar beneficiários: %w", err)
+	}
+
+	query = utils.NormalizeText(query)
+	if query == "" {
+		return payees, nil
+	}
+	filtered := make([]structs.Payee, 0)
+	for _, payee := range payees {
+		if strings.Contains(payee.NormalizedName, query) || strings.Contains(strings.Join(payee.Aliases, "|"), query) {
+			filtered = append(filtered, payee)
+		}
+	}
+	return filtered, nil
+}
+
+// GetPayeeByID busca um beneficiário do usuário
+func (s *PayeeService) GetPayeeByID(id string, userID string) (*structs.Payee, error) {
+	if !utils.IsValidUUID(id) {
+		return nil, fmt.Errorf("ID deve ser um UUID válido")
+	}
+
+	payee, err := s.db.GetPayeeByID(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar beneficiário: %w", err)
+	}
+	if payee == nil {
+		return nil, fmt.Errorf("beneficiário não encontrado")
+	}
+	return payee, nil
+}
+
+// UpdatePayee renomeia um beneficiário. O nome anterior continua reconhecendo o beneficiário
+// nas próximas importações.
+func (s *PayeeService) UpdatePayee(id string, userID string, req structs.PayeeRequest) (*structs.Payee, error) {
+	payee, err := s.GetPayeeByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("nome do beneficiário é obrigatório")
+	}
+	key := PayeeKey(name)
+	if err := s.checkNameAvailable(userID, id, key); err != nil {
+		return nil, err
+	}
+
+	if key != payee.NormalizedName {
+		payee.Aliases = mergeAliases(payee.Aliases, []string{payee.NormalizedName}, key)
+	}
+	payee.Name = name
+	payee.NormalizedName = key
+	if err := s.db.UpdatePayee(*payee); err != nil {
+		return nil, fmt.Errorf("erro ao atualizar beneficiário: %w", err)
+	}
+	return s.GetPayeeByID(id, userID)
+}
+
+// DeletePayee remove um beneficiário e suas regras
+func (s *PayeeService) DeletePayee(id string, userID string) error {
+	if _, err := s.GetPayeeByID(id, userID); err != nil {
+		return err
+	}
+
+	if err := s.db.DeletePayee(id, userID); err != nil {
+		return fmt.Errorf("erro ao excluir beneficiário: %w", err)
+	}
+	return nil
+}
+
+// MergePayees reúne outros beneficiários no informado: transações e regras passam para ele
+// e os nomes dos beneficiários mesclados continuam sendo reconhecidos como ele
+func (s *PayeeService) MergePayees(id string, userID string, req structs.PayeeMergeRequest) (*structs.Payee, error) {
+	target, err := s.GetPayeeByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceIDs := make([]string, 0, len(req.PayeeIDs))
+	seen := make(map[string]bool)
+	for _, sourceID := range req.PayeeIDs {
+		if sourceID == id {
+			return nil, fmt.Errorf("um beneficiário não pode ser mesclado com ele mesmo")
+		}
+		if seen[sourceID] {
+			continue
+		}
+		seen[sourceID] = true
+
+		source, err := s.GetPayeeByID(sourceID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("beneficiário %s: %w", sourceID, err)
+		}
+		target.Aliases = mergeAliases(target.Aliases, append([]string{source.NormalizedName}, source.Aliases...), target.NormalizedName)
+		sourceIDs = append(sourceIDs, sourceID)
+	}
+
+	if err := s.db.MergePayees(*target, sourceIDs); err != nil {
+		return nil, fmt.Errorf("erro ao mesclar beneficiários: %w", err)
+	}
+	return s.GetPayeeByID(id, userID)
+}
+
+// checkNameAvailable impede dois beneficiários com o mesmo nome normalizado ou com um nome
+// que já reconhece outro beneficiário
+func (s *PayeeService) checkNameAvailable(userID string, exceptID string, key string) error {
+	payees, err := s.db.GetPayeesByUser(userID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar beneficiários: %w", err)
+	}
+	for _, payee := range payees {
+		if payee.ID == exceptID {
+			continue
+		}
+		if payee.NormalizedName == key {
+			return fmt.Errorf("já existe um beneficiário com este nome")
+		}
+		for _, alias := range payee.Aliases {
+			if alias == key {
+				return fmt.Errorf("este nome já reconhece o beneficiário '%s'", payee.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeAliases une listas de nomes alternativos sem repetições, ignorando o nome principal
+func mergeAliases(current []string, extra []string, mainKey string) []string {
+	result := make([]string, 0, len(current)+len(extra))
+	seen := map[string]bool{mainKey: true}
+	for _, alias := range append(append([]string{}, current...), extra...) {
+		if alias == "" || seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		result = append(result, alias)
+	}
+	return result
+}
+
+// GetPayeeHistory monta o histórico de gastos e recebimentos do beneficiário, por moeda e por mês
+func (s *PayeeService) GetPayeeHistory(id string, userID string, from *time.Time, to *time.Time) (*structs.PayeeHistory, error) {
+	payee, err := s.GetPayeeByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.db.GetTransactionsByPayee(id, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações do beneficiário: %w", err)
+	}
+	accounts, err := s.db.GetAllAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar contas: %w", err)
+	}
+	currencies := make(map[string]string, len(accounts))
+	for _, account := range accounts {
+		currencies[account.ID] = account.Currency
+	}
+
+	history := &structs.PayeeHistory{
+		Payee:        *payee,
+		From:         from,
+		To:           to,
+		Totals:       []structs.PayeeTotals{},
+		Months:       []structs.PayeeMonth{},
+		Transactions: transactions,
+	}
+
+	totals := make(map[string]*structs.PayeeTotals)
+	months := make(map[string]*structs.PayeeMonth)
+	expenses := make(map[string]int)
+	for _, tx := range transactions {
+		currency := currencies[tx.AccountID]
+		total, ok := totals[currency]
+		if !ok {
+			total = &structs.PayeeTotals{Currency: currency}
+			totals[currency] = total
+		}
+		monthKey := tx.DueDate.Format("2006-01") + "|" + currency
+		month, ok := months[monthKey]
+		if !ok {
+			month = &structs.PayeeMonth{Month: tx.DueDate.Format("2006-01"), Currency: currency}
+			months[monthKey] = month
+		}
+
+		total.TransactionCount++
+		month.TransactionCount++
+		if tx.Type == "income" {
+			total.TotalIncome += tx.Amount
+			month.Income += tx.Amount
+		} else {
+			total.TotalExpense += tx.Amount
+			month.Expense += tx.Amount
+			expenses[currency]++
+		}
+
+		date := tx.DueDate
+		if total.FirstDate == nil || date.Before(*total.FirstDate) {
+			total.FirstDate = &date
+		}
+		if total.LastDate == nil || date.After(*total.LastDate) {
+			total.LastDate = &date
+		}
+	}
+
+	for currency, total := range totals {
+		if expenses[currency] > 0 {
+			total.AverageExpense = total.TotalExpense / expenses[currency]
+		}
+		history.Totals = append(history.Totals, *total)
+	}
+	sort.Slice(history.Totals, func(i, j int) bool { return history.Totals[i].Currency < history.Totals[j].Currency })
+
+	for _, month := range months {
+		history.Months = append(history.Months, *month)
+	}
+	sort.Slice(history.Months, func(i, j int) bool {
+		if history.Months[i].Month != history.Months[j].Month {
+			return history.Months[i].Month < history.Months[j].Month
+		}
+		return history.Months[i].Currency < history.Months[j].Currency
+	})
+
+	return history, nil
+}
+
+// ApplyPayeesResult representa o resultado da atribuição retroativa de beneficiários
+type ApplyPayeesResult struct {
+	DryRun         bool                      `json:"dry_run"`
+	TotalEvaluated int                       `json:"total_evaluated"`
+	TotalAssigned  int                       `json:"total_assigned"`
+	PayeesCreated  int                       `json:"payees_created"`
+	Assignments    []structs.PayeeAssignment `json:"assignments"`
+}
+
+// ApplyPayees reconhece o beneficiário das transações existentes a partir da descrição original
+// do banco (ou da descrição, quando não há original), criando os beneficiários que faltam.
+// Em modo dry_run apenas retorna o que seria gravado.
+func (s *PayeeService) ApplyPayees(userID string, req structs.ApplyPayeesRequest) (*ApplyPayeesResult, error) {
+	set, err := s.LoadPayeeSet(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	onlyUnassigned := true
+	if req.OnlyUnassigned != nil {
+		onlyUnassigned = *req.OnlyUnassigned
+	}
+
+	transactions, err := s.db.GetAllTransactionsByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+
+	result := &ApplyPayeesResult{DryRun: req.DryRun, Assignments: []structs.PayeeAssignment{}}
+	// Nomes que seriam criados em dry_run, para não contar o mesmo beneficiário duas vezes
+	pending := make(map[string]bool)
+	for _, tx := range transactions {
+		if tx.TransferID != nil {
+			continue
+		}
+		if onlyUnassigned && tx.PayeeID != nil {
+			continue
+		}
+		result.TotalEvaluated++
+
+		description := tx.OriginalDescription
+		if description == "" {
+			description = tx.Description
+		}
+		payee, name := set.Match(description)
+		if tx.PayeeID == nil && strings.TrimSpace(tx.Payee) != "" {
+			// Beneficiário já informado em texto (pelo usuário ou por uma regra de categorização)
+			name = strings.TrimSpace(tx.Payee)
+			payee = set.FindByName(name)
+		}
+		if payee != nil && tx.PayeeID != nil && *tx.PayeeID == payee.ID {
+			continue
+		}
+
+		assignment := structs.PayeeAssignment{
+			TransactionID:       tx.ID,
+			Description:         tx.Description,
+			OriginalDescription: tx.OriginalDescription,
+			DueDate:             tx.DueDate,
+			Amount:              tx.Amount,
+			PayeeName:           name,
+		}
+
+		if payee == nil {
+			assignment.NewPayee = !pending[PayeeKey(name)]
+			if req.DryRun {
+				pending[PayeeKey(name)] = true
+			} else {
+				var created bool
+				payee, created, err = set.Ensure(name)
+				if err != nil {
+					return nil, err
+				}
+				assignment.NewPayee = created
+			}
+			if assignment.NewPayee {
+				result.PayeesCreated++
+			}
+		}
+
+		if payee != nil {
+			assignment.PayeeID = payee.ID
+			assignment.PayeeName = payee.Name
+			if !req.DryRun {
+				if err := s.db.UpdateTransactionPayee(tx.ID, userID, payee.ID, payee.Name); err != nil {
+					return nil, fmt.Errorf("erro ao atualizar transação %s: %w", tx.ID, err)
+				}
+			}
+		}
+
+		result.Assignments = append(result.Assignments, assignment)
+		result.TotalAssigned++
+	}
+
+	return result, nil
+}
+
+// PayeeNormalization mostra como uma descrição bruta seria reconhecida
+type PayeeNormalization struct {
+	Description string         `json:"description"`
+	Name        string         `json:"name"`            // Nome normalizado, ou o nome do beneficiário encontrado
+	Payee       *structs.Payee `json:"payee,omitempty"` // Beneficiário existente reconhecido
+}
+
+// NormalizeDescription mostra o beneficiário que seria atribuído a uma descrição bruta, sem gravar nada
+func (s *PayeeService) NormalizeDescription(userID string, description string) (*PayeeNormalization, error) {
+	set, err := s.LoadPayeeSet(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	payee, name := set.Match(description)
+	return &PayeeNormalization{Description: description, Name: name, Payee: payee}, nil
+}
+
+// CreatePayeeRule cria uma nova regra de beneficiário
+func (s *PayeeService) CreatePayeeRule(userID string, req structs.PayeeRuleRequest) (*structs.PayeeRule, error) {
+	now := time.Now()
+	rule := structs.PayeeRule{
+		ID:        utils.GenerateUUID(),
+		UserID:    userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	applyPayeeRuleRequest(&rule, req)
+
+	if err := s.validatePayeeRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.CreatePayeeRule(rule); err != nil {
+		return nil, fmt.Errorf("erro ao criar regra de beneficiário: %w", err)
+	}
+	return &rule, nil
+}
+
+// GetPayeeRules lista as regras de beneficiário do usuário em ordem de prioridade
+func (s *PayeeService) GetPayeeRules(userID string) ([]structs.PayeeRule, error) {
+	rules, err := s.db.GetPayeeRulesByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras de beneficiário: %w", err)
+	}
+	return rules, nil
+}
+
+// GetPayeeRuleByID busca uma regra de beneficiário do usuário
+func (s *PayeeService) GetPayeeRuleByID(id string, userID string) (*structs.PayeeRule, error) {
+	if !utils.IsValidUUID(id) {
+		return nil, fmt.Errorf("ID deve ser um UUID válido")
+	}
+
+	rule, err := s.db.GetPayeeRuleByID(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regra de beneficiário: %w", err)
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("regra de beneficiário não encontrada")
+	}
+	return rule, nil
+}
+
+// UpdatePayeeRule atualiza uma regra de beneficiário
+func (s *PayeeService) UpdatePayeeRule(id string, userID string, req structs.PayeeRuleRequest) (*structs.PayeeRule, error) {
+	rule, err := s.GetPayeeRuleByID(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyPayeeRuleRequest(rule, req)
+	if err := s.validatePayeeRule(*rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.UpdatePayeeRule(*rule); err != nil {
+		return nil, fmt.Errorf("erro ao atualizar regra de beneficiário: %w", err)
+	}
+	return s.GetPayeeRuleByID(id, userID)
+}
+
+// DeletePayeeRule remove uma regra de beneficiário (soft delete)
+func (s *PayeeService) DeletePayeeRule(id string, userID string) error {
+	if _, err := s.GetPayeeRuleByID(id, userID); err != nil {
+		return err
+	}
+
+	if err := s.db.DeletePayeeRule(id, userID); err != nil {
+		return fmt.Errorf("erro ao excluir regra de beneficiário: %w", err)
+	}
+	return nil
+}
+
+// validatePayeeRule garante que a regra tem um padrão válido e aponta para um beneficiário do usuário
+func (s *PayeeService) validatePayeeRule(rule structs.PayeeRule) error {
+	if rule.Pattern == "" {
+		return fmt.Errorf("pattern é obrigatório")
+	}
+	switch rule.MatchType {
+	case structs.PayeeMatchExact, structs.PayeeMatchContains:
+	case structs.PayeeMatchRegex:
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("expressão regular inválida: %w", err)
+		}
+	default:
+		return fmt.Errorf("match_type deve ser 'exact', 'contains' ou 'regex'")
+	}
+
+	if _, err := s.GetPayeeByID(rule.PayeeID, rule.UserID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// applyPayeeRuleRequest copia os campos da requisição para a regra
+func applyPayeeRuleRequest(rule *structs.PayeeRule, req structs.PayeeRuleRequest) {
+	rule.PayeeID = strings.TrimSpace(req.PayeeID)
+	rule.Pattern = strings.TrimSpace(req.Pattern)
+	rule.MatchType = strings.TrimSpace(req.MatchType)
+	if rule.MatchType == "" {
+		rule.MatchType = structs.PayeeMatchContains
+	}
+	rule.Priority = req.Priority
+}
+
+// ResolvePayeeUpdate mantém payee e payee_id coerentes em uma atualização parcial de transação:
+// payee_id é validado e define o nome; um nome sem payee_id é ligado ao beneficiário com esse
+// nome, criado se não existir; nome vazio remove o beneficiário.
+func (s *PayeeService) ResolvePayeeUpdate(userID string, updates map[string]interface{}) error {
+	payeeIDValue, hasPayeeID := updates["payee_id"]
+	payeeValue, hasPayee := updates["payee"]
+	if !hasPayeeID && !hasPayee {
+		return nil
+	}
+
+	payeeID, _ := payeeIDValue.(string)
+	name, _ := payeeValue.(string)
+	name = strings.TrimSpace(name)
+
+	if payeeID != "" {
+		payee, err := s.GetPayeeByID(payeeID, userID)
+		if err != nil {
+			return err
+		}
+		updates["payee_id"] = payee.ID
+		updates["payee"] = payee.Name
+		return nil
+	}
+	if name == "" {
+		updates["payee_id"] = nil
+		updates["payee"] = nil
+		return nil
+	}
+
+	set, err := s.LoadPayeeSet(userID)
+	if err != nil {
+		return err
+	}
+	payee, _, err := set.Ensure(name)
+	if err != nil {
+		return err
+	}
+	updates["payee_id"] = payee.ID
+	updates["payee"] = payee.Name
+	return nil
+}
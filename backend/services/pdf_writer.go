@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Dimensões de uma página A4 em pontos (1/72 de polegada)
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfMargin     = 42.0
+)
+
+// pdfColor é uma cor RGB com componentes de 0 a 1
+type pdfColor struct{ r, g, b float64 }
+
+var (
+	pdfBlack     = pdfColor{0, 0, 0}
+	pdfGray      = pdfColor{0.45, 0.45, 0.45}
+	pdfLightGray = pdfColor{0.88, 0.88, 0.88}
+	pdfGreen     = pdfColor{0.13, 0.55, 0.27}
+	pdfRed       = pdfColor{0.78, 0.16, 0.16}
+	pdfBlue      = pdfColor{0.16, 0.38, 0.71}
+)
+
+// pdfDocument gera um PDF simples com texto em Helvetica, linhas e retângulos. As páginas são
+// montadas em memória e numeradas no rodapé ao gravar o arquivo. O texto usa a codificação
+// WinAnsi (Windows-1252), que cobre os acentos do português.
+type pdfDocument struct {
+	pages  []*bytes.Buffer
+	page   *bytes.Buffer
+	footer string // Texto à esquerda do rodapé de todas as páginas
+	// y é a posição vertical do cursor, medida a partir do topo da página
+	y float64
+}
+
+func newPDFDocument(footer string) *pdfDocument {
+	doc := &pdfDocument{footer: footer}
+	doc.AddPage()
+	return doc
+}
+
+// AddPage começa uma nova página e leva o cursor para a margem superior
+func (d *pdfDocument) AddPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pdfMargin
+}
+
+// Ensure começa uma nova página quando não há espaço para height pontos abaixo do cursor
+func (d *pdfDocument) Ensure(height float64) bool {
+	if d.y+height > pdfPageHeight-pdfMargin-20 {
+		d.AddPage()
+		return true
+	}
+	return false
+}
+
+// Text escreve o texto com a linha de base em y (medido a partir do topo)
+func (d *pdfDocument) Text(x, y float64, text string, size float64, bold bool, color pdfColor) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page, "BT %.3f %.3f %.3f rg /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+		color.r, color.g, color.b, font, size, x, pdfPageHeight-y, pdfEscape(text))
+}
+
+// TextRight escreve o texto alinhado à direita em x
+func (d *pdfDocument) TextRight(x, y float64, text string, size float64, bold bool, color pdfColor) {
+	d.Text(x-pdfTextWidth(text, size, bold), y, text, size, bold, color)
+}
+
+// TextFit escreve o texto cortando-o com reticências para caber em width
+func (d *pdfDocument) TextFit(x, y, width float64, text string, size float64, bold bool, color pdfColor) {
+	d.Text(x, y, pdfFitText(text, width, size, bold), size, bold, color)
+}
+
+// Line traça uma linha entre dois pontos (y medido a partir do topo)
+func (d *pdfDocument) Line(x1, y1, x2, y2, width float64, color pdfColor) {
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		color.r, color.g, color.b, width, x1, pdfPageHeight-y1, x2, pdfPageHeight-y2)
+}
+
+// Rect preenche um retângulo com o canto superior esquerdo em (x, y)
+func (d *pdfDocument) Rect(x, y, width, height float64, color pdfColor) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f\n",
+		color.r, color.g, color.b, x, pdfPageHeight-y-height, width, height)
+}
+
+// Write grava o documento, com o rodapé e a numeração das páginas
+func (d *pdfDocument) Write(w io.Writer) error {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(content string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), content)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objetos fixos: 1 catálogo, 2 árvore de páginas, 3 e 4 fontes; páginas a partir do 5
+	pageCount := len(d.pages)
+	kids := make([]string, pageCount)
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		d.page = page
+		footerY := pdfPageHeight - pdfMargin + 8
+		d.Line(pdfMargin, footerY-12, pdfPageWidth-pdfMargin, footerY-12, 0.5, pdfLightGray)
+		d.Text(pdfMargin, footerY, d.footer, 8, false, pdfGray)
+		d.TextRight(pdfPageWidth-pdfMargin, footerY, fmt.Sprintf("Página %d de %d", i+1, pageCount), 8, false, pdfGray)
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+i*2))
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// pdfEncode converte o texto para Windows-1252, trocando caracteres sem equivalente por "?"
+func pdfEncode(text string) []byte {
+	encoder := charmap.Windows1252.NewEncoder()
+	var encoded []byte
+	for _, r := range text {
+		b, err := encoder.Bytes([]byte(string(r)))
+		if err != nil || len(b) != 1 {
+			encoded = append(encoded, '?')
+			continue
+		}
+		encoded = append(encoded, b[0])
+	}
+	return encoded
+}
+
+// pdfEscape codifica o texto e escapa os caracteres especiais das strings do PDF
+func pdfEscape(text string) string {
+	var builder strings.Builder
+	for _, b := range pdfEncode(text) {
+		switch b {
+		case '(', ')', '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(b)
+		case '\n', '\r', '\t':
+			builder.WriteByte(' ')
+		default:
+			builder.WriteByte(b)
+		}
+	}
+	return builder.String()
+}
+
+// pdfTextWidth calcula a largura do texto em pontos, pelas métricas da Helvetica
+func pdfTextWidth(text string, size float64, bold bool) float64 {
+	total := 0
+	for _, b := range pdfEncode(text) {
+		width := helveticaWidths[b]
+		if width == 0 {
+			width = 556
+		}
+		if bold && b > '9' {
+			// A Helvetica-Bold é um pouco mais larga nas letras; os algarismos têm a mesma largura
+			width = width * 106 / 100
+		}
+		total += width
+	}
+	return float64(total) * size / 1000
+}
+
+// pdfFitText corta o texto com reticências para caber na largura informada
+func pdfFitText(text string, width, size float64, bold bool) string {
+	if pdfTextWidth(text, size, bold) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimSpace(string(runes)) + "…"
+		if pdfTextWidth(candidate, size, bold) <= width {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// helveticaWidths são as larguras da Helvetica (em milésimos do tamanho da fonte) por byte
+// da codificação WinAnsi; os bytes sem entrada usam a largura padrão de 556
+var helveticaWidths = func() [256]int {
+	var widths [256]int
+	ascii := []int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // espaço a /
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 a ?
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ a O
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P a _
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` a o
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p a ~
+	}
+	for i, width := range ascii {
+		widths[32+i] = width
+	}
+	// Latin-1 (0xC0 a 0xFF): maiúsculas e minúsculas acentuadas
+	latin := []int{
+		667, 667, 667, 667, 667, 667, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278, // À a Ï
+		722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611, // Ð a ß
+		556, 556, 556, 556, 556, 556, 889, 500, 556, 556, 556, 556, 278, 278, 278, 278, // à a ï
+		556, 556, 556, 556, 556, 556, 556, 584, 611, 556, 556, 556, 556, 500, 556, 500, // ð a ÿ
+	}
+	for i, width := range latin {
+		widths[0xC0+i] = width
+	}
+	widths[0x80] = 556  // €
+	widths[0x85] = 1000 // …
+	widths[0x95] = 350  // •
+	widths[0x96] = 556  // –
+	widths[0x97] = 1000 // —
+	widths[0xA3] = 556  // £
+	widths[0xB7] = 278  // ·
+	return widths
+}()
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// Ordens de data aceitas em arquivos QIF
+const (
+	QIFDateOrderDMY = "dmy"
+	QIFDateOrderMDY = "mdy"
+)
+
+// qifTransactionTypes são os blocos do QIF que contêm lançamentos de conta
+var qifTransactionTypes = map[string]bool{
+	"bank":  true,
+	"cash":  true,
+	"ccard": true,
+	"oth a": true,
+	"oth l": true,
+}
+
+// QIFParseResult reúne as linhas lidas e a ordem de data usada
+type QIFParseResult struct {
+	Lines     []structs.ImportLine
+	DateOrder string
+	// DateOrderAmbiguous indica que nenhuma data do arquivo permitiu distinguir dia e mês
+	DateOrderAmbiguous bool
+}
+
+// qifRecord é um lançamento QIF antes da conversão das datas
+type qifRecord struct {
+	date     string
+	amount   string
+	payee    string
+	memo     string
+	category string
+	number   string
+	splits   []qifSplit
+}
+
+type qifSplit struct {
+	category string
+	memo     string
+	amount   string
+}
+
+// ParseQIF lê os lançamentos de um arquivo QIF. Lançamentos com divisões (S/$) geram uma linha
+// por divisão. dateOrder ("dmy" ou "mdy") é usado apenas quando o arquivo é ambíguo.
+func ParseQIF(content []byte, dateOrder string) (*QIFParseResult, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	records := []qifRecord{}
+	current := qifRecord{}
+	inTransactions := false
+	hasData := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "!") {
+			header := strings.ToLower(strings.TrimSpace(line))
+			if strings.HasPrefix(header, "!type:") {
+				inTransactions = qifTransactionTypes[strings.TrimSpace(strings.TrimPrefix(header, "!type:"))]
+			} else if header == "!account" {
+				// Bloco de descrição de conta: os campos até "^" não são lançamentos
+				inTransactions = false
+			}
+			current, hasData = qifRecord{}, false
+			continue
+		}
+		if !inTransactions {
+			continue
+		}
+
+		code, value := line[0], strings.TrimSpace(line[1:])
+		switch code {
+		case '^':
+			if hasData {
+				records = append(records, current)
+			}
+			current, hasData = qifRecord{}, false
+			continue
+		case 'D':
+			current.date = value
+		case 'T', 'U':
+			current.amount = value
+		case 'P':
+			current.payee = value
+		case 'M':
+			current.memo = value
+		case 'L':
+			current.category = value
+		case 'N':
+			current.number = value
+		case 'S':
+			current.splits = append(current.splits, qifSplit{category: value})
+		case 'E':
+			if n := len(current.splits); n > 0 {
+				current.splits[n-1].memo = value
+			}
+		case '$':
+			if n := len(current.splits); n > 0 {
+				current.splits[n-1].amount = value
+			}
+		}
+		hasData = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo QIF: %w", err)
+	}
+	if hasData {
+		records = append(records, current)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("nenhum lançamento encontrado no arquivo QIF")
+	}
+
+	// A ordem de data é decidida pelo arquivo inteiro: basta um dia maior que 12 para desambiguar.
+	// Sem isso, vale a ordem informada ou a brasileira (dia/mês).
+	dates := make([]string, len(records))
+	for i, record := range records {
+		dates[i] = record.date
+	}
+	detectedOrder, ambiguous := detectQIFDateOrder(dates)
+	if ambiguous {
+		detectedOrder = QIFDateOrderDMY
+		if dateOrder == QIFDateOrderDMY || dateOrder == QIFDateOrderMDY {
+			detectedOrder = dateOrder
+		}
+	}
+
+	result := &QIFParseResult{DateOrder: detectedOrder, DateOrderAmbiguous: ambiguous}
+	occurrences := make(map[string]int)
+	for i, record := range records {
+		date, err := parseQIFDate(record.date, detectedOrder)
+		if err != nil {
+			return nil, fmt.Errorf("lançamento %d: %w", i+1, err)
+		}
+
+		parts := record.splits
+		if len(parts) == 0 {
+			parts = []qifSplit{{category: record.category, amount: record.amount}}
+		}
+
+		for _, part := range parts {
+			amount, err := parseQIFAmount(part.amount)
+			if err != nil {
+				return nil, fmt.Errorf("lançamento %d: %w", i+1, err)
+			}
+			if amount == 0 {
+				continue
+			}
+
+			memo := record.memo
+			if part.memo != "" {
+				memo = part.memo
+			}
+			category, transferAccount := splitQIFCategory(part.category)
+
+			line := structs.ImportLine{
+				Amount:          amount,
+				Date:            date,
+				Description:     record.payee,
+				Memo:            memo,
+				Category:        category,
+				TransferAccount: transferAccount,
+			}
+			if line.Description == "" {
+				line.Description = memo
+			}
+			if line.Description == "" && transferAccount != "" {
+				line.Description = "Transferência - " + transferAccount
+			}
+
+			// QIF não tem identificador de lançamento: usar um hash estável do conteúdo para que
+			// reimportar o mesmo histórico não duplique transações
+			key := strings.Join([]string{date.Format("2006-01-02"), part.amount, record.payee, memo, part.category, record.number}, "|")
+			occurrences[key]++
+			sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d", key, occurrences[key])))
+			line.ExternalID = "qif-" + hex.EncodeToString(sum[:8])
+
+			result.Lines = append(result.Lines, line)
+		}
+	}
+
+	assignImportLineIDs(result.Lines)
+	return result, nil
+}
+
+// detectQIFDateOrder decide entre dia/mês e mês/dia olhando todas as datas do arquivo
+func detectQIFDateOrder(dates []string) (string, bool) {
+	for _, value := range dates {
+		first, second, _, ok := splitQIFDate(value)
+		if !ok || strings.Contains(value, "-") && len(strings.SplitN(value, "-", 2)[0]) == 4 {
+			continue
+		}
+		if first > 12 {
+			return QIFDateOrderDMY, false
+		}
+		if second > 12 {
+			return QIFDateOrderMDY, false
+		}
+	}
+	return "", true
+}
+
+// parseQIFDate interpreta datas como 12/31/2023, 31/12/2023, 1/ 5'98, 12-31-23 ou 2023-12-31
+func parseQIFDate(value string, dateOrder string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if len(value) >= 10 && value[4] == '-' {
+		if date, err := time.Parse("2006-01-02", value[:10]); err == nil {
+			return date, nil
+		}
+	}
+
+	first, second, year, ok := splitQIFDate(value)
+	if !ok {
+		return time.Time{}, fmt.Errorf("data inválida '%s'", value)
+	}
+
+	day, month := first, second
+	if dateOrder == QIFDateOrderMDY {
+		day, month = second, first
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, fmt.Errorf("data inválida '%s'", value)
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return time.Time{}, fmt.Errorf("data inválida '%s'", value)
+	}
+	return date, nil
+}
+
+// splitQIFDate separa os três componentes de uma data QIF. Anos com apóstrofo (1'05) são
+// do século 21; anos com dois dígitos seguem a regra 00-69 = 20xx, 70-99 = 19xx.
+func splitQIFDate(value string) (int, int, int, bool) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	apostrophe := strings.Contains(value, "'")
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '/' || r == '-' || r == '.' || r == '\''
+	})
+	if len(fields) != 3 {
+		return 0, 0, 0, false
+	}
+
+	numbers := make([]int, 3)
+	for i, field := range fields {
+		number, err := strconv.Atoi(field)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		numbers[i] = number
+	}
+
+	year := numbers[2]
+	if year < 100 {
+		switch {
+		case apostrophe:
+			year += 2000
+		case year < 70:
+			year += 2000
+		default:
+			year += 1900
+		}
+	}
+	return numbers[0], numbers[1], year, true
+}
+
+// parseQIFAmount converte valores como "-1,234.56" (separador de milhar americano)
+func parseQIFAmount(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	// Arquivos exportados em português usam vírgula decimal
+	decimalSeparator := "."
+	if commaDecimalPattern.MatchString(value) {
+		decimalSeparator = ","
+	}
+	return parseCSVAmount(value, decimalSeparator)
+}
+
+// splitQIFCategory separa a categoria ("Categoria:Subcategoria/Classe") da conta de
+// transferência ("[Conta]")
+func splitQIFCategory(value string) (string, string) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "[") {
+		end := strings.Index(value, "]")
+		if end > 0 {
+			return "", strings.TrimSpace(value[1:end])
+		}
+	}
+	if slash := strings.Index(value, "/"); slash >= 0 {
+		value = value[:slash]
+	}
+	return strings.TrimSpace(value), ""
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+func TestParseQIFDateOrder(t *testing.T) {
+	ambiguous := "!Type:Bank\nD05/03/2024\nT-10.00\nPMercado\n^\nD06/04/2024\nT-20.00\nPPadaria\n^\n"
+	tests := []struct {
+		name          string
+		content       string
+		dateOrder     string
+		wantOrder     string
+		wantAmbiguous bool
+		wantFirstDate string
+	}{
+		{"ambígua usa dia/mês", ambiguous, "", QIFDateOrderDMY, true, "2024-03-05"},
+		{"ambígua com mês/dia informado", ambiguous, QIFDateOrderMDY, QIFDateOrderMDY, true, "2024-05-03"},
+		{"dia acima de 12", "!Type:Bank\nD05/03/2024\nT-10.00\n^\nD25/03/2024\nT-5.00\n^\n", "", QIFDateOrderDMY, false, "2024-03-05"},
+		{"mês/dia pelo arquivo", "!Type:Bank\nD03/05/2024\nT-10.00\n^\nD03/25/2024\nT-5.00\n^\n", QIFDateOrderDMY, QIFDateOrderMDY, false, "2024-03-05"},
+	}
+	for _, tt := range tests {
+		result, err := ParseQIF([]byte(tt.content), tt.dateOrder)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if result.DateOrder != tt.wantOrder || result.DateOrderAmbiguous != tt.wantAmbiguous {
+			t.Errorf("%s: ordem = %s (ambígua %v), esperado %s (ambígua %v)", tt.name, result.DateOrder, result.DateOrderAmbiguous, tt.wantOrder, tt.wantAmbiguous)
+		}
+		if got := result.Lines[0].Date.Format("2006-01-02"); got != tt.wantFirstDate {
+			t.Errorf("%s: data = %s, esperado %s", tt.name, got, tt.wantFirstDate)
+		}
+	}
+}
+
+func TestFindCategoryByPathFlattensDeepPaths(t *testing.T) {
+	parentID := "casa"
+	categories := []structs.Category{
+		{ID: "casa", Name: "Casa", Type: structs.CategoryTypeExpense, IsActive: true},
+		{ID: "contas-luz", Name: "Contas / Luz", Type: structs.CategoryTypeExpense, IsActive: true, ParentID: &parentID},
+	}
+
+	if got := flattenCategoryNames([]string{"Casa", "Contas", "Luz"}); len(got) != 2 || got[0] != "Casa" || got[1] != "Contas / Luz" {
+		t.Errorf("flattenCategoryNames = %q", got)
+	}
+	category := findCategoryByPath(categories, "Casa:Contas:Luz", "expense")
+	if category == nil || category.ID != "contas-luz" {
+		t.Errorf("categoria encontrada = %+v, esperado contas-luz", category)
+	}
+}
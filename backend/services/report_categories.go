@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// GetCategoryReport soma as receitas ou despesas do período por categoria. Sem parentID, lista as
+// categorias principais com os valores de todas as subcategorias (em qualquer nível) somados a
+// elas; com parentID, detalha as subcategorias diretas dessa categoria, e o que foi lançado na
+// própria categoria pai aparece numa linha marcada como Direct. Quando txType é vazio, vale o
+// tipo da categoria pai, ou despesa.
+func (s *ReportService) GetCategoryReport(userID, currency, txType string, from, to time.Time, parentID string) (*structs.CategoryReport, error) {
+	data, err := loadReportData(s.db, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &structs.CategoryReport{
+		Currency:   currency,
+		Type:       txType,
+		From:       from,
+		To:         to,
+		ParentID:   parentID,
+		Categories: []structs.CategorySpending{},
+	}
+
+	var parent *structs.Category
+	if parentID != "" {
+		var ok bool
+		if parent, ok = data.categories[parentID]; !ok {
+			return nil, fmt.Errorf("categoria não encontrada")
+		}
+		report.ParentName = parent.Name
+		if report.Type == "" {
+			report.Type = string(parent.Type)
+		}
+	}
+	if report.Type == "" {
+		report.Type = "expense"
+	}
+	if report.Type != "income" && report.Type != "expense" {
+		return nil, fmt.Errorf("type deve ser 'income' ou 'expense'")
+	}
+
+	transactions, err := s.periodTransactions(userID, data, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]*structs.CategorySpending)
+	for _, tx := range transactions {
+		if tx.Type != report.Type {
+			continue
+		}
+		slotID, ok := categoryReportSlot(data, tx.CategoryID, parentID)
+		if !ok {
+			continue
+		}
+
+		total, exists := totals[slotID]
+		if !exists {
+			total = newCategorySpending(data, slotID)
+			if parent != nil && slotID == parentID {
+				total.Name = "Sem subcategoria"
+				total.Direct = true
+			}
+			totals[slotID] = total
+		}
+		total.Amount += tx.Amount
+		total.Count++
+		if tx.CategoryID == slotID {
+			total.DirectAmount += tx.Amount
+		} else {
+			total.HasChildren = true
+		}
+		report.Total += tx.Amount
+		report.Count++
+	}
+
+	for _, total := range totals {
+		if report.Total > 0 {
+			total.Percent = float64(total.Amount) * 100 / float64(report.Total)
+		}
+		report.Categories = append(report.Categories, *total)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		a, b := report.Categories[i], report.Categories[j]
+		if a.Amount != b.Amount {
+			return a.Amount > b.Amount
+		}
+		return a.Name < b.Name
+	})
+	return report, nil
+}
+
+// categoryReportSlot decide em qual linha do relatório entra uma transação da categoria: a
+// categoria principal (sem parentID) ou o filho de parentID de quem ela descende. Retorna false
+// quando a categoria não fica abaixo de parentID.
+func categoryReportSlot(data reportData, categoryID, parentID string) (string, bool) {
+	current := categoryID
+	// O limite de passos protege contra ciclos na hierarquia
+	for steps := 0; steps <= len(data.categories); steps++ {
+		if current == parentID {
+			// Lançada na própria categoria pai
+			return current, true
+		}
+		category, ok := data.categories[current]
+		if !ok || category.ParentID == nil || *category.ParentID == "" {
+			break
+		}
+		if parentID != "" && *category.ParentID == parentID {
+			return current, true
+		}
+		current = *category.ParentID
+	}
+	if parentID != "" {
+		return "", false
+	}
+	return current, true
+}
+
+func newCategorySpending(data reportData, categoryID string) *structs.CategorySpending {
+	total := &structs.CategorySpending{CategoryID: categoryID, Name: reportCategoryName(data, categoryID)}
+	if category, ok := data.categories[categoryID]; ok {
+		total.Color = category.Color
+		total.Icon = category.Icon
+		total.ParentID = category.ParentID
+	}
+	return total
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// incomeStatementItems é a quantidade de transações listadas em cada ajuste da conciliação
+const incomeStatementItems = 20
+
+// incomeStatementAdjustments são os motivos de uma transação entrar em só um dos regimes, na ordem
+// em que aparecem na conciliação
+var incomeStatementAdjustments = []struct {
+	kind        string
+	description string
+	accrualOnly bool // Entra na competência e não no caixa; os demais entram só no caixa
+}{
+	{"unpaid", "Competência no período, ainda não pago", true},
+	{"paid_before", "Competência no período, pago antes do período", true},
+	{"paid_after", "Competência no período, pago depois do período", true},
+	{"accrued_before", "Pago no período, competência anterior", false},
+	{"accrued_after", "Pago no período, competência posterior", false},
+}
+
+// GetIncomeStatement monta a DRE do período nos regimes de competência e de caixa, com a
+// conciliação entre os dois. Ex.: o salário de janeiro recebido em 5 de fevereiro entra em janeiro
+// na competência e em fevereiro no caixa.
+func (s *ReportService) GetIncomeStatement(userID, currency string, from, to time.Time) (*structs.IncomeStatement, error) {
+	data, err := loadReportData(s.db, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	accrual, err := s.reportTransactions(userID, data, structs.TransactionFilter{From: &from, To: &to, ByCompetence: true})
+	if err != nil {
+		return nil, err
+	}
+	paid := true
+	cash, err := s.reportTransactions(userID, data, structs.TransactionFilter{From: &from, To: &to, IsPaid: &paid})
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &structs.IncomeStatement{
+		Currency:       currency,
+		From:           from,
+		To:             to,
+		Accrual:        sumPeriod(accrual, from, to),
+		Cash:           sumPeriod(cash, from, to),
+		Lines:          []structs.IncomeStatementLine{},
+		Reconciliation: []structs.IncomeStatementAdjustment{},
+	}
+	statement.Accrual.Label = "Competência"
+	statement.Cash.Label = "Caixa"
+
+	// Linhas por categoria principal
+	lines := make(map[string]*structs.IncomeStatementLine)
+	line := func(tx structs.Transaction) *structs.IncomeStatementLine {
+		categoryID, _ := categoryReportSlot(data, tx.CategoryID, "")
+		key := tx.Type + "|" + categoryID
+		if _, ok := lines[key]; !ok {
+			lines[key] = &structs.IncomeStatementLine{CategoryID: categoryID, Name: reportCategoryName(data, categoryID), Type: tx.Type}
+		}
+		return lines[key]
+	}
+	for _, tx := range accrual {
+		line(tx).Accrual += tx.Amount
+	}
+	for _, tx := range cash {
+		line(tx).Cash += tx.Amount
+	}
+	for _, l := range lines {
+		l.Difference = l.Accrual - l.Cash
+		statement.Lines = append(statement.Lines, *l)
+	}
+	sort.Slice(statement.Lines, func(i, j int) bool {
+		a, b := statement.Lines[i], statement.Lines[j]
+		if a.Type != b.Type {
+			return a.Type == "income" // Receitas antes das despesas
+		}
+		if max(a.Accrual, a.Cash) != max(b.Accrual, b.Cash) {
+			return max(a.Accrual, a.Cash) > max(b.Accrual, b.Cash)
+		}
+		return a.Name < b.Name
+	})
+
+	// Conciliação: o que está em só um dos regimes
+	grouped := make(map[string][]structs.Transaction)
+	for _, tx := range accrual {
+		switch {
+		case !tx.IsPaid:
+			grouped["unpaid|"+tx.Type] = append(grouped["unpaid|"+tx.Type], tx)
+		case tx.DueDate.Before(from):
+			grouped["paid_before|"+tx.Type] = append(grouped["paid_before|"+tx.Type], tx)
+		case !tx.DueDate.Before(to.AddDate(0, 0, 1)):
+			grouped["paid_after|"+tx.Type] = append(grouped["paid_after|"+tx.Type], tx)
+		}
+	}
+	for _, tx := range cash {
+		switch {
+		case tx.CompetenceDate.Before(from):
+			grouped["accrued_before|"+tx.Type] = append(grouped["accrued_before|"+tx.Type], tx)
+		case !tx.CompetenceDate.Before(to.AddDate(0, 0, 1)):
+			grouped["accrued_after|"+tx.Type] = append(grouped["accrued_after|"+tx.Type], tx)
+		}
+	}
+
+	for _, adjustment := range incomeStatementAdjustments {
+		for _, txType := range []string{"income", "expense"} {
+			transactions := grouped[adjustment.kind+"|"+txType]
+			if len(transactions) == 0 {
+				continue
+			}
+			statement.Reconciliation = append(statement.Reconciliation, incomeStatementAdjustment(data, adjustment.kind, adjustment.description, txType, adjustment.accrualOnly, transactions))
+		}
+	}
+	return statement, nil
+}
+
+// incomeStatementAdjustment soma as transações de um ajuste. O efeito sobre o resultado é positivo
+// para receitas que só a competência tem e para despesas que só o caixa tem.
+func incomeStatementAdjustment(data reportData, kind, description, txType string, accrualOnly bool, transactions []structs.Transaction) structs.IncomeStatementAdjustment {
+	adjustment := structs.IncomeStatementAdjustment{
+		Kind:         kind,
+		Description:  description,
+		Type:         txType,
+		Count:        len(transactions),
+		Transactions: []structs.IncomeStatementItem{},
+	}
+	for _, tx := range transactions {
+		adjustment.Amount += tx.Amount
+	}
+	adjustment.NetEffect = adjustment.Amount
+	if (txType == "income") != accrualOnly {
+		adjustment.NetEffect = -adjustment.Amount
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool { return transactions[i].Amount > transactions[j].Amount })
+	for i, tx := range transactions {
+		if i == incomeStatementItems {
+			break
+		}
+		account := ""
+		if a, ok := data.accounts[tx.AccountID]; ok {
+			account = a.Name
+		}
+		adjustment.Transactions = append(adjustment.Transactions, structs.IncomeStatementItem{
+			ID:             tx.ID,
+			Description:    tx.Description,
+			Category:       reportCategoryPath(data, tx.CategoryID),
+			Account:        account,
+			Amount:         tx.Amount,
+			CompetenceDate: tx.CompetenceDate,
+			DueDate:        tx.DueDate,
+			IsPaid:         tx.IsPaid,
+		})
+	}
+	return adjustment
+}
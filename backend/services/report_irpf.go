@@ -0,0 +1,181 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+// GetIRPFReport agrupa nas seções da declaração do IRPF as transações pagas no ano-calendário
+// (regime de caixa, pelo vencimento), com os totais por fonte pagadora ou prestador. A seção vem
+// da transação ou, se ela não tiver, da categoria ou da categoria pai mais próxima marcada.
+func (s *ReportService) GetIRPFReport(userID string, year int, currency string) (*structs.IRPFReport, error) {
+	data, err := loadReportData(s.db, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	paid := true
+	transactions, err := s.reportTransactions(userID, data, structs.TransactionFilter{From: &from, To: &to, IsPaid: &paid})
+	if err != nil {
+		return nil, err
+	}
+
+	// Transações sem CPF/CNPJ herdam o de outra transação do mesmo beneficiário que o tenha
+	taxIDs := make(map[string]string)
+	for _, tx := range transactions {
+		if tx.PayeeTaxID != "" {
+			taxIDs[irpfProviderName(tx)] = tx.PayeeTaxID
+		}
+	}
+
+	report := &structs.IRPFReport{Year: year, Currency: currency, Sections: []structs.IRPFSection{}}
+	for _, info := range structs.TaxSections {
+		section := structs.IRPFSection{TaxSectionInfo: info, Providers: []structs.IRPFProvider{}}
+
+		providers := make(map[string]*structs.IRPFProvider)
+		for _, tx := range transactions {
+			if irpfTaxSection(data, tx) != info.Section {
+				continue
+			}
+			taxID := tx.PayeeTaxID
+			if taxID == "" {
+				taxID = taxIDs[irpfProviderName(tx)]
+			}
+			key := "cpf-cnpj:" + taxID
+			if taxID == "" {
+				key = "nome:" + irpfProviderName(tx)
+			}
+
+			provider, ok := providers[key]
+			if !ok {
+				provider = &structs.IRPFProvider{TaxID: taxID, Name: tx.Payee, Transactions: []structs.IRPFEntry{}}
+				if taxID != "" {
+					provider.TaxIDType = utils.TaxIDKind(taxID)
+				}
+				providers[key] = provider
+			}
+			if provider.Name == "" {
+				provider.Name = tx.Payee
+			}
+
+			if tx.Type == info.Type {
+				provider.Amount += tx.Amount
+			} else {
+				provider.Reimbursed += tx.Amount
+			}
+			provider.Count++
+			provider.Transactions = append(provider.Transactions, structs.IRPFEntry{
+				ID:          tx.ID,
+				Description: tx.Description,
+				Type:        tx.Type,
+				Amount:      tx.Amount,
+				Date:        tx.DueDate,
+			})
+		}
+
+		for _, provider := range providers {
+			if provider.Name == "" {
+				provider.Name = provider.Transactions[0].Description
+			}
+			provider.Total = provider.Amount - provider.Reimbursed
+			sort.SliceStable(provider.Transactions, func(i, j int) bool {
+				return provider.Transactions[i].Date.Before(provider.Transactions[j].Date)
+			})
+			section.Total += provider.Total
+			section.Count += provider.Count
+			section.Providers = append(section.Providers, *provider)
+
+			switch {
+			case info.RequiresTaxID && provider.TaxID == "":
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s: informe o CPF/CNPJ de '%s'", info.Title, provider.Name))
+			case info.Section == structs.TaxSectionIncomePJ && provider.TaxIDType == "CPF":
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s: '%s' tem CPF; rendimentos de pessoa física vão em outra seção", info.Title, provider.Name))
+			}
+		}
+		sort.Slice(section.Providers, func(i, j int) bool {
+			a, b := section.Providers[i], section.Providers[j]
+			if a.Total != b.Total {
+				return a.Total > b.Total
+			}
+			return a.Name < b.Name
+		})
+		report.Sections = append(report.Sections, section)
+	}
+	sort.Strings(report.Warnings)
+	return report, nil
+}
+
+// irpfTaxSection devolve a seção do IRPF da transação, ou vazio quando ela fica fora da declaração
+func irpfTaxSection(data reportData, tx structs.Transaction) string {
+	if tx.TaxSection != nil {
+		if *tx.TaxSection == structs.TaxSectionNone {
+			return ""
+		}
+		return *tx.TaxSection
+	}
+	current := tx.CategoryID
+	// O limite de passos protege contra ciclos na hierarquia
+	for steps := 0; steps <= len(data.categories); steps++ {
+		category, ok := data.categories[current]
+		if !ok {
+			break
+		}
+		if category.TaxSection != nil {
+			return *category.TaxSection
+		}
+		if category.ParentID == nil {
+			break
+		}
+		current = *category.ParentID
+	}
+	return ""
+}
+
+// irpfProviderName identifica o beneficiário da transação pelo nome (ou pela descrição) normalizado
+func irpfProviderName(tx structs.Transaction) string {
+	if tx.Payee != "" {
+		return utils.NormalizeText(tx.Payee)
+	}
+	return utils.NormalizeText(tx.Description)
+}
+
+// WriteIRPFCSV escreve o relatório do IRPF em CSV, uma linha por fonte pagadora ou prestador, no
+// formato das planilhas em português (separador ";", vírgula decimal e BOM para o Excel)
+func WriteIRPFCSV(w io.Writer, report *structs.IRPFReport) error {
+	buffered := bufio.NewWriter(w)
+	buffered.WriteString("\ufeff")
+
+	writer := csv.NewWriter(buffered)
+	writer.Comma = ';'
+	writer.Write([]string{"Ano-calendário", "Seção", "CPF/CNPJ", "Nome", "Valor", "Reembolsos/estornos", "Total", "Transações"})
+	for _, section := range report.Sections {
+		for _, provider := range section.Providers {
+			writer.Write([]string{
+				strconv.Itoa(report.Year),
+				section.Title,
+				utils.FormatTaxID(provider.TaxID),
+				sanitizeSpreadsheetText(provider.Name),
+				formatCents(provider.Amount, ","),
+				formatCents(provider.Reimbursed, ","),
+				formatCents(provider.Total, ","),
+				strconv.Itoa(provider.Count),
+			})
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return buffered.Flush()
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// netWorthMaxPoints limita a quantidade de períodos da série
+const netWorthMaxPoints = 1000
+
+// GetNetWorth calcula o saldo de cada conta e o patrimônio total no fim de cada período (mês,
+// semana de segunda a domingo ou dia) entre from e to. O último período termina em to. Os saldos
+// em outras moedas são convertidos para currency pela taxa mais recente até o fim do período.
+func (s *ReportService) GetNetWorth(userID, currency string, from, to time.Time, interval string) (*structs.NetWorthReport, error) {
+	ends, err := netWorthPeriodEnds(from, to, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.db.GetAllAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar contas: %w", err)
+	}
+
+	report := &structs.NetWorthReport{
+		Currency: currency,
+		Interval: interval,
+		From:     from,
+		To:       to,
+		Accounts: []structs.NetWorthAccount{},
+		Points:   []structs.NetWorthPoint{},
+	}
+	index := make(map[string]int)
+	var currencies []string
+	seen := make(map[string]bool)
+	for _, account := range accounts {
+		index[account.ID] = len(report.Accounts)
+		report.Accounts = append(report.Accounts, structs.NetWorthAccount{
+			AccountID: account.ID,
+			Name:      account.Name,
+			Currency:  account.Currency,
+			Color:     account.Color,
+		})
+		if !seen[account.Currency] {
+			seen[account.Currency] = true
+			currencies = append(currencies, account.Currency)
+		}
+	}
+
+	rates, warnings, err := s.exchangeHistory.loadRateTable(userID, currency, currencies)
+	if err != nil {
+		return nil, err
+	}
+	report.Warnings = warnings
+
+	// Todas as transações pagas até o fim da série, incluindo saldo inicial e transferências,
+	// em ordem de vencimento
+	paid := true
+	transactions, err := s.db.GetFilteredTransactions(userID, structs.TransactionFilter{To: &to, IsPaid: &paid})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+
+	balances := make([]int, len(report.Accounts))
+	next := 0
+	for _, end := range ends {
+		limit := end.AddDate(0, 0, 1)
+		for ; next < len(transactions) && transactions[next].DueDate.Before(limit); next++ {
+			tx := transactions[next]
+			i, ok := index[tx.AccountID]
+			if !ok {
+				continue // Conta removida
+			}
+			if tx.Type == "income" {
+				balances[i] += tx.Amount
+			} else {
+				balances[i] -= tx.Amount
+			}
+		}
+
+		point := structs.NetWorthPoint{Date: end, Balances: make([]structs.NetWorthBalance, len(report.Accounts))}
+		for i, account := range report.Accounts {
+			balance := structs.NetWorthBalance{AccountID: account.AccountID, Balance: balances[i]}
+			if rate, ok := rates.Rate(account.Currency, end); ok {
+				balance.Rate = rate
+				balance.Converted = int(math.Round(float64(balances[i]) * rate))
+				point.Total += balance.Converted
+			}
+			point.Balances[i] = balance
+		}
+		report.Points = append(report.Points, point)
+	}
+
+	first, last := report.Points[0].Total, report.Points[len(report.Points)-1].Total
+	report.Change = last - first
+	report.ChangePercent = percentChange(first, last)
+	return report, nil
+}
+
+// netWorthPeriodEnds lista o último dia de cada período entre from e to; o último é sempre to
+func netWorthPeriodEnds(from, to time.Time, interval string) ([]time.Time, error) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+
+	var next func(date time.Time) time.Time
+	switch interval {
+	case "month":
+		next = func(date time.Time) time.Time { return time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC) }
+	case "week":
+		next = func(date time.Time) time.Time {
+			return date.AddDate(0, 0, (7-int(date.Weekday()))%7) // Domingo
+		}
+	case "day":
+		next = func(date time.Time) time.Time { return date }
+	default:
+		return nil, fmt.Errorf("interval deve ser 'month', 'week' ou 'day'")
+	}
+
+	var ends []time.Time
+	for start := from; !start.After(to); {
+		end := next(start)
+		if end.After(to) {
+			end = to
+		}
+		ends = append(ends, end)
+		if len(ends) > netWorthMaxPoints {
+			return nil, fmt.Errorf("o período tem mais de %d intervalos; use um intervalo maior", netWorthMaxPoints)
+		}
+		start = end.AddDate(0, 0, 1)
+	}
+	return ends, nil
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// currencySymbols são os símbolos usados na formatação de valores (os mesmos do frontend)
+var currencySymbols = map[string]string{"BRL": "R$", "EUR": "€", "USD": "US$", "GBP": "£"}
+
+// Colunas das tabelas do relatório
+const (
+	reportLeft  = pdfMargin
+	reportRight = pdfPageWidth - pdfMargin
+)
+
+// WritePeriodReportPDF gera o relatório mensal ou anual em PDF
+func WritePeriodReportPDF(w io.Writer, report *structs.PeriodReport) error {
+	kind := "Relatório mensal"
+	if report.Yearly {
+		kind = "Relatório anual"
+	}
+	doc := newPDFDocument(fmt.Sprintf("%s · %s · gerado em %s", kind, report.Title, time.Now().Format("02/01/2006 15:04")))
+	r := reportPDF{doc: doc, report: report}
+
+	r.header(kind)
+	r.summary()
+	if report.Yearly {
+		r.months()
+	}
+	r.categories("Despesas por categoria", report.ExpenseCategories, report.Expense, pdfRed)
+	r.categories("Receitas por categoria", report.IncomeCategories, report.Income, pdfGreen)
+	r.accounts()
+	r.topExpenses()
+
+	return doc.Write(w)
+}
+
+type reportPDF struct {
+	doc    *pdfDocument
+	report *structs.PeriodReport
+}
+
+func (r *reportPDF) money(cents int) string {
+	return formatMoney(cents, r.report.Currency)
+}
+
+func (r *reportPDF) header(kind string) {
+	d := r.doc
+	d.y += 18
+	d.Text(reportLeft, d.y, kind+" — "+r.report.Title, 18, true, pdfBlack)
+	d.y += 16
+	d.Text(reportLeft, d.y, fmt.Sprintf("Período de %s a %s · Moeda: %s · Transações pagas, pela data de competência",
+		r.report.From.Format("02/01/2006"), r.report.To.Format("02/01/2006"), r.report.Currency), 9, false, pdfGray)
+	d.y += 10
+	d.Line(reportLeft, d.y, reportRight, d.y, 1, pdfLightGray)
+	d.y += 14
+}
+
+// sectionTitle escreve o título de uma seção, começando nova página se não couber o mínimo
+func (r *reportPDF) sectionTitle(title string, minHeight float64) {
+	d := r.doc
+	d.Ensure(minHeight + 30)
+	d.y += 12
+	d.Text(reportLeft, d.y, title, 13, true, pdfBlack)
+	d.y += 8
+	d.Line(reportLeft, d.y, reportRight, d.y, 0.5, pdfLightGray)
+	d.y += 14
+}
+
+func (r *reportPDF) summary() {
+	d := r.doc
+	report := r.report
+	r.sectionTitle("Resumo", 90)
+
+	columns := []float64{reportLeft + 250, reportLeft + 370, reportRight}
+	d.TextRight(columns[0], d.y, report.PeriodTotals.Label, 9, true, pdfGray)
+	d.TextRight(columns[1], d.y, report.Previous.Label, 9, true, pdfGray)
+	d.TextRight(columns[2], d.y, "Variação", 9, true, pdfGray)
+	d.y += 16
+
+	rows := []struct {
+		label           string
+		current, before int
+		change          *float64
+		color           pdfColor
+	}{
+		{"Receitas", report.Income, report.Previous.Income, report.IncomeChange, pdfGreen},
+		{"Despesas", report.Expense, report.Previous.Expense, report.ExpenseChange, pdfRed},
+		{"Saldo do período", report.Net, report.Previous.Net, nil, netColor(report.Net)},
+	}
+	for i, row := range rows {
+		bold := i == len(rows)-1
+		d.Text(reportLeft, d.y, row.label, 11, bold, pdfBlack)
+		d.TextRight(columns[0], d.y, r.money(row.current), 11, true, row.color)
+		d.TextRight(columns[1], d.y, r.money(row.before), 11, false, pdfGray)
+		change := "—"
+		if i == len(rows)-1 {
+			change = r.money(row.current - row.before)
+			if row.current-row.before > 0 {
+				change = "+" + change
+			}
+		} else if row.change != nil {
+			change = formatPercentChange(*row.change)
+		}
+		d.TextRight(columns[2], d.y, change, 11, false, pdfBlack)
+		d.y += 18
+	}
+
+	if report.Income > 0 {
+		savings := float64(report.Net) * 100 / float64(report.Income)
+		d.Text(reportLeft, d.y, fmt.Sprintf("Taxa de poupança: %s da receita", formatPercent(savings)), 9, false, pdfGray)
+		d.y += 12
+	}
+}
+
+func (r *reportPDF) months() {
+	d := r.doc
+	r.sectionTitle("Mês a mês", 14*13)
+
+	columns := []float64{reportLeft + 250, reportLeft + 370, reportRight}
+	d.TextRight(columns[0], d.y, "Receitas", 9, true, pdfGray)
+	d.TextRight(columns[1], d.y, "Despesas", 9, true, pdfGray)
+	d.TextRight(columns[2], d.y, "Saldo", 9, true, pdfGray)
+	d.y += 14
+	for _, month := range r.report.Months {
+		d.Ensure(14)
+		d.Text(reportLeft, d.y, month.Label, 10, false, pdfBlack)
+		d.TextRight(columns[0], d.y, r.money(month.Income), 10, false, pdfGreen)
+		d.TextRight(columns[1], d.y, r.money(month.Expense), 10, false, pdfRed)
+		d.TextRight(columns[2], d.y, r.money(month.Net), 10, true, netColor(month.Net))
+		d.y += 14
+	}
+}
+
+// categories lista as categorias com barra proporcional, valor, participação e período anterior
+func (r *reportPDF) categories(title string, totals []structs.ReportCategoryTotal, total int, color pdfColor) {
+	d := r.doc
+	r.sectionTitle(title, 60)
+	if len(totals) == 0 {
+		d.Text(reportLeft, d.y, "Nenhum lançamento no período.", 10, false, pdfGray)
+		d.y += 14
+		return
+	}
+
+	const (
+		nameWidth = 190.0
+		barX      = reportLeft + nameWidth + 6
+		barWidth  = 110.0
+	)
+	amountX := reportLeft + 390.0
+	percentX := reportLeft + 435.0
+	previousX := reportRight
+
+	d.TextRight(amountX, d.y, "Valor", 9, true, pdfGray)
+	d.TextRight(percentX, d.y, "%", 9, true, pdfGray)
+	d.TextRight(previousX, d.y, r.report.Previous.Label, 9, true, pdfGray)
+	d.y += 14
+
+	largest := totals[0].Amount
+	for _, category := range totals {
+		d.Ensure(16 + float64(len(category.Subcategories))*13)
+		d.TextFit(reportLeft, d.y, nameWidth, category.Name, 10, true, pdfBlack)
+		d.Rect(barX, d.y-8, barWidth*float64(category.Amount)/float64(largest), 9, color)
+		d.TextRight(amountX, d.y, r.money(category.Amount), 10, true, pdfBlack)
+		d.TextRight(percentX, d.y, formatPercent(category.Percent), 9, false, pdfGray)
+		d.TextRight(previousX, d.y, r.money(category.Previous), 9, false, pdfGray)
+		d.y += 15
+
+		for _, sub := range category.Subcategories {
+			d.Ensure(13)
+			d.TextFit(reportLeft+14, d.y, nameWidth-14, sub.Name, 9, false, pdfGray)
+			d.Rect(barX, d.y-6, barWidth*float64(sub.Amount)/float64(largest), 5, pdfLightGray)
+			d.TextRight(amountX, d.y, r.money(sub.Amount), 9, false, pdfBlack)
+			d.TextRight(percentX, d.y, formatPercent(sub.Percent), 8, false, pdfGray)
+			d.TextRight(previousX, d.y, r.money(sub.Previous), 8, false, pdfGray)
+			d.y += 13
+		}
+	}
+
+	d.Line(reportLeft, d.y-8, reportRight, d.y-8, 0.5, pdfLightGray)
+	d.y += 4
+	d.Text(reportLeft, d.y, "Total", 10, true, pdfBlack)
+	d.TextRight(amountX, d.y, r.money(total), 10, true, pdfBlack)
+	d.y += 14
+}
+
+func (r *reportPDF) accounts() {
+	d := r.doc
+	r.sectionTitle("Saldos por conta", 40)
+	if len(r.report.Accounts) == 0 {
+		d.Text(reportLeft, d.y, fmt.Sprintf("Nenhuma conta em %s.", r.report.Currency), 10, false, pdfGray)
+		d.y += 14
+		return
+	}
+
+	columns := []float64{reportLeft + 250, reportLeft + 330, reportLeft + 410, reportRight}
+	d.TextRight(columns[0], d.y, "Saldo inicial", 9, true, pdfGray)
+	d.TextRight(columns[1], d.y, "Entradas", 9, true, pdfGray)
+	d.TextRight(columns[2], d.y, "Saídas", 9, true, pdfGray)
+	d.TextRight(columns[3], d.y, "Saldo final", 9, true, pdfGray)
+	d.y += 14
+
+	var opening, income, expense, closing int
+	for _, account := range r.report.Accounts {
+		d.Ensure(14)
+		d.TextFit(reportLeft, d.y, 160, account.Name, 10, false, pdfBlack)
+		d.TextRight(columns[0], d.y, r.money(account.Opening), 10, false, pdfBlack)
+		d.TextRight(columns[1], d.y, r.money(account.Income), 10, false, pdfGreen)
+		d.TextRight(columns[2], d.y, r.money(account.Expense), 10, false, pdfRed)
+		d.TextRight(columns[3], d.y, r.money(account.Closing), 10, true, netColor(account.Closing))
+		d.y += 14
+		opening += account.Opening
+		income += account.Income
+		expense += account.Expense
+		closing += account.Closing
+	}
+
+	d.Line(reportLeft, d.y-8, reportRight, d.y-8, 0.5, pdfLightGray)
+	d.y += 4
+	d.Text(reportLeft, d.y, "Total", 10, true, pdfBlack)
+	d.TextRight(columns[0], d.y, r.money(opening), 10, true, pdfBlack)
+	d.TextRight(columns[1], d.y, r.money(income), 10, true, pdfGreen)
+	d.TextRight(columns[2], d.y, r.money(expense), 10, true, pdfRed)
+	d.TextRight(columns[3], d.y, r.money(closing), 10, true, netColor(closing))
+	d.y += 12
+	d.Text(reportLeft, d.y, "Saldos pelo vencimento das transações pagas, incluindo transferências entre contas.", 8, false, pdfGray)
+	d.y += 12
+}
+
+func (r *reportPDF) topExpenses() {
+	d := r.doc
+	r.sectionTitle("Maiores despesas", 40)
+	if len(r.report.TopExpenses) == 0 {
+		d.Text(reportLeft, d.y, "Nenhuma despesa no período.", 10, false, pdfGray)
+		d.y += 14
+		return
+	}
+
+	d.Text(reportLeft, d.y, "Data", 9, true, pdfGray)
+	d.Text(reportLeft+60, d.y, "Descrição", 9, true, pdfGray)
+	d.Text(reportLeft+250, d.y, "Categoria", 9, true, pdfGray)
+	d.TextRight(reportRight, d.y, "Valor", 9, true, pdfGray)
+	d.y += 14
+	for _, tx := range r.report.TopExpenses {
+		d.Ensure(14)
+		d.Text(reportLeft, d.y, tx.Date.Format("02/01/2006"), 9, false, pdfBlack)
+		d.TextFit(reportLeft+60, d.y, 180, tx.Description, 9, false, pdfBlack)
+		d.TextFit(reportLeft+250, d.y, 170, tx.Category, 9, false, pdfGray)
+		d.TextRight(reportRight, d.y, r.money(tx.Amount), 9, true, pdfRed)
+		d.y += 14
+	}
+}
+
+func netColor(cents int) pdfColor {
+	if cents < 0 {
+		return pdfRed
+	}
+	return pdfBlue
+}
+
+// formatMoney formata centavos no padrão brasileiro com o símbolo da moeda: "R$ 1.234,56"
+func formatMoney(cents int, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	units := fmt.Sprintf("%d", cents/100)
+	var grouped strings.Builder
+	for i, digit := range units {
+		if i > 0 && (len(units)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(digit)
+	}
+	symbol, ok := currencySymbols[currency]
+	if !ok {
+		symbol = currency
+	}
+	return fmt.Sprintf("%s%s %s,%02d", sign, symbol, grouped.String(), cents%100)
+}
+
+// formatPercent formata um percentual com uma casa decimal e vírgula: "12,5%"
+func formatPercent(value float64) string {
+	return strings.Replace(fmt.Sprintf("%.1f%%", value), ".", ",", 1)
+}
+
+// formatPercentChange formata uma variação percentual com sinal: "+12,5%"
+func formatPercentChange(value float64) string {
+	value = math.Round(value*10) / 10
+	if value > 0 {
+		return "+" + formatPercent(value)
+	}
+	return formatPercent(value)
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+const (
+	// pivotMaxDimensions é o máximo de dimensões somando linhas e colunas
+	pivotMaxDimensions = 4
+	// pivotMaxGroups é o máximo de combinações de dimensões devolvidas pela consulta
+	pivotMaxGroups = 10000
+)
+
+// ValidatePivotQuery confere a consulta dinâmica e preenche os padrões (medidas sum e count,
+// moeda em maiúsculas). Os erros são do usuário e podem ser devolvidos como estão.
+func ValidatePivotQuery(query *structs.PivotQuery) (*structs.PivotSpec, error) {
+	spec := &structs.PivotSpec{
+		Currency:         strings.ToUpper(query.Currency),
+		Tags:             query.Tags,
+		IncludeTransfers: query.IncludeTransfers,
+		Limit:            pivotMaxGroups,
+	}
+	query.Currency = spec.Currency
+
+	spec.Dimensions = append(append([]string{}, query.Rows...), query.Columns...)
+	if len(spec.Dimensions) > pivotMaxDimensions {
+		return nil, fmt.Errorf("use no máximo %d dimensões entre linhas e colunas", pivotMaxDimensions)
+	}
+	for i, dimension := range spec.Dimensions {
+		if !slices.Contains(structs.PivotDimensions, dimension) {
+			return nil, fmt.Errorf("dimensão inválida: %s (aceitas: %s)", dimension, strings.Join(structs.PivotDimensions, ", "))
+		}
+		if slices.Contains(spec.Dimensions[:i], dimension) {
+			return nil, fmt.Errorf("dimensão repetida: %s", dimension)
+		}
+	}
+
+	if len(query.Measures) == 0 {
+		query.Measures = []string{"sum", "count"}
+	}
+	for _, measure := range query.Measures {
+		if !slices.Contains(structs.PivotMeasures, measure) {
+			return nil, fmt.Errorf("medida inválida: %s (aceitas: %s)", measure, strings.Join(structs.PivotMeasures, ", "))
+		}
+	}
+
+	switch query.DateField {
+	case "", "due":
+	case "competence":
+		spec.Filter.ByCompetence = true
+	default:
+		return nil, fmt.Errorf("date_field deve ser 'due' ou 'competence'")
+	}
+	for _, date := range []struct {
+		name  string
+		value string
+		dest  **time.Time
+	}{{"from", query.From, &spec.Filter.From}, {"to", query.To, &spec.Filter.To}} {
+		if date.value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", date.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s deve estar no formato AAAA-MM-DD", date.name)
+		}
+		*date.dest = &parsed
+	}
+	if spec.Filter.From != nil && spec.Filter.To != nil && spec.Filter.From.After(*spec.Filter.To) {
+		return nil, fmt.Errorf("from deve ser anterior a to")
+	}
+
+	for _, id := range []struct{ name, value string }{{"account_id", query.AccountID}, {"category_id", query.CategoryID}, {"payee_id", query.PayeeID}} {
+		if id.value != "" && !utils.IsValidUUID(id.value) {
+			return nil, fmt.Errorf("%s deve ser um UUID válido", id.name)
+		}
+	}
+	if query.Type != "" && query.Type != "income" && query.Type != "expense" {
+		return nil, fmt.Errorf("type deve ser 'income' ou 'expense'")
+	}
+	spec.Filter.AccountID = query.AccountID
+	spec.Filter.CategoryID = query.CategoryID
+	spec.Filter.PayeeID = query.PayeeID
+	spec.Filter.Type = query.Type
+	spec.Filter.IsPaid = query.IsPaid
+	return spec, nil
+}
+
+// RunPivot executa a consulta dinâmica e monta a tabela: uma linha para cada combinação das
+// dimensões das linhas e uma coluna para cada combinação das dimensões das colunas. Os totais
+// das linhas, das colunas e o geral somam as células.
+func (s *ReportService) RunPivot(userID string, query structs.PivotQuery) (*structs.PivotResult, error) {
+	spec, err := ValidatePivotQuery(&query)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := s.db.GetPivotGroups(userID, *spec)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &structs.PivotResult{
+		RowDimensions:    append([]string{}, query.Rows...),
+		ColumnDimensions: append([]string{}, query.Columns...),
+		Measures:         query.Measures,
+		Columns:          []structs.PivotHeader{},
+		Rows:             []structs.PivotRow{},
+	}
+	if len(groups) > spec.Limit {
+		groups = groups[:spec.Limit]
+		result.Truncated = true
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Mais de %d combinações; use menos dimensões ou mais filtros", spec.Limit))
+	}
+	if slices.Contains(spec.Dimensions, "tag") {
+		result.Warnings = append(result.Warnings, "Transações com várias tags entram em cada uma delas; os totais podem passar do valor movimentado")
+	}
+	if spec.Currency == "" && !slices.Contains(spec.Dimensions, "currency") {
+		mixed, err := s.hasMixedCurrencies(userID)
+		if err != nil {
+			return nil, err
+		}
+		if mixed {
+			result.Warnings = append(result.Warnings, "As contas têm moedas diferentes e os valores foram somados sem conversão; filtre por currency ou use a dimensão currency")
+		}
+	}
+
+	fillPivotTable(result, groups)
+	return result, nil
+}
+
+// fillPivotTable distribui os grupos da consulta nas linhas e colunas da tabela e calcula os totais
+func fillPivotTable(result *structs.PivotResult, groups []structs.PivotGroup) {
+	// Colunas, em ordem pelos rótulos
+	rowCount := len(result.RowDimensions)
+	columnIndex := make(map[string]int)
+	for _, group := range groups {
+		key := strings.Join(group.Keys[rowCount:], "\x00")
+		if _, ok := columnIndex[key]; !ok {
+			columnIndex[key] = len(result.Columns)
+			result.Columns = append(result.Columns, structs.PivotHeader{Keys: group.Keys[rowCount:], Labels: group.Labels[rowCount:]})
+		}
+	}
+	if len(groups) == 0 {
+		result.Columns = append(result.Columns, structs.PivotHeader{Keys: []string{}, Labels: []string{}})
+	}
+	sort.SliceStable(result.Columns, func(i, j int) bool {
+		return pivotHeaderLess(result.Columns[i], result.Columns[j])
+	})
+	for i, column := range result.Columns {
+		columnIndex[strings.Join(column.Keys, "\x00")] = i
+	}
+
+	// Linhas, na ordem da consulta (já ordenada pelas dimensões das linhas)
+	type totals struct{ sum, net, count int }
+	var rows [][]*totals
+	columnTotals := make([]totals, len(result.Columns))
+	var grandTotal totals
+	rowIndex := make(map[string]int)
+	for _, group := range groups {
+		key := strings.Join(group.Keys[:rowCount], "\x00")
+		i, ok := rowIndex[key]
+		if !ok {
+			i = len(result.Rows)
+			rowIndex[key] = i
+			result.Rows = append(result.Rows, structs.PivotRow{PivotHeader: structs.PivotHeader{Keys: group.Keys[:rowCount], Labels: group.Labels[:rowCount]}})
+			rows = append(rows, make([]*totals, len(result.Columns)))
+		}
+		j := columnIndex[strings.Join(group.Keys[rowCount:], "\x00")]
+		rows[i][j] = &totals{group.Sum, group.Net, group.Count}
+		columnTotals[j].sum += group.Sum
+		columnTotals[j].net += group.Net
+		columnTotals[j].count += group.Count
+		grandTotal.sum += group.Sum
+		grandTotal.net += group.Net
+		grandTotal.count += group.Count
+	}
+
+	for i := range result.Rows {
+		var rowTotal totals
+		result.Rows[i].Cells = make([]structs.PivotCell, len(result.Columns))
+		for j, cell := range rows[i] {
+			if cell == nil {
+				continue
+			}
+			result.Rows[i].Cells[j] = pivotCell(result.Measures, cell.sum, cell.net, cell.count)
+			rowTotal.sum += cell.sum
+			rowTotal.net += cell.net
+			rowTotal.count += cell.count
+		}
+		result.Rows[i].Total = pivotCell(result.Measures, rowTotal.sum, rowTotal.net, rowTotal.count)
+	}
+	for _, total := range columnTotals {
+		result.ColumnTotals = append(result.ColumnTotals, pivotCell(result.Measures, total.sum, total.net, total.count))
+	}
+	result.GrandTotal = pivotCell(result.Measures, grandTotal.sum, grandTotal.net, grandTotal.count)
+}
+
+// hasMixedCurrencies informa se o usuário tem contas em mais de uma moeda
+func (s *ReportService) hasMixedCurrencies(userID string) (bool, error) {
+	accounts, err := s.db.GetAllAccounts(userID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar contas: %w", err)
+	}
+	for _, account := range accounts {
+		if account.Currency != accounts[0].Currency {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// pivotCell calcula as medidas pedidas; a média é em centavos, com duas casas
+func pivotCell(measures []string, sum, net, count int) structs.PivotCell {
+	cell := structs.PivotCell{}
+	for _, measure := range measures {
+		switch measure {
+		case "sum":
+			cell[measure] = float64(sum)
+		case "count":
+			cell[measure] = float64(count)
+		case "net":
+			cell[measure] = float64(net)
+		case "avg":
+			if count > 0 {
+				cell[measure] = math.Round(float64(sum)/float64(count)*100) / 100
+			} else {
+				cell[measure] = 0
+			}
+		}
+	}
+	return cell
+}
+
+// pivotHeaderLess ordena cabeçalhos pelos rótulos e, no empate, pelas chaves
+func pivotHeaderLess(a, b structs.PivotHeader) bool {
+	for i := range a.Labels {
+		if a.Labels[i] != b.Labels[i] {
+			return a.Labels[i] < b.Labels[i]
+		}
+	}
+	return strings.Join(a.Keys, "\x00") < strings.Join(b.Keys, "\x00")
+}
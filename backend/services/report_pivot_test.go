@@ -0,0 +1,71 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+func TestValidatePivotQueryRejectsUnknownNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   structs.PivotQuery
+		wantErr string
+	}{
+		{"dimensão de linha desconhecida", structs.PivotQuery{Rows: []string{"t.amount"}}, "dimensão inválida"},
+		{"dimensão de coluna com SQL", structs.PivotQuery{Columns: []string{"month; DROP TABLE transactions"}}, "dimensão inválida"},
+		{"dimensão repetida", structs.PivotQuery{Rows: []string{"month"}, Columns: []string{"month"}}, "dimensão repetida"},
+		{"dimensões demais", structs.PivotQuery{Rows: []string{"month", "category", "account"}, Columns: []string{"type", "tag"}}, "no máximo"},
+		{"medida desconhecida", structs.PivotQuery{Rows: []string{"month"}, Measures: []string{"max(amount)"}}, "medida inválida"},
+		{"campo de data desconhecido", structs.PivotQuery{DateField: "created_at"}, "date_field"},
+		{"data fora do formato", structs.PivotQuery{From: "01/03/2024"}, "from"},
+		{"período invertido", structs.PivotQuery{From: "2024-04-01", To: "2024-03-01"}, "anterior"},
+		{"conta sem UUID", structs.PivotQuery{AccountID: "1 OR 1=1"}, "account_id"},
+		{"categoria sem UUID", structs.PivotQuery{CategoryID: "x"}, "category_id"},
+		{"beneficiário sem UUID", structs.PivotQuery{PayeeID: "x"}, "payee_id"},
+		{"tipo desconhecido", structs.PivotQuery{Type: "transfer"}, "type"},
+	}
+	for _, tt := range tests {
+		_, err := ValidatePivotQuery(&tt.query)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: erro = %v, esperado contendo %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidatePivotQueryDefaults(t *testing.T) {
+	query := structs.PivotQuery{
+		Rows:      []string{"category"},
+		Columns:   []string{"month"},
+		DateField: "competence",
+		From:      "2024-01-01",
+		To:        "2024-03-31",
+		AccountID: "0b0f8a6e-3c55-4f4e-9a55-1d0b6f3c2a11",
+		Type:      "expense",
+		Currency:  "brl",
+		Tags:      []string{"viagem"},
+	}
+	spec, err := ValidatePivotQuery(&query)
+	if err != nil {
+		t.Fatalf("ValidatePivotQuery: %v", err)
+	}
+	if strings.Join(spec.Dimensions, ",") != "category,month" {
+		t.Errorf("dimensões = %v", spec.Dimensions)
+	}
+	if strings.Join(query.Measures, ",") != "sum,count" {
+		t.Errorf("medidas padrão = %v", query.Measures)
+	}
+	if spec.Currency != "BRL" || query.Currency != "BRL" {
+		t.Errorf("moeda = %q", spec.Currency)
+	}
+	if !spec.Filter.ByCompetence || spec.Filter.From == nil || spec.Filter.To == nil {
+		t.Errorf("filtro de datas = %+v", spec.Filter)
+	}
+	if spec.Filter.AccountID != query.AccountID || spec.Filter.Type != "expense" {
+		t.Errorf("filtro = %+v", spec.Filter)
+	}
+	if spec.Limit != pivotMaxGroups {
+		t.Errorf("limite = %d", spec.Limit)
+	}
+}
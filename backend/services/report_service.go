@@ -0,0 +1,378 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// reportTopExpenses é a quantidade de maiores despesas listadas no relatório
+const reportTopExpenses = 10
+
+// monthNames são os nomes dos meses em português, a partir de janeiro
+var monthNames = []string{"Janeiro", "Fevereiro", "Março", "Abril", "Maio", "Junho", "Julho", "Agosto", "Setembro", "Outubro", "Novembro", "Dezembro"}
+
+type ReportService struct {
+	db              *database.Database
+	exchangeHistory *ExchangeHistoryService
+}
+
+// NewReportService cria uma nova instância do serviço de relatórios
+func NewReportService(db *database.Database, exchangeHistory *ExchangeHistoryService) *ReportService {
+	return &ReportService{db: db, exchangeHistory: exchangeHistory}
+}
+
+// GetMonthlyReport monta o relatório do mês informado, comparado com o mês anterior
+func (s *ReportService) GetMonthlyReport(userID string, month time.Time, currency string) (*structs.PeriodReport, error) {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, -1)
+	previousFrom := from.AddDate(0, -1, 0)
+
+	report, err := s.buildPeriodReport(userID, currency, from, to, previousFrom, from.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	report.Title = monthLabel(from)
+	report.PeriodTotals.Label = monthLabel(from)
+	report.Previous.Label = monthLabel(previousFrom)
+	return report, nil
+}
+
+// GetYearlyReport monta o relatório do ano informado, comparado com o ano anterior e
+// com os totais de cada mês
+func (s *ReportService) GetYearlyReport(userID string, year int, currency string) (*structs.PeriodReport, error) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	report, err := s.buildPeriodReport(userID, currency, from, to, from.AddDate(-1, 0, 0), from.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	report.Yearly = true
+	report.Title = fmt.Sprintf("%d", year)
+	report.PeriodTotals.Label = report.Title
+	report.Previous.Label = fmt.Sprintf("%d", year-1)
+	return report, nil
+}
+
+// reportData reúne o que é carregado uma vez para montar o relatório
+type reportData struct {
+	categories       map[string]*structs.Category
+	accounts         map[string]*structs.Account
+	transferCategory string
+}
+
+// loadReportData carrega as categorias do usuário e as suas contas na moeda informada
+func loadReportData(db *database.Database, userID, currency string) (reportData, error) {
+	data := reportData{
+		categories: make(map[string]*structs.Category),
+		accounts:   make(map[string]*structs.Account),
+	}
+
+	categories, err := db.GetAllCategories(userID)
+	if err != nil {
+		return data, fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+	for i := range categories {
+		data.categories[categories[i].ID] = &categories[i]
+	}
+	transferCategory, err := db.EnsureTransferCategory()
+	if err != nil {
+		return data, fmt.Errorf("erro ao buscar categoria de transferência: %w", err)
+	}
+	data.transferCategory = transferCategory.ID
+
+	accounts, err := db.GetAllAccounts(userID)
+	if err != nil {
+		return data, fmt.Errorf("erro ao buscar contas: %w", err)
+	}
+	for i := range accounts {
+		if accounts[i].Currency == currency {
+			data.accounts[accounts[i].ID] = &accounts[i]
+		}
+	}
+	return data, nil
+}
+
+func (s *ReportService) buildPeriodReport(userID, currency string, from, to, previousFrom, previousTo time.Time) (*structs.PeriodReport, error) {
+	data, err := loadReportData(s.db, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.periodTransactions(userID, data, from, to)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.periodTransactions(userID, data, previousFrom, previousTo)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &structs.PeriodReport{
+		Currency:          currency,
+		From:              from,
+		To:                to,
+		PeriodTotals:      sumPeriod(current, from, to),
+		Previous:          sumPeriod(previous, previousFrom, previousTo),
+		ExpenseCategories: categoryBreakdown(data, current, previous, "expense"),
+		IncomeCategories:  categoryBreakdown(data, current, previous, "income"),
+		TopExpenses:       topExpenses(data, current),
+	}
+	report.IncomeChange = percentChange(report.Previous.Income, report.Income)
+	report.ExpenseChange = percentChange(report.Previous.Expense, report.Expense)
+
+	if to.Sub(from) > 32*24*time.Hour {
+		for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+			monthEnd := month.AddDate(0, 1, -1)
+			var inMonth []structs.Transaction
+			for _, tx := range current {
+				if !tx.CompetenceDate.Before(month) && tx.CompetenceDate.Before(monthEnd.AddDate(0, 0, 1)) {
+					inMonth = append(inMonth, tx)
+				}
+			}
+			totals := sumPeriod(inMonth, month, monthEnd)
+			totals.Label = monthNames[month.Month()-1]
+			report.Months = append(report.Months, totals)
+		}
+	}
+
+	if report.Accounts, err = s.accountMovements(userID, data, from, to); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// periodTransactions busca as receitas e despesas pagas do período (pela competência) nas
+// contas da moeda do relatório, sem as transferências
+func (s *ReportService) periodTransactions(userID string, data reportData, from, to time.Time) ([]structs.Transaction, error) {
+	paid := true
+	return s.reportTransactions(userID, data, structs.TransactionFilter{
+		From:         &from,
+		To:           &to,
+		IsPaid:       &paid,
+		ByCompetence: true,
+	})
+}
+
+// reportTransactions busca as transações do filtro nas contas da moeda do relatório, sem as transferências
+func (s *ReportService) reportTransactions(userID string, data reportData, filter structs.TransactionFilter) ([]structs.Transaction, error) {
+	transactions, err := s.db.GetFilteredTransactions(userID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+
+	var filtered []structs.Transaction
+	for _, tx := range transactions {
+		if _, ok := data.accounts[tx.AccountID]; !ok {
+			continue
+		}
+		if tx.TransferID != nil || tx.CategoryID == data.transferCategory {
+			continue
+		}
+		filtered = append(filtered, tx)
+	}
+	return filtered, nil
+}
+
+// accountMovements calcula o saldo inicial, as entradas, as saídas e o saldo final de cada
+// conta da moeda. Aqui vale o vencimento e entram as transferências, como no saldo da conta.
+func (s *ReportService) accountMovements(userID string, data reportData, from, to time.Time) ([]structs.ReportAccount, error) {
+	paid := true
+	transactions, err := s.db.GetFilteredTransactions(userID, structs.TransactionFilter{From: &from, To: &to, IsPaid: &paid})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+
+	movements := make(map[string]*structs.ReportAccount)
+	for id, account := range data.accounts {
+		closing, err := s.db.GetAccountBalanceAsOf(id, userID, to)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao calcular saldo da conta: %w", err)
+		}
+		movements[id] = &structs.ReportAccount{AccountID: id, Name: account.Name, Closing: closing}
+	}
+	for _, tx := range transactions {
+		movement, ok := movements[tx.AccountID]
+		if !ok {
+			continue
+		}
+		if tx.Type == "income" {
+			movement.Income += tx.Amount
+		} else {
+			movement.Expense += tx.Amount
+		}
+	}
+
+	result := make([]structs.ReportAccount, 0, len(movements))
+	for _, movement := range movements {
+		movement.Opening = movement.Closing - movement.Income + movement.Expense
+		result = append(result, *movement)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name)
+	})
+	return result, nil
+}
+
+func sumPeriod(transactions []structs.Transaction, from, to time.Time) structs.PeriodTotals {
+	totals := structs.PeriodTotals{From: from, To: to}
+	for _, tx := range transactions {
+		if tx.Type == "income" {
+			totals.Income += tx.Amount
+		} else {
+			totals.Expense += tx.Amount
+		}
+	}
+	totals.Net = totals.Income - totals.Expense
+	return totals
+}
+
+// categoryBreakdown agrupa os valores do tipo pela categoria principal, com as subcategorias.
+// Valores lançados direto na categoria principal que tem subcategorias aparecem como "Sem subcategoria".
+func categoryBreakdown(data reportData, current, previous []structs.Transaction, txType string) []structs.ReportCategoryTotal {
+	type node struct {
+		total structs.ReportCategoryTotal
+		subs  map[string]*structs.ReportCategoryTotal
+	}
+	nodes := make(map[string]*node)
+	total := 0
+
+	add := func(tx structs.Transaction, isPrevious bool) {
+		if tx.Type != txType {
+			return
+		}
+		topID, subID := tx.CategoryID, ""
+		if category, ok := data.categories[tx.CategoryID]; ok && category.ParentID != nil {
+			topID, subID = *category.ParentID, tx.CategoryID
+		}
+
+		n, ok := nodes[topID]
+		if !ok {
+			n = &node{total: structs.ReportCategoryTotal{CategoryID: topID, Name: reportCategoryName(data, topID)}, subs: make(map[string]*structs.ReportCategoryTotal)}
+			nodes[topID] = n
+		}
+		sub, ok := n.subs[subID]
+		if !ok {
+			name := "Sem subcategoria"
+			if subID != "" {
+				name = reportCategoryName(data, subID)
+			}
+			sub = &structs.ReportCategoryTotal{CategoryID: subID, Name: name}
+			n.subs[subID] = sub
+		}
+
+		if isPrevious {
+			n.total.Previous += tx.Amount
+			sub.Previous += tx.Amount
+		} else {
+			n.total.Amount += tx.Amount
+			sub.Amount += tx.Amount
+			total += tx.Amount
+		}
+	}
+	for _, tx := range current {
+		add(tx, false)
+	}
+	for _, tx := range previous {
+		add(tx, true)
+	}
+
+	result := make([]structs.ReportCategoryTotal, 0, len(nodes))
+	for _, n := range nodes {
+		if n.total.Amount == 0 {
+			continue // Só aparece no período anterior
+		}
+		if total > 0 {
+			n.total.Percent = float64(n.total.Amount) * 100 / float64(total)
+		}
+		_, hasDirect := n.subs[""]
+		if len(n.subs) > 1 || !hasDirect {
+			for _, sub := range n.subs {
+				if sub.Amount == 0 {
+					continue
+				}
+				sub.Percent = float64(sub.Amount) * 100 / float64(total)
+				n.total.Subcategories = append(n.total.Subcategories, *sub)
+			}
+			sortCategoryTotals(n.total.Subcategories)
+		}
+		result = append(result, n.total)
+	}
+	sortCategoryTotals(result)
+	return result
+}
+
+func sortCategoryTotals(totals []structs.ReportCategoryTotal) {
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Amount != totals[j].Amount {
+			return totals[i].Amount > totals[j].Amount
+		}
+		return totals[i].Name < totals[j].Name
+	})
+}
+
+func reportCategoryName(data reportData, categoryID string) string {
+	if category, ok := data.categories[categoryID]; ok {
+		return category.Name
+	}
+	return "Sem categoria"
+}
+
+// reportCategoryPath retorna o nome da categoria precedido do nome da categoria pai
+func reportCategoryPath(data reportData, categoryID string) string {
+	category, ok := data.categories[categoryID]
+	if !ok {
+		return "Sem categoria"
+	}
+	if category.ParentID != nil {
+		if parent, ok := data.categories[*category.ParentID]; ok {
+			return parent.Name + " > " + category.Name
+		}
+	}
+	return category.Name
+}
+
+func topExpenses(data reportData, transactions []structs.Transaction) []structs.ReportTransaction {
+	var expenses []structs.ReportTransaction
+	for _, tx := range transactions {
+		if tx.Type != "expense" {
+			continue
+		}
+		account := ""
+		if a, ok := data.accounts[tx.AccountID]; ok {
+			account = a.Name
+		}
+		expenses = append(expenses, structs.ReportTransaction{
+			ID:          tx.ID,
+			Date:        tx.CompetenceDate,
+			Description: tx.Description,
+			Category:    reportCategoryPath(data, tx.CategoryID),
+			Account:     account,
+			Amount:      tx.Amount,
+		})
+	}
+	sort.SliceStable(expenses, func(i, j int) bool { return expenses[i].Amount > expenses[j].Amount })
+	if len(expenses) > reportTopExpenses {
+		expenses = expenses[:reportTopExpenses]
+	}
+	return expenses
+}
+
+// percentChange calcula a variação percentual de before para after (nula se before for zero)
+func percentChange(before, after int) *float64 {
+	if before == 0 {
+		return nil
+	}
+	change := float64(after-before) * 100 / float64(before)
+	return &change
+}
+
+// monthLabel formata o mês por extenso, como "Março de 2024"
+func monthLabel(date time.Time) string {
+	return fmt.Sprintf("%s de %d", monthNames[date.Month()-1], date.Year())
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/structs"
+)
+
+// trendHistoryMonths é quantos meses antes da série são buscados para as comparações com o ano
+// anterior e com a média dos 12 meses anteriores
+const trendHistoryMonths = 12
+
+// GetCategoryTrends monta as séries mensais por categoria principal dos months meses terminados em
+// end, com média móvel de window meses, comparação com o mesmo mês do ano anterior e o desvio em
+// relação à média dos 12 meses anteriores. A soma por mês e categoria é feita no banco.
+func (s *ReportService) GetCategoryTrends(userID, currency, txType string, end time.Time, months, window int) (*structs.CategoryTrends, error) {
+	if txType != "income" && txType != "expense" {
+		return nil, fmt.Errorf("type deve ser 'income' ou 'expense'")
+	}
+	if months < 1 || window < 1 || window > trendHistoryMonths {
+		return nil, fmt.Errorf("months deve ser positivo e window deve estar entre 1 e %d", trendHistoryMonths)
+	}
+	data, err := loadReportData(s.db, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	endMonth := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start := endMonth.AddDate(0, -(months - 1), 0)
+	fetchFrom := start.AddDate(0, -trendHistoryMonths, 0)
+	totalMonths := months + trendHistoryMonths
+
+	rows, err := s.db.GetMonthlyCategoryTotals(userID, currency, txType, fetchFrom, endMonth.AddDate(0, 1, -1), data.transferCategory)
+	if err != nil {
+		return nil, err
+	}
+
+	// Valores e quantidades por categoria principal, um item por mês desde fetchFrom
+	type series struct {
+		amounts []int
+		counts  []int
+	}
+	newSeries := func() *series {
+		return &series{amounts: make([]int, totalMonths), counts: make([]int, totalMonths)}
+	}
+	total := newSeries()
+	byCategory := make(map[string]*series)
+	for _, row := range rows {
+		month := row.Month.UTC()
+		index := (month.Year()-fetchFrom.Year())*12 + int(month.Month()) - int(fetchFrom.Month())
+		if index < 0 || index >= totalMonths {
+			continue
+		}
+		categoryID, _ := categoryReportSlot(data, row.CategoryID, "")
+		if _, ok := byCategory[categoryID]; !ok {
+			byCategory[categoryID] = newSeries()
+		}
+		byCategory[categoryID].amounts[index] += row.Amount
+		byCategory[categoryID].counts[index] += row.Count
+		total.amounts[index] += row.Amount
+		total.counts[index] += row.Count
+	}
+
+	trends := &structs.CategoryTrends{
+		Currency:   currency,
+		Type:       txType,
+		Window:     window,
+		Categories: []structs.CategoryTrend{},
+	}
+	for i := 0; i < months; i++ {
+		trends.Months = append(trends.Months, start.AddDate(0, i, 0).Format("2006-01"))
+	}
+
+	build := func(trend structs.CategoryTrend, values *series) structs.CategoryTrend {
+		for i := trendHistoryMonths; i < totalMonths; i++ {
+			amount := values.amounts[i]
+			point := structs.TrendPoint{
+				Month:           fetchFrom.AddDate(0, i, 0).Format("2006-01"),
+				Amount:          amount,
+				Count:           values.counts[i],
+				MovingAverage:   averageCents(values.amounts[i-window+1 : i+1]),
+				PreviousYear:    values.amounts[i-12],
+				TrailingAverage: averageCents(values.amounts[i-trendHistoryMonths : i]),
+			}
+			point.YearOverYear = percentChange(point.PreviousYear, amount)
+			point.Deviation = percentChange(point.TrailingAverage, amount)
+			trend.Points = append(trend.Points, point)
+		}
+		last := trend.Points[len(trend.Points)-1]
+		trend.Current = last.Amount
+		trend.TrailingAverage = last.TrailingAverage
+		trend.Deviation = last.Amount - last.TrailingAverage
+		trend.DeviationPercent = last.Deviation
+		return trend
+	}
+
+	trends.Total = build(structs.CategoryTrend{Name: "Total"}, total)
+	for categoryID, values := range byCategory {
+		trend := structs.CategoryTrend{CategoryID: categoryID, Name: reportCategoryName(data, categoryID)}
+		if category, ok := data.categories[categoryID]; ok {
+			trend.Color = category.Color
+		}
+		trend = build(trend, values)
+
+		// Categorias que só aparecem no histórico anterior à média dos últimos 12 meses ficam de fora
+		visible := trend.TrailingAverage > 0
+		for _, point := range trend.Points {
+			visible = visible || point.Amount > 0
+		}
+		if visible {
+			trends.Categories = append(trends.Categories, trend)
+		}
+	}
+
+	sort.Slice(trends.Categories, func(i, j int) bool {
+		a, b := trends.Categories[i], trends.Categories[j]
+		if a.Deviation != b.Deviation {
+			return a.Deviation > b.Deviation
+		}
+		if a.Current != b.Current {
+			return a.Current > b.Current
+		}
+		return a.Name < b.Name
+	})
+	return trends, nil
+}
+
+// averageCents calcula a média dos valores, arredondada para o centavo
+func averageCents(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, value := range values {
+		sum += value
+	}
+	return int(math.Round(float64(sum) / float64(len(values))))
+}
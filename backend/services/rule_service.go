@@ -0,0 +1,497 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+type RuleService struct {
+	db                *database.Database
+	suggestionService *SuggestionService
+	payeeService      *PayeeService
+}
+
+// NewRuleService cria uma nova instância do serviço de regras de categorização
+func NewRuleService(db *database.Database, suggestionService *SuggestionService, payeeService *PayeeService) *RuleService {
+	return &RuleService{db: db, suggestionService: suggestionService, payeeService: payeeService}
+}
+
+// RuleSet é o conjunto de regras ativas de um usuário, pronto para avaliação
+type RuleSet struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	rule         structs.CategorizationRule
+	contains     string
+	regex        *regexp.Regexp
+	categoryType structs.CategoryType
+}
+
+// RuleOutcome é o resultado da avaliação das regras para uma transação
+type RuleOutcome struct {
+	CategoryID  string   `json:"category_id,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Payee       string   `json:"payee,omitempty"`
+	Observation string   `json:"observation,omitempty"`
+	RuleIDs     []string `json:"rule_ids,omitempty"`
+}
+
+// Matched indica se alguma regra foi aplicada
+func (o RuleOutcome) Matched() bool {
+	return len(o.RuleIDs) > 0
+}
+
+// Evaluate avalia as regras em ordem de prioridade. Para categoria, beneficiário e
+// observação vale a primeira regra que os define; as tags de todas as regras são somadas.
+func (rs *RuleSet) Evaluate(description string, amount int, accountID string, txType string) RuleOutcome {
+	var outcome RuleOutcome
+	if rs == nil {
+		return outcome
+	}
+
+	normalized := utils.NormalizeText(description)
+	if amount < 0 {
+		amount = -amount
+	}
+
+	for _, compiled := range rs.rules {
+		rule := compiled.rule
+		if compiled.contains != "" && !strings.Contains(normalized, compiled.contains) {
+			continue
+		}
+		if compiled.regex != nil && !compiled.regex.MatchString(description) {
+			continue
+		}
+		if rule.AmountMin != nil && amount < *rule.AmountMin {
+			continue
+		}
+		if rule.AmountMax != nil && amount > *rule.AmountMax {
+			continue
+		}
+		if rule.AccountID != nil && *rule.AccountID != accountID {
+			continue
+		}
+		if rule.TransactionType != "" && rule.TransactionType != txType {
+			continue
+		}
+
+		outcome.RuleIDs = append(outcome.RuleIDs, rule.ID)
+
+		// A categoria só é aplicada se for do mesmo tipo da transação
+		if outcome.CategoryID == "" && rule.CategoryID != nil && string(compiled.categoryType) == txType {
+			outcome.CategoryID = *rule.CategoryID
+		}
+		if outcome.Payee == "" {
+			outcome.Payee = rule.Payee
+		}
+		if outcome.Observation == "" {
+			outcome.Observation = rule.Observation
+		}
+		outcome.Tags = mergeTags(outcome.Tags, rule.Tags)
+	}
+
+	return outcome
+}
+
+// ApplyTo avalia as regras para a transação e preenche apenas os campos ainda vazios.
+// As tags das regras são somadas às já existentes.
+func (rs *RuleSet) ApplyTo(tx *structs.Transaction) RuleOutcome {
+	outcome := rs.Evaluate(tx.Description, tx.Amount, tx.AccountID, tx.Type)
+	if !outcome.Matched() {
+		return outcome
+	}
+
+	if tx.CategoryID == "" {
+		tx.CategoryID = outcome.CategoryID
+	}
+	tx.Tags = mergeTags(tx.Tags, outcome.Tags)
+	if tx.Payee == "" {
+		tx.Payee = outcome.Payee
+	}
+	if tx.Observation == "" {
+		tx.Observation = outcome.Observation
+	}
+	return outcome
+}
+
+// LoadRuleSet carrega e compila as regras ativas do usuário
+func (s *RuleService) LoadRuleSet(userID string) (*RuleSet, error) {
+	rules, err := s.db.GetRulesByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras: %w", err)
+	}
+	return s.compileRules(rules, userID, nil)
+}
+
+func (s *RuleService) compileRules(rules []structs.CategorizationRule, userID string, onlyIDs []string) (*RuleSet, error) {
+	categories, err := s.db.GetAllCategories(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+	categoryTypes := make(map[string]structs.CategoryType, len(categories))
+	for _, category := range categories {
+		categoryTypes[category.ID] = category.Type
+	}
+
+	allowed := make(map[string]bool, len(onlyIDs))
+	for _, id := range onlyIDs {
+		allowed[id] = true
+	}
+
+	set := &RuleSet{}
+	for _, rule := range rules {
+		if !rule.IsActive || (len(allowed) > 0 && !allowed[rule.ID]) {
+			continue
+		}
+
+		compiled := compiledRule{
+			rule:     rule,
+			contains: utils.NormalizeText(rule.DescriptionContains),
+		}
+		if rule.DescriptionRegex != "" {
+			regex, err := regexp.Compile("(?i)" + rule.DescriptionRegex)
+			if err != nil {
+				// Regras inválidas são ignoradas em vez de interromper a importação
+				fmt.Printf("Regra %s com expressão regular inválida: %v\n", rule.ID, err)
+				continue
+			}
+			compiled.regex = regex
+		}
+		if rule.CategoryID != nil {
+			compiled.categoryType = categoryTypes[*rule.CategoryID]
+		}
+		set.rules = append(set.rules, compiled)
+	}
+
+	return set, nil
+}
+
+// CreateRule cria uma nova regra de categorização
+func (s *RuleService) CreateRule(req structs.CategorizationRuleRequest) (*structs.CategorizationRule, error) {
+	now := time.Now()
+	rule := structs.CategorizationRule{
+		ID:        utils.GenerateUUID(),
+		UserID:    req.UserID,
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	applyRuleRequest(&rule, req)
+
+	if err := s.validateRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.CreateRule(rule); err != nil {
+		return nil, fmt.Errorf("erro ao criar regra: %w", err)
+	}
+	return &rule, nil
+}
+
+// GetRules lista as regras do usuário em ordem de prioridade
+func (s *RuleService) GetRules(userID string) ([]structs.CategorizationRule, error) {
+	rules, err := s.db.GetRulesByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras: %w", err)
+	}
+	return rules, nil
+}
+
+// GetRuleByID busca uma regra do usuário
+func (s *RuleService) GetRuleByID(id string, userID string) (*structs.CategorizationRule, error) {
+	if !utils.IsValidUUID(id) {
+		return nil, fmt.Errorf("ID deve ser um UUID válido")
+	}
+
+	rule, err := s.db.GetRuleByID(id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regra: %w", err)
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("regra não encontrada")
+	}
+	return rule, nil
+}
+
+// UpdateRule atualiza uma regra existente
+func (s *RuleService) UpdateRule(id string, req structs.CategorizationRuleRequest) (*structs.CategorizationRule, error) {
+	rule, err := s.GetRuleByID(id, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	applyRuleRequest(rule, req)
+	if err := s.validateRule(*rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.UpdateRule(*rule); err != nil {
+		return nil, fmt.Errorf("erro ao atualizar regra: %w", err)
+	}
+	return s.GetRuleByID(id, req.UserID)
+}
+
+// DeleteRule remove uma regra (soft delete)
+func (s *RuleService) DeleteRule(id string, userID string) error {
+	if _, err := s.GetRuleByID(id, userID); err != nil {
+		return err
+	}
+
+	if err := s.db.DeleteRule(id, userID); err != nil {
+		return fmt.Errorf("erro ao excluir regra: %w", err)
+	}
+	return nil
+}
+
+// ApplyRulesResult representa o resultado da aplicação retroativa das regras
+type ApplyRulesResult struct {
+	DryRun         bool                 `json:"dry_run"`
+	TotalEvaluated int                  `json:"total_evaluated"`
+	TotalChanged   int                  `json:"total_changed"`
+	Changes        []structs.RuleChange `json:"changes"`
+}
+
+// ApplyRules aplica as regras às transações existentes. Em modo dry_run apenas
+// retorna a diferença que seria gravada.
+func (s *RuleService) ApplyRules(userID string, req structs.ApplyRulesRequest) (*ApplyRulesResult, error) {
+	rules, err := s.db.GetRulesByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras: %w", err)
+	}
+	ruleSet, err := s.compileRules(rules, userID, req.RuleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	onlyUncategorized := true
+	if req.OnlyUncategorized != nil {
+		onlyUncategorized = *req.OnlyUncategorized
+	}
+
+	transferCategory, err := s.db.EnsureTransferCategory()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categoria de transferência: %w", err)
+	}
+
+	transactions, err := s.db.GetAllTransactionsByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+
+	payees, err := s.payeeService.LoadPayeeSet(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ApplyRulesResult{DryRun: req.DryRun, Changes: []structs.RuleChange{}}
+	for _, tx := range transactions {
+		// Transferências reais nunca são recategorizadas
+		if tx.TransferID != nil {
+			continue
+		}
+		if onlyUncategorized && !IsUncategorized(tx, transferCategory.ID) {
+			continue
+		}
+		result.TotalEvaluated++
+
+		outcome := ruleSet.Evaluate(tx.Description, tx.Amount, tx.AccountID, tx.Type)
+		if !outcome.Matched() {
+			continue
+		}
+
+		original := tx
+		before := classifyingOf(tx)
+		if outcome.CategoryID != "" {
+			tx.CategoryID = outcome.CategoryID
+		}
+		tx.Tags = mergeTags(tx.Tags, outcome.Tags)
+		if outcome.Payee != "" {
+			tx.Payee = outcome.Payee
+		}
+		if outcome.Observation != "" {
+			tx.Observation = outcome.Observation
+		}
+		after := classifyingOf(tx)
+
+		if sameClassifying(before, after) {
+			continue
+		}
+
+		result.Changes = append(result.Changes, structs.RuleChange{
+			TransactionID: tx.ID,
+			Description:   tx.Description,
+			DueDate:       tx.DueDate,
+			Amount:        tx.Amount,
+			RuleIDs:       outcome.RuleIDs,
+			Before:        before,
+			After:         after,
+		})
+		result.TotalChanged++
+
+		if !req.DryRun {
+			// O beneficiário definido pela regra é ligado ao cadastro de beneficiários
+			if tx.Payee != original.Payee {
+				tx.PayeeID = nil
+				if err := payees.AssignTo(&tx, ""); err != nil {
+					return nil, err
+				}
+			}
+			if err := s.db.UpdateTransactionClassification(tx); err != nil {
+				return nil, fmt.Errorf("erro ao atualizar transação %s: %w", tx.ID, err)
+			}
+			if err := s.suggestionService.Relearn(original, tx); err != nil {
+				fmt.Printf("Erro ao atualizar modelo de sugestão de categorias: %v\n", err)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// IsUncategorized indica se a transação ficou sem categoria própria: importações antigas
+// eram gravadas na categoria de sistema "Transferência" sem formar um par de transferência
+func IsUncategorized(tx structs.Transaction, transferCategoryID string) bool {
+	return tx.CategoryID == transferCategoryID && tx.TransferID == nil
+}
+
+// validateRule garante que a regra tem condições e ações válidas e que referencia dados do usuário
+func (s *RuleService) validateRule(rule structs.CategorizationRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("nome da regra é obrigatório")
+	}
+
+	hasCondition := rule.DescriptionContains != "" || rule.DescriptionRegex != "" || rule.AmountMin != nil ||
+		rule.AmountMax != nil || rule.AccountID != nil || rule.TransactionType != ""
+	if !hasCondition {
+		return fmt.Errorf("a regra precisa de pelo menos uma condição")
+	}
+
+	hasAction := rule.CategoryID != nil || len(rule.Tags) > 0 || rule.Payee != "" || rule.Observation != ""
+	if !hasAction {
+		return fmt.Errorf("a regra precisa definir categoria, tags, beneficiário ou observação")
+	}
+
+	if rule.DescriptionRegex != "" {
+		if _, err := regexp.Compile(rule.DescriptionRegex); err != nil {
+			return fmt.Errorf("expressão regular inválida: %w", err)
+		}
+	}
+	if rule.AmountMin != nil && rule.AmountMax != nil && *rule.AmountMin > *rule.AmountMax {
+		return fmt.Errorf("amount_min não pode ser maior que amount_max")
+	}
+	if rule.TransactionType != "" && rule.TransactionType != "income" && rule.TransactionType != "expense" {
+		return fmt.Errorf("transaction_type deve ser 'income' ou 'expense'")
+	}
+
+	if rule.AccountID != nil {
+		if !utils.IsValidUUID(*rule.AccountID) {
+			return fmt.Errorf("account_id deve ser um UUID válido")
+		}
+		account, err := s.db.GetAccountByID(*rule.AccountID, rule.UserID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar conta: %w", err)
+		}
+		if account == nil {
+			return fmt.Errorf("conta não encontrada")
+		}
+	}
+
+	if rule.CategoryID != nil {
+		categories, err := s.db.GetAllCategories(rule.UserID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar categorias: %w", err)
+		}
+		var category *structs.Category
+		for i := range categories {
+			if categories[i].ID == *rule.CategoryID {
+				category = &categories[i]
+				break
+			}
+		}
+		if category == nil {
+			return fmt.Errorf("categoria não encontrada")
+		}
+		if category.Type == structs.CategoryTypeTransfer {
+			return fmt.Errorf("regras não podem atribuir a categoria de transferência")
+		}
+		if rule.TransactionType != "" && string(category.Type) != rule.TransactionType {
+			return fmt.Errorf("a categoria deve ser do mesmo tipo da condição transaction_type")
+		}
+	}
+
+	return nil
+}
+
+// applyRuleRequest copia os campos da requisição para a regra
+func applyRuleRequest(rule *structs.CategorizationRule, req structs.CategorizationRuleRequest) {
+	rule.Name = strings.TrimSpace(req.Name)
+	rule.Priority = req.Priority
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	rule.DescriptionContains = strings.TrimSpace(req.DescriptionContains)
+	rule.DescriptionRegex = strings.TrimSpace(req.DescriptionRegex)
+	rule.AmountMin = req.AmountMin
+	rule.AmountMax = req.AmountMax
+	rule.AccountID = emptyToNil(req.AccountID)
+	rule.TransactionType = req.TransactionType
+	rule.CategoryID = emptyToNil(req.CategoryID)
+	rule.Tags = mergeTags(nil, req.Tags)
+	rule.Payee = strings.TrimSpace(req.Payee)
+	rule.Observation = strings.TrimSpace(req.Observation)
+}
+
+// emptyToNil trata ponteiros para string vazia como ausentes
+func emptyToNil(value *string) *string {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil
+	}
+	return value
+}
+
+// mergeTags une duas listas de tags sem repetições, preservando a ordem
+func mergeTags(current []string, extra []string) []string {
+	result := make([]string, 0, len(current)+len(extra))
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, current...), extra...) {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+func classifyingOf(tx structs.Transaction) structs.TransactionClassifying {
+	tags := tx.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return structs.TransactionClassifying{
+		CategoryID:  tx.CategoryID,
+		Tags:        tags,
+		Payee:       tx.Payee,
+		Observation: tx.Observation,
+	}
+}
+
+func sameClassifying(a, b structs.TransactionClassifying) bool {
+	if a.CategoryID != b.CategoryID || a.Payee != b.Payee || a.Observation != b.Observation || len(a.Tags) != len(b.Tags) {
+		return false
+	}
+	for i := range a.Tags {
+		if a.Tags[i] != b.Tags[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+// maxSuggestions é a quantidade de categorias sugeridas por padrão
+const maxSuggestions = 3
+
+// suggestionStopWords são palavras frequentes que não ajudam a distinguir categorias
+var suggestionStopWords = map[string]bool{
+	"de": true, "da": true, "do": true, "das": true, "dos": true, "em": true, "na": true, "no": true,
+	"para": true, "com": true, "por": true, "e": true, "a": true, "o": true,
+}
+
+type SuggestionService struct {
+	db *database.Database
+}
+
+// NewSuggestionService cria uma nova instância do serviço de sugestão de categorias
+func NewSuggestionService(db *database.Database) *SuggestionService {
+	return &SuggestionService{db: db}
+}
+
+// CategoryModel é o modelo naive Bayes de um usuário carregado em memória
+type CategoryModel struct {
+	categories map[string]structs.Category
+	totals     map[string]structs.CategoryModelTotal
+	tokens     map[string]map[string]int // categoria -> token -> contagem
+	vocabulary int
+}
+
+// LoadModel carrega o modelo do usuário para avaliar várias transações de uma vez
+func (s *SuggestionService) LoadModel(userID string) (*CategoryModel, error) {
+	totals, err := s.db.GetCategoryModelTotals(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar modelo de categorias: %w", err)
+	}
+	tokens, err := s.db.GetCategoryModelTokens(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar modelo de categorias: %w", err)
+	}
+	categories, err := s.db.GetAllCategories(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+
+	model := &CategoryModel{
+		categories: make(map[string]structs.Category, len(categories)),
+		totals:     make(map[string]structs.CategoryModelTotal, len(totals)),
+		tokens:     make(map[string]map[string]int),
+	}
+	for _, category := range categories {
+		model.categories[category.ID] = category
+	}
+	for _, total := range totals {
+		model.totals[total.CategoryID] = total
+	}
+
+	vocabulary := make(map[string]bool)
+	for _, token := range tokens {
+		if model.tokens[token.CategoryID] == nil {
+			model.tokens[token.CategoryID] = make(map[string]int)
+		}
+		model.tokens[token.CategoryID][token.Token] = token.Count
+		vocabulary[token.Token] = true
+	}
+	model.vocabulary = len(vocabulary)
+
+	return model, nil
+}
+
+// Suggest retorna as categorias mais prováveis para a transação, com a confiança de cada uma.
+// txType restringe as sugestões a categorias de receita ou despesa quando informado.
+func (m *CategoryModel) Suggest(description string, amount int, accountID string, txType string, limit int) []structs.CategorySuggestion {
+	suggestions := []structs.CategorySuggestion{}
+	if m == nil || len(m.totals) == 0 {
+		return suggestions
+	}
+	if limit <= 0 {
+		limit = maxSuggestions
+	}
+
+	candidates := make([]structs.CategoryModelTotal, 0, len(m.totals))
+	documents := 0
+	for categoryID, total := range m.totals {
+		category, ok := m.categories[categoryID]
+		if !ok || !category.IsActive || category.Type == structs.CategoryTypeTransfer {
+			continue
+		}
+		if txType != "" && string(category.Type) != txType {
+			continue
+		}
+		candidates = append(candidates, total)
+		documents += total.Documents
+	}
+	if len(candidates) == 0 {
+		return suggestions
+	}
+
+	tokens := transactionTokens(description, amount, accountID)
+	vocabulary := m.vocabulary
+	if vocabulary == 0 {
+		vocabulary = 1
+	}
+
+	// Log-probabilidades com suavização de Laplace
+	scores := make([]float64, len(candidates))
+	best := math.Inf(-1)
+	for i, total := range candidates {
+		score := math.Log(float64(total.Documents+1) / float64(documents+len(candidates)))
+		counts := m.tokens[total.CategoryID]
+		for _, token := range tokens {
+			score += math.Log(float64(counts[token]+1) / float64(total.Tokens+vocabulary))
+		}
+		scores[i] = score
+		if score > best {
+			best = score
+		}
+	}
+
+	// Converter para probabilidades normalizadas (softmax)
+	sum := 0.0
+	for i := range scores {
+		scores[i] = math.Exp(scores[i] - best)
+		sum += scores[i]
+	}
+
+	for i, total := range candidates {
+		category := m.categories[total.CategoryID]
+		suggestions = append(suggestions, structs.CategorySuggestion{
+			CategoryID:   category.ID,
+			CategoryName: category.Name,
+			Type:         category.Type,
+			Confidence:   math.Round(scores[i]/sum*10000) / 10000,
+		})
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Confidence == suggestions[j].Confidence {
+			return suggestions[i].CategoryName < suggestions[j].CategoryName
+		}
+		return suggestions[i].Confidence > suggestions[j].Confidence
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// Suggest sugere categorias para uma transação a partir do histórico do usuário
+func (s *SuggestionService) Suggest(userID string, description string, amount int, accountID string, txType string) ([]structs.CategorySuggestion, error) {
+	if strings.TrimSpace(description) == "" {
+		return nil, fmt.Errorf("description é obrigatória")
+	}
+	if txType != "" && txType != "income" && txType != "expense" {
+		return nil, fmt.Errorf("type deve ser 'income' ou 'expense'")
+	}
+
+	model, err := s.LoadModel(userID)
+	if err != nil {
+		return nil, err
+	}
+	return model.Suggest(description, amount, accountID, txType, maxSuggestions), nil
+}
+
+// Learn inclui uma transação categorizada no modelo do usuário
+func (s *SuggestionService) Learn(tx structs.Transaction) error {
+	return s.adjust(tx, 1)
+}
+
+// Unlearn remove do modelo uma transação que foi excluída ou recategorizada
+func (s *SuggestionService) Unlearn(tx structs.Transaction) error {
+	return s.adjust(tx, -1)
+}
+
+// Relearn atualiza o modelo quando uma transação muda de categoria ou de descrição
+func (s *SuggestionService) Relearn(before structs.Transaction, after structs.Transaction) error {
+	if before.CategoryID == after.CategoryID && before.Description == after.Description &&
+		before.Amount == after.Amount && before.AccountID == after.AccountID {
+		return nil
+	}
+	if err := s.Unlearn(before); err != nil {
+		return err
+	}
+	return s.Learn(after)
+}
+
+func (s *SuggestionService) adjust(tx structs.Transaction, delta int) error {
+	// Transferências e transações sem categoria própria não ensinam nada ao modelo
+	if tx.TransferID != nil || tx.CategoryID == "" {
+		return nil
+	}
+	category, err := s.db.GetCategoryByID(tx.CategoryID, tx.UserID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar categoria: %w", err)
+	}
+	if category == nil || category.Type == structs.CategoryTypeTransfer {
+		return nil
+	}
+
+	tokens := transactionTokens(tx.Description, tx.Amount, tx.AccountID)
+	if err := s.db.AdjustCategoryModel(tx.UserID, tx.CategoryID, tokens, delta); err != nil {
+		return fmt.Errorf("erro ao atualizar modelo de categorias: %w", err)
+	}
+	return nil
+}
+
+// Retrain reconstrói o modelo do usuário a partir de todas as transações categorizadas
+func (s *SuggestionService) Retrain(userID string) (int, error) {
+	transactions, err := s.db.GetAllTransactionsByUser(userID)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+	categories, err := s.db.GetAllCategories(userID)
+	if err != nil {
+		return 0, fmt.Errorf("erro ao buscar categorias: %w", err)
+	}
+	categoryTypes := make(map[string]structs.CategoryType, len(categories))
+	for _, category := range categories {
+		categoryTypes[category.ID] = category.Type
+	}
+
+	totals := make(map[string]*structs.CategoryModelTotal)
+	counts := make(map[string]map[string]int)
+	documents := 0
+	for _, tx := range transactions {
+		categoryType, ok := categoryTypes[tx.CategoryID]
+		if tx.TransferID != nil || !ok || categoryType == structs.CategoryTypeTransfer {
+			continue
+		}
+
+		tokens := transactionTokens(tx.Description, tx.Amount, tx.AccountID)
+		total := totals[tx.CategoryID]
+		if total == nil {
+			total = &structs.CategoryModelTotal{CategoryID: tx.CategoryID}
+			totals[tx.CategoryID] = total
+			counts[tx.CategoryID] = make(map[string]int)
+		}
+		total.Documents++
+		total.Tokens += len(tokens)
+		for _, token := range tokens {
+			counts[tx.CategoryID][token]++
+		}
+		documents++
+	}
+
+	modelTotals := make([]structs.CategoryModelTotal, 0, len(totals))
+	modelTokens := []structs.CategoryModelToken{}
+	for categoryID, total := range totals {
+		modelTotals = append(modelTotals, *total)
+		for token, count := range counts[categoryID] {
+			modelTokens = append(modelTokens, structs.CategoryModelToken{CategoryID: categoryID, Token: token, Count: count})
+		}
+	}
+
+	if err := s.db.ReplaceCategoryModel(userID, modelTotals, modelTokens); err != nil {
+		return 0, fmt.Errorf("erro ao salvar modelo de categorias: %w", err)
+	}
+	return documents, nil
+}
+
+// transactionTokens extrai as características usadas pelo modelo: palavras normalizadas da
+// descrição, a conta e a faixa de valor
+func transactionTokens(description string, amount int, accountID string) []string {
+	words := strings.FieldsFunc(utils.NormalizeText(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words)+2)
+	for _, word := range words {
+		if len(word) < 2 || suggestionStopWords[word] || isDigits(word) {
+			continue
+		}
+		if len(word) > 50 {
+			word = word[:50]
+		}
+		tokens = append(tokens, word)
+	}
+
+	if accountID != "" {
+		tokens = append(tokens, "conta:"+accountID)
+	}
+	tokens = append(tokens, "valor:"+amountBucket(amount))
+	return tokens
+}
+
+// amountBucket agrupa valores (em centavos) em faixas para que valores próximos se pareçam
+func amountBucket(amount int) string {
+	if amount < 0 {
+		amount = -amount
+	}
+	limits := []int{1000, 5000, 10000, 25000, 50000, 100000, 500000}
+	for _, limit := range limits {
+		if amount < limit {
+			return fmt.Sprintf("ate_%d", limit/100)
+		}
+	}
+	return "acima_5000"
+}
+
+// isDigits indica se a palavra contém apenas números (datas, documentos, códigos)
+func isDigits(word string) bool {
+	for _, r := range word {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
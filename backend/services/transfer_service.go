@@ -0,0 +1,400 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tonnarruda/my-personal-finance/database"
+	"github.com/tonnarruda/my-personal-finance/structs"
+	"github.com/tonnarruda/my-personal-finance/utils"
+)
+
+const (
+	// transferMaxDaysApart é a distância máxima, em dias, entre os dois lados de uma transferência
+	transferMaxDaysApart = 3
+	// transferMaxCandidates limita as contrapartidas sugeridas para cada linha importada
+	transferMaxCandidates = 3
+	// transferExchangeTolerance é a diferença relativa aceita entre contas de moedas diferentes
+	transferExchangeTolerance = 0.02
+)
+
+type TransferService struct {
+	db                *database.Database
+	exchangeService   ExchangeServiceInterface
+	suggestionService *SuggestionService
+}
+
+// NewTransferService cria uma nova instância do serviço de detecção de transferências
+func NewTransferService(db *database.Database, exchangeService ExchangeServiceInterface, suggestionService *SuggestionService) *TransferService {
+	return &TransferService{db: db, exchangeService: exchangeService, suggestionService: suggestionService}
+}
+
+// transferMatcher compara valores entre as contas do usuário, guardando as taxas de câmbio já consultadas
+type transferMatcher struct {
+	exchangeService ExchangeServiceInterface
+	accounts        map[string]structs.Account
+	rates           map[string]float64
+}
+
+func (s *TransferService) newTransferMatcher(userID string) (*transferMatcher, error) {
+	accounts, err := s.db.GetAllAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar contas: %w", err)
+	}
+
+	m := &transferMatcher{
+		exchangeService: s.exchangeService,
+		accounts:        make(map[string]structs.Account, len(accounts)),
+		rates:           make(map[string]float64),
+	}
+	for _, account := range accounts {
+		m.accounts[account.ID] = account
+	}
+	return m, nil
+}
+
+// convert converte um valor em centavos da moeda de uma conta para a moeda de outra
+func (m *transferMatcher) convert(amount int, fromAccountID, toAccountID string) (int, error) {
+	from := m.accounts[fromAccountID].Currency
+	to := m.accounts[toAccountID].Currency
+	if from == to {
+		return amount, nil
+	}
+
+	key := from + "/" + to
+	rate, ok := m.rates[key]
+	if !ok {
+		var err error
+		rate, err = m.exchangeService.GetExchangeRateSimple(from, to)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao obter taxa de câmbio %s: %w", key, err)
+		}
+		m.rates[key] = rate
+	}
+	return int(float64(amount)*rate + 0.5), nil
+}
+
+// match verifica se a transação da outra conta tem o mesmo valor após a conversão e
+// retorna o valor convertido para a moeda da conta de origem
+func (m *transferMatcher) match(amount int, accountID string, other structs.Transaction) (int, bool, error) {
+	if _, ok := m.accounts[accountID]; !ok {
+		return 0, false, nil
+	}
+	if _, ok := m.accounts[other.AccountID]; !ok || other.AccountID == accountID {
+		return 0, false, nil
+	}
+
+	converted, err := m.convert(other.Amount, other.AccountID, accountID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	tolerance := 1
+	if m.accounts[other.AccountID].Currency != m.accounts[accountID].Currency {
+		tolerance = int(float64(amount) * transferExchangeTolerance)
+	}
+	diff := converted - amount
+	return converted, diff >= -tolerance && diff <= tolerance, nil
+}
+
+// FindLineCandidates procura, para cada linha de um arquivo da conta informada, transações em outras
+// contas do usuário que possam ser a contrapartida de uma transferência
+func (s *TransferService) FindLineCandidates(userID, accountID string, lines []structs.ImportLine) ([][]structs.TransferCandidate, error) {
+	candidates := make([][]structs.TransferCandidate, len(lines))
+	if accountID == "" || len(lines) == 0 {
+		return candidates, nil
+	}
+
+	matcher, err := s.newTransferMatcher(userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := matcher.accounts[accountID]; !ok {
+		return candidates, nil
+	}
+
+	from, to := lines[0].Date, lines[0].Date
+	for _, line := range lines {
+		if line.Date.Before(from) {
+			from = line.Date
+		}
+		if line.Date.After(to) {
+			to = line.Date
+		}
+	}
+	existing, err := s.db.GetUnlinkedTransactions(userID, startOfDay(from).AddDate(0, 0, -transferMaxDaysApart), startOfDay(to).AddDate(0, 0, transferMaxDaysApart))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+
+	for i, line := range lines {
+		// A contrapartida tem o sentido oposto ao da linha
+		counterpartType := "income"
+		if line.Amount > 0 {
+			counterpartType = "expense"
+		}
+		amount := amountToCents(line.Amount)
+		keyword := hasTransferKeyword(line.Description) || hasTransferKeyword(line.Memo)
+
+		for _, other := range existing {
+			if other.Type != counterpartType {
+				continue
+			}
+			days := daysApart(line.Date, other.DueDate)
+			if days > transferMaxDaysApart {
+				continue
+			}
+			converted, ok, err := matcher.match(amount, accountID, other)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			otherKeyword := keyword || hasTransferKeyword(other.Description)
+			candidates[i] = append(candidates[i], structs.TransferCandidate{
+				Transaction:     other,
+				AccountName:     matcher.accounts[other.AccountID].Name,
+				ConvertedAmount: converted,
+				DaysApart:       days,
+				KeywordMatch:    otherKeyword,
+				Score:           transferScore(days, otherKeyword),
+			})
+		}
+
+		sort.SliceStable(candidates[i], func(a, b int) bool {
+			return candidates[i][a].Score > candidates[i][b].Score
+		})
+		if len(candidates[i]) > transferMaxCandidates {
+			candidates[i] = candidates[i][:transferMaxCandidates]
+		}
+	}
+	return candidates, nil
+}
+
+// ScanCandidates procura, entre as transações já gravadas no período, pares de saída e entrada em contas
+// diferentes que parecem ser a mesma transferência. Cada transação aparece em no máximo um par.
+func (s *TransferService) ScanCandidates(userID, accountID string, from, to time.Time) ([]structs.TransferPairCandidate, error) {
+	matcher, err := s.newTransferMatcher(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.db.GetUnlinkedTransactions(userID, startOfDay(from).AddDate(0, 0, -transferMaxDaysApart), startOfDay(to).AddDate(0, 0, transferMaxDaysApart))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar transações: %w", err)
+	}
+
+	var expenses, incomes []structs.Transaction
+	for _, tx := range transactions {
+		switch tx.Type {
+		case "expense":
+			if tx.DueDate.Before(startOfDay(from)) || !tx.DueDate.Before(startOfDay(to).AddDate(0, 0, 1)) {
+				continue
+			}
+			expenses = append(expenses, tx)
+		case "income":
+			incomes = append(incomes, tx)
+		}
+	}
+
+	var pairs []structs.TransferPairCandidate
+	for _, expense := range expenses {
+		for _, income := range incomes {
+			if accountID != "" && expense.AccountID != accountID && income.AccountID != accountID {
+				continue
+			}
+			days := daysApart(expense.DueDate, income.DueDate)
+			if days > transferMaxDaysApart {
+				continue
+			}
+			_, ok, err := matcher.match(expense.Amount, expense.AccountID, income)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+
+			keyword := hasTransferKeyword(expense.Description) || hasTransferKeyword(income.Description)
+			pairs = append(pairs, structs.TransferPairCandidate{
+				Expense:      expense,
+				Income:       income,
+				DaysApart:    days,
+				KeywordMatch: keyword,
+				Score:        transferScore(days, keyword),
+			})
+		}
+	}
+
+	// Escolher os pares mais prováveis primeiro, sem repetir transações
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return pairs[a].Score > pairs[b].Score
+	})
+	used := make(map[string]bool)
+	selected := make([]structs.TransferPairCandidate, 0)
+	for _, pair := range pairs {
+		if used[pair.Expense.ID] || used[pair.Income.ID] {
+			continue
+		}
+		used[pair.Expense.ID] = true
+		used[pair.Income.ID] = true
+		selected = append(selected, pair)
+	}
+
+	sort.SliceStable(selected, func(a, b int) bool {
+		return selected[a].Expense.DueDate.Before(selected[b].Expense.DueDate)
+	})
+	return selected, nil
+}
+
+// LinkTransfers vincula os pares informados como transferências, com o mesmo transfer_id e a categoria
+// de transferência. Cada par é vinculado de forma independente; os erros são informados no resultado.
+func (s *TransferService) LinkTransfers(userID string, pairs []structs.TransferLinkPair) ([]structs.TransferLinkResult, error) {
+	transferCategory, err := s.db.EnsureTransferCategory()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar categoria de transferência: %w", err)
+	}
+
+	results := make([]structs.TransferLinkResult, 0, len(pairs))
+	for _, pair := range pairs {
+		result := structs.TransferLinkResult{ExpenseID: pair.ExpenseID, IncomeID: pair.IncomeID}
+		transferID, err := s.linkPair(userID, pair.ExpenseID, pair.IncomeID, transferCategory.ID)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.TransferID = transferID
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// linkPair valida e vincula um par de transações existentes
+func (s *TransferService) linkPair(userID, expenseID, incomeID, categoryID string) (string, error) {
+	if !utils.IsValidUUID(expenseID) || !utils.IsValidUUID(incomeID) {
+		return "", fmt.Errorf("IDs devem ser UUIDs válidos")
+	}
+
+	expense, err := s.db.GetTransactionByID(expenseID, userID)
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar transação: %w", err)
+	}
+	income, err := s.db.GetTransactionByID(incomeID, userID)
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar transação: %w", err)
+	}
+	if expense == nil || income == nil {
+		return "", fmt.Errorf("transação não encontrada")
+	}
+	if err := validateTransferPair(*expense, *income); err != nil {
+		return "", err
+	}
+
+	transferID := utils.GenerateUUID()
+	if err := s.db.LinkTransfer(expense.ID, income.ID, userID, transferID, categoryID); err != nil {
+		return "", fmt.Errorf("erro ao vincular transferência: %w", err)
+	}
+
+	// As duas transações deixam de ensinar suas categorias antigas ao modelo de sugestão
+	for _, tx := range []structs.Transaction{*expense, *income} {
+		if err := s.suggestionService.Unlearn(tx); err != nil {
+			fmt.Printf("Erro ao atualizar modelo de sugestão de categorias: %v\n", err)
+		}
+	}
+	return transferID, nil
+}
+
+// LinkImportedTransaction cria uma transação importada já vinculada, como transferência,
+// a uma transação existente de outra conta
+func (s *TransferService) LinkImportedTransaction(tx structs.Transaction, counterpartID string) error {
+	if !utils.IsValidUUID(counterpartID) {
+		return fmt.Errorf("ID da transação vinculada deve ser um UUID válido")
+	}
+
+	counterpart, err := s.db.GetTransactionByID(counterpartID, tx.UserID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar transação vinculada: %w", err)
+	}
+	if counterpart == nil {
+		return fmt.Errorf("transação vinculada não encontrada")
+	}
+
+	expense, income := tx, *counterpart
+	if tx.Type == "income" {
+		expense, income = *counterpart, tx
+	}
+	if err := validateTransferPair(expense, income); err != nil {
+		return err
+	}
+
+	transferCategory, err := s.db.EnsureTransferCategory()
+	if err != nil {
+		return fmt.Errorf("erro ao buscar categoria de transferência: %w", err)
+	}
+
+	tx.CategoryID = transferCategory.ID
+	tx.TransferID = nil
+	if err := s.db.CreateTransaction(tx); err != nil {
+		return fmt.Errorf("erro ao criar transação: %w", err)
+	}
+	if err := s.db.LinkTransfer(expense.ID, income.ID, tx.UserID, utils.GenerateUUID(), transferCategory.ID); err != nil {
+		return fmt.Errorf("transação criada, mas não foi possível vinculá-la à transferência: %w", err)
+	}
+	if err := s.suggestionService.Unlearn(*counterpart); err != nil {
+		fmt.Printf("Erro ao atualizar modelo de sugestão de categorias: %v\n", err)
+	}
+	return nil
+}
+
+// validateTransferPair verifica se duas transações podem formar uma transferência
+func validateTransferPair(expense, income structs.Transaction) error {
+	if expense.Type != "expense" || income.Type != "income" {
+		return fmt.Errorf("uma transferência precisa de uma saída (expense) e de uma entrada (income)")
+	}
+	if expense.AccountID == income.AccountID {
+		return fmt.Errorf("os dois lados da transferência devem estar em contas diferentes")
+	}
+	if expense.TransferID != nil || income.TransferID != nil {
+		return fmt.Errorf("a transação já faz parte de uma transferência")
+	}
+	return nil
+}
+
+// hasTransferKeyword indica se a descrição menciona PIX, TED, DOC ou transferência
+func hasTransferKeyword(description string) bool {
+	words := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		switch {
+		case word == "pix", word == "ted", word == "doc", word == "tef":
+			return true
+		case strings.HasPrefix(word, "transf"):
+			return true
+		}
+	}
+	return false
+}
+
+// daysApart retorna a distância em dias entre duas datas, ignorando o horário
+func daysApart(a, b time.Time) int {
+	days := int(startOfDay(a).Sub(startOfDay(b)).Hours() / 24)
+	if days < 0 {
+		days = -days
+	}
+	return days
+}
+
+// transferScore pontua um par: datas mais próximas e palavras como PIX/TED aumentam a confiança
+func transferScore(days int, keyword bool) float64 {
+	score := 0.7 * (1 - float64(days)/float64(transferMaxDaysApart+1))
+	if keyword {
+		score += 0.3
+	}
+	return score
+}
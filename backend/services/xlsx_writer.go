@@ -0,0 +1,223 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Estilos definidos em xlsxStyles (índices de cellXfs)
+const (
+	xlsxStyleDefault = 0
+	xlsxStyleHeader  = 1
+	xlsxStyleDate    = 2
+	xlsxStyleNumber  = 3
+)
+
+// xlsxEpoch é a data zero das planilhas do Excel (considerando o falso 29/02/1900)
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+type xlsxCellKind int
+
+const (
+	xlsxText xlsxCellKind = iota
+	xlsxNumber
+	xlsxDate
+	xlsxBool
+	xlsxEmpty
+)
+
+// xlsxCell é o valor tipado de uma célula; números e datas continuam utilizáveis em fórmulas
+type xlsxCell struct {
+	kind   xlsxCellKind
+	text   string
+	number float64
+	date   time.Time
+	flag   bool
+}
+
+func xlsxTextCell(text string) xlsxCell    { return xlsxCell{kind: xlsxText, text: text} }
+func xlsxNumberCell(n float64) xlsxCell    { return xlsxCell{kind: xlsxNumber, number: n} }
+func xlsxDateCell(date time.Time) xlsxCell { return xlsxCell{kind: xlsxDate, date: date} }
+func xlsxBoolCell(flag bool) xlsxCell      { return xlsxCell{kind: xlsxBool, flag: flag} }
+func xlsxEmptyCell() xlsxCell              { return xlsxCell{kind: xlsxEmpty} }
+
+// xlsxWriter gera uma planilha .xlsx com uma única aba, gravando as linhas à medida que
+// chegam. O arquivo é um zip com os XMLs do SpreadsheetML; as strings vão inline nas células
+// para não precisar manter uma tabela de strings compartilhadas em memória.
+type xlsxWriter struct {
+	zip     *zip.Writer
+	sheet   *bufio.Writer
+	rows    int
+	started bool
+}
+
+// newXLSXWriter grava as partes fixas da planilha. dateFormat é o formato de exibição das
+// datas no Excel, como "dd/mm/yyyy".
+func newXLSXWriter(w io.Writer, sheetName string, dateFormat string) (*xlsxWriter, error) {
+	archive := zip.NewWriter(w)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, xmlEscape(xlsxSheetName(sheetName)))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", fmt.Sprintf(xlsxStyles, xmlEscape(dateFormat))},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	return &xlsxWriter{zip: archive, sheet: bufio.NewWriter(sheet)}, nil
+}
+
+// start abre a aba; a largura das colunas precisa vir antes das linhas
+func (x *xlsxWriter) start(headers []string) {
+	x.started = true
+	x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	x.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(headers) > 0 {
+		x.sheet.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+		x.sheet.WriteString(`<cols>`)
+		for i, header := range headers {
+			width := utf8.RuneCountInString(header) + 4
+			if width < 12 {
+				width = 12
+			}
+			fmt.Fprintf(x.sheet, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+		}
+		x.sheet.WriteString(`</cols>`)
+	}
+	x.sheet.WriteString(`<sheetData>`)
+}
+
+// WriteHeader grava a linha de cabeçalho (em negrito e congelada no topo)
+func (x *xlsxWriter) WriteHeader(headers []string) error {
+	if !x.started {
+		x.start(headers)
+	}
+	cells := make([]xlsxCell, len(headers))
+	for i, header := range headers {
+		cells[i] = xlsxTextCell(header)
+	}
+	return x.writeRow(cells, xlsxStyleHeader)
+}
+
+// WriteRow grava uma linha de dados
+func (x *xlsxWriter) WriteRow(cells []xlsxCell) error {
+	if !x.started {
+		x.start(nil)
+	}
+	return x.writeRow(cells, xlsxStyleDefault)
+}
+
+func (x *xlsxWriter) writeRow(cells []xlsxCell, textStyle int) error {
+	x.rows++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.rows)
+	for i, cell := range cells {
+		ref := xlsxColumnName(i) + strconv.Itoa(x.rows)
+		switch cell.kind {
+		case xlsxNumber:
+			fmt.Fprintf(x.sheet, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleNumber, strconv.FormatFloat(cell.number, 'f', -1, 64))
+		case xlsxDate:
+			date := time.Date(cell.date.Year(), cell.date.Month(), cell.date.Day(), 0, 0, 0, 0, time.UTC)
+			serial := int(date.Sub(xlsxEpoch).Hours() / 24)
+			fmt.Fprintf(x.sheet, `<c r="%s" s="%d"><v>%d</v></c>`, ref, xlsxStyleDate, serial)
+		case xlsxBool:
+			value := 0
+			if cell.flag {
+				value = 1
+			}
+			fmt.Fprintf(x.sheet, `<c r="%s" t="b"><v>%d</v></c>`, ref, value)
+		case xlsxEmpty:
+			continue
+		default:
+			fmt.Fprintf(x.sheet, `<c r="%s" t="inlineStr" s="%d"><is><t xml:space="preserve">%s</t></is></c>`, ref, textStyle, xmlEscape(cell.text))
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+// Close fecha a aba e o arquivo zip
+func (x *xlsxWriter) Close() error {
+	if !x.started {
+		x.start(nil)
+	}
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+// xlsxColumnName converte o índice da coluna (a partir de 0) em letras: 0 -> A, 26 -> AA
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xlsxSheetName remove os caracteres que o Excel não aceita no nome da aba (máximo de 31)
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, name)
+	if utf8.RuneCountInString(name) > 31 {
+		name = string([]rune(name)[:31])
+	}
+	if name == "" {
+		name = "Planilha1"
+	}
+	return name
+}
+
+// xmlEscape escapa o texto para XML, descartando caracteres de controle não permitidos
+func xmlEscape(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(s))
+	return builder.String()
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+
+// xlsxStyles define, na ordem das constantes xlsxStyle*: padrão, cabeçalho em negrito, data e número com duas casas
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="2"><numFmt numFmtId="164" formatCode="%s"/><numFmt numFmtId="165" formatCode="#,##0.00"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="4"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs><cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`
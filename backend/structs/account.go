@@ -33,7 +33,7 @@ type UpdateAccountRequest struct {
 	Color          string  `json:"color"`
 	Type           string  `json:"type" binding:"oneof=income expense"` // income ou expense
 	IsActive       bool    `json:"is_active"`
-	UserID         string  `json:"user_id" binding:"required"`
+	UserID         string  `json:"user_id"`         // Preenchido com o usuário da sessão
 	DueDate        string  `json:"due_date"`        // Data de vencimento da transação inicial
 	CompetenceDate string  `json:"competence_date"` // Data de competência da transação inicial
 	InitialValue   float64 `json:"initial_value"`   // Valor inicial da conta em reais
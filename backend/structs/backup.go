@@ -0,0 +1,37 @@
+package structs
+
+import "time"
+
+// BackupVersion é a versão atual do formato do arquivo de backup. Arquivos de versões
+// anteriores continuam sendo aceitos na restauração; versões futuras são recusadas.
+const BackupVersion = 1
+
+// Backup reúne todos os dados de um usuário. Novas entidades entram como novas seções,
+// sem alterar as existentes; seções ausentes em arquivos antigos são tratadas como vazias.
+type Backup struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	UserID     string    `json:"user_id"` // Usuário de origem (apenas informativo)
+	// TransferCategoryID é a categoria de sistema das transferências no ambiente de origem;
+	// na restauração, é trocada pela categoria de transferência do ambiente de destino
+	TransferCategoryID string               `json:"transfer_category_id,omitempty"`
+	Categories         []Category           `json:"categories"`
+	Accounts           []Account            `json:"accounts"`
+	Payees             []Payee              `json:"payees"`
+	PayeeRules         []PayeeRule          `json:"payee_rules"`
+	Rules              []CategorizationRule `json:"rules"`
+	CSVProfiles        []CSVProfile         `json:"csv_profiles"`
+	Transactions       []Transaction        `json:"transactions"`
+}
+
+// RestoreResult resume uma restauração de backup
+type RestoreResult struct {
+	Message string `json:"message"`
+	// Created conta os registros criados por seção
+	Created map[string]int `json:"created"`
+	// Reused conta os registros que já existiam no usuário de destino e foram aproveitados
+	// (categorias com mesmo nome, tipo e categoria pai; beneficiários com mesmo nome)
+	Reused map[string]int `json:"reused"`
+	// Warnings lista ajustes feitos nos dados, como vínculos com registros fora do backup
+	Warnings []string `json:"warnings,omitempty"`
+}
@@ -0,0 +1,22 @@
+package structs
+
+import "time"
+
+// CalendarToken é o token secreto que dá acesso ao feed iCal de um usuário. O token em si
+// não é guardado, apenas o seu hash.
+type CalendarToken struct {
+	UserID         string     `json:"user_id"`
+	TokenHash      string     `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastAccessedAt *time.Time `json:"last_accessed_at"` // Última leitura do feed por um aplicativo de calendário
+}
+
+// CalendarFeedInfo descreve o feed do usuário. As URLs só são preenchidas quando o token
+// acaba de ser gerado, pois depois disso o token não pode mais ser recuperado.
+type CalendarFeedInfo struct {
+	Active         bool       `json:"active"`
+	CreatedAt      *time.Time `json:"created_at,omitempty"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	URL            string     `json:"url,omitempty"`
+	WebcalURL      string     `json:"webcal_url,omitempty"` // Mesmo endereço com webcal://, que abre direto no aplicativo
+}
@@ -25,6 +25,7 @@ type Category struct {
 	Color       string       `json:"color" db:"color"`
 	Icon        string       `json:"icon" db:"icon"`
 	ParentID    *string      `json:"parent_id,omitempty" db:"parent_id"`
+	TaxSection  *string      `json:"tax_section,omitempty" db:"tax_section"` // Seção do IRPF, herdada pelas subcategorias
 	IsActive    bool         `json:"is_active" db:"is_active"`
 	Visible     bool         `json:"visible" db:"visible"`
 	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
@@ -48,18 +49,20 @@ type CreateCategoryRequest struct {
 	Icon        string       `json:"icon"`
 	ParentID    *string      `json:"parent_id"`
 	Visible     *bool        `json:"visible"`
+	TaxSection  *string      `json:"tax_section"`
 	UserID      string       `json:"user_id"`
 }
 
 // UpdateCategoryRequest representa a requisição para atualizar uma categoria
 type UpdateCategoryRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	Color       string `json:"color"`
-	Icon        string `json:"icon"`
-	IsActive    *bool  `json:"is_active"`
-	Visible     *bool  `json:"visible"`
-	UserID      string `json:"user_id" binding:"required"`
+	Name        string  `json:"name" binding:"required"`
+	Description string  `json:"description"`
+	Color       string  `json:"color"`
+	Icon        string  `json:"icon"`
+	IsActive    *bool   `json:"is_active"`
+	Visible     *bool   `json:"visible"`
+	TaxSection  *string `json:"tax_section"` // Vazia ou ausente desmarca a categoria no IRPF
+	UserID      string  `json:"user_id"`     // Preenchido com o usuário da sessão
 }
 
 // NewCategory cria uma nova instância de Category
@@ -77,6 +80,7 @@ func NewCategory(req CreateCategoryRequest) Category {
 		Color:       req.Color,
 		Icon:        req.Icon,
 		ParentID:    req.ParentID,
+		TaxSection:  req.TaxSection,
 		IsActive:    true,
 		Visible:     visible,
 		CreatedAt:   now,
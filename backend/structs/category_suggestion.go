@@ -0,0 +1,23 @@
+package structs
+
+// CategorySuggestion representa uma categoria sugerida pelo histórico do usuário
+type CategorySuggestion struct {
+	CategoryID   string       `json:"category_id"`
+	CategoryName string       `json:"category_name"`
+	Type         CategoryType `json:"type"`
+	Confidence   float64      `json:"confidence"` // Entre 0 e 1
+}
+
+// CategoryModelTotal guarda quantas transações e tokens o modelo viu para uma categoria
+type CategoryModelTotal struct {
+	CategoryID string `json:"category_id"`
+	Documents  int    `json:"documents"`
+	Tokens     int    `json:"tokens"`
+}
+
+// CategoryModelToken guarda quantas vezes um token apareceu em transações de uma categoria
+type CategoryModelToken struct {
+	CategoryID string `json:"category_id"`
+	Token      string `json:"token"`
+	Count      int    `json:"count"`
+}
@@ -0,0 +1,60 @@
+package structs
+
+import "time"
+
+// CSVMapping descreve como ler um arquivo CSV. Campos vazios são detectados automaticamente.
+type CSVMapping struct {
+	Delimiter        string `json:"delimiter,omitempty"`         // ",", ";", "\t" ou "|"
+	Encoding         string `json:"encoding,omitempty"`          // utf-8, windows-1252 ou iso-8859-1
+	DecimalSeparator string `json:"decimal_separator,omitempty"` // "," ou "."
+	DateFormat       string `json:"date_format,omitempty"`       // Layout Go, ex.: 02/01/2006
+	// Colunas: nome do cabeçalho ou posição a partir de 1
+	DateColumn        string `json:"date_column"`
+	DescriptionColumn string `json:"description_column"`
+	MemoColumn        string `json:"memo_column,omitempty"`
+	AmountColumn      string `json:"amount_column,omitempty"` // Valor com sinal
+	DebitColumn       string `json:"debit_column,omitempty"`  // Alternativa a amount_column: saídas
+	CreditColumn      string `json:"credit_column,omitempty"` // Alternativa a amount_column: entradas
+	CategoryColumn    string `json:"category_column,omitempty"`
+	ExternalIDColumn  string `json:"external_id_column,omitempty"`
+	// InvertAmount inverte o sinal do valor (faturas de cartão trazem compras como positivas)
+	InvertAmount bool `json:"invert_amount"`
+	HasHeader    bool `json:"has_header"`
+}
+
+// CSVProfile é um mapeamento de colunas salvo pelo usuário
+type CSVProfile struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	Name   string `json:"name"`
+	CSVMapping
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// CSVProfileRequest representa a requisição para criar ou atualizar um perfil de CSV
+type CSVProfileRequest struct {
+	Name string `json:"name" binding:"required"`
+	CSVMapping
+	UserID string `json:"user_id"`
+}
+
+// CSVPreset é um mapeamento pronto para o CSV exportado por um banco
+type CSVPreset struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	CSVMapping
+}
+
+// CSVDetection descreve o que foi detectado (ou usado) ao ler um arquivo CSV
+type CSVDetection struct {
+	Delimiter        string     `json:"delimiter"`
+	Encoding         string     `json:"encoding"`
+	DecimalSeparator string     `json:"decimal_separator"`
+	DateFormat       string     `json:"date_format"`
+	HeaderRow        int        `json:"header_row"` // Linha do cabeçalho (a partir de 1), 0 se não houver
+	Columns          []string   `json:"columns"`
+	SampleRows       [][]string `json:"sample_rows"`
+	PresetID         string     `json:"preset_id,omitempty"`
+}
@@ -0,0 +1,24 @@
+package structs
+
+import "time"
+
+// ExchangeRate é a taxa de câmbio de um par de moedas em uma data: 1 FromCurrency = Rate ToCurrency
+type ExchangeRate struct {
+	ID           string    `json:"id"`
+	UserID       *string   `json:"user_id"` // Nulo nas taxas obtidas da API, que valem para todos
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Date         time.Time `json:"date"`
+	Rate         float64   `json:"rate"`
+	Source       string    `json:"source"` // api ou manual
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SaveExchangeRateRequest é a taxa histórica informada pelo usuário
+type SaveExchangeRateRequest struct {
+	FromCurrency string  `json:"from_currency" binding:"required"`
+	ToCurrency   string  `json:"to_currency" binding:"required"`
+	Date         string  `json:"date" binding:"required"` // AAAA-MM-DD
+	Rate         float64 `json:"rate" binding:"required,gt=0"`
+}
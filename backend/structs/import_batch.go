@@ -0,0 +1,62 @@
+package structs
+
+import "time"
+
+// Status possíveis de um lote de importação
+const (
+	ImportBatchStatusProcessing = "processing"
+	ImportBatchStatusCompleted  = "completed"
+	ImportBatchStatusCancelled  = "cancelled" // Interrompido pelo usuário; as transações já criadas continuam
+	ImportBatchStatusReverted   = "reverted"
+)
+
+// ImportBatch representa um arquivo importado e o resultado da sua importação
+type ImportBatch struct {
+	ID                   string     `json:"id"`
+	UserID               string     `json:"user_id"`
+	AccountID            string     `json:"account_id"`
+	Format               string     `json:"format"` // ofx
+	FileName             string     `json:"file_name"`
+	FileHash             string     `json:"file_hash"` // SHA-256 do conteúdo do arquivo
+	DateStart            *time.Time `json:"date_start,omitempty"`
+	DateEnd              *time.Time `json:"date_end,omitempty"`
+	TransactionsTotal    int        `json:"transactions_total"`
+	TransactionsImported int        `json:"transactions_imported"`
+	TransactionsSkipped  int        `json:"transactions_skipped"`
+	Errors               []string   `json:"errors"`
+	Status               string     `json:"status"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+	RevertedAt           *time.Time `json:"reverted_at,omitempty"`
+	// Saldo informado pelo banco no extrato e conferência com o saldo calculado da conta (em centavos)
+	LedgerBalance     *int       `json:"ledger_balance,omitempty"`
+	LedgerBalanceDate *time.Time `json:"ledger_balance_date,omitempty"`
+	OpeningBalance    *int       `json:"opening_balance,omitempty"` // Saldo do banco no início de DateStart
+	ComputedBalance   *int       `json:"computed_balance,omitempty"`
+	BalanceDifference *int       `json:"balance_difference,omitempty"`
+}
+
+// BalanceCheck compara o saldo informado pelo banco com o saldo calculado da conta na mesma data
+type BalanceCheck struct {
+	ImportBatchID     string    `json:"import_batch_id"`
+	AccountID         string    `json:"account_id"`
+	LedgerBalance     int       `json:"ledger_balance"` // Saldo do extrato, em centavos
+	LedgerBalanceDate time.Time `json:"ledger_balance_date"`
+	ComputedBalance   int       `json:"computed_balance"` // Soma das transações pagas da conta até a data do saldo
+	Difference        int       `json:"difference"`       // LedgerBalance - ComputedBalance
+	Matches           bool      `json:"matches"`
+	// Intervalo em que a divergência começou, quando foi possível localizá-lo.
+	// DivergenceFrom nulo indica que a diferença é anterior a qualquer saldo conferido.
+	DivergenceFrom *time.Time `json:"divergence_from,omitempty"`
+	DivergenceTo   *time.Time `json:"divergence_to,omitempty"`
+	Hint           string     `json:"hint,omitempty"`
+	// Transação de ajuste sugerida para igualar os saldos
+	AdjustmentType   string `json:"adjustment_type,omitempty"` // income ou expense
+	AdjustmentAmount int    `json:"adjustment_amount,omitempty"`
+}
+
+// BalanceAdjustmentRequest cria a transação de ajuste sugerida pela conferência de saldo
+type BalanceAdjustmentRequest struct {
+	CategoryID  string `json:"category_id"` // Padrão: "Outras Receitas" ou "Outros", conforme o tipo do ajuste
+	Description string `json:"description"` // Padrão: "Ajuste de saldo"
+}
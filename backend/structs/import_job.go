@@ -0,0 +1,74 @@
+package structs
+
+import "time"
+
+// Status possíveis de um job de importação
+const (
+	ImportJobStatusQueued    = "queued"
+	ImportJobStatusRunning   = "running"
+	ImportJobStatusCompleted = "completed"
+	ImportJobStatusFailed    = "failed"
+	ImportJobStatusCancelled = "cancelled"
+)
+
+// Tipos de job de importação
+const (
+	ImportJobKindFile    = "file"    // Arquivo inteiro, sem revisão (fluxo legado do OFX)
+	ImportJobKindPreview = "preview" // Linhas revisadas de uma pré-visualização
+)
+
+// Resultado de cada linha processada por uma importação
+const (
+	ImportLineImported = "imported"
+	ImportLineSkipped  = "skipped" // Já existia na conta
+	ImportLineError    = "error"
+	ImportLineReview   = "review" // Sem categoria; guardada em uma pré-visualização para revisão
+)
+
+// ImportJob é uma importação processada em segundo plano
+type ImportJob struct {
+	ID                   string              `json:"id"`
+	UserID               string              `json:"user_id"`
+	AccountID            string              `json:"account_id"`
+	Kind                 string              `json:"kind"`
+	Format               string              `json:"format"`
+	FileName             string              `json:"file_name"`
+	Status               string              `json:"status"`
+	TotalLines           int                 `json:"total_lines"`
+	ProcessedLines       int                 `json:"processed_lines"`
+	TransactionsImported int                 `json:"transactions_imported"`
+	TransactionsSkipped  int                 `json:"transactions_skipped"`
+	ImportBatchID        string              `json:"import_batch_id,omitempty"`
+	Outcomes             []ImportLineOutcome `json:"outcomes"`
+	Result               *ImportResult       `json:"result,omitempty"`
+	Error                string              `json:"error,omitempty"`
+	CancelRequested      bool                `json:"cancel_requested"`
+	Payload              ImportJobPayload    `json:"-"`
+	CreatedAt            time.Time           `json:"created_at"`
+	UpdatedAt            time.Time           `json:"updated_at"`
+	StartedAt            *time.Time          `json:"started_at,omitempty"`
+	FinishedAt           *time.Time          `json:"finished_at,omitempty"`
+}
+
+// Finished indica se o job já terminou (com sucesso, erro ou cancelamento)
+func (j ImportJob) Finished() bool {
+	return j.Status == ImportJobStatusCompleted || j.Status == ImportJobStatusFailed || j.Status == ImportJobStatusCancelled
+}
+
+// ImportJobPayload guarda o necessário para processar (ou retomar) o job após um reinício do servidor
+type ImportJobPayload struct {
+	// Modo arquivo: linhas lidas do arquivo e saldo informado pelo banco
+	FileHash      string            `json:"file_hash,omitempty"`
+	Lines         []ImportLine      `json:"lines,omitempty"`
+	LedgerBalance *StatementBalance `json:"ledger_balance,omitempty"`
+	// Modo pré-visualização: linhas revisadas pelo usuário
+	Request *ImportPreviewRequest `json:"request,omitempty"`
+}
+
+// ImportLineOutcome é o resultado do processamento de uma linha
+type ImportLineOutcome struct {
+	LineID      string `json:"line_id"`
+	Description string `json:"description"`
+	Status      string `json:"status"` // imported, skipped ou error
+	Message     string `json:"message,omitempty"`
+}
@@ -0,0 +1,87 @@
+package structs
+
+import "time"
+
+// ImportLine representa uma linha de extrato lida de um arquivo de importação
+type ImportLine struct {
+	ID         string    `json:"id"`                    // Identificador da linha dentro da pré-visualização
+	ExternalID string    `json:"external_id,omitempty"` // Identificador no arquivo de origem (ex.: FITID do OFX)
+	Amount     float64   `json:"amount"`                // Valor com sinal: positivo para entradas, negativo para saídas
+	Date       time.Time `json:"date"`
+	// ValueDate é a data em que o valor foi efetivado, quando o banco a informa separadamente (camt)
+	ValueDate   *time.Time `json:"value_date,omitempty"`
+	Description string     `json:"description"`
+	Memo        string     `json:"memo"`
+	Category    string     `json:"category,omitempty"` // Categoria informada no arquivo, como "Categoria:Subcategoria"
+	// TransferAccount é o nome da conta de destino quando o arquivo indica uma transferência (QIF)
+	TransferAccount string `json:"transfer_account,omitempty"`
+}
+
+// ImportPreview guarda no servidor o conteúdo de um arquivo já analisado,
+// permitindo importar a revisão do usuário sem reenviar o arquivo
+type ImportPreview struct {
+	ID        string       `json:"id"` // Token da pré-visualização
+	UserID    string       `json:"user_id"`
+	Format    string       `json:"format"`
+	FileName  string       `json:"file_name"`
+	FileHash  string       `json:"file_hash"`
+	Lines     []ImportLine `json:"lines"`
+	CreatedAt time.Time    `json:"created_at"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	// LedgerBalance é o saldo informado pelo banco no arquivo, quando existe
+	LedgerBalance *StatementBalance `json:"ledger_balance,omitempty"`
+}
+
+// StatementBalance é o saldo de uma conta informado pelo banco em uma data
+type StatementBalance struct {
+	Amount int       `json:"amount"` // Em centavos
+	Date   time.Time `json:"date"`
+}
+
+// ImportPreviewRequest representa a importação das linhas revisadas de uma pré-visualização
+type ImportPreviewRequest struct {
+	PreviewToken string `json:"preview_token" binding:"required"`
+	AccountID    string `json:"account_id" binding:"required"` // Conta padrão das linhas
+	Force        bool   `json:"force"`                         // Importar mesmo se o arquivo já foi importado
+	// CreateCategories cria as categorias informadas no arquivo que ainda não existem
+	CreateCategories bool                       `json:"create_categories"`
+	Lines            []ImportPreviewLineRequest `json:"lines" binding:"required,min=1"`
+}
+
+// ImportPreviewLineRequest representa a classificação escolhida pelo usuário para uma linha
+type ImportPreviewLineRequest struct {
+	ID                string `json:"id" binding:"required"`
+	Type              string `json:"type"`                // income ou expense; padrão pelo sinal do valor
+	CategoryID        string `json:"category_id"`         // Obrigatória, exceto para transferências
+	AccountID         string `json:"account_id"`          // Sobrescreve a conta padrão
+	Description       string `json:"description"`         // Descrição editada; padrão é a do arquivo
+	Observation       string `json:"observation"`         // Observação opcional
+	TransferAccountID string `json:"transfer_account_id"` // Marca a linha como transferência para esta conta
+	// LinkTransactionID vincula a linha, como transferência, a uma transação já existente em outra conta
+	LinkTransactionID string `json:"link_transaction_id"`
+	// PayeeID escolhe um beneficiário cadastrado; Payee informa o nome (criado se não existir).
+	// Sem nenhum dos dois, o beneficiário é reconhecido na descrição do banco.
+	PayeeID string `json:"payee_id"`
+	Payee   string `json:"payee"`
+}
+
+// ImportResult representa o resultado de uma importação
+type ImportResult struct {
+	Success              bool     `json:"success"`
+	Message              string   `json:"message"`
+	TransactionsImported int      `json:"transactions_imported"`
+	TransactionsSkipped  int      `json:"transactions_skipped"`
+	ImportBatchID        string   `json:"import_batch_id,omitempty"`
+	Errors               []string `json:"errors,omitempty"`
+	// BalanceCheck confere o saldo da conta com o saldo do extrato, quando o arquivo o informa
+	BalanceCheck *BalanceCheck `json:"balance_check,omitempty"`
+	// BalanceCheckPending indica que a conferência de saldo espera a importação das linhas em
+	// revisão; ela é feita quando a pré-visualização de ReviewToken for importada
+	BalanceCheckPending bool `json:"balance_check_pending,omitempty"`
+	// Flags é a quantidade de alertas de gastos incomuns gerados nas transações importadas
+	Flags int `json:"flags,omitempty"`
+	// PendingReview é a quantidade de linhas sem categoria que não foram importadas; elas ficam na
+	// pré-visualização de ReviewToken, para o usuário classificar e importar por /imports/preview
+	PendingReview int    `json:"pending_review,omitempty"`
+	ReviewToken   string `json:"review_token,omitempty"`
+}
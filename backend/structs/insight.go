@@ -0,0 +1,60 @@
+package structs
+
+import "time"
+
+// Tipos de alerta de transação
+const (
+	FlagAmountOutlier     = "amount_outlier"     // Valor muito acima do habitual do beneficiário ou da categoria
+	FlagPossibleDuplicate = "possible_duplicate" // Mesmo valor e beneficiário na mesma conta em poucos dias
+)
+
+// TransactionFlag é um alerta de gasto incomum em uma transação
+type TransactionFlag struct {
+	ID                   string     `json:"id"`
+	UserID               string     `json:"user_id"`
+	TransactionID        string     `json:"transaction_id"`
+	Kind                 string     `json:"kind"`
+	Message              string     `json:"message"`
+	RelatedTransactionID *string    `json:"related_transaction_id,omitempty"`
+	ExpectedAmount       *int       `json:"expected_amount,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	DismissedAt          *time.Time `json:"dismissed_at,omitempty"`
+	// Transaction traz os dados principais da transação, na listagem de alertas
+	Transaction *FlaggedTransaction `json:"transaction,omitempty"`
+}
+
+// FlaggedTransaction resume a transação de um alerta
+type FlaggedTransaction struct {
+	Description string    `json:"description"`
+	Amount      int       `json:"amount"`
+	DueDate     time.Time `json:"due_date"`
+	AccountID   string    `json:"account_id"`
+	CategoryID  string    `json:"category_id"`
+	Payee       string    `json:"payee,omitempty"`
+}
+
+// CategoryAlert avisa que o gasto de uma categoria no mês está a caminho de passar do habitual
+type CategoryAlert struct {
+	CategoryID string `json:"category_id"`
+	Name       string `json:"name"`
+	Spent      int    `json:"spent"`     // Gasto pago no mês até agora
+	Projected  int    `json:"projected"` // Projeção para o mês inteiro, no ritmo atual
+	Usual      int    `json:"usual"`     // Média mensal dos meses anteriores
+	// Exceeded indica que o gasto já passou do habitual; senão, a projeção passa
+	Exceeded bool    `json:"exceeded"`
+	Percent  float64 `json:"percent"` // Projeção em relação ao habitual (120 = 20% acima)
+}
+
+// Insights reúne os alertas de transações e de categorias do usuário
+type Insights struct {
+	Month      string            `json:"month"` // AAAA-MM dos alertas de categoria
+	Currency   string            `json:"currency"`
+	Flags      []TransactionFlag `json:"flags"`
+	Categories []CategoryAlert   `json:"categories"`
+}
+
+// InsightEvaluation é o resultado da avaliação de um conjunto de transações
+type InsightEvaluation struct {
+	Evaluated    int `json:"evaluated"`
+	FlagsCreated int `json:"flags_created"`
+}
@@ -0,0 +1,94 @@
+package structs
+
+import (
+	"fmt"
+	"time"
+)
+
+// Seções da declaração do IRPF em que categorias e transações podem ser marcadas
+const (
+	TaxSectionIncomePJ  = "rendimentos_pj"       // Rendimentos tributáveis recebidos de pessoa jurídica
+	TaxSectionIncomePF  = "rendimentos_pf"       // Rendimentos tributáveis recebidos de pessoa física e do exterior
+	TaxSectionExempt    = "rendimentos_isentos"  // Rendimentos isentos e não tributáveis
+	TaxSectionExclusive = "tributacao_exclusiva" // Rendimentos sujeitos à tributação exclusiva/definitiva
+	TaxSectionHealth    = "saude"                // Pagamentos: despesas médicas e de saúde
+	TaxSectionEducation = "educacao"             // Pagamentos: instrução
+	TaxSectionAlimony   = "pensao_alimenticia"   // Pagamentos: pensão alimentícia judicial
+	TaxSectionPension   = "previdencia_privada"  // Pagamentos: previdência complementar (PGBL)
+	// TaxSectionNone, só em transações, tira da declaração uma transação de categoria marcada
+	TaxSectionNone = "none"
+)
+
+// TaxSectionInfo descreve uma seção da declaração
+type TaxSectionInfo struct {
+	Section string `json:"section"`
+	Title   string `json:"title"`
+	// Type é o tipo das transações que somam na seção; as do tipo oposto são reembolsos ou estornos
+	Type string `json:"type"`
+	// RequiresTaxID indica que a declaração pede o CPF/CNPJ de cada fonte pagadora ou prestador
+	RequiresTaxID bool `json:"requires_tax_id"`
+}
+
+// TaxSections são as seções na ordem em que aparecem na declaração
+var TaxSections = []TaxSectionInfo{
+	{TaxSectionIncomePJ, "Rendimentos Tributáveis Recebidos de Pessoa Jurídica", "income", true},
+	{TaxSectionIncomePF, "Rendimentos Tributáveis Recebidos de Pessoa Física e do Exterior", "income", false},
+	{TaxSectionExempt, "Rendimentos Isentos e Não Tributáveis", "income", false},
+	{TaxSectionExclusive, "Rendimentos Sujeitos à Tributação Exclusiva/Definitiva", "income", false},
+	{TaxSectionHealth, "Pagamentos Efetuados - Despesas Médicas e de Saúde", "expense", true},
+	{TaxSectionEducation, "Pagamentos Efetuados - Instrução", "expense", true},
+	{TaxSectionAlimony, "Pagamentos Efetuados - Pensão Alimentícia Judicial", "expense", true},
+	{TaxSectionPension, "Pagamentos Efetuados - Previdência Complementar (PGBL)", "expense", true},
+}
+
+// ValidateTaxSection confere a seção do IRPF; allowNone aceita TaxSectionNone (só em transações)
+func ValidateTaxSection(section string, allowNone bool) error {
+	if allowNone && section == TaxSectionNone {
+		return nil
+	}
+	for _, info := range TaxSections {
+		if info.Section == section {
+			return nil
+		}
+	}
+	return fmt.Errorf("tax_section inválida: %s", section)
+}
+
+// IRPFReport reúne por seção da declaração as transações pagas no ano-calendário
+type IRPFReport struct {
+	Year     int           `json:"year"`
+	Currency string        `json:"currency"`
+	Sections []IRPFSection `json:"sections"`
+	// Warnings lista o que precisa ser completado antes de declarar (ex.: prestador sem CPF/CNPJ)
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// IRPFSection é uma seção da declaração com os totais por fonte pagadora ou prestador
+type IRPFSection struct {
+	TaxSectionInfo
+	Total     int            `json:"total"`
+	Count     int            `json:"count"`
+	Providers []IRPFProvider `json:"providers"`
+}
+
+// IRPFProvider soma as transações de uma fonte pagadora ou prestador na seção
+type IRPFProvider struct {
+	TaxID     string `json:"tax_id,omitempty"`      // Só os dígitos
+	TaxIDType string `json:"tax_id_type,omitempty"` // CPF ou CNPJ
+	Name      string `json:"name"`
+	Amount    int    `json:"amount"` // Valor pago ou recebido
+	// Reimbursed soma as transações de tipo oposto (reembolsos do plano de saúde, estornos)
+	Reimbursed   int         `json:"reimbursed"`
+	Total        int         `json:"total"`
+	Count        int         `json:"count"`
+	Transactions []IRPFEntry `json:"transactions"`
+}
+
+// IRPFEntry é uma transação de um prestador no relatório do IRPF
+type IRPFEntry struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	Type        string    `json:"type"`
+	Amount      int       `json:"amount"`
+	Date        time.Time `json:"date"`
+}
@@ -0,0 +1,106 @@
+package structs
+
+import "time"
+
+// Payee representa um beneficiário (estabelecimento ou pessoa) com nome canônico.
+// Descrições brutas do banco, como "PAG*JoseDaSilva 1234", são levadas ao beneficiário
+// pelas regras de beneficiário ou pelo nome normalizado.
+type Payee struct {
+	ID             string     `json:"id"`
+	UserID         string     `json:"user_id"`
+	Name           string     `json:"name"`
+	NormalizedName string     `json:"normalized_name"`
+	Aliases        []string   `json:"aliases"` // Outros nomes normalizados reconhecidos como este beneficiário
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	// Preenchidos na listagem
+	TransactionCount  int        `json:"transaction_count"`
+	LastTransactionAt *time.Time `json:"last_transaction_at,omitempty"`
+}
+
+// PayeeRequest representa a requisição para criar ou renomear um beneficiário
+type PayeeRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// PayeeMergeRequest reúne outros beneficiários neste, movendo transações, regras e nomes
+type PayeeMergeRequest struct {
+	PayeeIDs []string `json:"payee_ids" binding:"required,min=1"`
+}
+
+// Tipos de comparação das regras de beneficiário
+const (
+	PayeeMatchExact    = "exact"    // Descrição inteira igual ao padrão (sem acentos e sem diferenciar maiúsculas)
+	PayeeMatchContains = "contains" // Descrição contém o padrão (sem acentos e sem diferenciar maiúsculas)
+	PayeeMatchRegex    = "regex"    // Expressão regular sobre a descrição original
+)
+
+// PayeeRule leva as descrições que atendem ao padrão a um beneficiário
+type PayeeRule struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	PayeeID   string     `json:"payee_id"`
+	Pattern   string     `json:"pattern"`
+	MatchType string     `json:"match_type"`
+	Priority  int        `json:"priority"` // Menor valor é avaliado primeiro
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// PayeeRuleRequest representa a requisição para criar ou atualizar uma regra de beneficiário
+type PayeeRuleRequest struct {
+	PayeeID   string `json:"payee_id" binding:"required"`
+	Pattern   string `json:"pattern" binding:"required"`
+	MatchType string `json:"match_type"` // Padrão: contains
+	Priority  int    `json:"priority"`
+}
+
+// ApplyPayeesRequest representa a atribuição retroativa de beneficiários às transações
+type ApplyPayeesRequest struct {
+	DryRun         bool  `json:"dry_run"`
+	OnlyUnassigned *bool `json:"only_unassigned"` // Padrão: true
+}
+
+// PayeeAssignment descreve o beneficiário atribuído (ou que seria atribuído) a uma transação
+type PayeeAssignment struct {
+	TransactionID       string    `json:"transaction_id"`
+	Description         string    `json:"description"`
+	OriginalDescription string    `json:"original_description,omitempty"`
+	DueDate             time.Time `json:"due_date"`
+	Amount              int       `json:"amount"`
+	PayeeID             string    `json:"payee_id,omitempty"` // Vazio quando o beneficiário ainda seria criado (dry_run)
+	PayeeName           string    `json:"payee_name"`
+	NewPayee            bool      `json:"new_payee"`
+}
+
+// PayeeHistory é o histórico de gastos e recebimentos de um beneficiário
+type PayeeHistory struct {
+	Payee        Payee         `json:"payee"`
+	From         *time.Time    `json:"from,omitempty"`
+	To           *time.Time    `json:"to,omitempty"`
+	Totals       []PayeeTotals `json:"totals"` // Um item por moeda
+	Months       []PayeeMonth  `json:"months"`
+	Transactions []Transaction `json:"transactions"`
+}
+
+// PayeeTotals soma as transações de um beneficiário em uma moeda
+type PayeeTotals struct {
+	Currency         string     `json:"currency"`
+	TransactionCount int        `json:"transaction_count"`
+	TotalExpense     int        `json:"total_expense"`   // Em centavos
+	TotalIncome      int        `json:"total_income"`    // Em centavos
+	AverageExpense   int        `json:"average_expense"` // Média por despesa, em centavos
+	FirstDate        *time.Time `json:"first_date,omitempty"`
+	LastDate         *time.Time `json:"last_date,omitempty"`
+}
+
+// PayeeMonth soma as transações de um beneficiário em um mês e moeda
+type PayeeMonth struct {
+	Month            string `json:"month"` // AAAA-MM
+	Currency         string `json:"currency"`
+	TransactionCount int    `json:"transaction_count"`
+	Expense          int    `json:"expense"` // Em centavos
+	Income           int    `json:"income"`  // Em centavos
+}
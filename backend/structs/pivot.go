@@ -0,0 +1,77 @@
+package structs
+
+// PivotDimensions são as dimensões aceitas na consulta dinâmica
+var PivotDimensions = []string{"month", "week", "year", "category", "parent_category", "account", "currency", "type", "tag", "payee"}
+
+// PivotMeasures são as medidas aceitas: soma dos valores, quantidade, média e saldo (receitas
+// menos despesas)
+var PivotMeasures = []string{"sum", "count", "avg", "net"}
+
+// PivotQuery é a requisição da consulta dinâmica: as dimensões das linhas e das colunas, as
+// medidas de cada célula e os filtros
+type PivotQuery struct {
+	Rows     []string `json:"rows"`
+	Columns  []string `json:"columns"`
+	Measures []string `json:"measures"` // Padrão: sum e count
+	// DateField é a data usada nos filtros e nas dimensões de período: "due" (padrão) ou "competence"
+	DateField        string   `json:"date_field"`
+	From             string   `json:"from"` // AAAA-MM-DD
+	To               string   `json:"to"`   // AAAA-MM-DD, inclusive
+	AccountID        string   `json:"account_id"`
+	CategoryID       string   `json:"category_id"` // Inclui as subcategorias
+	Type             string   `json:"type"`
+	IsPaid           *bool    `json:"is_paid"`
+	PayeeID          string   `json:"payee_id"`
+	Currency         string   `json:"currency"` // Moeda das contas
+	Tags             []string `json:"tags"`     // Transações com qualquer uma das tags
+	IncludeTransfers bool     `json:"include_transfers"`
+}
+
+// PivotSpec é a consulta validada, pronta para virar SQL
+type PivotSpec struct {
+	Dimensions       []string
+	Filter           TransactionFilter
+	Currency         string
+	Tags             []string
+	IncludeTransfers bool
+	Limit            int
+}
+
+// PivotGroup é uma linha do GROUP BY: os valores das dimensões e os agregados
+type PivotGroup struct {
+	Keys   []string
+	Labels []string
+	Sum    int
+	Net    int
+	Count  int
+}
+
+// PivotCell traz as medidas pedidas de uma célula, pelo nome da medida
+type PivotCell map[string]float64
+
+// PivotHeader identifica uma linha ou coluna da tabela pelos valores das dimensões
+type PivotHeader struct {
+	Keys   []string `json:"keys"`
+	Labels []string `json:"labels"`
+}
+
+// PivotRow é uma linha da tabela; Cells segue a ordem de Columns e traz null onde não há transações
+type PivotRow struct {
+	PivotHeader
+	Cells []PivotCell `json:"cells"`
+	Total PivotCell   `json:"total"`
+}
+
+// PivotResult é o resultado da consulta dinâmica no formato de tabela dinâmica
+type PivotResult struct {
+	RowDimensions    []string      `json:"row_dimensions"`
+	ColumnDimensions []string      `json:"column_dimensions"`
+	Measures         []string      `json:"measures"`
+	Columns          []PivotHeader `json:"columns"`
+	Rows             []PivotRow    `json:"rows"`
+	ColumnTotals     []PivotCell   `json:"column_totals"`
+	GrandTotal       PivotCell     `json:"grand_total"`
+	// Truncated indica que havia mais combinações do que o limite e o resultado está incompleto
+	Truncated bool     `json:"truncated,omitempty"`
+	Warnings  []string `json:"warnings,omitempty"`
+}
@@ -0,0 +1,231 @@
+package structs
+
+import "time"
+
+// PeriodReport é o resumo financeiro de um mês ou ano, usado no relatório em PDF.
+// Considera as transações pagas na moeda do relatório, pela data de competência,
+// sem contar as transferências entre contas.
+type PeriodReport struct {
+	Title    string    `json:"title"` // Ex.: "Março de 2024" ou "2024"
+	Yearly   bool      `json:"yearly"`
+	Currency string    `json:"currency"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	PeriodTotals
+	Previous PeriodTotals `json:"previous"`
+	// IncomeChange e ExpenseChange são as variações percentuais em relação ao período anterior
+	// (nulas quando o período anterior não teve movimento)
+	IncomeChange      *float64              `json:"income_change,omitempty"`
+	ExpenseChange     *float64              `json:"expense_change,omitempty"`
+	ExpenseCategories []ReportCategoryTotal `json:"expense_categories"`
+	IncomeCategories  []ReportCategoryTotal `json:"income_categories"`
+	Accounts          []ReportAccount       `json:"accounts"`
+	TopExpenses       []ReportTransaction   `json:"top_expenses"`
+	Months            []PeriodTotals        `json:"months,omitempty"` // Apenas no relatório anual
+}
+
+// PeriodTotals reúne receitas, despesas e saldo de um período, em centavos
+type PeriodTotals struct {
+	Label   string    `json:"label"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Income  int       `json:"income"`
+	Expense int       `json:"expense"`
+	Net     int       `json:"net"`
+}
+
+// ReportCategoryTotal é o total de uma categoria no período, com as subcategorias
+type ReportCategoryTotal struct {
+	CategoryID    string                `json:"category_id"`
+	Name          string                `json:"name"`
+	Amount        int                   `json:"amount"`
+	Previous      int                   `json:"previous"` // Total no período anterior
+	Percent       float64               `json:"percent"`  // Participação no total do tipo
+	Subcategories []ReportCategoryTotal `json:"subcategories,omitempty"`
+}
+
+// ReportAccount é o movimento de uma conta no período, incluindo transferências
+type ReportAccount struct {
+	AccountID string `json:"account_id"`
+	Name      string `json:"name"`
+	Opening   int    `json:"opening"` // Saldo no início do período
+	Income    int    `json:"income"`
+	Expense   int    `json:"expense"`
+	Closing   int    `json:"closing"` // Saldo no fim do período
+}
+
+// ReportTransaction é uma transação listada no relatório
+type ReportTransaction struct {
+	ID          string    `json:"id"`
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	Account     string    `json:"account"`
+	Amount      int       `json:"amount"`
+}
+
+// CategoryReport é o total por categoria de um tipo (receita ou despesa) no período, com os
+// valores das subcategorias somados às categorias pai. Segue as regras do PeriodReport: transações
+// pagas na moeda do relatório, pela data de competência, sem as transferências.
+type CategoryReport struct {
+	Currency string    `json:"currency"`
+	Type     string    `json:"type"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	// ParentID e ParentName são preenchidos ao detalhar as subcategorias de uma categoria
+	ParentID   string             `json:"parent_id,omitempty"`
+	ParentName string             `json:"parent_name,omitempty"`
+	Total      int                `json:"total"`
+	Count      int                `json:"count"`
+	Categories []CategorySpending `json:"categories"`
+}
+
+// CategorySpending é o total de uma categoria no relatório por categoria
+type CategorySpending struct {
+	CategoryID   string  `json:"category_id"`
+	Name         string  `json:"name"`
+	Color        string  `json:"color"`
+	Icon         string  `json:"icon"`
+	ParentID     *string `json:"parent_id"`
+	Amount       int     `json:"amount"`        // Inclui as subcategorias
+	DirectAmount int     `json:"direct_amount"` // Lançado direto na categoria
+	Count        int     `json:"count"`         // Quantidade de transações, incluindo as subcategorias
+	Percent      float64 `json:"percent"`       // Participação no total do relatório
+	HasChildren  bool    `json:"has_children"`  // Há subcategorias com movimento, que podem ser detalhadas
+	// Direct marca, no detalhamento, a linha com o valor lançado na própria categoria pai
+	Direct bool `json:"direct,omitempty"`
+}
+
+// IncomeStatement é a demonstração de resultado (DRE pessoal) do período nos dois regimes:
+// competência (todas as transações com competence_date no período, pagas ou não) e caixa
+// (transações pagas com vencimento no período, que faz as vezes da data de pagamento).
+// A conciliação explica a diferença: Cash.Net somado ao NetEffect de cada ajuste dá Accrual.Net.
+type IncomeStatement struct {
+	Currency       string                      `json:"currency"`
+	From           time.Time                   `json:"from"`
+	To             time.Time                   `json:"to"`
+	Accrual        PeriodTotals                `json:"accrual"`
+	Cash           PeriodTotals                `json:"cash"`
+	Lines          []IncomeStatementLine       `json:"lines"`
+	Reconciliation []IncomeStatementAdjustment `json:"reconciliation"`
+}
+
+// IncomeStatementLine é uma categoria principal da DRE, com as subcategorias somadas, nos dois regimes
+type IncomeStatementLine struct {
+	CategoryID string `json:"category_id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Accrual    int    `json:"accrual"`
+	Cash       int    `json:"cash"`
+	Difference int    `json:"difference"` // Accrual - Cash
+}
+
+// IncomeStatementAdjustment agrupa as transações que entram em só um dos regimes, pelo motivo
+type IncomeStatementAdjustment struct {
+	Kind        string `json:"kind"` // Ex.: "unpaid", "paid_before", "paid_after", "accrued_before", "accrued_after"
+	Description string `json:"description"`
+	Type        string `json:"type"` // income ou expense
+	Amount      int    `json:"amount"`
+	// NetEffect é o efeito sobre o resultado ao passar do regime de caixa para o de competência
+	NetEffect    int                   `json:"net_effect"`
+	Count        int                   `json:"count"`
+	Transactions []IncomeStatementItem `json:"transactions"` // As maiores, até um limite
+}
+
+// IncomeStatementItem é uma transação listada na conciliação, com as duas datas
+type IncomeStatementItem struct {
+	ID             string    `json:"id"`
+	Description    string    `json:"description"`
+	Category       string    `json:"category"`
+	Account        string    `json:"account"`
+	Amount         int       `json:"amount"`
+	CompetenceDate time.Time `json:"competence_date"`
+	DueDate        time.Time `json:"due_date"`
+	IsPaid         bool      `json:"is_paid"`
+}
+
+// CategoryMonthTotal é o total pago de uma categoria em um mês, agregado no banco
+type CategoryMonthTotal struct {
+	Month      time.Time `json:"month"` // Primeiro dia do mês
+	CategoryID string    `json:"category_id"`
+	Type       string    `json:"type"`
+	Amount     int       `json:"amount"`
+	Count      int       `json:"count"`
+}
+
+// CategoryTrends são as séries mensais por categoria principal (subcategorias somadas), na moeda e
+// com as regras do PeriodReport, para comparar cada mês com o histórico
+type CategoryTrends struct {
+	Currency   string          `json:"currency"`
+	Type       string          `json:"type"`
+	Months     []string        `json:"months"` // AAAA-MM, do mais antigo ao mais recente
+	Window     int             `json:"window"` // Meses da média móvel
+	Total      CategoryTrend   `json:"total"`  // Soma de todas as categorias
+	Categories []CategoryTrend `json:"categories"`
+}
+
+// CategoryTrend é a série de uma categoria. Os campos de resumo se referem ao último mês.
+// As categorias vêm ordenadas pelo quanto o último mês ficou acima da média dos 12 meses anteriores.
+type CategoryTrend struct {
+	CategoryID       string       `json:"category_id"`
+	Name             string       `json:"name"`
+	Color            string       `json:"color"`
+	Points           []TrendPoint `json:"points"`
+	Current          int          `json:"current"`
+	TrailingAverage  int          `json:"trailing_average"`
+	Deviation        int          `json:"deviation"`                   // Current - TrailingAverage
+	DeviationPercent *float64     `json:"deviation_percent,omitempty"` // Nulo quando a média é zero
+}
+
+// TrendPoint é o valor de um mês da série, em centavos
+type TrendPoint struct {
+	Month         string `json:"month"` // AAAA-MM
+	Amount        int    `json:"amount"`
+	Count         int    `json:"count"`
+	MovingAverage int    `json:"moving_average"` // Média dos últimos Window meses, incluindo este
+	PreviousYear  int    `json:"previous_year"`  // Mesmo mês do ano anterior
+	// YearOverYear é a variação percentual em relação ao mesmo mês do ano anterior
+	YearOverYear *float64 `json:"year_over_year,omitempty"`
+	// TrailingAverage é a média dos 12 meses anteriores (sem este) e Deviation a variação
+	// percentual do mês em relação a ela
+	TrailingAverage int      `json:"trailing_average"`
+	Deviation       *float64 `json:"deviation,omitempty"`
+}
+
+// NetWorthReport é a evolução do saldo de cada conta e do patrimônio total, no fim de cada período.
+// Os saldos consideram todas as transações pagas pelo vencimento, incluindo o saldo inicial e as
+// transferências, e são convertidos para a moeda base pela taxa de câmbio da data.
+type NetWorthReport struct {
+	Currency      string            `json:"currency"` // Moeda base
+	Interval      string            `json:"interval"` // month, week ou day
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	Accounts      []NetWorthAccount `json:"accounts"`
+	Points        []NetWorthPoint   `json:"points"`
+	Change        int               `json:"change"` // Total do último período menos o do primeiro
+	ChangePercent *float64          `json:"change_percent,omitempty"`
+	Warnings      []string          `json:"warnings,omitempty"`
+}
+
+// NetWorthAccount identifica uma conta da série
+type NetWorthAccount struct {
+	AccountID string `json:"account_id"`
+	Name      string `json:"name"`
+	Currency  string `json:"currency"`
+	Color     string `json:"color"`
+}
+
+// NetWorthPoint é o patrimônio no fim de um período (Date é o último dia do período)
+type NetWorthPoint struct {
+	Date     time.Time         `json:"date"`
+	Total    int               `json:"total"` // Na moeda base
+	Balances []NetWorthBalance `json:"balances"`
+}
+
+// NetWorthBalance é o saldo de uma conta no fim do período
+type NetWorthBalance struct {
+	AccountID string  `json:"account_id"`
+	Balance   int     `json:"balance"`   // Na moeda da conta
+	Converted int     `json:"converted"` // Na moeda base
+	Rate      float64 `json:"rate"`      // Taxa usada na conversão (1 na moeda base, 0 sem taxa)
+}
@@ -0,0 +1,72 @@
+package structs
+
+import "time"
+
+// CategorizationRule representa uma regra de categorização automática.
+// Todas as condições preenchidas precisam ser atendidas para a regra se aplicar.
+type CategorizationRule struct {
+	ID       string `json:"id"`
+	UserID   string `json:"user_id"`
+	Name     string `json:"name"`
+	Priority int    `json:"priority"` // Menor valor é avaliado primeiro
+	IsActive bool   `json:"is_active"`
+	// Condições
+	DescriptionContains string  `json:"description_contains,omitempty"` // Comparação sem acentos e sem diferenciar maiúsculas
+	DescriptionRegex    string  `json:"description_regex,omitempty"`
+	AmountMin           *int    `json:"amount_min,omitempty"` // Em centavos
+	AmountMax           *int    `json:"amount_max,omitempty"` // Em centavos
+	AccountID           *string `json:"account_id,omitempty"`
+	TransactionType     string  `json:"transaction_type,omitempty"` // income ou expense
+	// Ações
+	CategoryID  *string    `json:"category_id,omitempty"`
+	Tags        []string   `json:"tags"`
+	Payee       string     `json:"payee,omitempty"`
+	Observation string     `json:"observation,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// CategorizationRuleRequest representa a requisição para criar ou atualizar uma regra
+type CategorizationRuleRequest struct {
+	Name                string   `json:"name" binding:"required"`
+	Priority            int      `json:"priority"`
+	IsActive            *bool    `json:"is_active"`
+	DescriptionContains string   `json:"description_contains"`
+	DescriptionRegex    string   `json:"description_regex"`
+	AmountMin           *int     `json:"amount_min"`
+	AmountMax           *int     `json:"amount_max"`
+	AccountID           *string  `json:"account_id"`
+	TransactionType     string   `json:"transaction_type"`
+	CategoryID          *string  `json:"category_id"`
+	Tags                []string `json:"tags"`
+	Payee               string   `json:"payee"`
+	Observation         string   `json:"observation"`
+	UserID              string   `json:"user_id"`
+}
+
+// ApplyRulesRequest representa a aplicação retroativa das regras
+type ApplyRulesRequest struct {
+	DryRun            bool     `json:"dry_run"`
+	OnlyUncategorized *bool    `json:"only_uncategorized"` // Padrão: true
+	RuleIDs           []string `json:"rule_ids"`           // Restringe a aplicação a estas regras
+}
+
+// RuleChange descreve a alteração que as regras fazem (ou fariam) em uma transação
+type RuleChange struct {
+	TransactionID string                 `json:"transaction_id"`
+	Description   string                 `json:"description"`
+	DueDate       time.Time              `json:"due_date"`
+	Amount        int                    `json:"amount"`
+	RuleIDs       []string               `json:"rule_ids"`
+	Before        TransactionClassifying `json:"before"`
+	After         TransactionClassifying `json:"after"`
+}
+
+// TransactionClassifying agrupa os campos de uma transação que as regras podem alterar
+type TransactionClassifying struct {
+	CategoryID  string   `json:"category_id"`
+	Tags        []string `json:"tags"`
+	Payee       string   `json:"payee"`
+	Observation string   `json:"observation"`
+}
@@ -20,6 +20,17 @@ type Transaction struct {
 	CurrentInstallment  int       `json:"current_installment"`
 	ParentTransactionID *string   `json:"parent_transaction_id"`
 	TransferID          *string   `json:"transfer_id"`
+	ImportBatchID       *string   `json:"import_batch_id,omitempty"` // Lote de importação que criou a transação
+	ExternalID          string    `json:"external_id,omitempty"`     // Identificador no arquivo de origem (ex.: FITID do OFX)
+	Tags                []string  `json:"tags"`
+	Payee               string    `json:"payee,omitempty"`
+	PayeeID             *string   `json:"payee_id"`
+	// OriginalDescription é a descrição como veio do banco, mantida para rastreabilidade
+	OriginalDescription string `json:"original_description,omitempty"`
+	// TaxSection substitui a seção do IRPF da categoria ("none" tira a transação da declaração)
+	TaxSection *string `json:"tax_section,omitempty"`
+	// PayeeTaxID é o CPF ou CNPJ do beneficiário, só com os dígitos
+	PayeeTaxID string `json:"payee_tax_id,omitempty"`
 	// Campos para taxa manual
 	UseManualRate *bool      `json:"use_manual_rate,omitempty"`
 	ManualRate    *float64   `json:"manual_rate,omitempty"`
@@ -27,3 +38,16 @@ type Transaction struct {
 	UpdatedAt     time.Time  `json:"updated_at"`
 	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
 }
+
+// TransactionFilter reúne os filtros aceitos pela listagem e pela exportação de transações
+type TransactionFilter struct {
+	From       *time.Time // Vencimento a partir desta data
+	To         *time.Time // Vencimento até esta data, inclusive
+	AccountID  string
+	CategoryID string // Inclui as subcategorias
+	Type       string // income ou expense
+	IsPaid     *bool
+	PayeeID    string
+	// ByCompetence aplica From e To à data de competência em vez do vencimento
+	ByCompetence bool
+}
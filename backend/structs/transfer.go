@@ -0,0 +1,40 @@
+package structs
+
+// TransferCandidate é uma transação existente em outra conta que pode ser a contrapartida
+// de uma transferência (mesmo valor após conversão, sentido oposto e data próxima)
+type TransferCandidate struct {
+	Transaction     Transaction `json:"transaction"`
+	AccountName     string      `json:"account_name"`
+	ConvertedAmount int         `json:"converted_amount"` // Valor convertido para a moeda da conta de origem, em centavos
+	DaysApart       int         `json:"days_apart"`
+	KeywordMatch    bool        `json:"keyword_match"` // Descrição menciona PIX, TED, DOC ou transferência
+	Score           float64     `json:"score"`         // De 0 a 1; maior é mais provável
+}
+
+// TransferPairCandidate é um par de transações já gravadas que parecem ser os dois lados de uma transferência
+type TransferPairCandidate struct {
+	Expense      Transaction `json:"expense"`
+	Income       Transaction `json:"income"`
+	DaysApart    int         `json:"days_apart"`
+	KeywordMatch bool        `json:"keyword_match"`
+	Score        float64     `json:"score"`
+}
+
+// TransferLinkRequest vincula pares de transações como transferências entre contas do usuário
+type TransferLinkRequest struct {
+	Pairs []TransferLinkPair `json:"pairs" binding:"required,min=1,dive"`
+}
+
+// TransferLinkPair identifica a saída e a entrada de uma transferência
+type TransferLinkPair struct {
+	ExpenseID string `json:"expense_id" binding:"required"`
+	IncomeID  string `json:"income_id" binding:"required"`
+}
+
+// TransferLinkResult representa o resultado da vinculação de um par
+type TransferLinkResult struct {
+	ExpenseID  string `json:"expense_id"`
+	IncomeID   string `json:"income_id"`
+	TransferID string `json:"transfer_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
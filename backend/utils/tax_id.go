@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeTaxID remove a pontuação de um CPF ou CNPJ e confere os dígitos verificadores.
+// Retorna apenas os dígitos (11 para CPF, 14 para CNPJ).
+func NormalizeTaxID(value string) (string, error) {
+	var digits strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '.' || r == '-' || r == '/' || r == ' ':
+		default:
+			return "", fmt.Errorf("CPF/CNPJ deve conter apenas números e pontuação")
+		}
+	}
+
+	normalized := digits.String()
+	switch len(normalized) {
+	case 11:
+		if !validTaxIDDigits(normalized, []int{10, 9, 8, 7, 6, 5, 4, 3, 2}, []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}) {
+			return "", fmt.Errorf("CPF inválido")
+		}
+	case 14:
+		if !validTaxIDDigits(normalized, []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}, []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}) {
+			return "", fmt.Errorf("CNPJ inválido")
+		}
+	default:
+		return "", fmt.Errorf("CPF deve ter 11 dígitos e CNPJ, 14")
+	}
+	return normalized, nil
+}
+
+// TaxIDKind informa se os dígitos são de um CPF ou de um CNPJ
+func TaxIDKind(digits string) string {
+	if len(digits) == 14 {
+		return "CNPJ"
+	}
+	return "CPF"
+}
+
+// FormatTaxID formata os dígitos de um CPF (000.000.000-00) ou CNPJ (00.000.000/0000-00)
+func FormatTaxID(digits string) string {
+	switch len(digits) {
+	case 11:
+		return digits[0:3] + "." + digits[3:6] + "." + digits[6:9] + "-" + digits[9:11]
+	case 14:
+		return digits[0:2] + "." + digits[2:5] + "." + digits[5:8] + "/" + digits[8:12] + "-" + digits[12:14]
+	}
+	return digits
+}
+
+// validTaxIDDigits confere os dois dígitos verificadores (módulo 11) com os pesos de cada um.
+// Sequências de um só dígito (ex.: 111.111.111-11) passam no cálculo, mas não são válidas.
+func validTaxIDDigits(digits string, firstWeights, secondWeights []int) bool {
+	if strings.Count(digits, digits[:1]) == len(digits) {
+		return false
+	}
+	check := func(weights []int) byte {
+		sum := 0
+		for i, weight := range weights {
+			sum += int(digits[i]-'0') * weight
+		}
+		rest := sum % 11
+		if rest < 2 {
+			return '0'
+		}
+		return byte('0' + 11 - rest)
+	}
+	n := len(digits)
+	return digits[n-2] == check(firstWeights) && digits[n-1] == check(secondWeights)
+}
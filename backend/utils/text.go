@@ -0,0 +1,19 @@
+package utils
+
+import "strings"
+
+var accentReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// NormalizeText deixa o texto em minúsculas, sem acentos e com espaços simples,
+// para comparar descrições vindas de bancos diferentes
+func NormalizeText(s string) string {
+	s = accentReplacer.Replace(strings.ToLower(s))
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -285,10 +285,60 @@ export const ofxService = {
         'Content-Type': 'multipart/form-data',
       },
     });
+
+    // A importação roda em segundo plano: acompanhar o job até o resultado final
+    if (response.status === 202 && response.data?.job) {
+      const job = await importJobService.waitForJob(response.data.job.id);
+      if (job.status !== 'completed') {
+        throw new Error(job.error || 'Importação cancelada');
+      }
+      return job.result;
+    }
     return response.data;
   },
 };
 
+// Serviço de jobs de importação (importações processadas em segundo plano)
+export const importJobService = {
+  // Buscar o progresso e o resultado de um job
+  getJob: async (id: string): Promise<any> => {
+    const response = await api.get(`/import-jobs/${id}`);
+    return response.data.job;
+  },
+
+  // Acompanhar o job por Server-Sent Events até ele terminar; se o stream cair, consulta o job
+  waitForJob: (id: string, onProgress?: (progress: any) => void): Promise<any> => {
+    return new Promise((resolve, reject) => {
+      const events = new EventSource(`${API_BASE_URL}/import-jobs/${id}/events`, { withCredentials: true });
+
+      events.addEventListener('progress', (event) => {
+        onProgress?.(JSON.parse((event as MessageEvent).data));
+      });
+      events.addEventListener('done', (event) => {
+        events.close();
+        resolve(JSON.parse((event as MessageEvent).data));
+      });
+      events.onerror = () => {
+        events.close();
+        const poll = async () => {
+          try {
+            const job = await importJobService.getJob(id);
+            if (['completed', 'failed', 'cancelled'].includes(job.status)) {
+              resolve(job);
+              return;
+            }
+            onProgress?.(job);
+            setTimeout(poll, 2000);
+          } catch (error) {
+            reject(error);
+          }
+        };
+        poll();
+      };
+    });
+  },
+};
+
 // Serviço de câmbio
 export const exchangeService = {
   // Obter taxa de câmbio com conversão de valor